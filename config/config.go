@@ -1,66 +1,458 @@
 package config
 
 import (
-	"github.com/spf13/viper"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
 )
 
-var COOKIE_ADDRESS, SERVER_ADDRESS, FRONTEND_ADDRESS, BACKEND_ADDRESS, WS_ADDRESS, DB_ADDRESS string
+// Config holds every setting of the server. It is loaded once at startup by Load and handed
+// to the parts of the server that need it
+type Config struct {
+	Server    ServerConfig
+	DB        DBConfig
+	Cookie    CookieConfig
+	CORS      CORSConfig
+	Matching  MatchingConfig
+	RateLimit RateLimitConfig
+	Features  FeatureConfig
+	Mail      MailConfig
+	Log       LogConfig
+}
 
-var (
-	MATCH_THRESHOLD int = 40
-	MATCH_GROUPSIZE int = 4
-)
+type ServerConfig struct {
+	Address         string // address the server listens on
+	FrontendAddress string // origin of the frontend, used in the links of account emails
+	BackendAddress  string // origin of this server, used by the testing pages
+	WSAddress       string // websocket origin of this server, used by the testing pages
+
+	// Time given to in flight requests and websocket clients to finish when the server stops
+	ShutdownTimeout time.Duration
+}
+
+type DBConfig struct {
+	Address         string
+	MaxOpenConns    int // 0 means unlimited
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // 0 means connections are reused forever
+	ConnectTimeout  time.Duration // time to keep retrying the database at startup
+
+	// Time a store operation may take before its queries are cancelled. 0 means no limit
+	StatementTimeout time.Duration
+}
+
+type CookieConfig struct {
+	Domain   string
+	Secure   bool
+	SameSite string // none, lax or strict
+}
+
+type CORSConfig struct {
+	// Origins allowed to make credentialed requests and open websockets. The frontend and
+	// backend addresses when not set
+	AllowedOrigins []string
+}
+
+type MatchingConfig struct {
+	Threshold int // match requests queued before the scheduler starts a matching run
+	GroupSize int
+
+	// Time after the last matching run when the scheduler matches whoever is queued, even below
+	// Threshold. 0 leaves matching to the queue size and admins
+	Interval time.Duration
+	// How often the scheduler checks whether a run is due. 0 turns the scheduler off on this instance
+	PollInterval time.Duration
+	// Time a matching run or dry run may take, in place of DB.StatementTimeout. 0 means no limit
+	RunTimeout time.Duration
+
+	Strategy string // greedy or annealing, the matcher deciding who is grouped together
+	// Seed of the matcher, so that the same queue is always matched into the same groups
+	Seed int64
+
+	Weights CompatibilityWeights
+
+	// Lets groups of GroupSize-1 and GroupSize+1 take in the match requests left over by a run
+	UnevenGroups bool
+	// Lets match requests left over by a run join groups formed by earlier runs with fewer than GroupSize members
+	FillGroups bool
+}
+
+// Weight of each signal of the compatibility of two match requests. Every signal scores 0 to 4
+// points, which are multiplied by its weight. 0 leaves a signal out
+type CompatibilityWeights struct {
+	Faculty      int
+	MBTI         int
+	Hobbies      int
+	Gender       int
+	YearOfStudy  int
+	Availability int
+	GroupSize    int
+}
+
+type RateLimitConfig struct {
+	RequestsPerMinute int // per client IP on the account endpoints, 0 disables the limit
+	Burst             int
+}
+
+type FeatureConfig struct {
+	TestingRoutes bool // serves the /testing pages, which should be off in production
+	Metrics       bool // serves Prometheus metrics on /metrics
+}
+
+type MailConfig struct {
+	Mailer       string // smtp, file or log. Messages are logged when not set
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	From         string
+	Dir          string
+}
+
+type LogConfig struct {
+	Level  string   // debug, info, warn or error
+	Levels []string // package=level pairs that override Level for a package, such as ws=debug
+}
+
+// Default returns the settings used for anything that is not configured
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		DB: DBConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
 
-// With docker compose
-func LoadENV(path string) {
-	_, err := os.Stat(path)
- 	if err == nil {
- 		viper.SetConfigFile(path)
- 		if err := viper.ReadInConfig(); err != nil {
- 			log.Fatal(err.Error())
- 		}
-
-		if _, ok := os.LookupEnv("FRONTEND_ADDRESS"); !ok {
-			log.Println("NOTE: Using FRONTEND_ADDRESS from .env")
-			os.Setenv("FRONTEND_ADDRESS", viper.GetString("FRONTEND_ADDRESS"))
+			StatementTimeout: 10 * time.Second,
+		},
+		Cookie: CookieConfig{
+			Secure:   true,
+			SameSite: "none",
+		},
+		Matching: MatchingConfig{
+			Threshold: 40,
+			GroupSize: 4,
+
+			Interval:     24 * time.Hour,
+			PollInterval: time.Minute,
+			RunTimeout:   5 * time.Minute,
+
+			Strategy: "annealing",
+			Seed:     1,
+			Weights: CompatibilityWeights{
+				Faculty:      1,
+				MBTI:         1,
+				Hobbies:      1,
+				Gender:       1,
+				YearOfStudy:  1,
+				Availability: 1,
+				GroupSize:    1,
+			},
+
+			UnevenGroups: true,
+			FillGroups:   true,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerMinute: 20,
+			Burst:             10,
+		},
+		Features: FeatureConfig{
+			TestingRoutes: true,
+			Metrics:       true,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+	}
+}
+
+// Comma separated list flag
+type listValue struct{ list *[]string }
+
+func (v listValue) String() string {
+	if v.list == nil {
+		return ""
+	}
+	return strings.Join(*v.list, ",")
+}
+
+func (v listValue) Set(s string) error {
+	*v.list = make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*v.list = append(*v.list, item)
 		}
-		if _, ok := os.LookupEnv("BACKEND_ADDRESS"); !ok {
-			log.Println("NOTE: Using BACKEND_ADDRESS from .env")
-			os.Setenv("BACKEND_ADDRESS", viper.GetString("BACKEND_ADDRESS"))
+	}
+	return nil
+}
+
+// Every setting is a flag named after its environment variable, so DB_MAX_OPEN_CONNS is
+// also -db-max-open-conns
+func flagSet(cfg *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet("wellnus", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	fs.StringVar(&cfg.Server.Address, "server-address", cfg.Server.Address, "address the server listens on")
+	fs.StringVar(&cfg.Server.FrontendAddress, "frontend-address", cfg.Server.FrontendAddress, "origin of the frontend")
+	fs.StringVar(&cfg.Server.BackendAddress, "backend-address", cfg.Server.BackendAddress, "origin of this server")
+	fs.StringVar(&cfg.Server.WSAddress, "ws-address", cfg.Server.WSAddress, "websocket origin of this server")
+	fs.DurationVar(&cfg.Server.ShutdownTimeout, "shutdown-timeout", cfg.Server.ShutdownTimeout, "time given to requests and websockets to finish on shutdown")
+
+	fs.StringVar(&cfg.DB.Address, "db-address", cfg.DB.Address, "postgres connection string")
+	fs.IntVar(&cfg.DB.MaxOpenConns, "db-max-open-conns", cfg.DB.MaxOpenConns, "maximum open database connections, 0 for unlimited")
+	fs.IntVar(&cfg.DB.MaxIdleConns, "db-max-idle-conns", cfg.DB.MaxIdleConns, "maximum idle database connections")
+	fs.DurationVar(&cfg.DB.ConnectTimeout, "db-connect-timeout", cfg.DB.ConnectTimeout, "time to keep retrying the database at startup")
+	fs.DurationVar(&cfg.DB.ConnMaxLifetime, "db-conn-max-lifetime", cfg.DB.ConnMaxLifetime, "time after which a database connection is replaced, 0 for never")
+	fs.DurationVar(&cfg.DB.StatementTimeout, "db-statement-timeout", cfg.DB.StatementTimeout, "time a store operation may take before its queries are cancelled, 0 for no limit")
+
+	fs.StringVar(&cfg.Cookie.Domain, "cookie-address", cfg.Cookie.Domain, "domain of the session cookie")
+	fs.BoolVar(&cfg.Cookie.Secure, "cookie-secure", cfg.Cookie.Secure, "only send the session cookie over https")
+	fs.StringVar(&cfg.Cookie.SameSite, "cookie-same-site", cfg.Cookie.SameSite, "SameSite of the session cookie: none, lax or strict")
+
+	fs.Var(listValue{&cfg.CORS.AllowedOrigins}, "cors-allowed-origins", "comma separated origins allowed to make credentialed requests")
+
+	fs.IntVar(&cfg.Matching.Threshold, "match-threshold", cfg.Matching.Threshold, "match requests queued before a matching run is started")
+	fs.IntVar(&cfg.Matching.GroupSize, "match-group-size", cfg.Matching.GroupSize, "members of a matched group")
+	fs.DurationVar(&cfg.Matching.Interval, "match-interval", cfg.Matching.Interval, "time after the last matching run when whoever is queued is matched, 0 for never")
+	fs.DurationVar(&cfg.Matching.PollInterval, "match-poll-interval", cfg.Matching.PollInterval, "how often the scheduler checks whether a matching run is due, 0 to turn it off")
+	fs.DurationVar(&cfg.Matching.RunTimeout, "match-run-timeout", cfg.Matching.RunTimeout, "time a matching run may take before its queries are cancelled, 0 for no limit")
+	fs.StringVar(&cfg.Matching.Strategy, "match-strategy", cfg.Matching.Strategy, "matcher grouping match requests: greedy or annealing")
+	fs.Int64Var(&cfg.Matching.Seed, "match-seed", cfg.Matching.Seed, "seed of the matcher")
+	fs.IntVar(&cfg.Matching.Weights.Faculty, "match-weight-faculty", cfg.Matching.Weights.Faculty, "weight of faculty preferences in compatibility")
+	fs.IntVar(&cfg.Matching.Weights.MBTI, "match-weight-mbti", cfg.Matching.Weights.MBTI, "weight of shared MBTI letters in compatibility")
+	fs.IntVar(&cfg.Matching.Weights.Hobbies, "match-weight-hobbies", cfg.Matching.Weights.Hobbies, "weight of shared hobbies in compatibility")
+	fs.IntVar(&cfg.Matching.Weights.Gender, "match-weight-gender", cfg.Matching.Weights.Gender, "weight of gender preferences in compatibility")
+	fs.IntVar(&cfg.Matching.Weights.YearOfStudy, "match-weight-year-of-study", cfg.Matching.Weights.YearOfStudy, "weight of closeness in year of study in compatibility")
+	fs.IntVar(&cfg.Matching.Weights.Availability, "match-weight-availability", cfg.Matching.Weights.Availability, "weight of overlapping availability in compatibility")
+	fs.IntVar(&cfg.Matching.Weights.GroupSize, "match-weight-group-size", cfg.Matching.Weights.GroupSize, "weight of agreeing preferred group sizes in compatibility")
+	fs.BoolVar(&cfg.Matching.UnevenGroups, "match-uneven-groups", cfg.Matching.UnevenGroups, "let groups one member short or over take in left over match requests")
+	fs.BoolVar(&cfg.Matching.FillGroups, "match-fill-groups", cfg.Matching.FillGroups, "let left over match requests join under-filled groups formed by matching")
+
+	fs.IntVar(&cfg.RateLimit.RequestsPerMinute, "rate-limit-per-minute", cfg.RateLimit.RequestsPerMinute, "requests per minute per client on the account endpoints, 0 to disable")
+	fs.IntVar(&cfg.RateLimit.Burst, "rate-limit-burst", cfg.RateLimit.Burst, "requests a client may make at once before it is limited")
+
+	fs.BoolVar(&cfg.Features.TestingRoutes, "feature-testing-routes", cfg.Features.TestingRoutes, "serve the /testing pages")
+	fs.BoolVar(&cfg.Features.Metrics, "feature-metrics", cfg.Features.Metrics, "serve Prometheus metrics on /metrics")
+
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "level of logging: debug, info, warn or error")
+	fs.Var(listValue{&cfg.Log.Levels}, "log-levels", "comma separated package=level pairs that override the level for a package")
+
+	fs.StringVar(&cfg.Mail.Mailer, "mailer", cfg.Mail.Mailer, "mailer for account emails: smtp, file or log")
+	fs.StringVar(&cfg.Mail.SMTPHost, "smtp-host", cfg.Mail.SMTPHost, "")
+	fs.StringVar(&cfg.Mail.SMTPPort, "smtp-port", cfg.Mail.SMTPPort, "")
+	fs.StringVar(&cfg.Mail.SMTPUsername, "smtp-username", cfg.Mail.SMTPUsername, "")
+	fs.StringVar(&cfg.Mail.SMTPPassword, "smtp-password", cfg.Mail.SMTPPassword, "")
+	fs.StringVar(&cfg.Mail.From, "mail-from", cfg.Mail.From, "sender of account emails")
+	fs.StringVar(&cfg.Mail.Dir, "mail-dir", cfg.Mail.Dir, "directory the file mailer writes to")
+	return fs
+}
+
+func envKey(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Keys that were renamed, mapped to their old names, which are still read when the new key is not set
+var renamedKeys = map[string]string{
+	"MATCH_GROUP_SIZE": "MATCH_GROUPSIZE",
+}
+
+// Value of key from the environment, or else from the .env file
+func lookupKey(dotEnv *viper.Viper, key string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return dotEnv.GetString(key)
+}
+
+// Load reads the settings, each taken from the first of: the command line flags in args, the
+// environment, the .env file at envPath (with docker compose) and the defaults, where empty values
+// count as not set. It returns the arguments left after the flags, such as a subcommand, and
+// fails on settings that do not validate
+func Load(envPath string, args []string) (Config, []string, error) {
+	cfg := Default()
+	fs := flagSet(&cfg)
+
+	dotEnv := viper.New()
+	if _, err := os.Stat(envPath); err == nil {
+		dotEnv.SetConfigFile(envPath)
+		dotEnv.SetConfigType("env")
+		if err := dotEnv.ReadInConfig(); err != nil {
+			return Config{}, nil, err
 		}
-		if _, ok := os.LookupEnv("WS_ADDRESS"); !ok {
-			log.Println("NOTE: Using WS_ADDRESS from .env")
-			os.Setenv("WS_ADDRESS",	viper.GetString("WS_ADDRESS"))
+	} else {
+		slog.Info("no .env file, reading settings from the environment and flags only", "path", envPath)
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		key := envKey(f.Name)
+		value := lookupKey(dotEnv, key)
+		if oldKey, ok := renamedKeys[key]; ok && value == "" {
+			if value = lookupKey(dotEnv, oldKey); value != "" {
+				slog.Warn("setting read from a renamed key", "key", oldKey, "renamed_to", key)
+				key = oldKey
+			}
+		}
+		if value == "" || err != nil {
+			return
 		}
-		if _, ok := os.LookupEnv("SERVER_ADDRESS"); !ok {
-			log.Println("NOTE: Using SERVER_ADDRESS from .env")
-			os.Setenv("SERVER_ADDRESS", viper.GetString("SERVER_ADDRESS"))
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%s: %v", key, setErr)
 		}
-		if _, ok := os.LookupEnv("COOKIE_ADDRESS"); !ok {
-			log.Println("NOTE: Using COOKIE_ADDRESS from .env")
-			os.Setenv("COOKIE_ADDRESS", viper.GetString("COOKIE_ADDRESS"))
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+	// FOR HEROKU ONLY
+	if port := os.Getenv("PORT"); port != "" {
+		cfg.Server.Address = ":" + port
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		for _, origin := range []string{cfg.Server.FrontendAddress, cfg.Server.BackendAddress} {
+			if origin != "" {
+				cfg.CORS.AllowedOrigins = append(cfg.CORS.AllowedOrigins, origin)
+			}
 		}
-		if _, ok := os.LookupEnv("DB_ADDRESS"); !ok {
-			log.Println("NOTE: Using DB_ADDRESS from .env")
-			os.Setenv("DB_ADDRESS", viper.GetString("DB_ADDRESS"))
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// Validate reports every setting that is out of range or not one of its allowed values
+func (cfg Config) Validate() error {
+	problems := make([]string, 0)
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
 		}
+	}
 
- 	} else {
- 		log.Println(err.Error())
- 	}
+	check(cfg.Server.Address != "", "SERVER_ADDRESS must be set")
+	check(cfg.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive")
 
-	FRONTEND_ADDRESS = os.Getenv("FRONTEND_ADDRESS")
-	BACKEND_ADDRESS = os.Getenv("BACKEND_ADDRESS")
-	WS_ADDRESS = os.Getenv("WS_ADDRESS")
-	SERVER_ADDRESS = os.Getenv("SERVER_ADDRESS")
-	COOKIE_ADDRESS = os.Getenv("COOKIE_ADDRESS")
-	DB_ADDRESS = os.Getenv("DB_ADDRESS")
+	check(cfg.DB.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(cfg.DB.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(cfg.DB.MaxOpenConns == 0 || cfg.DB.MaxIdleConns <= cfg.DB.MaxOpenConns, "DB_MAX_IDLE_CONNS must not be more than DB_MAX_OPEN_CONNS")
+	check(cfg.DB.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME must not be negative")
+	check(cfg.DB.ConnectTimeout > 0, "DB_CONNECT_TIMEOUT must be positive")
+	check(cfg.DB.StatementTimeout >= 0, "DB_STATEMENT_TIMEOUT must not be negative")
 
-	// FOR HEROKU ONLY
-	port, ok := os.LookupEnv("PORT")
-	if ok {
-		SERVER_ADDRESS = ":" + port
+	_, sameSiteOK := sameSiteModes[cfg.Cookie.SameSite]
+	check(sameSiteOK, "COOKIE_SAME_SITE must be one of none, lax or strict")
+	// Browsers drop SameSite=None cookies that are not secure
+	check(cfg.Cookie.SameSite != "none" || cfg.Cookie.Secure, "COOKIE_SECURE must be true when COOKIE_SAME_SITE is none")
+
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		u, err := url.Parse(origin)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/"),
+			"CORS_ALLOWED_ORIGINS has %q, which is not an http(s) origin", origin)
+	}
+
+	check(cfg.Matching.GroupSize >= 2, "MATCH_GROUP_SIZE must be at least 2")
+	check(cfg.Matching.Threshold >= cfg.Matching.GroupSize, "MATCH_THRESHOLD must be at least MATCH_GROUP_SIZE")
+	check(cfg.Matching.Interval >= 0, "MATCH_INTERVAL must not be negative")
+	check(cfg.Matching.PollInterval >= 0, "MATCH_POLL_INTERVAL must not be negative")
+	check(cfg.Matching.RunTimeout >= 0, "MATCH_RUN_TIMEOUT must not be negative")
+	check(cfg.Matching.Strategy == "greedy" || cfg.Matching.Strategy == "annealing", "MATCH_STRATEGY must be one of greedy or annealing")
+	weights := cfg.Matching.Weights
+	check(weights.Faculty >= 0 && weights.MBTI >= 0 && weights.Hobbies >= 0 && weights.Gender >= 0 &&
+		weights.YearOfStudy >= 0 && weights.Availability >= 0 && weights.GroupSize >= 0, "MATCH_WEIGHT_* must not be negative")
+	check(weights.Faculty+weights.MBTI+weights.Hobbies+weights.Gender+weights.YearOfStudy+weights.Availability+weights.GroupSize > 0,
+		"at least one MATCH_WEIGHT_* must be more than 0")
+
+	check(cfg.RateLimit.RequestsPerMinute >= 0, "RATE_LIMIT_PER_MINUTE must not be negative")
+	check(cfg.RateLimit.RequestsPerMinute == 0 || cfg.RateLimit.Burst >= 1, "RATE_LIMIT_BURST must be at least 1 when the rate limit is on")
+
+	switch cfg.Mail.Mailer {
+	case "", "log":
+	case "smtp":
+		check(cfg.Mail.SMTPHost != "" && cfg.Mail.SMTPPort != "", "SMTP_HOST and SMTP_PORT must be set for the smtp mailer")
+		check(cfg.Mail.From != "", "MAIL_FROM must be set for the smtp mailer")
+	case "file":
+		check(cfg.Mail.Dir != "", "MAIL_DIR must be set for the file mailer")
+	default:
+		check(false, "MAILER must be one of smtp, file or log")
+	}
+
+	_, err := parseLevel(cfg.Log.Level)
+	check(err == nil, "LOG_LEVEL must be one of debug, info, warn or error")
+	for _, pair := range cfg.Log.Levels {
+		pkg, l, ok := strings.Cut(pair, "=")
+		_, err := parseLevel(l)
+		check(ok && pkg != "" && err == nil, "LOG_LEVELS has %q, which is not a package=level pair", pair)
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var sameSiteModes = map[string]http.SameSite{
+	"none":   http.SameSiteNoneMode,
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+}
+
+func (cookie CookieConfig) SameSiteMode() http.SameSite {
+	if mode, ok := sameSiteModes[cookie.SameSite]; ok {
+		return mode
+	}
+	return http.SameSiteDefaultMode
+}
+
+// Reports whether origin may make credentialed requests and open websockets
+func (cors CORSConfig) Allows(origin string) bool {
+	for _, allowed := range cors.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func parseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(s))
+	return l, err
+}
+
+// Level of every package without one in Levels, info when it does not parse
+func (cfg LogConfig) DefaultLevel() slog.Level {
+	l, err := parseLevel(cfg.Level)
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// Levels by package, leaving out the pairs that do not parse
+func (cfg LogConfig) PackageLevels() map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, pair := range cfg.Levels {
+		pkg, s, _ := strings.Cut(pair, "=")
+		if l, err := parseLevel(s); err == nil && pkg != "" {
+			levels[pkg] = l
+		}
 	}
-}
\ No newline at end of file
+	return levels
+}
@@ -2,25 +2,62 @@ package db
 
 import (
 	"wellnus/backend/config"
+	"wellnus/backend/logger"
 	
+	"context"
 	"fmt"
-	"log"
+	"time"
 	
 	"database/sql"
 	_ "github.com/lib/pq"
 )
 
-func ConnectDB() *sql.DB {
-	address := config.DB_ADDRESS
-	fmt.Println("Connecting to database: ", address)
+var log = logger.For("db")
+
+// The wait after a failed ping starts at initialBackoff and doubles up to maxBackoff. A ping
+// that takes longer than pingTimeout counts as failed
+const (
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff = 8 * time.Second
+	pingTimeout = 5 * time.Second
+)
+
+// Opens the database and pings it until it answers, so that the server may start before the
+// database does. Gives up on the first failed ping after cfg.ConnectTimeout
+func ConnectDB(cfg config.DBConfig) (*sql.DB, error) {
+	address := cfg.Address
+	log.Info("connecting to database", "address", address)
 	db, err := sql.Open("postgres", address)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		pingErr := db.PingContext(ctx)
+		cancel()
+		if pingErr == nil {
+			break
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			db.Close()
+			return nil, fmt.Errorf("database did not answer after %d attempts: %w", attempt, pingErr)
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		log.Warn("database did not answer", "attempt", attempt, "retry_in", backoff.Round(time.Millisecond), "error", pingErr)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
 	}
-	pingErr := db.Ping()
-    if pingErr != nil {
-        log.Fatal(pingErr)
-    }
-    fmt.Println("Database Connected!")
-	return db
-}
\ No newline at end of file
+	log.Info("database connected")
+	return db, nil
+}
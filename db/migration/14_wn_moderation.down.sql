@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS wn_report;
+
+UPDATE wn_user SET user_role = 'MEMBER' WHERE user_role = 'ADMIN';
+ALTER TABLE wn_user DROP COLUMN IF EXISTS suspended;
+ALTER TABLE wn_user DROP COLUMN IF EXISTS verified;
+ALTER TABLE wn_user DROP CONSTRAINT IF EXISTS wn_user_user_role_check;
+ALTER TABLE wn_user ADD CONSTRAINT wn_user_user_role_check CHECK (user_role IN ('MEMBER', 'VOLUNTEER', 'COUNSELLOR'));
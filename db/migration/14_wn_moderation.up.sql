@@ -0,0 +1,18 @@
+ALTER TABLE wn_user DROP CONSTRAINT IF EXISTS wn_user_user_role_check;
+ALTER TABLE wn_user ADD CONSTRAINT wn_user_user_role_check CHECK (user_role IN ('MEMBER', 'VOLUNTEER', 'COUNSELLOR', 'ADMIN'));
+ALTER TABLE wn_user ADD COLUMN IF NOT EXISTS verified BOOLEAN NOT NULL DEFAULT FALSE;
+-- Providers from before verification existed keep their permissions and stay listed
+UPDATE wn_user SET verified = TRUE WHERE user_role IN ('VOLUNTEER', 'COUNSELLOR');
+ALTER TABLE wn_user ADD COLUMN IF NOT EXISTS suspended BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS wn_report (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    reporter_id BIGINT REFERENCES wn_user(id) ON DELETE CASCADE NOT NULL,
+    target_type VARCHAR(5) NOT NULL,
+    target_id BIGINT NOT NULL,
+    reason VARCHAR(512) NOT NULL,
+    time_added TIMESTAMPTZ NOT NULL,
+    unique(reporter_id, target_type, target_id),
+    check(target_type IN ('USER', 'GROUP', 'EVENT')),
+    check(reason != '')
+);
@@ -0,0 +1,11 @@
+-- Keep only the most recent session of each user so that unique(user_id) can be restored
+DELETE FROM wn_session a USING wn_session b
+WHERE a.user_id = b.user_id AND (a.created_at, a.id) < (b.created_at, b.id);
+
+DROP INDEX IF EXISTS wn_session_user_id_idx;
+ALTER TABLE wn_session DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE wn_session DROP COLUMN IF EXISTS last_seen;
+ALTER TABLE wn_session DROP COLUMN IF EXISTS created_at;
+ALTER TABLE wn_session DROP COLUMN IF EXISTS device_label;
+ALTER TABLE wn_session DROP COLUMN IF EXISTS id;
+ALTER TABLE wn_session ADD CONSTRAINT wn_session_user_id_key UNIQUE (user_id);
@@ -0,0 +1,7 @@
+ALTER TABLE wn_session DROP CONSTRAINT IF EXISTS wn_session_user_id_key;
+ALTER TABLE wn_session ADD COLUMN IF NOT EXISTS id BIGSERIAL NOT NULL UNIQUE;
+ALTER TABLE wn_session ADD COLUMN IF NOT EXISTS device_label VARCHAR(128) NOT NULL DEFAULT '';
+ALTER TABLE wn_session ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE wn_session ADD COLUMN IF NOT EXISTS last_seen TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE wn_session ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NOT NULL DEFAULT NOW() + INTERVAL '14 days';
+CREATE INDEX IF NOT EXISTS wn_session_user_id_idx ON wn_session (user_id);
@@ -0,0 +1,3 @@
+DELETE FROM wn_session;
+ALTER TABLE wn_session ALTER COLUMN session_key_hash TYPE VARCHAR(128);
+ALTER TABLE wn_session RENAME COLUMN session_key_hash TO session_key;
@@ -0,0 +1,4 @@
+-- Existing sessions hold plaintext keys and cannot be converted, so everyone has to log in again
+DELETE FROM wn_session;
+ALTER TABLE wn_session RENAME COLUMN session_key TO session_key_hash;
+ALTER TABLE wn_session ALTER COLUMN session_key_hash TYPE CHAR(64);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wn_user_token;
+ALTER TABLE wn_user DROP COLUMN IF EXISTS email_verified;
@@ -0,0 +1,12 @@
+ALTER TABLE wn_user ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+-- Accounts created before email verification existed keep being able to log in
+UPDATE wn_user SET email_verified = TRUE;
+
+CREATE TABLE IF NOT EXISTS wn_user_token (
+    token_hash CHAR(64) NOT NULL PRIMARY KEY,
+    user_id BIGINT REFERENCES wn_user(id) ON DELETE CASCADE NOT NULL,
+    purpose VARCHAR(14) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    check(purpose IN ('VERIFY_EMAIL', 'RESET_PASSWORD'))
+);
+CREATE INDEX IF NOT EXISTS wn_user_token_user_id_idx ON wn_user_token (user_id);
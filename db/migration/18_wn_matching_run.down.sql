@@ -0,0 +1 @@
+DROP TABLE IF EXISTS wn_matching_run;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS wn_matching_run (
+    id BIGSERIAL NOT NULL PRIMARY KEY,
+    trigger_type VARCHAR(10) NOT NULL,
+    triggered_by BIGINT REFERENCES wn_user(id) ON DELETE SET NULL,
+    outcome VARCHAR(15) NOT NULL,
+    queue_size INT NOT NULL,
+    user_ids BIGINT[] NOT NULL,
+    group_size INT NOT NULL,
+    threshold INT NOT NULL,
+    group_ids BIGINT[] NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    time_started TIMESTAMPTZ NOT NULL,
+    time_finished TIMESTAMPTZ NOT NULL,
+    check(trigger_type IN ('SCHEDULE', 'QUEUE_SIZE', 'MANUAL')),
+    check(outcome IN ('MATCHED', 'BELOW_THRESHOLD', 'FAILED'))
+);
+CREATE INDEX IF NOT EXISTS wn_matching_run_time_started_idx ON wn_matching_run (time_started);
@@ -0,0 +1,4 @@
+ALTER TABLE wn_match_setting DROP COLUMN IF EXISTS preferred_group_size;
+ALTER TABLE wn_match_setting DROP COLUMN IF EXISTS availability;
+ALTER TABLE wn_match_setting DROP COLUMN IF EXISTS year_of_study;
+ALTER TABLE wn_match_setting DROP COLUMN IF EXISTS gender_preference;
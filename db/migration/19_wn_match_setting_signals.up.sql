@@ -0,0 +1,13 @@
+ALTER TABLE wn_match_setting ADD COLUMN IF NOT EXISTS gender_preference VARCHAR(4) NOT NULL DEFAULT 'NONE';
+ALTER TABLE wn_match_setting ADD COLUMN IF NOT EXISTS year_of_study INT NOT NULL DEFAULT 0;
+ALTER TABLE wn_match_setting ADD COLUMN IF NOT EXISTS availability TEXT[];
+ALTER TABLE wn_match_setting ADD COLUMN IF NOT EXISTS preferred_group_size INT NOT NULL DEFAULT 0;
+ALTER TABLE wn_match_setting ADD CONSTRAINT wn_match_setting_gender_preference_check
+    CHECK(gender_preference IN ('MIX', 'SAME', 'NONE'));
+-- 0 leaves year of study and preferred group size unset
+ALTER TABLE wn_match_setting ADD CONSTRAINT wn_match_setting_year_of_study_check
+    CHECK(year_of_study BETWEEN 0 AND 6);
+ALTER TABLE wn_match_setting ADD CONSTRAINT wn_match_setting_availability_check
+    CHECK(availability <@ ARRAY['WEEKDAY_MORNING', 'WEEKDAY_AFTERNOON', 'WEEKDAY_EVENING', 'WEEKEND_MORNING', 'WEEKEND_AFTERNOON', 'WEEKEND_EVENING']);
+ALTER TABLE wn_match_setting ADD CONSTRAINT wn_match_setting_preferred_group_size_check
+    CHECK(preferred_group_size = 0 OR preferred_group_size BETWEEN 2 AND 10);
@@ -0,0 +1 @@
+ALTER TABLE wn_matching_run DROP COLUMN IF EXISTS filled_group_ids;
@@ -0,0 +1 @@
+ALTER TABLE wn_matching_run ADD COLUMN IF NOT EXISTS filled_group_ids BIGINT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,265 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// The SQL files of this directory are compiled into the binary so that it can bring
+// any database up to date without the migrate CLI or the files on disk
+//
+//go:embed *.sql
+var files embed.FS
+
+// The version is kept in the table the migrate CLI used, so databases it set up carry on as they are
+const versionTable = "schema_migrations"
+
+// Key of the advisory lock that keeps two instances from migrating at the same time
+const lockID = 764813297
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one numbered pair of up and down SQL files
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the version of the schema of a database. Version 0 means nothing has been applied
+type Status struct {
+	Version int64
+	Dirty   bool
+	Latest  int64
+}
+
+// Reads the embedded migrations in order of version
+func Migrations() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.(up|down).sql", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		b, err := files.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if match[3] == "up" {
+			migration.Up = string(b)
+		} else {
+			migration.Down = string(b)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Version of the last embedded migration
+func Latest() (int64, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Refuses schemas that a failed migration left half applied, or that a newer build migrated
+// past the migrations this binary knows about
+func (status Status) Check() error {
+	if status.Dirty {
+		return fmt.Errorf("schema is dirty at version %d: repair it by hand, then clear the dirty flag in %s", status.Version, versionTable)
+	}
+	if status.Version > status.Latest {
+		return fmt.Errorf("schema version %d is newer than the latest migration %d of this build", status.Version, status.Latest)
+	}
+	return nil
+}
+
+func (status Status) String() string {
+	s := fmt.Sprintf("schema version %d of %d", status.Version, status.Latest)
+	if status.Dirty {
+		s += " (dirty)"
+	}
+	return s
+}
+
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func readStatus(ctx context.Context, db queryer) (Status, error) {
+	latest, err := Latest()
+	if err != nil {
+		return Status{}, err
+	}
+	if _, err := db.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+versionTable+" (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)"); err != nil {
+		return Status{}, err
+	}
+	status := Status{Latest: latest}
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM "+versionTable+" LIMIT 1").Scan(&status.Version, &status.Dirty)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+func GetStatus(ctx context.Context, db *sql.DB) (Status, error) {
+	return readStatus(ctx, db)
+}
+
+// Runs one migration and records the version it leaves the schema at, both in a single
+// transaction so that a failing migration leaves nothing behind. pick chooses the SQL from
+// the current version and reports false once there is nothing left to run
+func step(db *sql.DB, pick func(current int64) (string, int64, bool)) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+		return false, err
+	}
+	status, err := readStatus(context.Background(), tx)
+	if err != nil {
+		return false, err
+	}
+	if err := status.Check(); err != nil {
+		return false, err
+	}
+	query, version, ok := pick(status.Version)
+	if !ok {
+		return false, nil
+	}
+	if _, err := tx.Exec(query); err != nil {
+		return false, fmt.Errorf("migrating from version %d to %d: %w", status.Version, version, err)
+	}
+	if _, err := tx.Exec("DELETE FROM " + versionTable); err != nil {
+		return false, err
+	}
+	if version > 0 {
+		if _, err := tx.Exec("INSERT INTO "+versionTable+" (version, dirty) VALUES ($1, FALSE)", version); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// Applies every pending migration and returns how many were applied
+func Up(db *sql.DB) (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	applied := 0
+	for {
+		ok, err := step(db, func(current int64) (string, int64, bool) {
+			for _, migration := range migrations {
+				if migration.Version > current {
+					return migration.Up, migration.Version, true
+				}
+			}
+			return "", 0, false
+		})
+		if err != nil || !ok {
+			return applied, err
+		}
+		applied++
+	}
+}
+
+// Rolls back the last steps migrations, or all of them when steps is not positive,
+// and returns how many were rolled back
+func Down(db *sql.DB, steps int) (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	rolledBack := 0
+	for steps <= 0 || rolledBack < steps {
+		ok, err := step(db, func(current int64) (string, int64, bool) {
+			for i := len(migrations) - 1; i >= 0; i-- {
+				if migrations[i].Version == current {
+					previous := int64(0)
+					if i > 0 {
+						previous = migrations[i-1].Version
+					}
+					return migrations[i].Down, previous, true
+				}
+			}
+			return "", 0, false
+		})
+		if err != nil || !ok {
+			return rolledBack, err
+		}
+		rolledBack++
+	}
+	return rolledBack, nil
+}
+
+// Runs the migrate subcommand: up, down [steps] or status
+func Run(db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up | down [steps] | status")
+	}
+	switch args[0] {
+	case "up":
+		applied, err := Up(db)
+		fmt.Fprintf(out, "applied %d migrations\n", applied)
+		if err != nil {
+			return err
+		}
+	case "down":
+		steps := 0
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("steps must be a positive integer, not %q", args[1])
+			}
+			steps = n
+		}
+		rolledBack, err := Down(db, steps)
+		fmt.Fprintf(out, "rolled back %d migrations\n", rolledBack)
+		if err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+	status, err := GetStatus(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, status)
+	return nil
+}
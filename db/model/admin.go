@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+type Report struct {
+	ID			int64		`json:"id"`
+	ReporterID	int64		`json:"reporter_id"`
+	TargetType	string		`json:"target_type"`
+	TargetID	int64		`json:"target_id"`
+	Reason		string		`json:"reason"`
+	TimeAdded	time.Time	`json:"time_added"`
+}
+
+type UserRoleBody struct {
+	UserRole	string	`json:"user_role"`
+}
+
+type VerifiedBody struct {
+	Verified	bool	`json:"verified"`
+}
+
+type SuspendedBody struct {
+	Suspended	bool	`json:"suspended"`
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Helper function
+
+func ReadReports(rows *sql.Rows) ([]Report, error) {
+	reports := make([]Report, 0)
+	for rows.Next() {
+		var report Report
+		if err := rows.Scan(
+			&report.ID,
+			&report.ReporterID,
+			&report.TargetType,
+			&report.TargetID,
+			&report.Reason,
+			&report.TimeAdded);
+			err != nil {
+				return nil, err
+			}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func checkReportTarget(ctx context.Context, db DBTX, report Report) error {
+	var err error
+	switch report.TargetType {
+	case "USER":
+		_, err = GetUser(ctx, db, report.TargetID)
+	case "GROUP":
+		_, err = GetGroup(ctx, db, report.TargetID)
+	case "EVENT":
+		_, err = GetEvent(ctx, db, report.TargetID)
+	}
+	return err
+}
+
+// Main functions
+
+func VerifyUser(ctx context.Context, db DBTX, userID int64, verified bool, adminID int64) (User, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionVerifyUser, nil); err != nil { return User{}, err }
+	targetUser, err := GetUser(ctx, db, userID)
+	if err != nil { return User{}, err }
+	_, err = db.ExecContext(ctx, "UPDATE wn_user SET verified = $1 WHERE id = $2;", verified, userID)
+	if err != nil { return User{}, err }
+	targetUser.Verified = verified
+	return targetUser, nil
+}
+
+// Roles granted by an admin are verified along with the change
+func ChangeUserRole(ctx context.Context, db DBTX, userID int64, userRole string, adminID int64) (User, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionChangeUserRole, nil); err != nil { return User{}, err }
+	targetUser, err := GetUser(ctx, db, userID)
+	if err != nil { return User{}, err }
+	_, err = db.ExecContext(ctx, "UPDATE wn_user SET user_role = $1, verified = TRUE WHERE id = $2;", userRole, userID)
+	if err != nil { return User{}, err }
+	targetUser.UserRole = userRole
+	targetUser.Verified = true
+	return targetUser, nil
+}
+
+// Suspending a user also ends their session so they are logged out immediately
+func SuspendUser(ctx context.Context, db *sql.DB, userID int64, suspended bool, adminID int64) (User, error) {
+	return WithTx(ctx, db, func(tx DBTX) (User, error) {
+		return suspendUser(ctx, tx, userID, suspended, adminID)
+	})
+}
+
+func suspendUser(ctx context.Context, db DBTX, userID int64, suspended bool, adminID int64) (User, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionSuspendUser, nil); err != nil { return User{}, err }
+	targetUser, err := GetUser(ctx, db, userID)
+	if err != nil { return User{}, err }
+	_, err = db.ExecContext(ctx, "UPDATE wn_user SET suspended = $1 WHERE id = $2;", suspended, userID)
+	if err != nil { return User{}, err }
+	if suspended {
+		_, err = db.ExecContext(ctx, "DELETE FROM wn_session WHERE user_id = $1;", userID)
+		if err != nil { return User{}, err }
+	}
+	targetUser.Suspended = suspended
+	return targetUser, nil
+}
+
+func ForceDeleteGroup(ctx context.Context, db DBTX, groupID int64, adminID int64) (Group, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionForceDeleteGroup, nil); err != nil { return Group{}, err }
+	group, err := GetGroup(ctx, db, groupID)
+	if err != nil { return Group{}, err }
+	if err := DeleteGroup(ctx, db, groupID); err != nil { return Group{}, err }
+	return group, nil
+}
+
+func ForceDeleteEvent(ctx context.Context, db DBTX, eventID int64, adminID int64) (Event, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionForceDeleteEvent, nil); err != nil { return Event{}, err }
+	event, err := GetEvent(ctx, db, eventID)
+	if err != nil { return Event{}, err }
+	if err := DeleteEvent(ctx, db, eventID); err != nil { return Event{}, err }
+	return event, nil
+}
+
+var ReportSorting = Sorting[Report]{
+	IDSQL: "id",
+	ID: func(report Report) int64 { return report.ID },
+	Keys: map[string]SortKey[Report]{
+		"id":			{ SQL: "id", Value: func(report Report) interface{} { return report.ID } },
+		"time_added":	{ SQL: "time_added", Value: func(report Report) interface{} { return report.TimeAdded } },
+	},
+}
+
+func GetReportsPage(ctx context.Context, db DBTX, adminID int64, page PageQuery) (Page[Report], error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionListReports, nil); err != nil { return Page[Report]{}, err }
+	return QueryPage(ctx, db, ReportSorting, page, "SELECT * FROM wn_report", nil, nil, ReadReports)
+}
+
+func AddReport(ctx context.Context, db DBTX, report Report, userID int64) (Report, error) {
+	if err := checkReportTarget(ctx, db, report); err != nil { return Report{}, err }
+	report.ReporterID = userID
+	report.TimeAdded = time.Now()
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO wn_report (
+			reporter_id,
+			target_type,
+			target_id,
+			reason,
+			time_added
+		) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;`,
+		report.ReporterID,
+		report.TargetType,
+		report.TargetID,
+		report.Reason,
+		report.TimeAdded).Scan(&report.ID)
+	if err != nil { return Report{}, err }
+	return report, nil
+}
+
+func DeleteReport(ctx context.Context, db DBTX, reportID int64, adminID int64) (Report, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionDeleteReport, nil); err != nil { return Report{}, err }
+	rows, err := db.QueryContext(ctx, "DELETE FROM wn_report WHERE id = $1 RETURNING *;", reportID)
+	if err != nil { return Report{}, err }
+	defer rows.Close()
+	reports, err := ReadReports(rows)
+	if err != nil { return Report{}, err }
+	if len(reports) == 0 { return Report{}, http_error.NotFoundError }
+	return reports[0], nil
+}
@@ -1,7 +1,7 @@
 package model
 
 import (
-	"database/sql"
+	"context"
 	"time"
 )
 
@@ -18,7 +18,7 @@ type Booking struct {
 
 type BookingUser struct {
 	Booking Booking `json:"booking"`
-	User    User    `json:"user"`
+	User    PublicUser `json:"user"`
 }
 
 type BookingProvider struct {
@@ -57,33 +57,20 @@ func (bMain Booking) MergeBooking(bAdd Booking) Booking {
 	return bMain
 }
 
-func (b Booking) LoadLastBookingID(db *sql.DB) (Booking, error) {
-	row, err := db.Query("SELECT last_value FROM wn_booking_id_seq;")
-	if err != nil {
-		return Booking{}, err
-	}
-	defer row.Close()
-	row.Next()
-	if err := row.Scan(&b.ID); err != nil {
-		return Booking{}, err
-	}
-	return b, nil
-}
-
-func (b Booking) LoadBookingWithProvider(db *sql.DB) (BookingProvider, error) {
-	provider, err := GetProvider(db, b.ProviderID)
+func (b Booking) LoadBookingWithProvider(ctx context.Context, db DBTX) (BookingProvider, error) {
+	provider, err := GetProvider(ctx, db, b.ProviderID)
 	if err != nil {
 		return BookingProvider{}, err
 	}
 	return BookingProvider{Booking: b, Provider: provider}, nil
 }
 
-func (b Booking) LoadBookingWithUser(db *sql.DB) (BookingUser, error) {
-	user, err := GetUser(db, b.ProviderID)
+func (b Booking) LoadBookingWithUser(ctx context.Context, db DBTX) (BookingUser, error) {
+	user, err := GetUser(ctx, db, b.ProviderID)
 	if err != nil {
 		return BookingUser{}, err
 	}
-	return BookingUser{Booking: b, User: user}, nil
+	return BookingUser{Booking: b, User: user.Public()}, nil
 }
 
 func (b Booking) FlippedApproveBy() int64 {
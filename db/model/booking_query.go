@@ -2,6 +2,7 @@ package model
 
 import (
 	"wellnus/backend/router/http_helper/http_error"
+	"context"
 	"database/sql"
 	"fmt"
 )
@@ -47,9 +48,8 @@ func ReadBookingUsers(rows *sql.Rows) ([]BookingUser, error) {
 		 	&bookingUser.User.LastName, 
 			&bookingUser.User.Gender, 
 			&bookingUser.User.Faculty, 
-			&bookingUser.User.Email, 
 			&bookingUser.User.UserRole, 
-			&bookingUser.User.PasswordHash); 
+			&bookingUser.User.Verified); 
 			err != nil {
 				return nil, err
 			}
@@ -58,8 +58,8 @@ func ReadBookingUsers(rows *sql.Rows) ([]BookingUser, error) {
 	return bookingUsers, nil
 }
 
-func GetBooking(db *sql.DB, bookingID int64) (Booking, error) {
-	rows, err := db.Query("SELECT * FROM wn_booking WHERE id = $1", bookingID)
+func GetBooking(ctx context.Context, db DBTX, bookingID int64) (Booking, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_booking WHERE id = $1", bookingID)
 	if err != nil { return Booking{}, err }
 	defer rows.Close()
 	bookings, err := ReadBookings(rows)
@@ -68,106 +68,44 @@ func GetBooking(db *sql.DB, bookingID int64) (Booking, error) {
 	return bookings[0], nil
 }
 
-func DeleteBooking(db *sql.DB, bookingID int64) (Booking, error) {
-	_, err := db.Exec("DELETE FROM wn_booking WHERE id = $1", bookingID)
+func DeleteBooking(ctx context.Context, db DBTX, bookingID int64) (Booking, error) {
+	_, err := db.ExecContext(ctx, "DELETE FROM wn_booking WHERE id = $1", bookingID)
 	if err != nil { return Booking{}, err }
 	return Booking{ ID : bookingID }, nil
 }
 
 // Main function
 
-func GetAllBookingUsersSentOfUser(db *sql.DB, userID int64) ([]BookingUser, error) {
-	rows, err := db.Query(
-		`SELECT 
-			wn_booking.id, 
-			wn_booking.recipient_id, 
-			wn_booking.provider_id,
-			wn_booking.approve_by,
-			wn_booking.nickname,
-			wn_booking.details,
-			wn_booking.start_time,
-			wn_booking.end_time,
-			wn_user.id,
-			wn_user.first_name,
-			wn_user.last_name,
-			wn_user.gender,
-			wn_user.faculty,
-			wn_user.email,
-			wn_user.user_role,
-			wn_user.password_hash
-		FROM wn_booking JOIN wn_user
-		ON wn_booking.provider_id = wn_user.id
-		WHERE wn_booking.recipient_id = $1`,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	bookingUsers, err := ReadBookingUsers(rows)
-	if err != nil { return nil, err }
-	return bookingUsers, nil
-}
-
-func GetAllBookingUsersReceivedOfUser(db *sql.DB, userID int64) ([]BookingUser, error) {
-	rows, err := db.Query(
-		`SELECT 
-			wn_booking.id, 
-			wn_booking.recipient_id, 
-			wn_booking.provider_id,
-			wn_booking.approve_by,
-			wn_booking.nickname,
-			wn_booking.details,
-			wn_booking.start_time,
-			wn_booking.end_time,
-			wn_user.id,
-			wn_user.first_name,
-			wn_user.last_name,
-			wn_user.gender,
-			wn_user.faculty,
-			wn_user.email,
-			wn_user.user_role,
-			wn_user.password_hash
-		FROM wn_booking JOIN wn_user
-		ON wn_booking.provider_id = wn_user.id
-		WHERE wn_booking.provider_id = $1`,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	bookingUsers, err := ReadBookingUsers(rows)
-	if err != nil { return nil, err }
-	return bookingUsers, nil
-}
+// Which of the bookings of a user to list
+const (
+	BookingsAll			= ""
+	BookingsReceived	= "RECEIVED"	// bookings with the user as provider
+	BookingsSent		= "SENT"		// bookings the user made
+	BookingsRequired	= "REQUIRED"	// bookings waiting on the approval of the user
+)
 
-func GetAllBookingUsersRequiredOfUser(db *sql.DB, userID int64) ([]BookingUser, error) {
-	rows, err := db.Query(
-		`SELECT 
-			wn_booking.id, 
-			wn_booking.recipient_id, 
-			wn_booking.provider_id,
-			wn_booking.approve_by,
-			wn_booking.nickname,
-			wn_booking.details,
-			wn_booking.start_time,
-			wn_booking.end_time,
-			wn_user.id,
-			wn_user.first_name,
-			wn_user.last_name,
-			wn_user.gender,
-			wn_user.faculty,
-			wn_user.email,
-			wn_user.user_role,
-			wn_user.password_hash
-		FROM wn_booking JOIN wn_user
-		ON wn_booking.provider_id = wn_user.id
-		WHERE wn_booking.approve_by = $1`,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	bookingUsers, err := ReadBookingUsers(rows)
-	if err != nil { return nil, err }
-	return bookingUsers, nil
+var BookingUserSorting = Sorting[BookingUser]{
+	IDSQL: "wn_booking.id",
+	ID: func(bookingUser BookingUser) int64 { return bookingUser.Booking.ID },
+	Keys: map[string]SortKey[BookingUser]{
+		"id":			{ SQL: "wn_booking.id", Value: func(bookingUser BookingUser) interface{} { return bookingUser.Booking.ID } },
+		"start_time":	{ SQL: "wn_booking.start_time", Value: func(bookingUser BookingUser) interface{} { return bookingUser.Booking.StartTime } },
+	},
 }
 
-func GetAllBookingUsersOfUser(db *sql.DB, userID int64) ([]BookingUser, error) {
-	rows, err := db.Query(
+func GetBookingUsersPageOfUser(ctx context.Context, db DBTX, userID int64, scope string, page PageQuery) (Page[BookingUser], error) {
+	var condition string
+	switch scope {
+	case BookingsReceived:
+		condition = "wn_booking.provider_id = $1"
+	case BookingsSent:
+		condition = "wn_booking.recipient_id = $1"
+	case BookingsRequired:
+		condition = "wn_booking.approve_by = $1"
+	default:
+		condition = "(wn_booking.recipient_id = $1 OR wn_booking.provider_id = $1)"
+	}
+	return QueryPage(ctx, db, BookingUserSorting, page,
 		`SELECT 
 			wn_booking.id, 
 			wn_booking.recipient_id, 
@@ -182,44 +120,39 @@ func GetAllBookingUsersOfUser(db *sql.DB, userID int64) ([]BookingUser, error) {
 			wn_user.last_name,
 			wn_user.gender,
 			wn_user.faculty,
-			wn_user.email,
 			wn_user.user_role,
-			wn_user.password_hash
+			wn_user.verified
 		FROM wn_booking JOIN wn_user
-		ON wn_booking.provider_id = wn_user.id
-		WHERE wn_booking.recipient_id = $1 
-		OR wn_booking.provider_id = $2`,
-		userID,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	bookingUsers, err := ReadBookingUsers(rows)
-	if err != nil { return nil, err }
-	return bookingUsers, nil
+		ON wn_booking.provider_id = wn_user.id`,
+		[]string{condition},
+		[]interface{}{userID},
+		ReadBookingUsers)
 }
 
-func GetBookingUser(db *sql.DB, bookingID int64) (BookingUser, error) {
-	booking, err := GetBooking(db, bookingID)
+func GetBookingUser(ctx context.Context, db DBTX, bookingID int64) (BookingUser, error) {
+	booking, err := GetBooking(ctx, db, bookingID)
 	if err != nil { return BookingUser{}, err }
-	bookingUser, err := booking.LoadBookingWithUser(db)
+	bookingUser, err := booking.LoadBookingWithUser(ctx, db)
 	if err != nil { return BookingUser{}, err }
 	return bookingUser, nil
 }
 
-func GetBookingProvider(db *sql.DB, bookingID int64) (BookingProvider, error) {
-	booking, err := GetBooking(db, bookingID)
+// Bookings are only seen by their recipient and provider
+func GetBookingProvider(ctx context.Context, db DBTX, bookingID int64, userID int64) (BookingProvider, error) {
+	booking, err := GetBooking(ctx, db, bookingID)
 	if err != nil { return BookingProvider{}, err }
-	bookingProvider, err := booking.LoadBookingWithProvider(db)
+	if err := AuthorizeUserID(ctx, db, userID, ActionViewBooking, BookingRelations(userID, booking)); err != nil { return BookingProvider{}, err }
+	bookingProvider, err := booking.LoadBookingWithProvider(ctx, db)
 	if err != nil { return BookingProvider{}, err }
 	return bookingProvider, nil
 }
 
-func AddBooking(db *sql.DB, booking Booking, providerID int64, recipientID int64) (Booking, error) {
-	if !AuthoriseProvider(db, providerID) { return Booking{}, http_error.UnauthorizedError }
+func AddBooking(ctx context.Context, db DBTX, booking Booking, providerID int64, recipientID int64) (Booking, error) {
+	if err := AuthorizeUserID(ctx, db, providerID, ActionProvideBooking, nil); err != nil { return Booking{}, err }
 	booking.RecipientID = recipientID
 	booking.ProviderID = providerID
 	booking.ApproveBy = providerID
-	_, err := db.Exec(
+	err := db.QueryRowContext(ctx,
 		`INSERT INTO wn_booking (
 			recipient_id, 
 			provider_id,
@@ -228,28 +161,27 @@ func AddBooking(db *sql.DB, booking Booking, providerID int64, recipientID int64
 			details,
 			start_time,
 			end_time
-		) values ($1, $2, $3, $4, $5, $6, $7);`, 
+		) values ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;`, 
 		booking.RecipientID,
 		booking.ProviderID,
 		booking.ApproveBy,
 		booking.Nickname,
 		booking.Details,
 		booking.StartTime,
-		booking.EndTime)
-	if err != nil { return Booking{}, err }
-	booking, err = booking.LoadLastBookingID(db)
+		booking.EndTime).Scan(&booking.ID)
 	if err != nil { return Booking{}, err }
 	return booking, nil
 }
 
-func UpdateBooking(db *sql.DB, updatedBooking Booking, bookingID int64, userID int64) (Booking, error) {
-	targetBooking, err := GetBooking(db, bookingID)
+func UpdateBooking(ctx context.Context, db DBTX, updatedBooking Booking, bookingID int64, userID int64) (Booking, error) {
+	targetBooking, err := GetBooking(ctx, db, bookingID)
 	if err != nil { return Booking{}, err }
-	if userID != targetBooking.RecipientID {
-		return Booking{}, http_error.UnauthorizedError
+	if err := AuthorizeUserID(ctx, db, userID, ActionUpdateBooking, BookingRelations(userID, targetBooking)); err != nil {
+		return Booking{}, err
 	}
 	updatedBooking = updatedBooking.MergeBooking(targetBooking)
-	_, err = db.Exec(
+	_, err = db.ExecContext(ctx,
 		`UPDATE wn_booking SET 
 			recipient_id = $1, 
 			provider_id = $2,
@@ -271,11 +203,17 @@ func UpdateBooking(db *sql.DB, updatedBooking Booking, bookingID int64, userID i
 	return updatedBooking, nil
 }
 
-func RespondBooking(db *sql.DB, bookingRespond BookingRespond, bookingID int64, userID int64) (interface{}, error) {
-	bookingUser, err := GetBookingUser(db, bookingID)
-	if err != nil { return BookingRespond{}, nil }
-	if bookingUser.Booking.ApproveBy != userID {
-		return BookingRespond{}, http_error.UnauthorizedError 
+func RespondBooking(ctx context.Context, db *sql.DB, bookingRespond BookingRespond, bookingID int64, userID int64) (interface{}, error) {
+	return WithTx(ctx, db, func(tx DBTX) (interface{}, error) {
+		return respondBooking(ctx, tx, bookingRespond, bookingID, userID)
+	})
+}
+
+func respondBooking(ctx context.Context, db DBTX, bookingRespond BookingRespond, bookingID int64, userID int64) (interface{}, error) {
+	bookingUser, err := GetBookingUser(ctx, db, bookingID)
+	if err != nil { return BookingRespond{}, err }
+	if err := AuthorizeUserID(ctx, db, userID, ActionRespondBooking, BookingRelations(userID, bookingUser.Booking)); err != nil {
+		return BookingRespond{}, err
 	}
 	booking := bookingUser.Booking
 	user := bookingUser.User
@@ -290,26 +228,28 @@ func RespondBooking(db *sql.DB, bookingRespond BookingRespond, bookingID int64,
 			Access: "PRIVATE",
 			Category: "COUNSEL",
 		}
-		eventWithUsers, err := AddEventWithUserIDs(db, event, []int64{booking.ProviderID, booking.RecipientID})
+		eventWithUsers, err := addEventWithUserIDs(ctx, db, event, []int64{booking.ProviderID, booking.RecipientID})
 		if err != nil { return BookingRespond{}, err }
-		_, err = DeleteBooking(db, bookingID)
+		_, err = DeleteBooking(ctx, db, bookingID)
 		if err != nil { return BookingRespond{}, err }
 		return eventWithUsers, nil
 	} else {
 		updatedBooking := bookingRespond.Booking.MergeBooking(booking)
 		updatedBooking.ApproveBy = booking.FlippedApproveBy()
-		updatedBooking, err = UpdateBooking(db, updatedBooking, bookingID, booking.RecipientID)
+		updatedBooking, err = UpdateBooking(ctx, db, updatedBooking, bookingID, booking.RecipientID)
 		if err != nil { return BookingRespond{}, err }
 		bookingRespond.Booking = updatedBooking
 		return bookingRespond, nil
 	}
 }
 
-func DeleteBookingAuthorized(db *sql.DB, bookingID int64, userID int64) (Booking, error) {
-	targetBooking, err := GetBooking(db, bookingID)
+func DeleteBookingAuthorized(ctx context.Context, db DBTX, bookingID int64, userID int64) (Booking, error) {
+	targetBooking, err := GetBooking(ctx, db, bookingID)
 	if err != nil { return Booking{}, err }
-	if targetBooking.RecipientID != userID { return Booking{}, http_error.UnauthorizedError }
-	booking, err := DeleteBooking(db, bookingID)
+	if err := AuthorizeUserID(ctx, db, userID, ActionDeleteBooking, BookingRelations(userID, targetBooking)); err != nil {
+		return Booking{}, err
+	}
+	booking, err := DeleteBooking(ctx, db, bookingID)
 	if err != nil { return Booking{}, err }
 	return booking, nil
 }
@@ -1,8 +1,8 @@
 package model
 
 import (
+	"context"
 	"time"
-	"database/sql"
 )
 
 const (
@@ -37,14 +37,14 @@ func (m Message) IsServerMessage() bool {
 	return m.UserID == ServerUserID;
 }
 
-func (m Message) Payload(db *sql.DB) (MessagePayload, error) {
-	group, err := GetGroup(db, m.GroupID)
+func (m Message) Payload(ctx context.Context, db DBTX) (MessagePayload, error) {
+	group, err := GetGroup(ctx, db, m.GroupID)
 	if err != nil { return MessagePayload{}, err }
 	var senderName string
 	if m.IsServerMessage() {
 		senderName = "[WellNUS Server]"
 	} else {
-		sender, err := GetUser(db, m.UserID)
+		sender, err := GetUser(ctx, db, m.UserID)
 		if err != nil { return  MessagePayload{}, err }
 		senderName = sender.FirstName
 	}
@@ -56,7 +56,7 @@ type ChatStatusPayload struct {
 	Tag						int 			`json:"tag"`
 	GroupID					int64			`json:"group_id"`
 	GroupName				string			`json:"group_name"`
-	SortedInChatMembers		[]User			`json:"sorted_in_chat_members"`
-	SortedOnlineMembers 	[]User			`json:"sorted_online_members"`
-	SortedOfflineMembers	[]User			`json:"sorted_offline_members"`
+	SortedInChatMembers		[]PublicUser	`json:"sorted_in_chat_members"`
+	SortedOnlineMembers 	[]PublicUser	`json:"sorted_online_members"`
+	SortedOfflineMembers	[]PublicUser	`json:"sorted_offline_members"`
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"database/sql"
 	"time"
 )
@@ -23,11 +24,11 @@ func readMessagePayloads(rows *sql.Rows) ([]MessagePayload, error) {
 	return messagePayloads, nil
 }
 
-func GetMessagesChunkOfGroupCustomise(db *sql.DB, groupID int64, latestTime time.Time, limit int64) (MessagesChunk, error) {
+func GetMessagesChunkOfGroupCustomise(ctx context.Context, db DBTX, groupID int64, latestTime time.Time, limit int64) (MessagesChunk, error) {
 	var rows *sql.Rows
 	var err error
 	if limit <= 0 {
-		rows, err = db.Query(
+		rows, err = db.QueryContext(ctx,
 			`WITH t AS (
 				SELECT 
 					wn_user.first_name,
@@ -47,7 +48,7 @@ func GetMessagesChunkOfGroupCustomise(db *sql.DB, groupID int64, latestTime time
 			groupID,
 			latestTime)
 	} else {
-		rows, err = db.Query(
+		rows, err = db.QueryContext(ctx,
 			`WITH t AS (
 				SELECT 
 					wn_user.first_name,
@@ -82,8 +83,8 @@ func GetMessagesChunkOfGroupCustomise(db *sql.DB, groupID int64, latestTime time
 	return messagesChunk, nil
 }
 
-func AddMessage(db *sql.DB, message Message) error {
-	_, err := db.Exec(
+func AddMessage(ctx context.Context, db DBTX, message Message) error {
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_message (
 			user_id,
 			group_id,
@@ -0,0 +1,186 @@
+package model
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/router/http_helper/http_error"
+)
+
+// Points each signal of compatibility scores at most, before it is weighted
+const CompatibilityPoints = 4
+
+// Points a signal scores when either match request leaves it unset
+const neutralPoints = CompatibilityPoints / 2
+
+// Slots of the week a user may be available in
+var Availabilities = []string{"WEEKDAY_MORNING", "WEEKDAY_AFTERNOON", "WEEKDAY_EVENING", "WEEKEND_MORNING", "WEEKEND_AFTERNOON", "WEEKEND_EVENING"}
+
+// Weighted points of each signal of the compatibility between 2 match requests, and their sum
+type CompatibilityScore struct {
+	Faculty			int	`json:"faculty"`
+	MBTI			int	`json:"mbti"`
+	Hobbies			int	`json:"hobbies"`
+	Gender			int	`json:"gender"`
+	YearOfStudy		int	`json:"year_of_study"`
+	Availability	int	`json:"availability"`
+	GroupSize		int	`json:"group_size"`
+	Total			int	`json:"total"`
+}
+
+// Compatibility of a member of a group with one of the others
+type MemberCompatibility struct {
+	User	PublicUser			`json:"user"`
+	Score	CompatibilityScore	`json:"score"`
+}
+
+// Explains the compatibility of a user with the rest of a group they were matched into.
+// Members without a match setting are left out
+type CompatibilityBreakdown struct {
+	GroupID		int64					`json:"group_id"`
+	UserID		int64					`json:"user_id"`
+	Members		[]MemberCompatibility	`json:"members"`
+	Total		CompatibilityScore		`json:"total"`		// sum of the scores with every member
+	MaxScore	int						`json:"max_score"`	// most a pair of match requests can score
+}
+
+func (score CompatibilityScore) add(other CompatibilityScore) CompatibilityScore {
+	return CompatibilityScore{
+		Faculty: score.Faculty + other.Faculty,
+		MBTI: score.MBTI + other.MBTI,
+		Hobbies: score.Hobbies + other.Hobbies,
+		Gender: score.Gender + other.Gender,
+		YearOfStudy: score.YearOfStudy + other.YearOfStudy,
+		Availability: score.Availability + other.Availability,
+		GroupSize: score.GroupSize + other.GroupSize,
+		Total: score.Total + other.Total,
+	}
+}
+
+// Most a pair of match requests can score with weights
+func MaxCompatibility(weights config.CompatibilityWeights) int {
+	return CompatibilityPoints * (weights.Faculty + weights.MBTI + weights.Hobbies + weights.Gender + weights.YearOfStudy + weights.Availability + weights.GroupSize)
+}
+
+// Determines the compatibility between 2 loaded match request, from 0 to MaxCompatibility(weights)
+func Compatibility(loadedMatchRequest1, loadedMatchRequest2 LoadedMatchRequest, weights config.CompatibilityWeights) int {
+	return ScoreCompatibility(loadedMatchRequest1, loadedMatchRequest2, weights).Total
+}
+
+// Scores every signal of the compatibility between 2 loaded match requests out of CompatibilityPoints and weighs it
+func ScoreCompatibility(loadedMatchRequest1, loadedMatchRequest2 LoadedMatchRequest, weights config.CompatibilityWeights) CompatibilityScore {
+	ms1, ms2 := loadedMatchRequest1.MatchSetting, loadedMatchRequest2.MatchSetting
+	score := CompatibilityScore{
+		Faculty: weights.Faculty * preferencePoints(loadedMatchRequest1.User.Faculty == loadedMatchRequest2.User.Faculty, ms1.FacultyPreference, ms2.FacultyPreference),
+		MBTI: weights.MBTI * mbtiPoints(ms1.MBTI, ms2.MBTI),
+		Hobbies: weights.Hobbies * sharedPoints(ms1.Hobbies, ms2.Hobbies),
+		Gender: weights.Gender * preferencePoints(loadedMatchRequest1.User.Gender == loadedMatchRequest2.User.Gender, ms1.GenderPreference, ms2.GenderPreference),
+		YearOfStudy: weights.YearOfStudy * yearOfStudyPoints(ms1.YearOfStudy, ms2.YearOfStudy),
+		Availability: weights.Availability * availabilityPoints(ms1.Availability, ms2.Availability),
+		GroupSize: weights.GroupSize * groupSizePoints(ms1.PreferredGroupSize, ms2.PreferredGroupSize),
+	}
+	score.Total = score.Faculty + score.MBTI + score.Hobbies + score.Gender + score.YearOfStudy + score.Availability + score.GroupSize
+	return score
+}
+
+// Breaks down the compatibility of the user with userID with the other members of the group with groupID.
+// members are the loaded match requests of the members with a match setting, the user among them
+func BreakDownCompatibility(groupID int64, userID int64, members []LoadedMatchRequest, weights config.CompatibilityWeights) (CompatibilityBreakdown, error) {
+	var user LoadedMatchRequest
+	found := false
+	for _, member := range members {
+		if member.User.ID == userID {
+			user = member
+			found = true
+		}
+	}
+	if !found { return CompatibilityBreakdown{}, http_error.NotFoundError }
+	breakdown := CompatibilityBreakdown{
+		GroupID: groupID,
+		UserID: userID,
+		Members: make([]MemberCompatibility, 0),
+		MaxScore: MaxCompatibility(weights),
+	}
+	for _, member := range members {
+		if member.User.ID == userID { continue }
+		score := ScoreCompatibility(user, member, weights)
+		breakdown.Members = append(breakdown.Members, MemberCompatibility{ User: member.User, Score: score })
+		breakdown.Total = breakdown.Total.add(score)
+	}
+	return breakdown, nil
+}
+
+// Full points when both prefer SAME and are the same, or both prefer MIX and are not. None when
+// both prefer what they are not, and half otherwise
+func preferencePoints(same bool, preference1, preference2 string) int {
+	if preference1 == "SAME" && preference2 == "SAME" {
+		if same { return CompatibilityPoints }
+		return 0
+	}
+	if preference1 == "MIX" && preference2 == "MIX" {
+		if same { return 0 }
+		return CompatibilityPoints
+	}
+	return neutralPoints
+}
+
+// A point for every letter the MBTI types share
+func mbtiPoints(mbti1, mbti2 string) int {
+	if len(mbti1) != len(mbti2) { return 0 }
+	points := 0
+	for i := 0; i < len(mbti1); i++ {
+		if mbti1[i] == mbti2[i] { points++ }
+	}
+	return points
+}
+
+// A point for every item shared, up to CompatibilityPoints
+func sharedPoints(items1, items2 []string) int {
+	points := 0
+	for _, item1 := range items1 {
+		for _, item2 := range items2 {
+			if item1 == item2 {
+				points++
+				break
+			}
+		}
+	}
+	if points > CompatibilityPoints { return CompatibilityPoints }
+	return points
+}
+
+// A point less for every year apart
+func yearOfStudyPoints(year1, year2 int) int {
+	if year1 == 0 || year2 == 0 { return neutralPoints }
+	apart := year1 - year2
+	if apart < 0 { apart = -apart }
+	if apart >= CompatibilityPoints { return 0 }
+	return CompatibilityPoints - apart
+}
+
+// Share of the slots of the less available request that the other is available in too
+func availabilityPoints(availability1, availability2 []string) int {
+	if len(availability1) == 0 || len(availability2) == 0 { return neutralPoints }
+	fewer := len(availability1)
+	if len(availability2) < fewer { fewer = len(availability2) }
+	shared := 0
+	for _, slot1 := range availability1 {
+		for _, slot2 := range availability2 {
+			if slot1 == slot2 {
+				shared++
+				break
+			}
+		}
+	}
+	return CompatibilityPoints * shared / fewer
+}
+
+// Full points for the same preferred group size and half for sizes 1 apart
+func groupSizePoints(size1, size2 int) int {
+	if size1 == 0 || size2 == 0 { return neutralPoints }
+	switch size1 - size2 {
+	case 0:
+		return CompatibilityPoints
+	case -1, 1:
+		return neutralPoints
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+)
+
+// Breaks down the compatibility of the user with userID with the rest of the group with groupID,
+// which only its members may see
+func GetCompatibilityBreakdown(ctx context.Context, db DBTX, weights config.CompatibilityWeights, groupID int64, userID int64) (CompatibilityBreakdown, error) {
+	groupWithUsers, err := GetGroupWithUsers(ctx, db, groupID)
+	if err != nil { return CompatibilityBreakdown{}, err }
+	isMember := false
+	for _, user := range groupWithUsers.Users {
+		if user.ID == userID { isMember = true }
+	}
+	if err := AuthorizeUserID(ctx, db, userID, ActionViewCompatibility, GroupRelations(userID, groupWithUsers.Group, isMember)); err != nil {
+		return CompatibilityBreakdown{}, err
+	}
+
+	members := make([]LoadedMatchRequest, 0, len(groupWithUsers.Users))
+	for _, user := range groupWithUsers.Users {
+		matchSetting, err := GetMatchSettingOfUser(ctx, db, user.ID)
+		if err == http_error.NotFoundError { continue }
+		if err != nil { return CompatibilityBreakdown{}, err }
+		members = append(members, LoadedMatchRequest{ User: user, MatchSetting: matchSetting })
+	}
+	return BreakDownCompatibility(groupID, userID, members, weights)
+}
@@ -3,8 +3,7 @@ package model
 import (
 	"wellnus/backend/router/http_helper/http_error"
 
-	"log"
-	"fmt"
+	"context"
 	"database/sql"
 	"time"
 
@@ -29,8 +28,8 @@ func ReadCounselRequests(rows *sql.Rows) ([]CounselRequest, error) {
 	return counselRequests, nil
 }
 
-func CheckCounselRequest(db *sql.DB, userID int64) (bool, error) {
-	row, err := db.Query("SELECT COUNT(*) != 0 FROM wn_counsel_request WHERE user_id = $1", userID)
+func CheckCounselRequest(ctx context.Context, db DBTX, userID int64) (bool, error) {
+	row, err := db.QueryContext(ctx, "SELECT COUNT(*) != 0 FROM wn_counsel_request WHERE user_id = $1", userID)
 	if err != nil { return false, err }
 	defer row.Close()
 	row.Next()
@@ -43,27 +42,31 @@ func CheckCounselRequest(db *sql.DB, userID int64) (bool, error) {
 
 // Main functions
 
-func GetAllCounselRequests(db *sql.DB, topics []string, userID int64) ([]CounselRequest, error) {
-	authorized := AuthoriseProvider(db, userID)
-	if !authorized { return nil, http_error.UnauthorizedError }
-	var rows *sql.Rows
-	var err error
-	if topics == nil {
-		rows, err = db.Query("SELECT * FROM wn_counsel_request")
-	} else {
-		rows, err = db.Query("SELECT * FROM wn_counsel_request WHERE $1 <@ topics", pq.Array(topics))
+// Counsel requests are keyed by the user who made them
+var CounselRequestSorting = Sorting[CounselRequest]{
+	IDSQL: "user_id",
+	ID: func(counselRequest CounselRequest) int64 { return counselRequest.UserID },
+	Keys: map[string]SortKey[CounselRequest]{
+		"id":			{ SQL: "user_id", Value: func(counselRequest CounselRequest) interface{} { return counselRequest.UserID } },
+		"last_updated":	{ SQL: "last_updated", Value: func(counselRequest CounselRequest) interface{} { return counselRequest.LastUpdated } },
+	},
+}
+
+func GetCounselRequestsPage(ctx context.Context, db DBTX, topics []string, userID int64, page PageQuery) (Page[CounselRequest], error) {
+	if err := AuthorizeUserID(ctx, db, userID, ActionListCounselRequests, nil); err != nil { return Page[CounselRequest]{}, err }
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	if len(topics) > 0 {
+		conditions = append(conditions, "$1 <@ topics")
+		args = append(args, pq.Array(topics))
 	}
-	if err != nil { return nil, err }
-	defer rows.Close()
-	counselRequests, err := ReadCounselRequests(rows)
-	if err != nil { return nil, err }
-	return counselRequests, nil
+	return QueryPage(ctx, db, CounselRequestSorting, page, "SELECT * FROM wn_counsel_request", conditions, args, ReadCounselRequests)
 }
 
-func GetCounselRequest(db *sql.DB, recipientUserID int64, userID int64) (CounselRequest, error) {
-	authorized := recipientUserID == userID || AuthoriseProvider(db, userID)
-	if !authorized { return CounselRequest{}, http_error.UnauthorizedError }
-	rows, err := db.Query("SELECT * FROM wn_counsel_request WHERE user_id = $1", recipientUserID)
+func GetCounselRequest(ctx context.Context, db DBTX, recipientUserID int64, userID int64) (CounselRequest, error) {
+	relations := CounselRequestRelations(userID, recipientUserID)
+	if err := AuthorizeUserID(ctx, db, userID, ActionViewCounselRequest, relations); err != nil { return CounselRequest{}, err }
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_counsel_request WHERE user_id = $1", recipientUserID)
 	if err != nil { return CounselRequest{}, err }
 	defer rows.Close()
 	counselRequests, err := ReadCounselRequests(rows)
@@ -72,10 +75,10 @@ func GetCounselRequest(db *sql.DB, recipientUserID int64, userID int64) (Counsel
 	return counselRequests[0], nil
 }
 
-func AddUpdateCounselRequest(db *sql.DB, counselRequest CounselRequest, userID int64) (CounselRequest, error) {
+func AddUpdateCounselRequest(ctx context.Context, db DBTX, counselRequest CounselRequest, userID int64) (CounselRequest, error) {
 	counselRequest.UserID = userID
 	counselRequest.LastUpdated = time.Now()
-	_, err := db.Exec(
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_counsel_request (
 			user_id,
 			nickname,
@@ -99,30 +102,30 @@ func AddUpdateCounselRequest(db *sql.DB, counselRequest CounselRequest, userID i
 	return counselRequest, nil
 }
 
-func DeleteCounselRequest(db *sql.DB, userID int64) (CounselRequest, error) {
-	_, err := db.Exec(`DELETE FROM wn_counsel_request WHERE user_id = $1`, userID)
+func DeleteCounselRequest(ctx context.Context, db DBTX, userID int64) (CounselRequest, error) {
+	_, err := db.ExecContext(ctx, `DELETE FROM wn_counsel_request WHERE user_id = $1`, userID)
 	if err != nil { return CounselRequest{}, err }
 	return CounselRequest{ UserID: userID }, nil
 }
 
-func AcceptCounselRequest(db *sql.DB, recipientUserID int64, providerUserID int64) (GroupWithUsers, error) {
-	authorized := AuthoriseProvider(db, providerUserID)
-	if !authorized { return GroupWithUsers{}, http_error.UnauthorizedError }
-	present, err := CheckCounselRequest(db, recipientUserID)
+func AcceptCounselRequest(ctx context.Context, db *sql.DB, recipientUserID int64, providerUserID int64) (GroupWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) (GroupWithUsers, error) {
+		return acceptCounselRequest(ctx, tx, recipientUserID, providerUserID)
+	})
+}
+
+func acceptCounselRequest(ctx context.Context, db DBTX, recipientUserID int64, providerUserID int64) (GroupWithUsers, error) {
+	if err := AuthorizeUserID(ctx, db, providerUserID, ActionAcceptCounselRequest, nil); err != nil { return GroupWithUsers{}, err }
+	present, err := CheckCounselRequest(ctx, db, recipientUserID)
 	if err != nil { return GroupWithUsers{}, err }
 	if !present { return GroupWithUsers{}, http_error.NotFoundError }
-	user, err := GetUser(db, providerUserID)
-	if err != nil { return GroupWithUsers{}, err }
-	if !IsProvider(user) { return GroupWithUsers{}, http_error.UnauthorizedError }
 	group := Group{
 		GroupName: "Counsel Room",
 		GroupDescription: "Welcome to your new Counsel Room",
 		Category: "COUNSEL",
 	}
-	groupWithUsers, err := AddGroupWithUserIDs(db, group, []int64{providerUserID, recipientUserID})
+	groupWithUsers, err := addGroupWithUserIDs(ctx, db, group, []int64{providerUserID, recipientUserID})
 	if err != nil { return GroupWithUsers{}, err }
-	if _, fatal := DeleteCounselRequest(db, recipientUserID); fatal != nil {
-		log.Fatal(fmt.Sprintf("Failed to remove counsel request after creating group. Fatal: %v", fatal))
-	}
+	if _, err = DeleteCounselRequest(ctx, db, recipientUserID); err != nil { return GroupWithUsers{}, err }
 	return groupWithUsers, nil
 }
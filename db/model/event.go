@@ -2,7 +2,6 @@ package model
 
 import (
 	"time"
-	"database/sql"
 )
 
 type UserIDBody struct {
@@ -22,7 +21,7 @@ type Event struct {
 
 type EventWithUsers struct {
 	Event	Event	`json:"event"`
-	Users	[]User	`json:"users"`
+	Users	[]PublicUser	`json:"users"`
 }
 
 func (eventMain Event) MergeEvent(eventAdd Event) Event {
@@ -51,15 +50,6 @@ func (eventMain Event) MergeEvent(eventAdd Event) Event {
 	return eventMain
 }
 
-func (event Event) LoadLastEventID(db *sql.DB) (Event, error) {
-	row, err := db.Query("SELECT last_value FROM wn_event_id_seq;")
-	if err != nil { return Event{}, err }
-	defer row.Close()
-	row.Next()
-	if err := row.Scan(&event.ID); err != nil { return Event{}, err }
-	return event, nil
-}
-
 func (event1 Event) Equal(event2 Event) bool {
 	return event1.ID == event2.ID &&
 		event1.OwnerID == event2.OwnerID &&
@@ -2,10 +2,10 @@ package model
 
 import (
 	"wellnus/backend/router/http_helper/http_error"	
-	"log"
+	"context"
 	"fmt"
 	"database/sql"
-	"errors"
+	"strings"
 )
 
 // Helper function
@@ -30,8 +30,8 @@ func ReadEvents(rows *sql.Rows) ([]Event, error) {
 	return events, nil
 }
 
-func GetEvent(db *sql.DB, eventID int64) (Event, error) {
-	rows, err := db.Query("SELECT * FROM wn_event WHERE id = $1;", eventID)
+func GetEvent(ctx context.Context, db DBTX, eventID int64) (Event, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_event WHERE id = $1;", eventID)
 	if err != nil { return Event{}, err }
 	defer rows.Close()
 	events, err := ReadEvents(rows)
@@ -40,8 +40,8 @@ func GetEvent(db *sql.DB, eventID int64) (Event, error) {
 	return events[0], nil
 }
 
-func AddUserToEvent(db *sql.DB, eventID int64, userID int64) error {
-	_, err := db.Exec(
+func AddUserToEvent(ctx context.Context, db DBTX, eventID int64, userID int64) error {
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_user_event (
 			user_id, 
 			event_id) 
@@ -51,8 +51,8 @@ func AddUserToEvent(db *sql.DB, eventID int64, userID int64) error {
 	return err
 }
 
-func RemoveUserFromEvent(db *sql.DB, eventID int64, userID int64) error {
-	_, err := db.Exec(
+func RemoveUserFromEvent(ctx context.Context, db DBTX, eventID int64, userID int64) error {
+	_, err := db.ExecContext(ctx,
 		`DELETE FROM wn_user_event WHERE
 			user_id = $1 AND
 			event_id = $2`,
@@ -61,23 +61,23 @@ func RemoveUserFromEvent(db *sql.DB, eventID int64, userID int64) error {
 	return err
 }
 
-func DeleteEvent(db *sql.DB, eventID int64) error {
-	_, err := db.Exec("DELETE FROM wn_event WHERE id = $1", eventID)
+func DeleteEvent(ctx context.Context, db DBTX, eventID int64) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM wn_event WHERE id = $1", eventID)
 	return err
 }
 
 // Main Functions
 
-func GetEventWithUsers(db *sql.DB, eventID int64) (EventWithUsers, error) {
-	event, err := GetEvent(db, eventID)
+func GetEventWithUsers(ctx context.Context, db DBTX, eventID int64) (EventWithUsers, error) {
+	event, err := GetEvent(ctx, db, eventID)
 	if err != nil { return EventWithUsers{}, err }
-	users, err := GetAllUsersOfEvent(db, eventID)
+	users, err := GetAllUsersOfEvent(ctx, db, eventID)
 	if err != nil { return EventWithUsers{}, err }
-	return EventWithUsers{ Event: event, Users: users}, nil
+	return EventWithUsers{ Event: event, Users: PublicUsers(users)}, nil
 }
 
-func GetAllEventsOfUser(db *sql.DB, userID int64) ([]Event, error) {
-	rows, err := db.Query(
+func GetAllEventsOfUser(ctx context.Context, db DBTX, userID int64) ([]Event, error) {
+	rows, err := db.QueryContext(ctx,
 		`SELECT
 			wn_event.id,
 			wn_event.owner_id,
@@ -98,12 +98,45 @@ func GetAllEventsOfUser(db *sql.DB, userID int64) ([]Event, error) {
 	return events, nil
 }
 
-func AddEventWithUserIDs(db *sql.DB, event Event, userIDs []int64) (EventWithUsers, error) {
-	if len(userIDs) == 0 { return EventWithUsers{}, errors.New("Insufficient users to form a event") }
-	ownerID := userIDs[0]
-	userIDs = userIDs[1:]
-	event.OwnerID = ownerID //Taking first userID as ownerID
-	_, err := db.Exec(
+var EventSorting = Sorting[Event]{
+	IDSQL: "wn_event.id",
+	ID: func(event Event) int64 { return event.ID },
+	Keys: map[string]SortKey[Event]{
+		"id":			{ SQL: "wn_event.id", Value: func(event Event) interface{} { return event.ID } },
+		"event_name":	{ SQL: `lower(wn_event.event_name) COLLATE "C"`, Value: func(event Event) interface{} { return strings.ToLower(event.EventName) } },
+		"start_time":	{ SQL: "wn_event.start_time", Value: func(event Event) interface{} { return event.StartTime } },
+	},
+}
+
+func GetEventsPageOfUser(ctx context.Context, db DBTX, userID int64, page PageQuery) (Page[Event], error) {
+	return QueryPage(ctx, db, EventSorting, page,
+		`SELECT
+			wn_event.id,
+			wn_event.owner_id,
+			wn_event.event_name, 
+			wn_event.event_description,
+			wn_event.start_time, 
+			wn_event.end_time,
+			wn_event.access,
+			wn_event.category
+		FROM wn_user_event JOIN wn_event 
+		ON wn_user_event.event_id = wn_event.id`,
+		[]string{"wn_user_event.user_id = $1"},
+		[]interface{}{userID},
+		ReadEvents)
+}
+
+func AddEventWithUserIDs(ctx context.Context, db *sql.DB, event Event, userIDs []int64) (EventWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) (EventWithUsers, error) {
+		return addEventWithUserIDs(ctx, tx, event, userIDs)
+	})
+}
+
+func addEventWithUserIDs(ctx context.Context, db DBTX, event Event, userIDs []int64) (EventWithUsers, error) {
+	userIDs = DistinctIDs(userIDs)
+	if len(userIDs) == 0 { return EventWithUsers{}, NoMembersError }
+	event.OwnerID = userIDs[0] //Taking first userID as ownerID
+	err := db.QueryRowContext(ctx,
 		`INSERT INTO wn_event (
 			owner_id,
 			event_name, 
@@ -112,50 +145,39 @@ func AddEventWithUserIDs(db *sql.DB, event Event, userIDs []int64) (EventWithUse
 			end_time,
 			access,
 			category) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;`,
 		event.OwnerID,
 		event.EventName,
 		event.EventDescription,
 		event.StartTime,
 		event.EndTime,
 		event.Access,
-		event.Category)
-	if err != nil { return EventWithUsers{}, err }
-	event, err = event.LoadLastEventID(db)
+		event.Category).Scan(&event.ID)
 	if err != nil { return EventWithUsers{}, err }
 
-	// Adding Owner
-	if err = AddUserToEvent(db, event.ID, ownerID); err != nil {
-		log.Printf("Failed to add Owner: %v", err)
-		if _, fatal := db.Exec("DELETE FROM wn_event WHERE id = $1", event.ID); fatal != nil {
-			log.Fatal(fmt.Sprintf("Failed to remove added event after failing to add owner. Fatal: %v", fatal))
-		}
-		return EventWithUsers{}, err
-	}
-
-	// Adding Other Users
+	// Adding Owner and Other Users. Any failure rolls back the whole event
 	for _, userID := range userIDs {
-		AddUserToEvent(db, event.ID, userID)
-
-		// Strict version
-		// if err := AddUserToEvent(db, event.ID, userID); err != nil {
-		// 	return EventWithUsers{}, err
-		// }
+		if err := AddUserToEvent(ctx, db, event.ID, userID); err != nil {
+			return EventWithUsers{}, err
+		}
 	}
-	users, err := GetAllUsersOfEvent(db, event.ID)
+	users, err := GetAllUsersOfEvent(ctx, db, event.ID)
 	if err != nil { return EventWithUsers{}, err }
 
-	return EventWithUsers{ Event: event, Users: users }, nil
+	return EventWithUsers{ Event: event, Users: PublicUsers(users) }, nil
 }
 
-func UpdateEvent(db *sql.DB, updatedEvent Event, eventID int64, userID int64) (Event, error) {
-	targetEvent, err := GetEvent(db, eventID)
+func UpdateEvent(ctx context.Context, db DBTX, updatedEvent Event, eventID int64, userID int64) (Event, error) {
+	targetEvent, err := GetEvent(ctx, db, eventID)
 	if err != nil { return Event{}, err }
-	if targetEvent.OwnerID != userID { return Event{}, http_error.UnauthorizedError }
+	if err := AuthorizeUserID(ctx, db, userID, ActionUpdateEvent, EventRelations(userID, targetEvent)); err != nil {
+		return Event{}, err
+	}
 
 	updatedEvent = updatedEvent.MergeEvent(targetEvent)
 
-	_, err = db.Exec(
+	_, err = db.ExecContext(ctx,
 		`UPDATE wn_event SET 
 			owner_id = $1,
 			event_name = $2, 
@@ -177,36 +199,42 @@ func UpdateEvent(db *sql.DB, updatedEvent Event, eventID int64, userID int64) (E
 	return updatedEvent, nil
 }
 
-func LeaveDeleteEvent(db *sql.DB, eventID int64, userID int64) (EventWithUsers, error) {
-	targetEvent, err := GetEvent(db, eventID)
+func LeaveDeleteEvent(ctx context.Context, db DBTX, eventID int64, userID int64) (EventWithUsers, error) {
+	targetEvent, err := GetEvent(ctx, db, eventID)
 	if err != nil { return EventWithUsers{}, err }
 	if targetEvent.OwnerID == userID {
-		err = DeleteEvent(db, eventID)
+		err = DeleteEvent(ctx, db, eventID)
 		if err != nil { return EventWithUsers{}, err }
 		return EventWithUsers{ Event: Event{ ID: eventID } }, nil
 	} else {
-		err = RemoveUserFromEvent(db, eventID, userID)
+		err = RemoveUserFromEvent(ctx, db, eventID, userID)
 		if err != nil { return EventWithUsers{}, err }
-		users, err := GetAllUsersOfEvent(db, eventID)
+		users, err := GetAllUsersOfEvent(ctx, db, eventID)
 		if err != nil { return EventWithUsers{}, err }
-		return EventWithUsers{ Event: targetEvent, Users: users }, nil
+		return EventWithUsers{ Event: targetEvent, Users: PublicUsers(users) }, nil
 	}
 }
 
-func LeaveDeleteAllEvents(db *sql.DB, userID int64) ([]EventWithUsers, error) {
-	events, err := GetAllEventsOfUser(db, userID)
+func LeaveDeleteAllEvents(ctx context.Context, db *sql.DB, userID int64) ([]EventWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) ([]EventWithUsers, error) {
+		return leaveDeleteAllEvents(ctx, tx, userID)
+	})
+}
+
+func leaveDeleteAllEvents(ctx context.Context, db DBTX, userID int64) ([]EventWithUsers, error) {
+	events, err := GetAllEventsOfUser(ctx, db, userID)
 	if err != nil { return nil, err}
 	eventsWithUsers := make([]EventWithUsers, 0)
 	for _, event := range events {
-		eventWithUsers, err := LeaveDeleteEvent(db, event.ID, userID)
+		eventWithUsers, err := LeaveDeleteEvent(ctx, db, event.ID, userID)
 		if err != nil { return nil, err}
 		eventsWithUsers = append(eventsWithUsers, eventWithUsers)
 	}
 	return eventsWithUsers, nil
 }
 
-func IsUserInEvent(db *sql.DB, userID int64, eventID int64) (bool, error) {
-	row, err := db.Query(
+func IsUserInEvent(ctx context.Context, db DBTX, userID int64, eventID int64) (bool, error) {
+	row, err := db.QueryContext(ctx,
 		`SELECT COUNT(*) != 0 FROM wn_user_event 
 		WHERE user_id = $1 and event_id = $2`,
 		userID,
@@ -219,25 +247,34 @@ func IsUserInEvent(db *sql.DB, userID int64, eventID int64) (bool, error) {
 	return membership, nil
 }
 
-func AddUserToEventAuthorized(db *sql.DB, userID int64, eventID int64, adderID int64) (EventWithUsers, error) {
-	targetEvent, err := GetEvent(db, eventID)
+func AddUserToEventAuthorized(ctx context.Context, db DBTX, userID int64, eventID int64, adderID int64) (EventWithUsers, error) {
+	targetEvent, err := GetEvent(ctx, db, eventID)
 	if err != nil { return EventWithUsers{}, err }
-	if targetEvent.Access == "PRIVATE" && targetEvent.OwnerID != adderID  {
-		return EventWithUsers{}, http_error.UnauthorizedError
+	action := ActionJoinPublicEvent
+	if targetEvent.Access == "PRIVATE" { action = ActionAddUserToPrivateEvent }
+	if err := AuthorizeUserID(ctx, db, adderID, action, EventRelations(adderID, targetEvent)); err != nil {
+		return EventWithUsers{}, err
 	}
-	if err = AddUserToEvent(db, eventID, userID); err != nil {
+	if err = AddUserToEvent(ctx, db, eventID, userID); err != nil {
 		return EventWithUsers{}, err
 	}
-	users, err := GetAllUsersOfEvent(db, eventID)
+	users, err := GetAllUsersOfEvent(ctx, db, eventID)
 	if err != nil { return EventWithUsers{}, err }
-	return EventWithUsers{ Event: targetEvent, Users: users }, nil
+	return EventWithUsers{ Event: targetEvent, Users: PublicUsers(users) }, nil
+}
+
+func CreateGroupDeleteEvent(ctx context.Context, db *sql.DB, eventID int64, userID int64) (GroupWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) (GroupWithUsers, error) {
+		return createGroupDeleteEvent(ctx, tx, eventID, userID)
+	})
 }
 
-func CreateGroupDeleteEvent(db *sql.DB, eventID int64, userID int64) (GroupWithUsers, error) {
-	targetEventWithUsers, err := GetEventWithUsers(db, eventID)
+func createGroupDeleteEvent(ctx context.Context, db DBTX, eventID int64, userID int64) (GroupWithUsers, error) {
+	targetEventWithUsers, err := GetEventWithUsers(ctx, db, eventID)
 	if err != nil { return GroupWithUsers{}, err }
-	if targetEventWithUsers.Event.OwnerID != userID  {
-		return GroupWithUsers{}, http_error.UnauthorizedError
+	relations := EventRelations(userID, targetEventWithUsers.Event)
+	if err := AuthorizeUserID(ctx, db, userID, ActionStartEvent, relations); err != nil {
+		return GroupWithUsers{}, err
 	}
 	group := Group{
 		GroupName: fmt.Sprintf("%s Room", targetEventWithUsers.Event.EventName),
@@ -249,8 +286,8 @@ func CreateGroupDeleteEvent(db *sql.DB, eventID int64, userID int64) (GroupWithU
 	for _, user := range targetEventWithUsers.Users {
 		users = append(users, user.ID)
 	}
-	groupWithUsers, err := AddGroupWithUserIDs(db, group, users)
+	groupWithUsers, err := addGroupWithUserIDs(ctx, db, group, users)
 	if err != nil { return GroupWithUsers{}, err }
-	if err = DeleteEvent(db, eventID); err != nil { return GroupWithUsers{}, err }
+	if err = DeleteEvent(ctx, db, eventID); err != nil { return GroupWithUsers{}, err }
 	return groupWithUsers, nil
 }
\ No newline at end of file
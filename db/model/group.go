@@ -1,9 +1,15 @@
 package model
 
 import (
-	"database/sql"
+	"wellnus/backend/router/http_helper/http_error"
 )
 
+// Groups and events are always created with a member, who becomes the owner
+var NoMembersError = http_error.NewValidationError("users", "must include at least one user")
+
+// Ownership only passes to a member of the group
+var OwnerNotMemberError = http_error.NewValidationError("owner_id", "must be a member of the group")
+
 type Group struct {
 	ID					int64	`json:"id"`
 	GroupName			string	`json:"group_name"`
@@ -14,7 +20,7 @@ type Group struct {
 
 type GroupWithUsers struct {
 	Group	Group	`json:"group"`
-	Users	[]User	`json:"users"`
+	Users	[]PublicUser	`json:"users"`
 }
 
 func (groupMain Group) MergeGroup(groupAdd Group) Group {
@@ -34,15 +40,6 @@ func (groupMain Group) MergeGroup(groupAdd Group) Group {
 	return groupMain
 }
 
-func (group Group) LoadLastGroupID(db *sql.DB) (Group, error) {
-	row, err := db.Query("SELECT last_value FROM wn_group_id_seq;")
-	if err != nil { return Group{}, err }
-	defer row.Close()
-	row.Next()
-	if err := row.Scan(&group.ID); err != nil { return Group{}, err }
-	return group, nil
-}
-
 func (groupWithUsers GroupWithUsers) GetNewOwnerID() int64 {
 	currOwnerID := groupWithUsers.Group.OwnerID
 	users := groupWithUsers.Users
@@ -2,13 +2,23 @@ package model
 
 import (
 	"wellnus/backend/router/http_helper/http_error"	
-	"log"
-	"fmt"
+	"context"
 	"database/sql"
-	"errors"
+	"strings"
 )
 
 // Helper function
+func DistinctIDs(ids []int64) []int64 {
+	seen := make(map[int64]bool)
+	distinct := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] { continue }
+		seen[id] = true
+		distinct = append(distinct, id)
+	}
+	return distinct
+}
+
 func ReadGroups(rows *sql.Rows) ([]Group, error) {
 	groups := make([]Group, 0)
 	for rows.Next() {
@@ -21,8 +31,8 @@ func ReadGroups(rows *sql.Rows) ([]Group, error) {
 	return groups, nil
 }
 
-func GetGroup(db *sql.DB, groupID int64) (Group, error) {
-	rows, err := db.Query("SELECT * FROM wn_group WHERE id = $1;", groupID)
+func GetGroup(ctx context.Context, db DBTX, groupID int64) (Group, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_group WHERE id = $1;", groupID)
 	if err != nil { return Group{}, err }
 	defer rows.Close()
 	groups, err := ReadGroups(rows)
@@ -31,9 +41,9 @@ func GetGroup(db *sql.DB, groupID int64) (Group, error) {
 	return groups[0], nil
 }
 
-func ChangeOwnership(db *sql.DB, group Group, newOwnerID int64) (Group, error) {
+func ChangeOwnership(ctx context.Context, db DBTX, group Group, newOwnerID int64) (Group, error) {
 	group.OwnerID = newOwnerID
-	_, err := db.Exec(
+	_, err := db.ExecContext(ctx,
 		`UPDATE wn_group SET 
 			owner_id = $1
 		WHERE id = $2;`,
@@ -43,8 +53,8 @@ func ChangeOwnership(db *sql.DB, group Group, newOwnerID int64) (Group, error) {
 	return group, nil
 }
 
-func AddUserToGroup(db *sql.DB, groupID int64, userID int64) error {
-	_, err := db.Exec(
+func AddUserToGroup(ctx context.Context, db DBTX, groupID int64, userID int64) error {
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_user_group (
 			user_id, 
 			group_id) 
@@ -54,8 +64,8 @@ func AddUserToGroup(db *sql.DB, groupID int64, userID int64) error {
 	return err
 }
 
-func RemoveUserFromGroup(db *sql.DB, groupID int64, userID int64) error {
-	_, err := db.Exec(
+func RemoveUserFromGroup(ctx context.Context, db DBTX, groupID int64, userID int64) error {
+	_, err := db.ExecContext(ctx,
 		`DELETE FROM wn_user_group WHERE
 			user_id = $1 AND
 			group_id = $2`,
@@ -64,23 +74,23 @@ func RemoveUserFromGroup(db *sql.DB, groupID int64, userID int64) error {
 	return err
 }
 
-func DeleteGroup(db *sql.DB, groupID int64) error {
-	_, err := db.Exec("DELETE FROM wn_group WHERE id = $1", groupID)
+func DeleteGroup(ctx context.Context, db DBTX, groupID int64) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM wn_group WHERE id = $1", groupID)
 	return err
 }
 
 // Main Functions
 
-func GetGroupWithUsers(db *sql.DB, groupID int64) (GroupWithUsers, error) {
-	group, err := GetGroup(db, groupID)
+func GetGroupWithUsers(ctx context.Context, db DBTX, groupID int64) (GroupWithUsers, error) {
+	group, err := GetGroup(ctx, db, groupID)
 	if err != nil { return GroupWithUsers{}, err }
-	users, err := GetAllUsersOfGroup(db, groupID)
+	users, err := GetAllUsersOfGroup(ctx, db, groupID)
 	if err != nil { return GroupWithUsers{}, err }
-	return GroupWithUsers{ Group: group, Users: users}, nil
+	return GroupWithUsers{ Group: group, Users: PublicUsers(users)}, nil
 }
 
-func GetAllGroupsOfUser(db *sql.DB, userID int64) ([]Group, error) {
-	rows, err := db.Query(
+func GetAllGroupsOfUser(ctx context.Context, db DBTX, userID int64) ([]Group, error) {
+	rows, err := db.QueryContext(ctx,
 		`SELECT
 			wn_group.id, 
 			wn_group.group_name, 
@@ -98,61 +108,79 @@ func GetAllGroupsOfUser(db *sql.DB, userID int64) ([]Group, error) {
 	return groups, nil
 }
 
-func AddGroupWithUserIDs(db *sql.DB, group Group, userIDs []int64) (GroupWithUsers, error) {
-	if len(userIDs) == 0 { return GroupWithUsers{}, errors.New("Insufficient users to form a group") }
-	ownerID := userIDs[0]
-	userIDs = userIDs[1:]
-	group.OwnerID = ownerID //Taking first userID as ownerID
-	_, err := db.Exec(
+var GroupSorting = Sorting[Group]{
+	IDSQL: "wn_group.id",
+	ID: func(group Group) int64 { return group.ID },
+	Keys: map[string]SortKey[Group]{
+		"id":			{ SQL: "wn_group.id", Value: func(group Group) interface{} { return group.ID } },
+		"group_name":	{ SQL: `lower(wn_group.group_name) COLLATE "C"`, Value: func(group Group) interface{} { return strings.ToLower(group.GroupName) } },
+	},
+}
+
+func GetGroupsPageOfUser(ctx context.Context, db DBTX, userID int64, page PageQuery) (Page[Group], error) {
+	return QueryPage(ctx, db, GroupSorting, page,
+		`SELECT
+			wn_group.id, 
+			wn_group.group_name, 
+			wn_group.group_description,
+			wn_group.category, 
+			wn_group.owner_id
+		FROM wn_user_group JOIN wn_group 
+		ON wn_user_group.group_id = wn_group.id`,
+		[]string{"wn_user_group.user_id = $1"},
+		[]interface{}{userID},
+		ReadGroups)
+}
+
+func AddGroupWithUserIDs(ctx context.Context, db *sql.DB, group Group, userIDs []int64) (GroupWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) (GroupWithUsers, error) {
+		return addGroupWithUserIDs(ctx, tx, group, userIDs)
+	})
+}
+
+func addGroupWithUserIDs(ctx context.Context, db DBTX, group Group, userIDs []int64) (GroupWithUsers, error) {
+	userIDs = DistinctIDs(userIDs)
+	if len(userIDs) == 0 { return GroupWithUsers{}, NoMembersError }
+	group.OwnerID = userIDs[0] //Taking first userID as ownerID
+	err := db.QueryRowContext(ctx,
 		`INSERT INTO wn_group (
 			group_name, 
 			group_description, 
 			category, 
 			owner_id) 
-		VALUES ($1, $2, $3, $4);`,
+		VALUES ($1, $2, $3, $4)
+		RETURNING id;`,
 		group.GroupName,
 		group.GroupDescription,
 		group.Category,
-		group.OwnerID)
-	if err != nil { return GroupWithUsers{}, err }
-	group, err = group.LoadLastGroupID(db)
+		group.OwnerID).Scan(&group.ID)
 	if err != nil { return GroupWithUsers{}, err }
 
-	// Adding Owner
-	if err = AddUserToGroup(db, group.ID, ownerID); err != nil {
-		log.Printf("Failed to add Owner: %v", err)
-		if _, fatal := db.Exec("DELETE FROM wn_group WHERE id = $1", group.ID); fatal != nil {
-			log.Fatal(fmt.Sprintf("Failed to remove added group after failing to add owner. Fatal: %v", fatal))
-		}
-		return GroupWithUsers{}, err
-	}
-
-	// Adding Other Users
+	// Adding Owner and Other Users. Any failure rolls back the whole group
 	for _, userID := range userIDs {
-		AddUserToGroup(db, group.ID, userID)
-
-		// Strict version
-		// if err := AddUserToGroup(db, group.ID, userID); err != nil {
-		// 	return GroupWithUsers{}, err
-		// }
+		if err := AddUserToGroup(ctx, db, group.ID, userID); err != nil {
+			return GroupWithUsers{}, err
+		}
 	}
-	users, err := GetAllUsersOfGroup(db, group.ID)
+	users, err := GetAllUsersOfGroup(ctx, db, group.ID)
 	if err != nil { return GroupWithUsers{}, err }
 
-	return GroupWithUsers{ Group: group, Users: users }, nil
+	return GroupWithUsers{ Group: group, Users: PublicUsers(users) }, nil
 }
 
-func UpdateGroup(db *sql.DB, updatedGroup Group, groupID int64, userID int64) (Group, error) {
-	targetGroup, err := GetGroup(db, groupID)
+func UpdateGroup(ctx context.Context, db DBTX, updatedGroup Group, groupID int64, userID int64) (Group, error) {
+	targetGroup, err := GetGroup(ctx, db, groupID)
 	if err != nil { return Group{}, err }
-	if targetGroup.OwnerID != userID { return Group{}, http_error.UnauthorizedError }
+	if err := AuthorizeUserID(ctx, db, userID, ActionUpdateGroup, GroupRelations(userID, targetGroup, false)); err != nil {
+		return Group{}, err
+	}
 
 	updatedGroup = updatedGroup.MergeGroup(targetGroup)
-	inGroup, err := IsUserInGroup(db, updatedGroup.OwnerID, updatedGroup.ID)
+	inGroup, err := IsUserInGroup(ctx, db, updatedGroup.OwnerID, updatedGroup.ID)
 	if err != nil { return Group{}, err }
-	if !inGroup { return Group{}, errors.New("New owner is not a member of group")}
+	if !inGroup { return Group{}, OwnerNotMemberError}
 
-	_, err = db.Exec(
+	_, err = db.ExecContext(ctx,
 		`UPDATE wn_group SET 
 			group_name = $1,
 			group_description = $2,
@@ -168,42 +196,54 @@ func UpdateGroup(db *sql.DB, updatedGroup Group, groupID int64, userID int64) (G
 	return updatedGroup, nil
 }
 
-func LeaveGroup(db *sql.DB, groupID int64, userID int64) (GroupWithUsers, error) {
-	targetGroupWithUsers, err := GetGroupWithUsers(db, groupID)
+func LeaveGroup(ctx context.Context, db *sql.DB, groupID int64, userID int64) (GroupWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) (GroupWithUsers, error) {
+		return leaveGroup(ctx, tx, groupID, userID)
+	})
+}
+
+func leaveGroup(ctx context.Context, db DBTX, groupID int64, userID int64) (GroupWithUsers, error) {
+	targetGroupWithUsers, err := GetGroupWithUsers(ctx, db, groupID)
 	if err != nil { return GroupWithUsers{}, err }
 	if targetGroupWithUsers.Group.OwnerID == userID {
 		newOwnerID := targetGroupWithUsers.GetNewOwnerID()
 		if newOwnerID == 0 {	
-			err = DeleteGroup(db, groupID)
+			err = DeleteGroup(ctx, db, groupID)
 			if err != nil { return GroupWithUsers{}, err } // Group not deleted
 			return GroupWithUsers{ Group: Group{ID: groupID} }, nil
 		}
-		targetGroupWithUsers.Group, err = ChangeOwnership(db, targetGroupWithUsers.Group, newOwnerID)
+		targetGroupWithUsers.Group, err = ChangeOwnership(ctx, db, targetGroupWithUsers.Group, newOwnerID)
 		if err != nil { return GroupWithUsers{}, err } // Ownership not transferred
 	}
-	err = RemoveUserFromGroup(db, groupID, userID)
+	err = RemoveUserFromGroup(ctx, db, groupID, userID)
 	if err != nil { return GroupWithUsers{}, err } // User not properly removed
-	users, err := GetAllUsersOfGroup(db, groupID)
+	users, err := GetAllUsersOfGroup(ctx, db, groupID)
 	if err != nil { return GroupWithUsers{}, err }
-	targetGroupWithUsers.Users = users
+	targetGroupWithUsers.Users = PublicUsers(users)
 	if err != nil { return GroupWithUsers{}, err } // reloading of group with users failed
 	return targetGroupWithUsers, nil
 }
 
-func LeaveAllGroups(db *sql.DB, userID int64) ([]GroupWithUsers, error) {
-	groups, err := GetAllGroupsOfUser(db, userID)
+func LeaveAllGroups(ctx context.Context, db *sql.DB, userID int64) ([]GroupWithUsers, error) {
+	return WithTx(ctx, db, func(tx DBTX) ([]GroupWithUsers, error) {
+		return leaveAllGroups(ctx, tx, userID)
+	})
+}
+
+func leaveAllGroups(ctx context.Context, db DBTX, userID int64) ([]GroupWithUsers, error) {
+	groups, err := GetAllGroupsOfUser(ctx, db, userID)
 	if err != nil { return nil, err}
 	groupsWithUsers := make([]GroupWithUsers, 0)
 	for _, group := range groups {
-		groupWithUsers, err := LeaveGroup(db, group.ID, userID)
+		groupWithUsers, err := leaveGroup(ctx, db, group.ID, userID)
 		if err != nil { return nil, err}
 		groupsWithUsers = append(groupsWithUsers, groupWithUsers)
 	}
 	return groupsWithUsers, nil
 }
 
-func IsUserInGroup(db *sql.DB, userID int64, groupID int64) (bool, error) {
-	row, err := db.Query(
+func IsUserInGroup(ctx context.Context, db DBTX, userID int64, groupID int64) (bool, error) {
+	row, err := db.QueryContext(ctx,
 		`SELECT COUNT(*) != 0 FROM wn_user_group 
 		WHERE user_id = $1 and group_id = $2`,
 		userID,
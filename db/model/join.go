@@ -1,8 +1,6 @@
 package model
 
-import (
-	"database/sql"
-)
+import "context"
 
 type JoinRequestRespond struct {
 	Approve bool `json:"approve"`
@@ -16,25 +14,16 @@ type JoinRequest struct {
 
 type LoadedJoinRequest struct {
 	JoinRequest		JoinRequest 	`json:"join_request"`
-	User			User			`json:"user"`
+	User			PublicUser		`json:"user"`
 	Group			Group			`json:"group"`
 }
 
-func (joinRequest JoinRequest) LoadJoinRequest(db *sql.DB) (LoadedJoinRequest, error) {
-	user, err := GetUser(db, joinRequest.UserID)
+func (joinRequest JoinRequest) LoadJoinRequest(ctx context.Context, db DBTX) (LoadedJoinRequest, error) {
+	user, err := GetUser(ctx, db, joinRequest.UserID)
 	if err != nil { return LoadedJoinRequest{}, err }
-	group, err := GetGroup(db, joinRequest.GroupID)
+	group, err := GetGroup(ctx, db, joinRequest.GroupID)
 	if err != nil { return LoadedJoinRequest{}, err }
-	return LoadedJoinRequest{ JoinRequest: joinRequest, User: user, Group: group }, nil
-}
-
-func (joinRequest JoinRequest) LoadLastJoinRequestID(db *sql.DB) (JoinRequest, error) {
-	row, err := db.Query("SELECT last_value FROM wn_join_request_id_seq;")
-	if err != nil { return JoinRequest{}, err }
-	defer row.Close()
-	row.Next()
-	if err := row.Scan(&joinRequest.ID); err != nil { return JoinRequest{}, err }
-	return joinRequest, nil
+	return LoadedJoinRequest{ JoinRequest: joinRequest, User: user.Public(), Group: group }, nil
 }
 
 func (joinRequest1 JoinRequest) Equal(joinRequest2 JoinRequest) bool {
@@ -2,7 +2,9 @@ package model
 
 import (
 	"wellnus/backend/router/http_helper/http_error"
+	"context"
 	"database/sql"
+	"strings"
 )
 
 // Helper function
@@ -32,9 +34,8 @@ func ReadLoadedJoinRequests(rows *sql.Rows) ([]LoadedJoinRequest, error) {
 			&loadedJoinRequest.User.LastName,
 			&loadedJoinRequest.User.Gender,
 			&loadedJoinRequest.User.Faculty,
-			&loadedJoinRequest.User.Email,
 			&loadedJoinRequest.User.UserRole,
-			&loadedJoinRequest.User.PasswordHash,
+			&loadedJoinRequest.User.Verified,
 			&loadedJoinRequest.Group.ID,
 			&loadedJoinRequest.Group.GroupName,
 			&loadedJoinRequest.Group.GroupDescription,
@@ -48,8 +49,8 @@ func ReadLoadedJoinRequests(rows *sql.Rows) ([]LoadedJoinRequest, error) {
 	return loadedJoinRequests, nil
 }
 
-func GetJoinRequest(db *sql.DB, joinRequestID int64) (JoinRequest, error) {
-	rows, err := db.Query("SELECT * FROM wn_join_request WHERE id = $1", joinRequestID)
+func GetJoinRequest(ctx context.Context, db DBTX, joinRequestID int64) (JoinRequest, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_join_request WHERE id = $1", joinRequestID)
 	if err != nil { return JoinRequest{}, err }
 	defer rows.Close()
 	joinRequests, err := ReadJoinRequests(rows)
@@ -60,39 +61,33 @@ func GetJoinRequest(db *sql.DB, joinRequestID int64) (JoinRequest, error) {
 
 // Main function
 
-func GetAllLoadedJoinRequestsSentOfUser(db *sql.DB, userID int64) ([]LoadedJoinRequest, error) {
-	rows, err := db.Query(
-		`SELECT 
-			wn_join_request.id, 
-			wn_join_request.user_id, 
-			wn_join_request.group_id,
-			wn_user.id,
-			wn_user.first_name, 
-			wn_user.last_name, 
-			wn_user.gender, 
-			wn_user.faculty, 
-			wn_user.email, 
-			wn_user.user_role, 
-			wn_user.password_hash,
-			wn_group.id,
-			wn_group.group_name, 
-			wn_group.group_description, 
-			wn_group.category, 
-			wn_group.owner_id
-		FROM wn_join_request 
-		JOIN wn_user ON wn_user.id = wn_join_request.user_id
-		JOIN wn_group ON wn_group.id = wn_join_request.group_id
-		WHERE wn_join_request.user_id = $1`,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	loadedJoinRequests, err := ReadLoadedJoinRequests(rows)
-	if err != nil { return nil, err }
-	return loadedJoinRequests, nil
+// Which of the join requests of a user to list
+const (
+	JoinRequestsAll			= ""
+	JoinRequestsReceived	= "RECEIVED"	// requests to join groups the user owns
+	JoinRequestsSent		= "SENT"		// requests the user made
+)
+
+var LoadedJoinRequestSorting = Sorting[LoadedJoinRequest]{
+	IDSQL: "wn_join_request.id",
+	ID: func(ljr LoadedJoinRequest) int64 { return ljr.JoinRequest.ID },
+	Keys: map[string]SortKey[LoadedJoinRequest]{
+		"id":			{ SQL: "wn_join_request.id", Value: func(ljr LoadedJoinRequest) interface{} { return ljr.JoinRequest.ID } },
+		"group_name":	{ SQL: `lower(wn_group.group_name) COLLATE "C"`, Value: func(ljr LoadedJoinRequest) interface{} { return strings.ToLower(ljr.Group.GroupName) } },
+	},
 }
 
-func GetAllLoadedJoinRequestsReceivedOfUser(db *sql.DB, userID int64) ([]LoadedJoinRequest, error) {
-	rows, err := db.Query(
+func GetLoadedJoinRequestsPageOfUser(ctx context.Context, db DBTX, userID int64, scope string, page PageQuery) (Page[LoadedJoinRequest], error) {
+	var condition string
+	switch scope {
+	case JoinRequestsReceived:
+		condition = "wn_group.owner_id = $1"
+	case JoinRequestsSent:
+		condition = "wn_join_request.user_id = $1"
+	default:
+		condition = "(wn_join_request.user_id = $1 OR wn_group.owner_id = $1)"
+	}
+	return QueryPage(ctx, db, LoadedJoinRequestSorting, page,
 		`SELECT 
 			wn_join_request.id, 
 			wn_join_request.user_id, 
@@ -102,9 +97,8 @@ func GetAllLoadedJoinRequestsReceivedOfUser(db *sql.DB, userID int64) ([]LoadedJ
 			wn_user.last_name, 
 			wn_user.gender, 
 			wn_user.faculty, 
-			wn_user.email, 
 			wn_user.user_role, 
-			wn_user.password_hash,
+			wn_user.verified,
 			wn_group.id,
 			wn_group.group_name, 
 			wn_group.group_description, 
@@ -112,92 +106,73 @@ func GetAllLoadedJoinRequestsReceivedOfUser(db *sql.DB, userID int64) ([]LoadedJ
 			wn_group.owner_id
 		FROM wn_join_request 
 		JOIN wn_user ON wn_user.id = wn_join_request.user_id
-		JOIN wn_group ON wn_group.id = wn_join_request.group_id
-		WHERE wn_group.owner_id = $1`,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	loadedJoinRequests, err := ReadLoadedJoinRequests(rows)
-	if err != nil { return nil, err }
-	return loadedJoinRequests, nil
+		JOIN wn_group ON wn_group.id = wn_join_request.group_id`,
+		[]string{condition},
+		[]interface{}{userID},
+		ReadLoadedJoinRequests)
 }
 
-func GetAllLoadedJoinRequestsOfUser(db *sql.DB, userID int64) ([]LoadedJoinRequest, error) {
-	rows, err := db.Query(
-		`SELECT 
-			wn_join_request.id, 
-			wn_join_request.user_id, 
-			wn_join_request.group_id,
-			wn_user.id,
-			wn_user.first_name, 
-			wn_user.last_name, 
-			wn_user.gender, 
-			wn_user.faculty, 
-			wn_user.email, 
-			wn_user.user_role, 
-			wn_user.password_hash,
-			wn_group.id,
-			wn_group.group_name, 
-			wn_group.group_description, 
-			wn_group.category, 
-			wn_group.owner_id
-		FROM wn_join_request 
-		JOIN wn_user ON wn_user.id = wn_join_request.user_id
-		JOIN wn_group ON wn_group.id = wn_join_request.group_id
-		WHERE wn_group.owner_id = $1 OR wn_join_request.user_id = $2`,
-		userID,
-		userID)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	loadedJoinRequests, err := ReadLoadedJoinRequests(rows)
-	if err != nil { return nil, err }
-	return loadedJoinRequests, nil
+// Join requests are only seen by the user who sent them and the owner of their group
+func GetLoadedJoinRequest(ctx context.Context, db DBTX, joinRequestID int64, userID int64) (LoadedJoinRequest, error) {
+	loadedJoinRequest, err := getLoadedJoinRequest(ctx, db, joinRequestID)
+	if err != nil { return LoadedJoinRequest{}, err }
+	relations := JoinRequestRelations(userID, loadedJoinRequest)
+	if err := AuthorizeUserID(ctx, db, userID, ActionViewJoinRequest, relations); err != nil { return LoadedJoinRequest{}, err }
+	return loadedJoinRequest, nil
 }
 
-func GetLoadedJoinRequest(db *sql.DB, joinRequestID int64) (LoadedJoinRequest, error) {
-	joinRequest, err := GetJoinRequest(db, joinRequestID)
+func getLoadedJoinRequest(ctx context.Context, db DBTX, joinRequestID int64) (LoadedJoinRequest, error) {
+	joinRequest, err := GetJoinRequest(ctx, db, joinRequestID)
 	if err != nil { return LoadedJoinRequest{}, err }
-	loadedJoinRequest, err := joinRequest.LoadJoinRequest(db)
+	loadedJoinRequest, err := joinRequest.LoadJoinRequest(ctx, db)
 	if err != nil { return LoadedJoinRequest{}, err }
 	return loadedJoinRequest, nil
 }
 
-func AddJoinRequest(db *sql.DB, groupID int64, userID int64) (JoinRequest, error) {
-	_, err := db.Exec(
+func AddJoinRequest(ctx context.Context, db DBTX, groupID int64, userID int64) (JoinRequest, error) {
+	joinRequest := JoinRequest{ UserID: userID, GroupID: groupID }
+	err := db.QueryRowContext(ctx,
 		`INSERT INTO wn_join_request (
 			user_id, 
 			group_id
-		) values ($1, $2);`, 
+		) values ($1, $2)
+		RETURNING id;`, 
 		userID,
-		groupID)
-	if err != nil { return JoinRequest{}, err }
-	joinRequest, err := JoinRequest{ UserID: userID, GroupID: groupID }.LoadLastJoinRequestID(db)
+		groupID).Scan(&joinRequest.ID)
 	if err != nil { return JoinRequest{}, err }
 	return joinRequest, nil
 }
 
-func RespondJoinRequest(db *sql.DB, joinRequestID int64, userID int64, approve bool) (JoinRequestRespond, error) {
-	loadedJoinRequest, err := GetLoadedJoinRequest(db, joinRequestID)
+func RespondJoinRequest(ctx context.Context, db *sql.DB, joinRequestID int64, userID int64, approve bool) (JoinRequestRespond, error) {
+	return WithTx(ctx, db, func(tx DBTX) (JoinRequestRespond, error) {
+		return respondJoinRequest(ctx, tx, joinRequestID, userID, approve)
+	})
+}
+
+func respondJoinRequest(ctx context.Context, db DBTX, joinRequestID int64, userID int64, approve bool) (JoinRequestRespond, error) {
+	loadedJoinRequest, err := getLoadedJoinRequest(ctx, db, joinRequestID)
 	if err != nil { return JoinRequestRespond{}, nil }
-	if loadedJoinRequest.Group.OwnerID != userID { return JoinRequestRespond{}, http_error.UnauthorizedError }
+	relations := GroupRelations(userID, loadedJoinRequest.Group, false)
+	if err := AuthorizeUserID(ctx, db, userID, ActionRespondJoinRequest, relations); err != nil { return JoinRequestRespond{}, err }
 	
 	//Adding user into group if necessary
 	if approve { 
-		if err = AddUserToGroup(db, loadedJoinRequest.Group.ID, loadedJoinRequest.JoinRequest.UserID); err != nil {
+		if err = AddUserToGroup(ctx, db, loadedJoinRequest.Group.ID, loadedJoinRequest.JoinRequest.UserID); err != nil {
 			return JoinRequestRespond{}, err
 		}
 	}
-	_, err = db.Exec("DELETE FROM wn_join_request WHERE id = $1", joinRequestID)
+	_, err = db.ExecContext(ctx, "DELETE FROM wn_join_request WHERE id = $1", joinRequestID)
 	if err != nil { return JoinRequestRespond{}, err }
 	return JoinRequestRespond{ Approve: approve }, nil
 }
 
-func DeleteJoinRequest(db *sql.DB, joinRequestID int64, userID int64) (JoinRequest, error) {
-	joinRequest, err := GetJoinRequest(db, joinRequestID)
+func DeleteJoinRequest(ctx context.Context, db DBTX, joinRequestID int64, userID int64) (JoinRequest, error) {
+	joinRequest, err := GetJoinRequest(ctx, db, joinRequestID)
 	if err != nil { return JoinRequest{}, err }
-	if joinRequest.UserID != userID { return JoinRequest{}, http_error.UnauthorizedError }
+	relations := UserRelations(userID, joinRequest.UserID)
+	if err := AuthorizeUserID(ctx, db, userID, ActionDeleteJoinRequest, relations); err != nil { return JoinRequest{}, err }
 
-	_, err = db.Exec("DELETE FROM wn_join_request WHERE id = $1", joinRequestID)
+	_, err = db.ExecContext(ctx, "DELETE FROM wn_join_request WHERE id = $1", joinRequestID)
 	if err != nil { return JoinRequest{}, err }
 	return JoinRequest{ ID : joinRequestID }, nil
 }
\ No newline at end of file
@@ -1,8 +1,10 @@
 package model
 
 import (
+	"wellnus/backend/config"
+
+	"context"
 	"time"
-	"database/sql"
 )
 
 type MatchSetting struct {
@@ -10,6 +12,10 @@ type MatchSetting struct {
 	FacultyPreference 	string 		`json:"faculty_preference"`
 	Hobbies 			[]string	`json:"hobbies"`
 	MBTI				string		`json:"mbti"`
+	GenderPreference	string		`json:"gender_preference"`
+	YearOfStudy			int			`json:"year_of_study"`			// 0 when not given
+	Availability		[]string	`json:"availability"`
+	PreferredGroupSize	int			`json:"preferred_group_size"`	// 0 for any
 }
 
 type MatchRequest struct {
@@ -19,14 +25,33 @@ type MatchRequest struct {
 
 type LoadedMatchRequest struct {
 	MatchRequest 	MatchRequest 	`json:"match_request"`
-	User			User			`json:"user"`
+	User			PublicUser		`json:"user"`
 	MatchSetting	MatchSetting	`json:"match_setting"`
 }
 
-func (mr MatchRequest) LoadMatchRequest(db *sql.DB) (LoadedMatchRequest, error) {
-	user, err := GetUser(db, mr.UserID)
+// A loaded match request with its place in the queue, oldest first, and how long it is expected
+// to wait to be matched
+type QueuedMatchRequest struct {
+	LoadedMatchRequest
+	QueuePosition			int		`json:"queue_position"`
+	QueueSize				int		`json:"queue_size"`
+	EstimatedWaitSeconds	*int64	`json:"estimated_wait_seconds"`	// null when no run is expected to match it
+}
+
+// Fills in the estimated wait of qmr as of now, given the time of the last matching run
+func (qmr QueuedMatchRequest) Estimate(matching config.MatchingConfig, lastRun time.Time, now time.Time) QueuedMatchRequest {
+	qmr.EstimatedWaitSeconds = nil
+	if wait, ok := EstimateWait(matching, qmr.QueuePosition, qmr.QueueSize, lastRun, now); ok {
+		seconds := int64(wait.Seconds())
+		qmr.EstimatedWaitSeconds = &seconds
+	}
+	return qmr
+}
+
+func (mr MatchRequest) LoadMatchRequest(ctx context.Context, db DBTX) (LoadedMatchRequest, error) {
+	user, err := GetUser(ctx, db, mr.UserID)
 	if err != nil { return LoadedMatchRequest{}, err }
-	matchSetting, err := GetMatchSettingOfUser(db, mr.UserID)
+	matchSetting, err := GetMatchSettingOfUser(ctx, db, mr.UserID)
 	if err != nil { return LoadedMatchRequest{}, err }
-	return LoadedMatchRequest{ MatchRequest: mr, User: user, MatchSetting: matchSetting }, nil
+	return LoadedMatchRequest{ MatchRequest: mr, User: user.Public(), MatchSetting: matchSetting }, nil
 }
\ No newline at end of file
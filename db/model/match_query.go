@@ -1,7 +1,9 @@
 package model
 
 import (
+	"wellnus/backend/config"
 	"wellnus/backend/router/http_helper/http_error"
+	"context"
 	"database/sql"
 	"time"
 	"github.com/lib/pq"
@@ -15,7 +17,11 @@ func ReadMatchSettings(rows *sql.Rows) ([]MatchSetting, error) {
 			&matchSetting.UserID,
 			&matchSetting.FacultyPreference,
 			pq.Array(&matchSetting.Hobbies),
-			&matchSetting.MBTI);
+			&matchSetting.MBTI,
+			&matchSetting.GenderPreference,
+			&matchSetting.YearOfStudy,
+			pq.Array(&matchSetting.Availability),
+			&matchSetting.PreferredGroupSize);
 			err != nil {
 				return nil, err
 			}
@@ -48,13 +54,16 @@ func ReadLoadedMatchRequests(rows *sql.Rows) ([]LoadedMatchRequest, error) {
 			&loadedMatchRequest.User.LastName,
 			&loadedMatchRequest.User.Gender,
 			&loadedMatchRequest.User.Faculty,
-			&loadedMatchRequest.User.Email,
 			&loadedMatchRequest.User.UserRole,
-			&loadedMatchRequest.User.PasswordHash,
+			&loadedMatchRequest.User.Verified,
 			&loadedMatchRequest.MatchSetting.UserID,
 			&loadedMatchRequest.MatchSetting.FacultyPreference,
 			pq.Array(&loadedMatchRequest.MatchSetting.Hobbies),
-			&loadedMatchRequest.MatchSetting.MBTI);
+			&loadedMatchRequest.MatchSetting.MBTI,
+			&loadedMatchRequest.MatchSetting.GenderPreference,
+			&loadedMatchRequest.MatchSetting.YearOfStudy,
+			pq.Array(&loadedMatchRequest.MatchSetting.Availability),
+			&loadedMatchRequest.MatchSetting.PreferredGroupSize);
 			err != nil {
 				return nil, err
 			}
@@ -65,8 +74,20 @@ func ReadLoadedMatchRequests(rows *sql.Rows) ([]LoadedMatchRequest, error) {
 
 // Match setting
 
-func GetMatchSettingOfUser(db *sql.DB, userID int64) (MatchSetting, error){
-	rows, err := db.Query(`SELECT * FROM wn_match_setting WHERE user_id = $1`, userID)
+const selectMatchSettingSQL = `
+	SELECT
+		user_id,
+		faculty_preference,
+		hobbies,
+		mbti,
+		gender_preference,
+		year_of_study,
+		availability,
+		preferred_group_size
+	FROM wn_match_setting`
+
+func GetMatchSettingOfUser(ctx context.Context, db DBTX, userID int64) (MatchSetting, error){
+	rows, err := db.QueryContext(ctx, selectMatchSettingSQL + ` WHERE user_id = $1`, userID)
 	if err != nil { return MatchSetting{}, err }
 	defer rows.Close()
 	matchSettings, err := ReadMatchSettings(rows);
@@ -75,31 +96,43 @@ func GetMatchSettingOfUser(db *sql.DB, userID int64) (MatchSetting, error){
 	return matchSettings[0], nil
 }
 
-func AddUpdateMatchSettingOfUser(db *sql.DB, matchSetting MatchSetting, userID int64) (MatchSetting, error) {
+func AddUpdateMatchSettingOfUser(ctx context.Context, db DBTX, matchSetting MatchSetting, userID int64) (MatchSetting, error) {
 	matchSetting.UserID = userID
-	_, err := db.Exec(
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_match_setting (
 			user_id,
 			faculty_preference,
 			hobbies,
-			mbti
-		) VALUES ($1, $2, $3, $4)
+			mbti,
+			gender_preference,
+			year_of_study,
+			availability,
+			preferred_group_size
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (user_id)
 		DO UPDATE SET
 			user_id = EXCLUDED.user_id,
 			faculty_preference = EXCLUDED.faculty_preference,
 			hobbies = EXCLUDED.hobbies,
-			mbti = EXCLUDED.mbti`,
+			mbti = EXCLUDED.mbti,
+			gender_preference = EXCLUDED.gender_preference,
+			year_of_study = EXCLUDED.year_of_study,
+			availability = EXCLUDED.availability,
+			preferred_group_size = EXCLUDED.preferred_group_size`,
 		matchSetting.UserID,
 		matchSetting.FacultyPreference,
 		pq.Array(matchSetting.Hobbies),
-		matchSetting.MBTI)
+		matchSetting.MBTI,
+		matchSetting.GenderPreference,
+		matchSetting.YearOfStudy,
+		pq.Array(matchSetting.Availability),
+		matchSetting.PreferredGroupSize)
 	if err != nil { return MatchSetting{}, err }
 	return matchSetting, nil
 }
 
-func DeleteMatchSettingOfUser(db *sql.DB, userID int64) (MatchSetting, error) {
-	if _, err := db.Exec(`DELETE FROM wn_match_setting WHERE user_id = $1`, userID); err != nil {
+func DeleteMatchSettingOfUser(ctx context.Context, db DBTX, userID int64) (MatchSetting, error) {
+	if _, err := db.ExecContext(ctx, `DELETE FROM wn_match_setting WHERE user_id = $1`, userID); err != nil {
 		return MatchSetting{}, err
 	}
 	return MatchSetting{ UserID: userID }, nil
@@ -107,8 +140,8 @@ func DeleteMatchSettingOfUser(db *sql.DB, userID int64) (MatchSetting, error) {
 
 // Match Request
 
-func GetMatchRequestCount(db *sql.DB) (int64, error) {
-	rows, err := db.Query(`SELECT COUNT(*) FROM wn_match_request`)
+func GetMatchRequestCount(ctx context.Context, db DBTX) (int64, error) {
+	rows, err := db.QueryContext(ctx, `SELECT COUNT(*) FROM wn_match_request`)
 	if err != nil { return 0, err }
 	defer rows.Close()
 	rows.Next()
@@ -117,8 +150,8 @@ func GetMatchRequestCount(db *sql.DB) (int64, error) {
 	return count, nil
 }
 
-func GetMatchRequestOfUser(db *sql.DB, userID int64) (MatchRequest, error) {
-	rows, err := db.Query(`SELECT * FROM wn_match_request WHERE user_id = $1`, userID)
+func GetMatchRequestOfUser(ctx context.Context, db DBTX, userID int64) (MatchRequest, error) {
+	rows, err := db.QueryContext(ctx, `SELECT * FROM wn_match_request WHERE user_id = $1`, userID)
 	if err != nil { return MatchRequest{}, err }
 	defer rows.Close()
 	matchRequests, err := ReadMatchRequests(rows)
@@ -127,16 +160,16 @@ func GetMatchRequestOfUser(db *sql.DB, userID int64) (MatchRequest, error) {
 	return matchRequests[0], nil
 }
 
-func GetLoadedMatchRequestOfUser(db *sql.DB, userID int64) (LoadedMatchRequest, error) {
-	matchRequest, err := GetMatchRequestOfUser(db, userID)
+func GetLoadedMatchRequestOfUser(ctx context.Context, db DBTX, userID int64) (LoadedMatchRequest, error) {
+	matchRequest, err := GetMatchRequestOfUser(ctx, db, userID)
 	if err != nil { return LoadedMatchRequest{}, err }
-	loadedMatchRequest, err := matchRequest.LoadMatchRequest(db)
+	loadedMatchRequest, err := matchRequest.LoadMatchRequest(ctx, db)
 	if err != nil { return LoadedMatchRequest{}, err }
 	return loadedMatchRequest, nil
 }
 
-func GetAllLoadedMatchRequest(db *sql.DB) ([]LoadedMatchRequest, error) {
-	rows, err := db.Query(
+func GetAllLoadedMatchRequest(ctx context.Context, db DBTX) ([]LoadedMatchRequest, error) {
+	rows, err := db.QueryContext(ctx,
 		`SELECT
 			wn_match_request.user_id,
 			wn_match_request.time_added,
@@ -145,16 +178,20 @@ func GetAllLoadedMatchRequest(db *sql.DB) ([]LoadedMatchRequest, error) {
 			wn_user.last_name,
 			wn_user.gender,
 			wn_user.faculty,
-			wn_user.email,
 			wn_user.user_role,
-			wn_user.password_hash,
+			wn_user.verified,
 			wn_match_setting.user_id,
 			wn_match_setting.faculty_preference,
 			wn_match_setting.hobbies,
-			wn_match_setting.mbti
+			wn_match_setting.mbti,
+			wn_match_setting.gender_preference,
+			wn_match_setting.year_of_study,
+			wn_match_setting.availability,
+			wn_match_setting.preferred_group_size
 		FROM wn_match_request
 		JOIN wn_user ON wn_match_request.user_id = wn_user.id
-		LEFT JOIN wn_match_setting ON wn_match_request.user_id = wn_match_setting.user_id`)
+		LEFT JOIN wn_match_setting ON wn_match_request.user_id = wn_match_setting.user_id
+		ORDER BY wn_match_request.time_added, wn_match_request.user_id`)
 	if err != nil { return nil, err }
 	defer rows.Close()
 	loadedMatchRequests, err := ReadLoadedMatchRequests(rows)
@@ -162,10 +199,37 @@ func GetAllLoadedMatchRequest(db *sql.DB) ([]LoadedMatchRequest, error) {
 	return loadedMatchRequests, nil
 }
 
-// Continue with add match request and match algorithm after a threshold is met
-func AddMatchRequest(db *sql.DB, userID int64) (MatchRequest, error) {
+// Queues the user for matching. Groups are formed later by the matching runs of the scheduler
+// Returns the match request of the user with userID with its place in the queue and estimated wait
+func GetQueuedMatchRequestOfUser(ctx context.Context, db DBTX, matching config.MatchingConfig, userID int64) (QueuedMatchRequest, error) {
+	loadedMatchRequest, err := GetLoadedMatchRequestOfUser(ctx, db, userID)
+	if err != nil { return QueuedMatchRequest{}, err }
+	qmr := QueuedMatchRequest{ LoadedMatchRequest: loadedMatchRequest }
+	// Ties in time_added are broken by user_id, as GetAllLoadedMatchRequest orders them
+	if err := db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM wn_match_request WHERE (time_added, user_id) <= ($1, $2)`,
+		loadedMatchRequest.MatchRequest.TimeAdded,
+		userID).Scan(&qmr.QueuePosition); err != nil {
+		return QueuedMatchRequest{}, err
+	}
+	queueSize, err := GetMatchRequestCount(ctx, db)
+	if err != nil { return QueuedMatchRequest{}, err }
+	qmr.QueueSize = int(queueSize)
+
+	// Without a run yet, the scheduler is taken to have been waiting since the request was queued
+	lastRun := loadedMatchRequest.MatchRequest.TimeAdded
+	run, err := GetLatestMatchingRun(ctx, db)
+	if err == nil {
+		lastRun = run.TimeStarted
+	} else if err != http_error.NotFoundError {
+		return QueuedMatchRequest{}, err
+	}
+	return qmr.Estimate(matching, lastRun, time.Now()), nil
+}
+
+func AddMatchRequest(ctx context.Context, db DBTX, userID int64) (MatchRequest, error) {
 	matchRequest := MatchRequest{ UserID: userID, TimeAdded: time.Now() }
-	_, err := db.Exec(
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_match_request (
 			user_id,
 			time_added
@@ -173,13 +237,103 @@ func AddMatchRequest(db *sql.DB, userID int64) (MatchRequest, error) {
 		matchRequest.UserID,
 		matchRequest.TimeAdded)
 	if err != nil { return MatchRequest{}, err }
-	PerformMatching(db)
 	return matchRequest, nil
 }
 
-func DeleteMatchRequestOfUser(db *sql.DB, userID int64) (MatchRequest, error) {
-	if _, err := db.Exec(`DELETE FROM wn_match_request WHERE user_id = $1`, userID); err != nil {
+func DeleteMatchRequestOfUser(ctx context.Context, db DBTX, userID int64) (MatchRequest, error) {
+	if _, err := db.ExecContext(ctx, `DELETE FROM wn_match_request WHERE user_id = $1`, userID); err != nil {
 		return MatchRequest{}, err
 	}
 	return MatchRequest{ UserID: userID }, nil
-} 
\ No newline at end of file
+} 
+// Open groups
+
+// Returns the groups formed by matching runs that have fewer than groupSize members, the emptiest
+// first, loaded with the match settings of their members. Groups made by users are never filled
+func GetAllOpenGroups(ctx context.Context, db DBTX, groupSize int) ([]OpenGroup, error) {
+	groups, err := getAllOpenGroupsOnly(ctx, db, groupSize)
+	if err != nil { return nil, err }
+	if len(groups) == 0 { return make([]OpenGroup, 0), nil }
+	openGroups := make([]OpenGroup, len(groups))
+	groupIDs := make([]int64, len(groups))
+	indexOf := make(map[int64]int, len(groups))
+	for i, group := range groups {
+		openGroups[i] = OpenGroup{ Group: group, Members: make([]LoadedMatchRequest, 0) }
+		groupIDs[i] = group.ID
+		indexOf[group.ID] = i
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT
+			wn_user_group.group_id,
+			wn_user.id,
+			wn_user.first_name,
+			wn_user.last_name,
+			wn_user.gender,
+			wn_user.faculty,
+			wn_user.user_role,
+			wn_user.verified,
+			COALESCE(wn_match_setting.user_id, 0),
+			COALESCE(wn_match_setting.faculty_preference, ''),
+			wn_match_setting.hobbies,
+			COALESCE(wn_match_setting.mbti, ''),
+			COALESCE(wn_match_setting.gender_preference, ''),
+			COALESCE(wn_match_setting.year_of_study, 0),
+			wn_match_setting.availability,
+			COALESCE(wn_match_setting.preferred_group_size, 0)
+		FROM wn_user_group
+		JOIN wn_user ON wn_user_group.user_id = wn_user.id
+		LEFT JOIN wn_match_setting ON wn_user_group.user_id = wn_match_setting.user_id
+		WHERE wn_user_group.group_id = ANY($1)
+		ORDER BY wn_user.id`,
+		pq.Array(groupIDs))
+	if err != nil { return nil, err }
+	defer rows.Close()
+	for rows.Next() {
+		var groupID int64
+		var member LoadedMatchRequest
+		if err := rows.Scan(
+			&groupID,
+			&member.User.ID,
+			&member.User.FirstName,
+			&member.User.LastName,
+			&member.User.Gender,
+			&member.User.Faculty,
+			&member.User.UserRole,
+			&member.User.Verified,
+			&member.MatchSetting.UserID,
+			&member.MatchSetting.FacultyPreference,
+			pq.Array(&member.MatchSetting.Hobbies),
+			&member.MatchSetting.MBTI,
+			&member.MatchSetting.GenderPreference,
+			&member.MatchSetting.YearOfStudy,
+			pq.Array(&member.MatchSetting.Availability),
+			&member.MatchSetting.PreferredGroupSize);
+			err != nil {
+				return nil, err
+			}
+		openGroups[indexOf[groupID]].Members = append(openGroups[indexOf[groupID]].Members, member)
+	}
+	return openGroups, nil
+}
+
+func getAllOpenGroupsOnly(ctx context.Context, db DBTX, groupSize int) ([]Group, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT
+			wn_group.id,
+			wn_group.group_name,
+			wn_group.group_description,
+			wn_group.category,
+			wn_group.owner_id
+		FROM wn_group
+		JOIN wn_user_group ON wn_group.id = wn_user_group.group_id
+		WHERE wn_group.category = 'SUPPORT'
+		AND EXISTS (SELECT 1 FROM wn_matching_run WHERE wn_group.id = ANY(wn_matching_run.group_ids))
+		GROUP BY wn_group.id
+		HAVING COUNT(*) < $1
+		ORDER BY COUNT(*), wn_group.id`,
+		groupSize)
+	if err != nil { return nil, err }
+	defer rows.Close()
+	return ReadGroups(rows)
+}
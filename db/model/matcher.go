@@ -0,0 +1,187 @@
+package model
+
+import (
+	"wellnus/backend/config"
+
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Strategies of MATCH_STRATEGY
+const (
+	MatchStrategyGreedy		= "greedy"
+	MatchStrategyAnnealing	= "annealing"
+)
+
+// Matcher decides which queued match requests are grouped together. Matchers are deterministic:
+// the same requests in the same order always give the same groups
+type Matcher interface {
+	// Returns the groups formed out of lmrs as indices into it, each of groupSize, and the
+	// indices of the requests left queued
+	Match(lmrs []LoadedMatchRequest, groupSize int) ([][]int, []int)
+}
+
+// Returns the matcher of the strategy, seed and weights of matching
+func NewMatcher(matching config.MatchingConfig) Matcher {
+	if matching.Strategy == MatchStrategyGreedy { return GreedyMatcher{ Seed: matching.Seed, Weights: matching.Weights } }
+	return AnnealingMatcher{ Seed: matching.Seed, Weights: matching.Weights }
+}
+
+// Sum of the Compatibility of every pair of members of every group, which the annealing matcher maximizes
+func TotalCompatibility(lmrs []LoadedMatchRequest, groups [][]int, weights config.CompatibilityWeights) int {
+	total := 0
+	for _, group := range groups {
+		for i := range group {
+			for j := i + 1; j < len(group); j++ {
+				total += Compatibility(lmrs[group[i]], lmrs[group[j]], weights)
+			}
+		}
+	}
+	return total
+}
+
+// GreedyMatcher builds one group at a time around a pivot picked at random, taking the requests
+// most compatible with the pivot. Groups formed later get what the earlier ones left
+type GreedyMatcher struct {
+	Seed	int64
+	Weights	config.CompatibilityWeights
+}
+
+func (m GreedyMatcher) Match(lmrs []LoadedMatchRequest, groupSize int) ([][]int, []int) {
+	rng := rand.New(rand.NewSource(m.Seed))
+	groups := make([][]int, 0)
+	remaining := make([]int, len(lmrs))
+	for i := range remaining { remaining[i] = i }
+	for len(remaining) >= groupSize {
+		remainingLMRs := make([]LoadedMatchRequest, len(remaining))
+		for i, index := range remaining {
+			remainingLMRs[i] = lmrs[index]
+		}
+		groupingIndices, remainingIndices := GetGroupingRemainingIndices(remainingLMRs, groupSize, m.Weights, rng)
+
+		group := make([]int, len(groupingIndices))
+		for i, index := range groupingIndices {
+			group[i] = remaining[index]
+		}
+		groups = append(groups, group)
+
+		nextRemaining := make([]int, len(remainingIndices))
+		for i, index := range remainingIndices {
+			nextRemaining[i] = remaining[index]
+		}
+		remaining = nextRemaining
+	}
+	return groups, remaining
+}
+
+// Return a slice of the indices of match request that will form a group among given loadmatchrequests
+// Done by selecting a pivoting match request with rng and building the group to best suit that pivot
+func GetGroupingRemainingIndices(lmrs []LoadedMatchRequest, groupSize int, weights config.CompatibilityWeights, rng *rand.Rand) ([]int, []int) {
+	l := len(lmrs)
+	if l < groupSize { return nil, nil }
+	p := rng.Intn(l)
+	scoreMap := make([]int, l)
+	indices := make([]int, l)
+	for j, lmr := range lmrs {
+		scoreMap[j] = Compatibility(lmrs[p], lmr, weights)
+		indices[j] = j
+	}
+	// The pivot leads its group, as the first member becomes the owner
+	scoreMap[p] = math.MaxInt
+	sort.SliceStable(indices, func(i, j int) bool {
+		return scoreMap[indices[i]] > scoreMap[indices[j]]
+	})
+	return indices[:groupSize], indices[groupSize:]
+}
+
+// Temperatures the annealing matcher cools from and to. A swap that loses d points of
+// compatibility is taken with probability e^(-d/temperature)
+const (
+	annealingStartTemperature	= 2.0
+	annealingEndTemperature		= 0.05
+)
+
+// Swaps tried for every match request when Iterations is not set, up to AnnealingMaxIterations
+// in all. A run holds the matching lock while it anneals, so the cap keeps queues of more than
+// 500 requests to about the time a queue of 500 takes, at the cost of fewer swaps for each
+const (
+	AnnealingIterationsPerRequest	= 2000
+	AnnealingMaxIterations			= 1000000
+)
+
+// AnnealingMatcher maximizes TotalCompatibility over every group at once by simulated annealing.
+// Starting from the groups of the greedy matcher, it swaps the requests of two places at random,
+// keeping swaps that lose compatibility less and less often as it cools, and returns the best
+// groups it came across. Requests left queued take part in the swaps too
+type AnnealingMatcher struct {
+	Seed		int64
+	Weights		config.CompatibilityWeights
+	Iterations	int
+}
+
+// Swaps tried on a queue of n match requests
+func (m AnnealingMatcher) IterationsFor(n int) int {
+	if m.Iterations > 0 { return m.Iterations }
+	return min(AnnealingIterationsPerRequest * n, AnnealingMaxIterations)
+}
+
+func (m AnnealingMatcher) Match(lmrs []LoadedMatchRequest, groupSize int) ([][]int, []int) {
+	groups, remaining := GreedyMatcher{ Seed: m.Seed, Weights: m.Weights }.Match(lmrs, groupSize)
+	n := len(lmrs)
+	grouped := len(groups) * groupSize
+	if len(groups) == 0 || (len(groups) == 1 && len(remaining) == 0) { return groups, remaining }
+
+	scores := make([][]int, n)
+	for i := range scores {
+		scores[i] = make([]int, n)
+		for j := range scores[i] {
+			if i != j { scores[i][j] = Compatibility(lmrs[i], lmrs[j], m.Weights) }
+		}
+	}
+
+	// slots holds the requests of every group in turn, followed by those left queued
+	slots := make([]int, 0, n)
+	for _, group := range groups {
+		slots = append(slots, group...)
+	}
+	slots = append(slots, remaining...)
+	groupOf := func(slot int) int {
+		if slot >= grouped { return -1 }
+		return slot / groupSize
+	}
+	// Compatibility the group of slot a gains when the request in it is replaced by the one in slot b
+	gain := func(a, b int) int {
+		g := groupOf(a)
+		if g < 0 { return 0 }
+		d := 0
+		for s := g * groupSize; s < (g + 1) * groupSize; s++ {
+			if s != a { d += scores[slots[b]][slots[s]] - scores[slots[a]][slots[s]] }
+		}
+		return d
+	}
+
+	rng := rand.New(rand.NewSource(m.Seed))
+	iterations := m.IterationsFor(n)
+	current := TotalCompatibility(lmrs, groups, m.Weights)
+	best := current
+	bestSlots := append([]int(nil), slots...)
+	for i := 0; i < iterations; i++ {
+		a, b := rng.Intn(n), rng.Intn(n)
+		if groupOf(a) == groupOf(b) { continue }
+		d := gain(a, b) + gain(b, a)
+		temperature := annealingStartTemperature * math.Pow(annealingEndTemperature / annealingStartTemperature, float64(i) / float64(iterations))
+		if d < 0 && rng.Float64() >= math.Exp(float64(d) / temperature) { continue }
+		slots[a], slots[b] = slots[b], slots[a]
+		current += d
+		if current > best {
+			best = current
+			copy(bestSlots, slots)
+		}
+	}
+
+	for g := range groups {
+		groups[g] = bestSlots[g * groupSize : (g + 1) * groupSize]
+	}
+	return groups, bestSlots[grouped:]
+}
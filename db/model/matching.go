@@ -2,69 +2,111 @@ package model
 
 import (
 	"wellnus/backend/config"
+	"wellnus/backend/logger"
+	"wellnus/backend/metrics"
 
+	"context"
 	"database/sql"
-	"math/rand"
 	"time"
-	"sort"
-	"log"
-	"bytes"
-	"encoding/json"
-	"os"
 )
 
-// Determines the compatibility between 2 loaded match request on a scale of 0 to 12
-func Compatibility(loadedMatchRequest1, loadedMatchRequest2 LoadedMatchRequest) int {
-	totalScore := 0
+var log = logger.For("model")
 
-	// Faculty check max 4 points
-	fac1, fac2 := loadedMatchRequest1.User.Faculty, loadedMatchRequest2.User.Faculty
-	pfac1, pfac2 := loadedMatchRequest1.MatchSetting.FacultyPreference, loadedMatchRequest2.MatchSetting.FacultyPreference
-	if fac1 == fac2 {
-		if pfac1 == "SAME" && pfac2 == "SAME" {
-			totalScore += 4
-		} else if pfac1 == "MIX" && pfac2 == "MIX" {
-			totalScore += 0
-		} else {
-			totalScore += 2
-		}
+// Runs matching once on every queued match request and records the run in wn_matching_run.
+// The groups formed and the record are created in one transaction, so a failure leaves all match
+// requests queued and is recorded on its own afterwards. The transaction holds the advisory lock
+// MatchingLockID, and MatchingInProgressError is returned without a record when another instance holds it
+func RunMatching(ctx context.Context, db *sql.DB, matching config.MatchingConfig, trigger string, triggeredBy int64) (MatchingRun, error) {
+	run := MatchingRun{
+		Trigger: trigger,
+		TriggeredBy: triggeredBy,
+		GroupSize: matching.GroupSize,
+		Threshold: matching.Threshold,
+		TimeStarted: time.Now(),
+	}
+	recorded, err := WithTx(ctx, db, func(tx DBTX) (MatchingRun, error) {
+		return runMatching(ctx, tx, matching, &run)
+	})
+	if err == MatchingInProgressError { return MatchingRun{}, err }
+	if err == nil {
+		run = recorded
 	} else {
-		if pfac1 == "SAME" && pfac2 == "SAME" {
-			totalScore += 0
-		} else if pfac1 == "MIX" && pfac2 == "MIX" {
-			totalScore += 4
+		run.Outcome = MatchingOutcomeFailed
+		run.GroupIDs = nil
+		run.FilledGroupIDs = nil
+		run.Error = err.Error()
+		run.TimeFinished = time.Now()
+		// Recorded even when it was ctx running out that failed the run
+		if recorded, recordErr := addMatchingRun(context.WithoutCancel(ctx), db, run); recordErr == nil {
+			run = recorded
 		} else {
-			totalScore += 2
+			log.ErrorContext(ctx, "failed to record matching run", "error", recordErr)
 		}
 	}
+	LogMatchingRun(ctx, run, err)
+	return run, err
+}
 
-	// MBTI check max 4 points
-	MBTI1 := loadedMatchRequest1.MatchSetting.MBTI
-	MBTI2 := loadedMatchRequest2.MatchSetting.MBTI
-	for i := 0; i < 4; i++ {
-		if (MBTI1[i] == MBTI2[i]) {
-			totalScore += 1
-		}
+// Label of every matching outcome in the metrics
+var matchingOutcomeLabels = map[string]string{
+	MatchingOutcomeFailed:         metrics.MatchingFailed,
+	MatchingOutcomeBelowThreshold: metrics.MatchingBelowThreshold,
+	MatchingOutcomeMatched:        metrics.MatchingMatched,
+}
+
+// Records a finished matching run in the metrics and the log
+func LogMatchingRun(ctx context.Context, run MatchingRun, err error) {
+	metrics.ObserveMatchingRun(run.TimeStarted, matchingOutcomeLabels[run.Outcome], len(run.GroupIDs), len(run.FilledGroupIDs))
+	if err != nil {
+		log.ErrorContext(ctx, "matching run failed", "trigger", run.Trigger, "queue_size", run.QueueSize, "error", err)
+	} else if run.Outcome == MatchingOutcomeMatched {
+		log.InfoContext(ctx, "matching run formed groups", "trigger", run.Trigger, "queue_size", run.QueueSize, "groups", len(run.GroupIDs), "filled_groups", len(run.FilledGroupIDs), "duration", run.TimeFinished.Sub(run.TimeStarted))
+	}
+}
+
+// Fills in the queue, groups and outcome of run as it goes, so that a failed run can still be
+// recorded with its inputs, and returns the record of the run
+func runMatching(ctx context.Context, db DBTX, matching config.MatchingConfig, run *MatchingRun) (MatchingRun, error) {
+	var locked bool
+	if err := db.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", MatchingLockID).Scan(&locked); err != nil { return MatchingRun{}, err }
+	if !locked { return MatchingRun{}, MatchingInProgressError }
+
+	loadedMatchRequests, err := GetAllLoadedMatchRequest(ctx, db)
+	if err != nil { return MatchingRun{}, err }
+	run.QueueSize = len(loadedMatchRequests)
+	run.UserIDs = make([]int64, len(loadedMatchRequests))
+	for i, loadedMatchRequest := range loadedMatchRequests {
+		run.UserIDs[i] = loadedMatchRequest.MatchRequest.UserID
 	}
 
-	// Hobbies check max 4 points (max of 4 hobbies)
-	for _, hobby1 := range loadedMatchRequest1.MatchSetting.Hobbies {
-		for _, hobby2 := range loadedMatchRequest2.MatchSetting.Hobbies {
-			if hobby1 == hobby2 {
-				totalScore += 1
-				break
-			}
+	run.Outcome = MatchingOutcomeBelowThreshold
+	if run.QueueSize >= run.MinimumQueueSize() {
+		openGroups, err := getOpenGroupsToFill(ctx, db, matching)
+		if err != nil { return MatchingRun{}, err }
+		plan := PlanMatching(NewMatcher(matching), loadedMatchRequests, openGroups, matching)
+		groupsWithUsers, err := performMatching(ctx, db, plan, loadedMatchRequests)
+		if err != nil { return MatchingRun{}, err }
+		for _, groupWithUsers := range groupsWithUsers {
+			run.GroupIDs = append(run.GroupIDs, groupWithUsers.Group.ID)
 		}
+		for _, fill := range plan.Fills {
+			run.FilledGroupIDs = append(run.FilledGroupIDs, fill.GroupID)
+		}
+		if len(run.GroupIDs) > 0 || len(run.FilledGroupIDs) > 0 { run.Outcome = MatchingOutcomeMatched }
 	}
-	return totalScore // out of 12
+	run.TimeFinished = time.Now()
+	return addMatchingRun(ctx, db, *run)
 }
 
-// Retrieves all loadedMatchRequest and performs matching on all available match request
-func PerformMatching(db *sql.DB) ([]GroupWithUsers, error) {
-	loadedMatchRequests, err := GetAllLoadedMatchRequest(db)
-	if err != nil { return nil, err }
-	if len(loadedMatchRequests) < config.MATCH_THRESHOLD { return make([]GroupWithUsers, 0), nil }
+// Groups that a run may place match requests into, none unless MATCH_FILL_GROUPS
+func getOpenGroupsToFill(ctx context.Context, db DBTX, matching config.MatchingConfig) ([]OpenGroup, error) {
+	if !matching.FillGroups { return nil, nil }
+	return GetAllOpenGroups(ctx, db, matching.GroupSize)
+}
 
+// Forms the new groups of plan and adds the members of its fills to their groups, removing the
+// match requests of everyone placed
+func performMatching(ctx context.Context, db DBTX, plan MatchingPlan, loadedMatchRequests []LoadedMatchRequest) ([]GroupWithUsers, error) {
 	groupsWithUsers := make([]GroupWithUsers, 0)
 	group := Group{
 		GroupName: "Support Group",
@@ -72,55 +114,26 @@ func PerformMatching(db *sql.DB) ([]GroupWithUsers, error) {
 		Category: "SUPPORT",
 	}
 
-	for len(loadedMatchRequests) >= config.MATCH_GROUPSIZE {
-		groupingIndices, remainingIndices := GetGroupingRemainingIndices(loadedMatchRequests)
-
+	for _, groupingIndices := range plan.Groups {
 		groupingUserIDs := make([]int64, len(groupingIndices))
 		for i, index := range groupingIndices {
 			userID := loadedMatchRequests[index].MatchRequest.UserID
 			groupingUserIDs[i] = userID
-			_, err := DeleteMatchRequestOfUser(db, userID)
+			_, err := DeleteMatchRequestOfUser(ctx, db, userID)
 			if err != nil { return nil, err }
 		}
 
-		groupWithUsers, err := AddGroupWithUserIDs(db, group, groupingUserIDs)
+		groupWithUsers, err := addGroupWithUserIDs(ctx, db, group, groupingUserIDs)
 		if err != nil { return nil, err }
 		groupsWithUsers = append(groupsWithUsers, groupWithUsers)
+	}
 
-		remainingLMRs := make([]LoadedMatchRequest, len(remainingIndices))
-		for i, index := range remainingIndices {
-			remainingLMRs[i] = loadedMatchRequests[index]
+	for _, fill := range plan.Fills {
+		for _, index := range fill.Indices {
+			userID := loadedMatchRequests[index].MatchRequest.UserID
+			if _, err := DeleteMatchRequestOfUser(ctx, db, userID); err != nil { return nil, err }
+			if err := AddUserToGroup(ctx, db, fill.GroupID, userID); err != nil { return nil, err }
 		}
-		loadedMatchRequests = remainingLMRs
 	}
-	// writeToStdOut(groupsWithUsers)
 	return groupsWithUsers, nil
 }
-
-// Return a slice of the indices of match request that will form a group among given loadmatchrequests
-// Done by randomly selecting a pivoting match request and building the group to best suit that pivot
-func GetGroupingRemainingIndices(lmrs []LoadedMatchRequest) ([]int, []int) {
-	l := len(lmrs)
-	if l < config.MATCH_GROUPSIZE { return nil, nil }
-	p := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(l)
-	scoreMap := make([]int, l)
-	indices := make([]int, l)
-	for j, lmr := range lmrs {
-		scoreMap[j] = Compatibility(lmrs[p], lmr)
-		indices[j] = j
-	}
-	sort.Slice(indices, func(i, j int) bool {
-		return scoreMap[indices[i]] > scoreMap[indices[j]]
-	})
-	return indices[:config.MATCH_GROUPSIZE], indices[config.MATCH_GROUPSIZE:]
-}
-
-func writeToStdOut(item interface{}) {
-	b, err := json.Marshal(item)
-	if err != nil {
-		log.Fatal(err)
-	}
-	var out bytes.Buffer
-	json.Indent(&out, b, "=", " ")
-	out.WriteTo(os.Stdout)
-}
\ No newline at end of file
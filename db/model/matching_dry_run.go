@@ -0,0 +1,137 @@
+package model
+
+import (
+	"wellnus/backend/config"
+
+	"context"
+	"database/sql"
+)
+
+// Compatibility of a pair of users that a matching run would place in the same group
+type PairCompatibility struct {
+	UserIDs	[2]int64			`json:"user_ids"`
+	Score	CompatibilityScore	`json:"score"`
+}
+
+// A group that a matching run would form, or an existing one it would place match requests into
+type ProposedGroup struct {
+	GroupID		int64				`json:"group_id"`	// 0 for a new group
+	UserIDs		[]int64				`json:"user_ids"`	// users whose match requests would be placed, the first owning a new group
+	MemberIDs	[]int64				`json:"member_ids"`	// members the existing group already has
+	Pairs		[]PairCompatibility	`json:"pairs"`		// every pair with at least one of the users placed
+	Total		int					`json:"total"`		// sum of the scores of the pairs
+}
+
+// Aggregates of what a matching run would do
+type MatchingStats struct {
+	QueueSize			int		`json:"queue_size"`
+	Groups				int		`json:"groups"`
+	FilledGroups		int		`json:"filled_groups"`
+	Matched				int		`json:"matched"`		// match requests placed into a group
+	Remaining			int		`json:"remaining"`		// match requests left queued
+	Pairs				int		`json:"pairs"`
+	TotalCompatibility	int		`json:"total_compatibility"`
+	MeanPairScore		float64	`json:"mean_pair_score"`
+	MinPairScore		int		`json:"min_pair_score"`
+	MaxPairScore		int		`json:"max_pair_score"`
+	MaxScore			int		`json:"max_score"`		// most a pair of match requests can score
+}
+
+// What a matching run would do with the queue, worked out without forming any group
+type MatchingDryRun struct {
+	Strategy			string			`json:"strategy"`
+	Groups				[]ProposedGroup	`json:"groups"`
+	Fills				[]ProposedGroup	`json:"fills"`
+	RemainingUserIDs	[]int64			`json:"remaining_user_ids"`
+	Stats				MatchingStats	`json:"stats"`
+}
+
+// Plans matching on lmrs and openGroups as a run would, and scores every pair it would place together
+func DryRunPlan(lmrs []LoadedMatchRequest, openGroups []OpenGroup, matching config.MatchingConfig) MatchingDryRun {
+	matcher := NewMatcher(matching)
+	plan := PlanMatching(matcher, lmrs, openGroups, matching)
+	dryRun := MatchingDryRun{
+		Strategy: MatchStrategyAnnealing,
+		Groups: make([]ProposedGroup, 0),
+		Fills: make([]ProposedGroup, 0),
+		RemainingUserIDs: make([]int64, 0),
+		Stats: MatchingStats{ QueueSize: len(lmrs), MaxScore: MaxCompatibility(matching.Weights) },
+	}
+	if _, ok := matcher.(GreedyMatcher); ok { dryRun.Strategy = MatchStrategyGreedy }
+
+	for _, indices := range plan.Groups {
+		dryRun.Groups = append(dryRun.Groups, proposeGroup(0, pick(lmrs, indices), nil, matching.Weights))
+	}
+	for _, fill := range plan.Fills {
+		var members []LoadedMatchRequest
+		for _, openGroup := range openGroups {
+			if openGroup.Group.ID == fill.GroupID { members = openGroup.Members }
+		}
+		dryRun.Fills = append(dryRun.Fills, proposeGroup(fill.GroupID, pick(lmrs, fill.Indices), members, matching.Weights))
+	}
+	for _, index := range plan.Remaining {
+		dryRun.RemainingUserIDs = append(dryRun.RemainingUserIDs, lmrs[index].MatchRequest.UserID)
+	}
+
+	stats := &dryRun.Stats
+	stats.Groups, stats.FilledGroups, stats.Remaining = len(dryRun.Groups), len(dryRun.Fills), len(dryRun.RemainingUserIDs)
+	stats.Matched = stats.QueueSize - stats.Remaining
+	for _, proposed := range append(append([]ProposedGroup(nil), dryRun.Groups...), dryRun.Fills...) {
+		for _, pair := range proposed.Pairs {
+			if stats.Pairs == 0 || pair.Score.Total < stats.MinPairScore { stats.MinPairScore = pair.Score.Total }
+			if pair.Score.Total > stats.MaxPairScore { stats.MaxPairScore = pair.Score.Total }
+			stats.Pairs++
+		}
+		stats.TotalCompatibility += proposed.Total
+	}
+	if stats.Pairs > 0 { stats.MeanPairScore = float64(stats.TotalCompatibility) / float64(stats.Pairs) }
+	return dryRun
+}
+
+func pick(lmrs []LoadedMatchRequest, indices []int) []LoadedMatchRequest {
+	picked := make([]LoadedMatchRequest, len(indices))
+	for i, index := range indices {
+		picked[i] = lmrs[index]
+	}
+	return picked
+}
+
+// Scores every pair of placed, and each of placed with every one of members
+func proposeGroup(groupID int64, placed []LoadedMatchRequest, members []LoadedMatchRequest, weights config.CompatibilityWeights) ProposedGroup {
+	proposed := ProposedGroup{
+		GroupID: groupID,
+		UserIDs: make([]int64, len(placed)),
+		MemberIDs: make([]int64, len(members)),
+		Pairs: make([]PairCompatibility, 0),
+	}
+	for i, member := range members {
+		proposed.MemberIDs[i] = member.User.ID
+	}
+	for i, lmr := range placed {
+		proposed.UserIDs[i] = lmr.MatchRequest.UserID
+		others := append(append([]LoadedMatchRequest(nil), placed[:i]...), members...)
+		for _, other := range others {
+			score := ScoreCompatibility(lmr, other, weights)
+			proposed.Pairs = append(proposed.Pairs, PairCompatibility{ UserIDs: [2]int64{ lmr.User.ID, other.User.ID }, Score: score })
+			proposed.Total += score.Total
+		}
+	}
+	return proposed
+}
+
+// Works out what a run of matching would do with the queue as it is, ignoring the threshold as a
+// manual run does. No group is formed, no match request removed and no run recorded
+func DryRunMatching(ctx context.Context, db DBTX, matching config.MatchingConfig) (MatchingDryRun, error) {
+	loadedMatchRequests, err := GetAllLoadedMatchRequest(ctx, db)
+	if err != nil { return MatchingDryRun{}, err }
+	openGroups, err := getOpenGroupsToFill(ctx, db, matching)
+	if err != nil { return MatchingDryRun{}, err }
+	return DryRunPlan(loadedMatchRequests, openGroups, matching), nil
+}
+
+// DryRunMatching for the admin with adminID, with strategy in place of MATCH_STRATEGY unless empty
+func TriggerDryRunMatching(ctx context.Context, db *sql.DB, matching config.MatchingConfig, adminID int64, strategy string) (MatchingDryRun, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionDryRunMatching, nil); err != nil { return MatchingDryRun{}, err }
+	if strategy != "" { matching.Strategy = strategy }
+	return DryRunMatching(ctx, db, matching)
+}
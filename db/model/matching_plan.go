@@ -0,0 +1,153 @@
+package model
+
+import (
+	"wellnus/backend/config"
+
+	"time"
+)
+
+// SUPPORT group with fewer than MATCH_GROUP_SIZE members, which match requests left over by a run may join
+type OpenGroup struct {
+	Group	Group
+	Members	[]LoadedMatchRequest	// only User and MatchSetting are set, and MatchSetting is empty for members without one
+}
+
+// Match requests of a run joining an existing group, as indices into the queue
+type GroupFill struct {
+	GroupID	int64
+	Indices	[]int
+}
+
+// What a matching run does with every queued match request, as indices into the queue
+type MatchingPlan struct {
+	Groups		[][]int		// new groups
+	Fills		[]GroupFill	// existing groups joined
+	Remaining	[]int		// left queued
+}
+
+// Decides what a run does with lmrs, which are ordered from the oldest. The oldest match requests
+// are grouped by matcher first, so that only the newest can be left over. The policy of matching
+// for those left over then
+//   - places them into openGroups, with the members they are most compatible with, if FillGroups
+//   - forms a group one member short of them, or adds each to a different new group, if UnevenGroups
+// and leaves the rest queued
+func PlanMatching(matcher Matcher, lmrs []LoadedMatchRequest, openGroups []OpenGroup, matching config.MatchingConfig) MatchingPlan {
+	grouped := len(lmrs) / matching.GroupSize * matching.GroupSize
+	groups, _ := matcher.Match(lmrs[:grouped], matching.GroupSize)
+	leftovers := make([]int, 0, len(lmrs) - grouped)
+	for i := grouped; i < len(lmrs); i++ {
+		leftovers = append(leftovers, i)
+	}
+
+	plan := MatchingPlan{ Groups: groups, Fills: make([]GroupFill, 0) }
+	if matching.FillGroups {
+		plan.Fills, leftovers = fillOpenGroups(lmrs, leftovers, openGroups, matching)
+	}
+	if matching.UnevenGroups {
+		plan.Groups, leftovers = placeUneven(lmrs, plan.Groups, leftovers, matching)
+	}
+	plan.Remaining = leftovers
+	return plan
+}
+
+// Match requests left queued after a run on a queue of queueSize, before any join open groups
+func LeftoverCount(matching config.MatchingConfig, queueSize int) int {
+	left := queueSize % matching.GroupSize
+	if !matching.UnevenGroups { return left }
+	if left == matching.GroupSize - 1 && left >= 2 { return 0 }
+	if left <= queueSize / matching.GroupSize { return 0 }
+	return left
+}
+
+// Estimates how long the match request at position of a queue of queueSize waits to be matched,
+// given the time of the last run. The next run is expected once the scheduler polls if the queue
+// has reached the threshold, or MATCH_INTERVAL after the last run otherwise. Those it would leave
+// over wait for the run after. Reports false when no run is expected
+func EstimateWait(matching config.MatchingConfig, position int, queueSize int, lastRun time.Time, now time.Time) (time.Duration, bool) {
+	var next time.Time
+	if queueSize >= matching.Threshold {
+		next = now.Add(matching.PollInterval)
+	} else if matching.Interval > 0 {
+		next = lastRun.Add(matching.Interval)
+		if next.Before(now) { next = now.Add(matching.PollInterval) }
+	} else {
+		return 0, false
+	}
+	if position > queueSize - LeftoverCount(matching, queueSize) {
+		if matching.Interval <= 0 { return 0, false }
+		next = next.Add(matching.Interval)
+	}
+	return next.Sub(now), true
+}
+
+// Places each leftover, oldest first, into the open group it is most compatible with on average,
+// until the group has GroupSize members. Returns the fills and the leftovers not placed
+func fillOpenGroups(lmrs []LoadedMatchRequest, leftovers []int, openGroups []OpenGroup, matching config.MatchingConfig) ([]GroupFill, []int) {
+	members := make([][]LoadedMatchRequest, len(openGroups))
+	for g, openGroup := range openGroups {
+		members[g] = append([]LoadedMatchRequest(nil), openGroup.Members...)
+	}
+	indices := make([][]int, len(openGroups))
+	remaining := make([]int, 0)
+	for _, leftover := range leftovers {
+		best, bestScore, bestSize := -1, 0, 0
+		for g := range openGroups {
+			if len(members[g]) >= matching.GroupSize || hasMember(members[g], lmrs[leftover].User.ID) { continue }
+			score := 0
+			for _, member := range members[g] {
+				score += Compatibility(lmrs[leftover], member, matching.Weights)
+			}
+			// Compares the average compatibility with the members, score / len(members[g])
+			if best < 0 || score * bestSize > bestScore * len(members[g]) {
+				best, bestScore, bestSize = g, score, len(members[g])
+			}
+		}
+		if best < 0 {
+			remaining = append(remaining, leftover)
+			continue
+		}
+		members[best] = append(members[best], lmrs[leftover])
+		indices[best] = append(indices[best], leftover)
+	}
+
+	fills := make([]GroupFill, 0)
+	for g, openGroup := range openGroups {
+		if len(indices[g]) > 0 { fills = append(fills, GroupFill{ GroupID: openGroup.Group.ID, Indices: indices[g] }) }
+	}
+	return fills, remaining
+}
+
+func hasMember(members []LoadedMatchRequest, userID int64) bool {
+	for _, member := range members {
+		if member.User.ID == userID { return true }
+	}
+	return false
+}
+
+// Forms a group of the leftovers when they are one short of GroupSize, or else adds each to the
+// new group it is most compatible with, no group taking more than one. Returns the groups and the
+// leftovers not placed
+func placeUneven(lmrs []LoadedMatchRequest, groups [][]int, leftovers []int, matching config.MatchingConfig) ([][]int, []int) {
+	if len(leftovers) == 0 { return groups, leftovers }
+	if len(leftovers) == matching.GroupSize - 1 && len(leftovers) >= 2 {
+		return append(groups, leftovers), nil
+	}
+	if len(leftovers) > len(groups) { return groups, leftovers }
+
+	enlarged := make([]bool, len(groups))
+	for _, leftover := range leftovers {
+		best, bestScore := -1, 0
+		for g, group := range groups {
+			if enlarged[g] { continue }
+			score := 0
+			for _, index := range group {
+				score += Compatibility(lmrs[leftover], lmrs[index], matching.Weights)
+			}
+			if best < 0 || score > bestScore { best, bestScore = g, score }
+		}
+		// Groups may share the array of the matcher, so the enlarged one is copied
+		groups[best] = append(append([]int(nil), groups[best]...), leftover)
+		enlarged[best] = true
+	}
+	return groups, nil
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+
+	"net/http"
+	"time"
+)
+
+// What started a matching run
+const (
+	MatchingTriggerSchedule		= "SCHEDULE"	// MATCH_INTERVAL passed since the last run
+	MatchingTriggerQueueSize	= "QUEUE_SIZE"	// MATCH_THRESHOLD match requests were queued
+	MatchingTriggerManual		= "MANUAL"		// an admin asked for it
+)
+
+// How a matching run ended
+const (
+	MatchingOutcomeMatched			= "MATCHED"
+	MatchingOutcomeBelowThreshold	= "BELOW_THRESHOLD"
+	MatchingOutcomeFailed			= "FAILED"
+)
+
+// Key of the advisory lock held for the length of a matching run, so that only one instance runs at a time
+const MatchingLockID = 764813298
+
+var MatchingInProgressError = http_error.NewAPIError(http.StatusConflict, http_error.CodeConflict, "A matching run is already in progress")
+
+// MatchingRun is the record of one matching run, with the queue it was given and the groups it formed
+type MatchingRun struct {
+	ID				int64		`json:"id"`
+	Trigger			string		`json:"trigger"`
+	TriggeredBy		int64		`json:"triggered_by"`	// the admin of a manual run, 0 otherwise
+	Outcome			string		`json:"outcome"`
+	QueueSize		int			`json:"queue_size"`
+	UserIDs			[]int64		`json:"user_ids"`		// users whose match requests were queued
+	GroupSize		int			`json:"group_size"`
+	Threshold		int			`json:"threshold"`
+	GroupIDs		[]int64		`json:"group_ids"`
+	FilledGroupIDs	[]int64		`json:"filled_group_ids"`	// existing groups that match requests were placed into
+	Error			string		`json:"error"`
+	TimeStarted		time.Time	`json:"time_started"`
+	TimeFinished	time.Time	`json:"time_finished"`
+}
+
+// Fewest match requests a run with trigger needs before it forms groups. Runs started by the
+// queue size wait for the threshold, while the others place whoever is queued as best they can
+func (run MatchingRun) MinimumQueueSize() int {
+	if run.Trigger == MatchingTriggerQueueSize { return run.Threshold }
+	return 1
+}
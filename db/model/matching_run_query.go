@@ -0,0 +1,119 @@
+package model
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/router/http_helper/http_error"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+)
+
+const selectMatchingRunSQL = `SELECT
+		id,
+		trigger_type,
+		COALESCE(triggered_by, 0),
+		outcome,
+		queue_size,
+		user_ids,
+		group_size,
+		threshold,
+		group_ids,
+		filled_group_ids,
+		error,
+		time_started,
+		time_finished
+	FROM wn_matching_run`
+
+func ReadMatchingRuns(rows *sql.Rows) ([]MatchingRun, error) {
+	runs := make([]MatchingRun, 0)
+	for rows.Next() {
+		var run MatchingRun
+		if err := rows.Scan(
+			&run.ID,
+			&run.Trigger,
+			&run.TriggeredBy,
+			&run.Outcome,
+			&run.QueueSize,
+			pq.Array(&run.UserIDs),
+			&run.GroupSize,
+			&run.Threshold,
+			pq.Array(&run.GroupIDs),
+			pq.Array(&run.FilledGroupIDs),
+			&run.Error,
+			&run.TimeStarted,
+			&run.TimeFinished);
+			err != nil {
+				return nil, err
+			}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+func addMatchingRun(ctx context.Context, db DBTX, run MatchingRun) (MatchingRun, error) {
+	if run.UserIDs == nil { run.UserIDs = make([]int64, 0) }
+	if run.GroupIDs == nil { run.GroupIDs = make([]int64, 0) }
+	if run.FilledGroupIDs == nil { run.FilledGroupIDs = make([]int64, 0) }
+	err := db.QueryRowContext(ctx,
+		`INSERT INTO wn_matching_run (
+			trigger_type,
+			triggered_by,
+			outcome,
+			queue_size,
+			user_ids,
+			group_size,
+			threshold,
+			group_ids,
+			filled_group_ids,
+			error,
+			time_started,
+			time_finished
+		) VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id;`,
+		run.Trigger,
+		run.TriggeredBy,
+		run.Outcome,
+		run.QueueSize,
+		pq.Array(run.UserIDs),
+		run.GroupSize,
+		run.Threshold,
+		pq.Array(run.GroupIDs),
+		pq.Array(run.FilledGroupIDs),
+		run.Error,
+		run.TimeStarted,
+		run.TimeFinished).Scan(&run.ID)
+	if err != nil { return MatchingRun{}, err }
+	return run, nil
+}
+
+// Main functions
+
+// Runs matching at the request of the admin with adminID, forming groups out of whoever is queued
+func TriggerMatching(ctx context.Context, db *sql.DB, matching config.MatchingConfig, adminID int64) (MatchingRun, error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionRunMatching, nil); err != nil { return MatchingRun{}, err }
+	return RunMatching(ctx, db, matching, MatchingTriggerManual, adminID)
+}
+
+func GetLatestMatchingRun(ctx context.Context, db DBTX) (MatchingRun, error) {
+	rows, err := db.QueryContext(ctx, selectMatchingRunSQL + " ORDER BY time_started DESC, id DESC LIMIT 1;")
+	if err != nil { return MatchingRun{}, err }
+	defer rows.Close()
+	runs, err := ReadMatchingRuns(rows)
+	if err != nil { return MatchingRun{}, err }
+	if len(runs) == 0 { return MatchingRun{}, http_error.NotFoundError }
+	return runs[0], nil
+}
+
+var MatchingRunSorting = Sorting[MatchingRun]{
+	IDSQL: "id",
+	ID: func(run MatchingRun) int64 { return run.ID },
+	Keys: map[string]SortKey[MatchingRun]{
+		"id":			{ SQL: "id", Value: func(run MatchingRun) interface{} { return run.ID } },
+		"time_started":	{ SQL: "time_started", Value: func(run MatchingRun) interface{} { return run.TimeStarted } },
+	},
+}
+
+func GetMatchingRunsPage(ctx context.Context, db DBTX, adminID int64, page PageQuery) (Page[MatchingRun], error) {
+	if err := AuthorizeUserID(ctx, db, adminID, ActionListMatchingRuns, nil); err != nil { return Page[MatchingRun]{}, err }
+	return QueryPage(ctx, db, MatchingRunSorting, page, selectMatchingRunSQL, nil, nil, ReadMatchingRuns)
+}
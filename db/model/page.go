@@ -0,0 +1,208 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// PageQuery is the pagination contract shared by every list endpoint. The first page is
+// requested without a Cursor; every following page passes the NextCursor of the page before it
+// together with the same SortBy and Descending
+type PageQuery struct {
+	Limit		int64
+	Cursor		string
+	SortBy		string		// one of the keys of the Sorting of the list, id by default
+	Descending	bool
+}
+
+// Page is one page of a list. NextCursor is empty on the last page
+type Page[T any] struct {
+	Items		[]T			`json:"items"`
+	NextCursor	string		`json:"next_cursor"`
+}
+
+// SortKey is a column a list may be sorted by
+type SortKey[T any] struct {
+	SQL		string					// expression the rows are ordered on
+	Value	func(T) interface{}		// the same value for an item, an int64, string or time.Time
+}
+
+// Sorting describes the orders a list can be paged in. Ties are broken by the ID so that every
+// item has a unique position, which is what lets a cursor pick up exactly where a page ended
+type Sorting[T any] struct {
+	IDSQL	string
+	ID		func(T) int64
+	Keys	map[string]SortKey[T]	// must include "id"
+}
+
+// Position of the last item of a page, encoded into the opaque cursor given to clients
+type pageCursor struct {
+	SortBy		string	`json:"s"`
+	Descending	bool	`json:"d"`
+	Key			string	`json:"k"`
+	ID			int64	`json:"i"`
+}
+
+type pagePosition struct {
+	key	interface{}
+	id	int64
+}
+
+var InvalidCursorError = http_error.NewValidationError("cursor", "is invalid")
+
+func encodeCursor(cursor pageCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cursor pageCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil { return pageCursor{}, InvalidCursorError }
+	if err := json.Unmarshal(b, &cursor); err != nil { return pageCursor{}, InvalidCursorError }
+	return cursor, nil
+}
+
+func formatSortValue(value interface{}) string {
+	switch v := value.(type) {
+	case int64:
+		return fmt.Sprint(v)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(value)
+}
+
+// Parses the key of a cursor as the same type as sample
+func parseSortValue(sample interface{}, s string) (interface{}, error) {
+	switch sample.(type) {
+	case int64:
+		var v int64
+		if _, err := fmt.Sscan(s, &v); err != nil { return nil, err }
+		return v, nil
+	case time.Time:
+		return time.Parse(time.RFC3339Nano, s)
+	}
+	return s, nil
+}
+
+func compareSortValues(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case int64:
+		b := b.(int64)
+		if a < b { return -1 } else if a > b { return 1 }
+		return 0
+	case time.Time:
+		b := b.(time.Time)
+		if a.Before(b) { return -1 } else if a.After(b) { return 1 }
+		return 0
+	}
+	return strings.Compare(a.(string), b.(string))
+}
+
+// Checks the query, fills in the defaults and decodes the position of its cursor, if any
+func (sorting Sorting[T]) normalize(page PageQuery) (PageQuery, *pagePosition, error) {
+	if page.SortBy == "" { page.SortBy = "id" }
+	key, ok := sorting.Keys[page.SortBy]
+	if !ok { return PageQuery{}, nil, http_error.NewValidationError("sort_by", "is invalid") }
+	if page.Limit == 0 { page.Limit = DefaultPageLimit }
+	if page.Limit < 0 || page.Limit > MaxPageLimit {
+		return PageQuery{}, nil, http_error.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", MaxPageLimit))
+	}
+	if page.Cursor == "" { return page, nil, nil }
+	cursor, err := decodeCursor(page.Cursor)
+	if err != nil { return PageQuery{}, nil, err }
+	if cursor.SortBy != page.SortBy || cursor.Descending != page.Descending {
+		return PageQuery{}, nil, http_error.NewValidationError("cursor", "does not match sort_by and order")
+	}
+	var zero T
+	value, err := parseSortValue(key.Value(zero), cursor.Key)
+	if err != nil { return PageQuery{}, nil, InvalidCursorError }
+	return page, &pagePosition{ key: value, id: cursor.ID }, nil
+}
+
+// Cuts items, fetched one beyond the limit, down to the page and points the cursor at its last item
+func (sorting Sorting[T]) page(items []T, page PageQuery) Page[T] {
+	if items == nil { items = make([]T, 0) }
+	if int64(len(items)) <= page.Limit { return Page[T]{ Items: items } }
+	items = items[:page.Limit]
+	last := items[len(items) - 1]
+	cursor := pageCursor{
+		SortBy: page.SortBy,
+		Descending: page.Descending,
+		Key: formatSortValue(sorting.Keys[page.SortBy].Value(last)),
+		ID: sorting.ID(last),
+	}
+	return Page[T]{ Items: items, NextCursor: encodeCursor(cursor) }
+}
+
+// Order of a and b in the sort of page
+func (sorting Sorting[T]) compare(a T, b T, page PageQuery) int {
+	key := sorting.Keys[page.SortBy]
+	c := compareSortValues(key.Value(a), key.Value(b))
+	if c == 0 { c = compareSortValues(sorting.ID(a), sorting.ID(b)) }
+	if page.Descending { return -c }
+	return c
+}
+
+func (sorting Sorting[T]) isAfter(item T, position pagePosition, page PageQuery) bool {
+	c := compareSortValues(sorting.Keys[page.SortBy].Value(item), position.key)
+	if c == 0 { c = compareSortValues(sorting.ID(item), position.id) }
+	if page.Descending { return c < 0 }
+	return c > 0
+}
+
+// Runs selectSQL restricted to conditions and returns the page that page asks for. The keyset
+// condition of the cursor is added to the conditions, so only rows after the cursor are read
+func QueryPage[T any](ctx context.Context, db DBTX, sorting Sorting[T], page PageQuery, selectSQL string, conditions []string, args []interface{}, read func(*sql.Rows) ([]T, error)) (Page[T], error) {
+	page, position, err := sorting.normalize(page)
+	if err != nil { return Page[T]{}, err }
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	key := sorting.Keys[page.SortBy]
+	direction, operator := "ASC", ">"
+	if page.Descending { direction, operator = "DESC", "<" }
+	if position != nil {
+		conditions = append(conditions,
+			fmt.Sprintf("(%s, %s) %s (%s, %s)", key.SQL, sorting.IDSQL, operator, arg(position.key), arg(position.id)))
+	}
+	query := selectSQL
+	if len(conditions) > 0 { query += " WHERE " + strings.Join(conditions, " AND ") }
+	query += fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT %s;", key.SQL, direction, sorting.IDSQL, direction, arg(page.Limit + 1))
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil { return Page[T]{}, err }
+	defer rows.Close()
+	items, err := read(rows)
+	if err != nil { return Page[T]{}, err }
+	return sorting.page(items, page), nil
+}
+
+// Sorts items in process and returns the page that page asks for. Used by stores without SQL
+func PaginateItems[T any](items []T, sorting Sorting[T], page PageQuery) (Page[T], error) {
+	page, position, err := sorting.normalize(page)
+	if err != nil { return Page[T]{}, err }
+	sorted := append([]T(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorting.compare(sorted[i], sorted[j], page) < 0 })
+	pageItems := make([]T, 0)
+	for _, item := range sorted {
+		if position != nil && !sorting.isAfter(item, *position, page) { continue }
+		pageItems = append(pageItems, item)
+		if int64(len(pageItems)) > page.Limit { break }
+	}
+	return sorting.page(pageItems, page), nil
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+	"context"
+)
+
+// Action is an operation on a user, group, event, booking or counsel request that needs authorization
+type Action string
+
+const (
+	ActionUpdateUser            Action = "user.update"
+	ActionDeleteUser            Action = "user.delete"
+	ActionViewMatchRequest      Action = "match_request.view"
+	ActionUpdateGroup           Action = "group.update"
+	ActionReadGroupMessages     Action = "group.read_messages"
+	ActionViewCompatibility     Action = "group.view_compatibility"
+	ActionViewJoinRequest       Action = "join_request.view"
+	ActionRespondJoinRequest    Action = "join_request.respond"
+	ActionDeleteJoinRequest     Action = "join_request.delete"
+	ActionUpdateEvent           Action = "event.update"
+	ActionStartEvent            Action = "event.start"
+	ActionJoinPublicEvent       Action = "event.join_public"
+	ActionAddUserToPrivateEvent Action = "event.add_private"
+	ActionViewBooking           Action = "booking.view"
+	ActionProvideBooking        Action = "booking.provide"
+	ActionUpdateBooking         Action = "booking.update"
+	ActionRespondBooking        Action = "booking.respond"
+	ActionDeleteBooking         Action = "booking.delete"
+	ActionListCounselRequests   Action = "counsel_request.list"
+	ActionViewCounselRequest    Action = "counsel_request.view"
+	ActionAcceptCounselRequest  Action = "counsel_request.accept"
+	ActionUpdateProviderSetting Action = "provider_setting.update"
+	ActionVerifyUser            Action = "admin.verify_user"
+	ActionChangeUserRole        Action = "admin.change_role"
+	ActionSuspendUser           Action = "admin.suspend_user"
+	ActionForceDeleteGroup      Action = "admin.delete_group"
+	ActionForceDeleteEvent      Action = "admin.delete_event"
+	ActionListReports           Action = "admin.list_reports"
+	ActionDeleteReport          Action = "admin.delete_report"
+	ActionRunMatching           Action = "admin.run_matching"
+	ActionListMatchingRuns      Action = "admin.list_matching_runs"
+	ActionDryRunMatching        Action = "admin.dry_run_matching"
+)
+
+// Relation is what the acting user is to the resource being acted on
+type Relation string
+
+const (
+	RelationSelf      Relation = "self"      // the resource is the user, or was created by the user
+	RelationOwner     Relation = "owner"     // owner of a group or event
+	RelationMember    Relation = "member"    // member of a group
+	RelationProvider  Relation = "provider"  // provider of a booking
+	RelationRecipient Relation = "recipient" // recipient of a booking or counsel request
+	RelationApprover  Relation = "approver"  // party whose approval a booking is waiting on
+)
+
+var (
+	AllRoles      = []string{"MEMBER", "VOLUNTEER", "COUNSELLOR", "ADMIN"}
+	ProviderRoles = []string{"VOLUNTEER", "COUNSELLOR"}
+	AdminRoles    = []string{"ADMIN"}
+)
+
+// Rule allows an action to users holding any of Roles or any of Relations to the resource
+type Rule struct {
+	Roles     []string
+	Relations []Relation
+}
+
+// Policy is the single source of truth for who may perform each action
+var Policy = map[Action]Rule{
+	ActionUpdateUser:            { Relations: []Relation{RelationSelf} },
+	ActionDeleteUser:            { Relations: []Relation{RelationSelf} },
+	ActionViewMatchRequest:      { Relations: []Relation{RelationSelf} },
+	ActionUpdateGroup:           { Relations: []Relation{RelationOwner} },
+	ActionReadGroupMessages:     { Relations: []Relation{RelationMember} },
+	ActionViewCompatibility:     { Relations: []Relation{RelationMember} },
+	ActionViewJoinRequest:       { Relations: []Relation{RelationSelf, RelationOwner} },
+	ActionRespondJoinRequest:    { Relations: []Relation{RelationOwner} },
+	ActionDeleteJoinRequest:     { Relations: []Relation{RelationSelf} },
+	ActionUpdateEvent:           { Relations: []Relation{RelationOwner} },
+	ActionStartEvent:            { Relations: []Relation{RelationOwner} },
+	ActionJoinPublicEvent:       { Roles: AllRoles },
+	ActionAddUserToPrivateEvent: { Relations: []Relation{RelationOwner} },
+	ActionViewBooking:           { Relations: []Relation{RelationRecipient, RelationProvider} },
+	ActionProvideBooking:        { Roles: ProviderRoles },
+	ActionUpdateBooking:         { Relations: []Relation{RelationRecipient} },
+	ActionRespondBooking:        { Relations: []Relation{RelationApprover} },
+	ActionDeleteBooking:         { Relations: []Relation{RelationRecipient} },
+	ActionListCounselRequests:   { Roles: ProviderRoles },
+	ActionViewCounselRequest:    { Roles: ProviderRoles, Relations: []Relation{RelationRecipient} },
+	ActionAcceptCounselRequest:  { Roles: ProviderRoles },
+	ActionUpdateProviderSetting: { Roles: ProviderRoles },
+	ActionVerifyUser:            { Roles: AdminRoles },
+	ActionChangeUserRole:        { Roles: AdminRoles },
+	ActionSuspendUser:           { Roles: AdminRoles },
+	ActionForceDeleteGroup:      { Roles: AdminRoles },
+	ActionForceDeleteEvent:      { Roles: AdminRoles },
+	ActionListReports:           { Roles: AdminRoles },
+	ActionDeleteReport:          { Roles: AdminRoles },
+	ActionRunMatching:           { Roles: AdminRoles },
+	ActionListMatchingRuns:      { Roles: AdminRoles },
+	ActionDryRunMatching:        { Roles: AdminRoles },
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s { return true }
+	}
+	return false
+}
+
+// Role the user holds for policy checks. Counsellors act as members until an admin verifies them
+func PolicyRole(user User) string {
+	if user.UserRole == "COUNSELLOR" && !user.Verified { return "MEMBER" }
+	return user.UserRole
+}
+
+// Reports whether user may perform action given the relations they hold to the resource.
+// Actions missing from Policy are denied.
+func Can(user User, action Action, relations []Relation) bool {
+	rule, ok := Policy[action]
+	if !ok { return false }
+	if containsString(rule.Roles, PolicyRole(user)) { return true }
+	for _, allowed := range rule.Relations {
+		for _, relation := range relations {
+			if relation == allowed { return true }
+		}
+	}
+	return false
+}
+
+func Authorize(user User, action Action, relations []Relation) error {
+	if !Can(user, action, relations) { return http_error.UnauthorizedError }
+	return nil
+}
+
+// Authorizes the user with userID, loading their role only when the rule for action depends on it
+func AuthorizeUserID(ctx context.Context, db DBTX, userID int64, action Action, relations []Relation) error {
+	if Can(User{ ID: userID }, action, relations) { return nil }
+	if len(Policy[action].Roles) == 0 { return http_error.UnauthorizedError }
+	user, err := GetUser(ctx, db, userID)
+	if err != nil { return http_error.UnauthorizedError }
+	return Authorize(user, action, relations)
+}
+
+// Relation helpers
+
+func UserRelations(userID int64, targetUserID int64) []Relation {
+	if userID == targetUserID { return []Relation{RelationSelf} }
+	return nil
+}
+
+func GroupRelations(userID int64, group Group, isMember bool) []Relation {
+	relations := make([]Relation, 0)
+	if group.OwnerID == userID { relations = append(relations, RelationOwner) }
+	if isMember { relations = append(relations, RelationMember) }
+	return relations
+}
+
+// The user who sent a join request is self to it, and the owner of the group it is for is owner
+func JoinRequestRelations(userID int64, loadedJoinRequest LoadedJoinRequest) []Relation {
+	relations := make([]Relation, 0)
+	if loadedJoinRequest.JoinRequest.UserID == userID { relations = append(relations, RelationSelf) }
+	if loadedJoinRequest.Group.OwnerID == userID { relations = append(relations, RelationOwner) }
+	return relations
+}
+
+func EventRelations(userID int64, event Event) []Relation {
+	if event.OwnerID == userID { return []Relation{RelationOwner} }
+	return nil
+}
+
+func BookingRelations(userID int64, booking Booking) []Relation {
+	relations := make([]Relation, 0)
+	if booking.RecipientID == userID { relations = append(relations, RelationRecipient) }
+	if booking.ProviderID == userID { relations = append(relations, RelationProvider) }
+	if booking.ApproveBy == userID { relations = append(relations, RelationApprover) }
+	return relations
+}
+
+func CounselRequestRelations(userID int64, recipientUserID int64) []Relation {
+	if userID == recipientUserID { return []Relation{RelationRecipient} }
+	return nil
+}
@@ -1,8 +1,6 @@
 package model
 
-import (
-	"database/sql"
-)
+import "context"
 
 type ProviderSetting struct {
 	UserID 	int64 		`json:"user_id"`
@@ -11,7 +9,7 @@ type ProviderSetting struct {
 }
 
 type Provider struct {
-	User 		User 			`json:"user"`
+	User 		PublicUser 		`json:"user"`
 	Setting		ProviderSetting	`json:"setting"`
 }
 
@@ -20,10 +18,10 @@ type ProviderWithEvents struct {
 	Events		[]Event		`json:"events"`
 }
 
-func (ps ProviderSetting) LoadProviderSetting(db *sql.DB) (Provider, error) {
-	user, err := GetUser(db, ps.UserID)
+func (ps ProviderSetting) LoadProviderSetting(ctx context.Context, db DBTX) (Provider, error) {
+	user, err := GetUser(ctx, db, ps.UserID)
 	if err != nil { return Provider{}, err }
-	return Provider{ User: user, Setting: ps }, nil
+	return Provider{ User: user.Public(), Setting: ps }, nil
 }
 
 func (ps ProviderSetting) HasTopic(topic string) bool {
@@ -35,8 +33,8 @@ func (ps ProviderSetting) HasTopic(topic string) bool {
 	return false
 }
 
-func (p Provider) LoadProvider(db *sql.DB) (ProviderWithEvents, error) {
-	events, err := GetAllEventsOfUser(db, p.User.ID)
+func (p Provider) LoadProvider(ctx context.Context, db DBTX) (ProviderWithEvents, error) {
+	events, err := GetAllEventsOfUser(ctx, db, p.User.ID)
 	if err != nil { return ProviderWithEvents{}, err }
 	return ProviderWithEvents{ Provider: p, Events: events }, nil
 }
\ No newline at end of file
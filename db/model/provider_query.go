@@ -3,18 +3,15 @@ package model
 import (
 	"wellnus/backend/router/http_helper/http_error"
 
+	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/lib/pq"
 )
 
 func IsProvider(user User) bool {
-	return user.UserRole == "VOLUNTEER" || user.UserRole == "COUNSELLOR";
-}
-
-func AuthoriseProvider(db *sql.DB, userID int64) bool {
-	user, _ := GetUser(db, userID)
-	return IsProvider(user)
+	return containsString(ProviderRoles, PolicyRole(user))
 }
 
 func ReadProviderSettings(rows *sql.Rows) ([]ProviderSetting, error) {
@@ -43,9 +40,8 @@ func ReadProviders(rows *sql.Rows) ([]Provider, error) {
 			&provider.User.LastName, 
 			&provider.User.Gender, 
 			&provider.User.Faculty, 
-			&provider.User.Email,
 			&provider.User.UserRole, 
-			&provider.User.PasswordHash,
+			&provider.User.Verified,
 			&provider.Setting.UserID,
 			&provider.Setting.Intro,
 			pq.Array(&provider.Setting.Topics)); 
@@ -57,8 +53,8 @@ func ReadProviders(rows *sql.Rows) ([]Provider, error) {
 	return providers, nil
 }
 
-func GetProviderSetting(db *sql.DB, userID int64) (ProviderSetting, error) {
-	rows, err := db.Query("SELECT * FROM wn_provider_setting WHERE user_id = $1", userID)
+func GetProviderSetting(ctx context.Context, db DBTX, userID int64) (ProviderSetting, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_provider_setting WHERE user_id = $1", userID)
 	if err != nil { return ProviderSetting{}, err }
 	defer rows.Close()
 	providerSettings, err := ReadProviderSettings(rows)
@@ -67,75 +63,64 @@ func GetProviderSetting(db *sql.DB, userID int64) (ProviderSetting, error) {
 	return providerSettings[0], nil
 }
 
-func GetAllProviders(db *sql.DB, topics []string) ([]Provider, error) {
-	var rows *sql.Rows
-	var err error
-	if topics == nil {
-		rows, err = db.Query(
+var ProviderSorting = Sorting[Provider]{
+	IDSQL: "wn_user.id",
+	ID: func(provider Provider) int64 { return provider.User.ID },
+	Keys: map[string]SortKey[Provider]{
+		"id":			{ SQL: "wn_user.id", Value: func(provider Provider) interface{} { return provider.User.ID } },
+		"first_name":	{ SQL: `lower(wn_user.first_name) COLLATE "C"`, Value: func(provider Provider) interface{} { return strings.ToLower(provider.User.FirstName) } },
+		"last_name":	{ SQL: `lower(wn_user.last_name) COLLATE "C"`, Value: func(provider Provider) interface{} { return strings.ToLower(provider.User.LastName) } },
+	},
+}
+
+func GetProvidersPage(ctx context.Context, db DBTX, topics []string, page PageQuery) (Page[Provider], error) {
+	conditions := []string{"(wn_user.user_role = 'VOLUNTEER' OR (wn_user.user_role = 'COUNSELLOR' AND wn_user.verified))"}
+	args := make([]interface{}, 0)
+	if len(topics) > 0 {
+		conditions = append(conditions, "$1 <@ wn_provider_setting.topics")
+		args = append(args, pq.Array(topics))
+	}
+	return QueryPage(ctx, db, ProviderSorting, page,
 		`SELECT 
 			wn_user.id,
 			wn_user.first_name,
 			wn_user.last_name,
 			wn_user.gender,
 			wn_user.faculty,
-			wn_user.email,
 			wn_user.user_role,
-			wn_user.password_hash,
+			wn_user.verified,
 			wn_provider_setting.user_id,
 			wn_provider_setting.intro,
 			wn_provider_setting.topics
 		FROM wn_provider_setting 
-		JOIN wn_user ON wn_user.id = wn_provider_setting.user_id
-		WHERE wn_user.user_role IN ('VOLUNTEER', 'COUNSELLOR')`)
-	} else {
-		rows, err = db.Query(
-			`SELECT 
-				wn_user.id,
-				wn_user.first_name,
-				wn_user.last_name,
-				wn_user.gender,
-				wn_user.faculty,
-				wn_user.email,
-				wn_user.user_role,
-				wn_user.password_hash,
-				wn_provider_setting.user_id,
-				wn_provider_setting.intro,
-				wn_provider_setting.topics
-			FROM wn_provider_setting 
-			JOIN wn_user ON wn_user.id = wn_provider_setting.user_id
-			WHERE wn_user.user_role IN ('VOLUNTEER', 'COUNSELLOR') 
-			AND $1 <@ wn_provider_setting.topics`,
-			pq.Array(topics))
-	}
-	if err != nil { return nil, err }
-	defer rows.Close()
-	providers, err := ReadProviders(rows)
-	if err != nil { return nil, err }
-	return providers, nil
+		JOIN wn_user ON wn_user.id = wn_provider_setting.user_id`,
+		conditions,
+		args,
+		ReadProviders)
 }
 
-func GetProvider(db *sql.DB, userID int64) (Provider, error) {
-	providerSetting, err := GetProviderSetting(db, userID)
+func GetProvider(ctx context.Context, db DBTX, userID int64) (Provider, error) {
+	providerSetting, err := GetProviderSetting(ctx, db, userID)
 	if err != nil { return Provider{}, err }
-	provider, err := providerSetting.LoadProviderSetting(db)
+	provider, err := providerSetting.LoadProviderSetting(ctx, db)
 	if err != nil { return Provider{}, err }
 	return provider, nil
 }
 
-func GetProviderWithEvents(db *sql.DB, userID int64) (ProviderWithEvents, error) {
-	provider, err := GetProvider(db, userID)
+func GetProviderWithEvents(ctx context.Context, db DBTX, userID int64) (ProviderWithEvents, error) {
+	provider, err := GetProvider(ctx, db, userID)
 	if err != nil { return ProviderWithEvents{}, err }
-	providerWithEvents, err := provider.LoadProvider(db)
+	providerWithEvents, err := provider.LoadProvider(ctx, db)
 	if err != nil { return ProviderWithEvents{}, err }
 	return providerWithEvents, nil
 }
 
-func AddUpdateProviderSettingOfUser(db *sql.DB, providerSetting ProviderSetting, userID int64) (ProviderSetting, error) {
+func AddUpdateProviderSettingOfUser(ctx context.Context, db DBTX, providerSetting ProviderSetting, userID int64) (ProviderSetting, error) {
 	providerSetting.UserID = userID
-	if !AuthoriseProvider(db, userID) {
-		return ProviderSetting{}, http_error.UnauthorizedError
+	if err := AuthorizeUserID(ctx, db, userID, ActionUpdateProviderSetting, nil); err != nil {
+		return ProviderSetting{}, err
 	}
-	_, err := db.Exec(
+	_, err := db.ExecContext(ctx,
 		`INSERT INTO wn_provider_setting (
 			user_id,
 			intro,
@@ -153,8 +138,8 @@ func AddUpdateProviderSettingOfUser(db *sql.DB, providerSetting ProviderSetting,
 	return providerSetting, nil
 }
 
-func DeleteProviderSettingOfUser(db *sql.DB, userID int64) (ProviderSetting, error) {
-	_, err := db.Exec("DELETE FROM wn_provider_setting WHERE user_id = $1", userID)
+func DeleteProviderSettingOfUser(ctx context.Context, db DBTX, userID int64) (ProviderSetting, error) {
+	_, err := db.ExecContext(ctx, "DELETE FROM wn_provider_setting WHERE user_id = $1", userID)
 	if err != nil { return ProviderSetting{}, err }
 	return ProviderSetting{ UserID: userID }, nil
 }
@@ -1,16 +1,55 @@
 package model
 
+import (
+	"time"
+)
+
 const (
-	SessionKeyLength = 128
-	SessionKeyCharSet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ1234567890"
+	// Number of random bytes in a session key before it is encoded
+	SessionKeyBytes = 32
+	// Sessions expire after SessionTTL without use
+	SessionTTL = 14 * 24 * time.Hour
+	// Last seen and expiry are only written again once this much time has passed, to avoid a write on every request
+	SessionRefreshInterval = time.Minute
+	DeviceLabelMaxLength = 128
 )
 
 type Session struct {
-	SessionKey 	string 	`json:"session_key"`
-	UserID		int64	`json:"user_id"`	
+	ID			int64		`json:"id"`
+	SessionKeyHash	string		`json:"-"`
+	UserID		int64		`json:"user_id"`
+	DeviceLabel	string		`json:"device_label"`
+	CreatedAt	time.Time	`json:"created_at"`
+	LastSeen	time.Time	`json:"last_seen"`
+	ExpiresAt	time.Time	`json:"expires_at"`
+	Current		bool		`json:"current"`
 }
 
 type SessionResponse struct {
 	LoggedIn 	bool `json:"logged_in"`
-	User	 	User `json:"user"`
-}
\ No newline at end of file
+	User	 	PrivateUser `json:"user"`
+}
+
+// Only the hash of a session key is stored, so a leaked session table cannot be used to log in
+func HashSessionKey(sessionKey string) string {
+	return HashToken(sessionKey)
+}
+
+func GenerateNewSessionKey() (string, error) {
+	return GenerateToken(SessionKeyBytes)
+}
+
+func (session Session) Expired(now time.Time) bool {
+	return !now.Before(session.ExpiresAt)
+}
+
+// Reports whether last seen and expiry are stale enough to be written again
+func (session Session) NeedsRefresh(now time.Time) bool {
+	return now.Sub(session.LastSeen) >= SessionRefreshInterval
+}
+
+func TruncateDeviceLabel(deviceLabel string) string {
+	runes := []rune(deviceLabel)
+	if len(runes) > DeviceLabelMaxLength { return string(runes[:DeviceLabelMaxLength]) }
+	return deviceLabel
+}
@@ -2,8 +2,8 @@ package model
 
 import (
 	"wellnus/backend/router/http_helper/http_error"
+	"context"
 	"database/sql"
-	"math/rand"
 	"time"
 )
 
@@ -12,55 +12,116 @@ func readSessions(rows *sql.Rows) ([]Session, error) {
 	sessions := make([]Session, 0)
 	for rows.Next() {
 		var session Session
-		if err := rows.Scan(&session.SessionKey, &session.UserID); err != nil {
-			return nil, err
-		}
+		if err := rows.Scan(
+			&session.ID,
+			&session.SessionKeyHash,
+			&session.UserID,
+			&session.DeviceLabel,
+			&session.CreatedAt,
+			&session.LastSeen,
+			&session.ExpiresAt);
+			err != nil {
+				return nil, err
+			}
 		sessions = append(sessions, session)
 	}
 	return sessions, nil
 }
 
-func GenerateNewSessionKey() string {
-	Rand := rand.New(rand.NewSource(time.Now().UnixNano()))
-	b := make([]byte, SessionKeyLength)
-	charSetLen := len(SessionKeyCharSet)
-	for i := range b {
-		b[i] = SessionKeyCharSet[Rand.Intn(charSetLen)]
-	}
-	return string(b)
+func getSessionWithSessionKey(ctx context.Context, db DBTX, sessionKey string) (Session, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, session_key_hash, user_id, device_label, created_at, last_seen, expires_at
+		FROM wn_session WHERE session_key_hash = $1`,
+		HashSessionKey(sessionKey))
+	if err != nil { return Session{}, err }
+	defer rows.Close()
+	sessions, err := readSessions(rows)
+	if err != nil { return Session{}, err }
+	if len(sessions) == 0 { return Session{}, http_error.UnauthorizedError }
+	return sessions[0], nil
 }
 
-
 // Main function
-func GetUserIDFromSessionKey(db *sql.DB, sessionKey string) (int64, error) {
-	rows, err := db.Query(`SELECT * FROM wn_session WHERE session_key = $1`, sessionKey)
+
+// Resolves the session key to its user. Expired sessions are deleted and rejected, while
+// sessions in use have their expiry pushed back by SessionTTL
+func GetUserIDFromSessionKey(ctx context.Context, db DBTX, sessionKey string) (int64, error) {
+	session, err := getSessionWithSessionKey(ctx, db, sessionKey)
 	if err != nil { return 0, err }
+	now := time.Now()
+	if session.Expired(now) {
+		if err := DeleteSessionWithSessionKey(ctx, db, sessionKey); err != nil { return 0, err }
+		return 0, http_error.UnauthorizedError
+	}
+	if session.NeedsRefresh(now) {
+		_, err = db.ExecContext(ctx,
+			`UPDATE wn_session SET last_seen = $1, expires_at = $2 WHERE id = $3`,
+			now,
+			now.Add(SessionTTL),
+			session.ID)
+		if err != nil { return 0, err }
+	}
+	return session.UserID, nil
+}
+
+func GetAllSessionsOfUser(ctx context.Context, db DBTX, userID int64) ([]Session, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id, session_key_hash, user_id, device_label, created_at, last_seen, expires_at
+		FROM wn_session WHERE user_id = $1 AND expires_at > $2
+		ORDER BY last_seen DESC, id DESC`,
+		userID,
+		time.Now())
+	if err != nil { return nil, err }
 	defer rows.Close()
 	sessions, err := readSessions(rows)
-	if err != nil { return 0, err }
-	if len(sessions) == 0 { return 0, http_error.UnauthorizedError }
-	return sessions[0].UserID, nil
+	if err != nil { return nil, err }
+	return sessions, nil
 }
 
-func DeleteSessionWithSessionKey(db *sql.DB, sessionKey string) error {
-	_, err := db.Exec(`DELETE FROM wn_session WHERE session_key = $1`, sessionKey)
+func DeleteSessionWithSessionKey(ctx context.Context, db DBTX, sessionKey string) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM wn_session WHERE session_key_hash = $1`, HashSessionKey(sessionKey))
 	return err
 }
 
-func CreateNewSession(db *sql.DB, userID int64) (string, error) {
-	newSessionKey := GenerateNewSessionKey()
-	_, err := db.Exec(
-		`INSERT INTO wn_session (
-			session_key,
-			user_id
-		) VALUES ($1, $2)
-		ON CONFLICT (user_id) 
-		DO UPDATE SET 
-			session_key = EXCLUDED.session_key,
-			user_id = EXCLUDED.user_id`,
-		newSessionKey,
+func DeleteSessionOfUser(ctx context.Context, db DBTX, sessionID int64, userID int64) (Session, error) {
+	rows, err := db.QueryContext(ctx,
+		`DELETE FROM wn_session WHERE id = $1 AND user_id = $2
+		RETURNING id, session_key_hash, user_id, device_label, created_at, last_seen, expires_at`,
+		sessionID,
 		userID)
+	if err != nil { return Session{}, err }
+	defer rows.Close()
+	sessions, err := readSessions(rows)
+	if err != nil { return Session{}, err }
+	if len(sessions) == 0 { return Session{}, http_error.NotFoundError }
+	return sessions[0], nil
+}
+
+func DeleteAllSessionsOfUser(ctx context.Context, db DBTX, userID int64) error {
+	_, err := db.ExecContext(ctx, `DELETE FROM wn_session WHERE user_id = $1`, userID)
+	return err
+}
+
+// Adds a session alongside any the user already has on other devices
+func CreateNewSession(ctx context.Context, db DBTX, userID int64, deviceLabel string) (string, error) {
+	newSessionKey, err := GenerateNewSessionKey()
+	if err != nil { return "", err }
+	now := time.Now()
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO wn_session (
+			session_key_hash,
+			user_id,
+			device_label,
+			created_at,
+			last_seen,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6)`,
+		HashSessionKey(newSessionKey),
+		userID,
+		TruncateDeviceLabel(deviceLabel),
+		now,
+		now,
+		now.Add(SessionTTL))
 	if err != nil { return "", err }
 	return newSessionKey, nil
 }
-
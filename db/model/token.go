@@ -0,0 +1,53 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// Purposes of single use tokens sent to users by email
+const (
+	TokenVerifyEmail   = "VERIFY_EMAIL"
+	TokenResetPassword = "RESET_PASSWORD"
+	TokenBytes         = 32
+)
+
+var TokenTTL = map[string]time.Duration{
+	TokenVerifyEmail:   24 * time.Hour,
+	TokenResetPassword: time.Hour,
+}
+
+var InvalidTokenError = http_error.NewValidationError("token", "is invalid or expired")
+
+type UserToken struct {
+	TokenHash	string
+	UserID		int64
+	Purpose		string
+	ExpiresAt	time.Time
+}
+
+type TokenBody struct {
+	Token		string	`json:"token"`
+	Password	string	`json:"password"`
+}
+
+type EmailBody struct {
+	Email	string	`json:"email"`
+}
+
+// Returns nBytes from crypto/rand encoded for use in URLs and cookies
+func GenerateToken(nBytes int) (string, error) {
+	b := make([]byte, nBytes)
+	if _, err := rand.Read(b); err != nil { return "", err }
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
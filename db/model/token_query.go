@@ -0,0 +1,92 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Helper function
+
+// Deletes the token if it has expired. Runs outside the transaction that consumes tokens, whose
+// rollback on the expiry error would otherwise keep it
+func deleteExpiredUserToken(ctx context.Context, db DBTX, token string, purpose string) error {
+	_, err := db.ExecContext(ctx,
+		`DELETE FROM wn_user_token WHERE token_hash = $1 AND purpose = $2 AND expires_at <= $3`,
+		HashToken(token),
+		purpose,
+		time.Now())
+	return err
+}
+
+func consumeUserToken(ctx context.Context, db DBTX, token string, purpose string) (int64, error) {
+	var userID int64
+	err := db.QueryRowContext(ctx,
+		`DELETE FROM wn_user_token WHERE token_hash = $1 AND purpose = $2 AND expires_at > $3
+		RETURNING user_id`,
+		HashToken(token),
+		purpose,
+		time.Now()).Scan(&userID)
+	if err == sql.ErrNoRows { return 0, InvalidTokenError }
+	if err != nil { return 0, err }
+	return userID, nil
+}
+
+// Main functions
+
+// Creates a token for purpose, replacing any earlier token of the same purpose for the user
+func CreateUserToken(ctx context.Context, db DBTX, userID int64, purpose string) (string, error) {
+	token, err := GenerateToken(TokenBytes)
+	if err != nil { return "", err }
+	_, err = db.ExecContext(ctx, `DELETE FROM wn_user_token WHERE user_id = $1 AND purpose = $2`, userID, purpose)
+	if err != nil { return "", err }
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO wn_user_token (
+			token_hash,
+			user_id,
+			purpose,
+			expires_at
+		) VALUES ($1, $2, $3, $4)`,
+		HashToken(token),
+		userID,
+		purpose,
+		time.Now().Add(TokenTTL[purpose]))
+	if err != nil { return "", err }
+	return token, nil
+}
+
+func VerifyEmail(ctx context.Context, db *sql.DB, token string) (User, error) {
+	if err := deleteExpiredUserToken(ctx, db, token, TokenVerifyEmail); err != nil { return User{}, err }
+	return WithTx(ctx, db, func(tx DBTX) (User, error) {
+		return verifyEmail(ctx, tx, token)
+	})
+}
+
+func verifyEmail(ctx context.Context, db DBTX, token string) (User, error) {
+	userID, err := consumeUserToken(ctx, db, token, TokenVerifyEmail)
+	if err != nil { return User{}, err }
+	_, err = db.ExecContext(ctx, `UPDATE wn_user SET email_verified = TRUE WHERE id = $1`, userID)
+	if err != nil { return User{}, err }
+	return GetUser(ctx, db, userID)
+}
+
+// Sets a new password and logs the user out everywhere
+func ResetPassword(ctx context.Context, db *sql.DB, token string, password string) (User, error) {
+	if err := deleteExpiredUserToken(ctx, db, token, TokenResetPassword); err != nil { return User{}, err }
+	return WithTx(ctx, db, func(tx DBTX) (User, error) {
+		return resetPassword(ctx, tx, token, password)
+	})
+}
+
+func resetPassword(ctx context.Context, db DBTX, token string, password string) (User, error) {
+	userID, err := consumeUserToken(ctx, db, token, TokenResetPassword)
+	if err != nil { return User{}, err }
+	if password == "" { return User{}, http_error.NewValidationError("password", "is required") }
+	user, err := User{ Password: password }.HashPassword()
+	if err != nil { return User{}, err }
+	_, err = db.ExecContext(ctx, `UPDATE wn_user SET password_hash = $1 WHERE id = $2`, user.PasswordHash, userID)
+	if err != nil { return User{}, err }
+	if err := DeleteAllSessionsOfUser(ctx, db, userID); err != nil { return User{}, err }
+	return GetUser(ctx, db, userID)
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+)
+
+// DBTX is satisfied by both *sql.DB and *sql.Tx so that the same query functions
+// can run on their own or as one step of a larger transaction
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Runs fn inside a single transaction. The transaction is committed only if fn succeeds
+// and is rolled back otherwise, so a failed step never leaves the earlier steps behind.
+// Once ctx is done the transaction is rolled back by database/sql and its queries fail
+func WithTx[T any](ctx context.Context, db *sql.DB, fn func(tx DBTX) (T, error)) (T, error) {
+	var zero T
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil { return zero, err }
+	defer tx.Rollback()
+	result, err := fn(tx)
+	if err != nil { return zero, err }
+	if err = tx.Commit(); err != nil { return zero, err }
+	return result, nil
+}
@@ -1,7 +1,6 @@
 package model
 
 import (
-	"database/sql"
 	"github.com/alexedwards/argon2id"
 )
 
@@ -14,12 +13,69 @@ type User struct {
 	Email			string	`json:"email"`
 	UserRole		string 	`json:"user_role"`
 	Password		string 	`json:"password"`
-	PasswordHash 	string	`json:"password_hash"`
+	PasswordHash 	string	`json:"-"`
+	Verified		bool	`json:"verified"`
+	Suspended		bool	`json:"suspended"`
+	EmailVerified	bool	`json:"email_verified"`
+}
+
+// PublicUser is the profile of a user that other users may see
+type PublicUser struct {
+	ID 				int64 	`json:"id"`
+	FirstName 		string 	`json:"first_name"`
+	LastName 		string	`json:"last_name"`
+	Gender			string 	`json:"gender"`
+	Faculty			string 	`json:"faculty"`
+	UserRole		string 	`json:"user_role"`
+	Verified		bool	`json:"verified"`
+}
+
+// PrivateUser is the full profile of a user, shown only to the user themselves and to admins.
+// Neither view carries the password or its hash, so responses built from them cannot leak it
+type PrivateUser struct {
+	PublicUser
+	Email			string	`json:"email"`
+	Suspended		bool	`json:"suspended"`
+	EmailVerified	bool	`json:"email_verified"`
 }
 
 type UserWithGroups struct {
-	User 	User 	`json:"user"`
-	Groups 	[]Group `json:"groups"`
+	User 	PublicUser 	`json:"user"`
+	Groups 	[]Group 	`json:"groups"`
+}
+
+type PrivateUserWithGroups struct {
+	User 	PrivateUser `json:"user"`
+	Groups 	[]Group 	`json:"groups"`
+}
+
+func (user User) Public() PublicUser {
+	return PublicUser{
+		ID: user.ID,
+		FirstName: user.FirstName,
+		LastName: user.LastName,
+		Gender: user.Gender,
+		Faculty: user.Faculty,
+		UserRole: user.UserRole,
+		Verified: user.Verified,
+	}
+}
+
+func (user User) Private() PrivateUser {
+	return PrivateUser{
+		PublicUser: user.Public(),
+		Email: user.Email,
+		Suspended: user.Suspended,
+		EmailVerified: user.EmailVerified,
+	}
+}
+
+func PublicUsers(users []User) []PublicUser {
+	publicUsers := make([]PublicUser, len(users))
+	for i, user := range users {
+		publicUsers[i] = user.Public()
+	}
+	return publicUsers
 }
 
 func (user User) HashPassword() (User, error) {
@@ -30,16 +86,6 @@ func (user User) HashPassword() (User, error) {
 	return user, nil
 }
 
-func (user User) LoadLastUserID(db *sql.DB) (User, error) {
-	row, err := db.Query("SELECT last_value FROM wn_user_id_seq;")
-	if err != nil { return User{}, err }
-	defer row.Close()
-
-	row.Next()
-	if err := row.Scan(&user.ID); err != nil { return User{}, err }
-	return user, nil
-}
-
 func (userMain User) MergeUser(userAdd User) (User, error) {
 	userMain.ID = userAdd.ID
 	if userMain.FirstName == "" {
@@ -57,9 +103,12 @@ func (userMain User) MergeUser(userAdd User) (User, error) {
 	if userMain.Email == "" {
 		userMain.Email = userAdd.Email
 	}
-	if userMain.UserRole == "" {
-		userMain.UserRole = userAdd.UserRole
-	}
+	// Role, verification and suspension are only changed through the admin functions,
+	// and a changed email has to be verified again
+	userMain.UserRole = userAdd.UserRole
+	userMain.Verified = userAdd.Verified
+	userMain.Suspended = userAdd.Suspended
+	userMain.EmailVerified = userAdd.EmailVerified && userMain.Email == userAdd.Email
 	if userMain.Password == "" {
 		userMain.PasswordHash = userAdd.PasswordHash
 	} else {
@@ -79,5 +128,8 @@ func (user1 User) Equal(user2 User) bool {
 		user1.Faculty == user2.Faculty &&
 		user1.Email == user2.Email &&
 		user1.UserRole == user2.UserRole &&
-		user1.PasswordHash == user2.PasswordHash
+		user1.PasswordHash == user2.PasswordHash &&
+		user1.Verified == user2.Verified &&
+		user1.Suspended == user2.Suspended &&
+		user1.EmailVerified == user2.EmailVerified
 }
\ No newline at end of file
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"wellnus/backend/router/http_helper/http_error"
+
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Shorthand for every role in ProviderRoles
+const RoleProvider = "PROVIDER"
+
+// Orders users may be listed in. Names are compared byte by byte so that the order does not
+// depend on the database locale
+var UserSorting = Sorting[User]{
+	IDSQL: "id",
+	ID: func(user User) int64 { return user.ID },
+	Keys: map[string]SortKey[User]{
+		"id":			{ SQL: "id", Value: func(user User) interface{} { return user.ID } },
+		"first_name":	{ SQL: `lower(first_name) COLLATE "C"`, Value: func(user User) interface{} { return strings.ToLower(user.FirstName) } },
+		"last_name":	{ SQL: `lower(last_name) COLLATE "C"`, Value: func(user User) interface{} { return strings.ToLower(user.LastName) } },
+	},
+}
+
+// UserFilter selects users for GET /user. Empty fields match every user
+type UserFilter struct {
+	Roles		[]string	// any of the roles, where PROVIDER stands for VOLUNTEER and COUNSELLOR
+	Faculty		string
+	Gender		string
+	NamePrefix	string		// case insensitive prefix of the first or last name
+	Topics		[]string	// users whose provider setting covers every topic
+}
+
+// Checks the filter and fills in the defaults
+func (filter UserFilter) Normalize() (UserFilter, error) {
+	roles := make([]string, 0, len(filter.Roles))
+	for _, role := range filter.Roles {
+		if role == RoleProvider {
+			roles = append(roles, ProviderRoles...)
+			continue
+		}
+		if !containsString(AllRoles, role) { return UserFilter{}, http_error.NewValidationError("role", "is invalid") }
+		roles = append(roles, role)
+	}
+	filter.Roles = roles
+	return filter, nil
+}
+
+// Reports whether user matches every condition of the filter except Topics, which need the
+// provider setting of the user. Used by stores that filter in process
+func (filter UserFilter) MatchUser(user User) bool {
+	if len(filter.Roles) > 0 && !containsString(filter.Roles, user.UserRole) { return false }
+	if filter.Faculty != "" && user.Faculty != filter.Faculty { return false }
+	if filter.Gender != "" && user.Gender != filter.Gender { return false }
+	if prefix := strings.ToLower(filter.NamePrefix); prefix != "" {
+		if !strings.HasPrefix(strings.ToLower(user.FirstName), prefix) &&
+			!strings.HasPrefix(strings.ToLower(user.LastName), prefix) { return false }
+	}
+	return true
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Builds the parameterized conditions of the filter. Only values go into the arguments;
+// the conditions themselves are made of fixed fragments
+func (filter UserFilter) conditions() ([]string, []interface{}) {
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if len(filter.Roles) > 0 {
+		conditions = append(conditions, "user_role = ANY(" + arg(pq.Array(filter.Roles)) + ")")
+	}
+	if filter.Faculty != "" {
+		conditions = append(conditions, "faculty = " + arg(filter.Faculty))
+	}
+	if filter.Gender != "" {
+		conditions = append(conditions, "gender = " + arg(filter.Gender))
+	}
+	if filter.NamePrefix != "" {
+		prefix := arg(likeEscaper.Replace(strings.ToLower(filter.NamePrefix)) + "%")
+		conditions = append(conditions, fmt.Sprintf("(lower(first_name) LIKE %s OR lower(last_name) LIKE %s)", prefix, prefix))
+	}
+	if len(filter.Topics) > 0 {
+		conditions = append(conditions,
+			"id IN (SELECT user_id FROM wn_provider_setting WHERE topics @> " + arg(pq.Array(filter.Topics)) + ")")
+	}
+
+	return conditions, args
+}
@@ -2,8 +2,8 @@ package model
 
 import (
 	"wellnus/backend/router/http_helper/http_error"
+	"context"
 	"database/sql"
-	"fmt"
 )
 
 //Helper functions
@@ -19,7 +19,10 @@ func readUsers(rows *sql.Rows) ([]User, error) {
 			&user.Faculty, 
 			&user.Email, 
 			&user.UserRole, 
-			&user.PasswordHash); 
+			&user.PasswordHash,
+			&user.Verified,
+			&user.Suspended,
+			&user.EmailVerified); 
 			err != nil {
 				return nil, err
 			}
@@ -28,8 +31,8 @@ func readUsers(rows *sql.Rows) ([]User, error) {
 	return users, nil
 }
 
-func GetUser(db *sql.DB, id int64) (User, error) {
-	rows, err := db.Query("SELECT * FROM wn_user WHERE id = $1;", id)
+func GetUser(ctx context.Context, db DBTX, id int64) (User, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_user WHERE id = $1;", id)
 	if err != nil { return User{}, err }
 	defer rows.Close()
 	users, err := readUsers(rows)
@@ -40,16 +43,16 @@ func GetUser(db *sql.DB, id int64) (User, error) {
 
 // Main functions
 
-func GetUserWithGroups(db *sql.DB, userID int64) (UserWithGroups, error) {
-	user, err := GetUser(db, userID)
+func GetUserWithGroups(ctx context.Context, db DBTX, userID int64) (UserWithGroups, error) {
+	user, err := GetUser(ctx, db, userID)
 	if err != nil { return UserWithGroups{}, err }
-	groups, err := GetAllGroupsOfUser(db, userID)
+	groups, err := GetAllGroupsOfUser(ctx, db, userID)
 	if err != nil { return UserWithGroups{}, err }
-	return UserWithGroups{ User: user, Groups: groups}, nil
+	return UserWithGroups{ User: user.Public(), Groups: groups}, nil
 }
 
-func GetAllUsersOfGroup(db *sql.DB, groupID int64) ([]User, error) {
-	rows, err := db.Query(
+func GetAllUsersOfGroup(ctx context.Context, db DBTX, groupID int64) ([]User, error) {
+	rows, err := db.QueryContext(ctx,
 		`SELECT 
 			wn_user.id,
 			wn_user.first_name,
@@ -58,7 +61,10 @@ func GetAllUsersOfGroup(db *sql.DB, groupID int64) ([]User, error) {
 			wn_user.faculty,
 			wn_user.email,
 			wn_user.user_role,
-			wn_user.password_hash
+			wn_user.password_hash,
+			wn_user.verified,
+			wn_user.suspended,
+			wn_user.email_verified
 		FROM wn_user_group JOIN wn_user 
 		ON wn_user_group.user_id = wn_user.id 
 		WHERE wn_user_group.group_id = $1`, 
@@ -70,8 +76,8 @@ func GetAllUsersOfGroup(db *sql.DB, groupID int64) ([]User, error) {
 	return users, nil
 }
 
-func GetAllUsersOfEvent(db *sql.DB, eventID int64) ([]User, error) {
-	rows, err := db.Query(
+func GetAllUsersOfEvent(ctx context.Context, db DBTX, eventID int64) ([]User, error) {
+	rows, err := db.QueryContext(ctx,
 		`SELECT 
 			wn_user.id,
 			wn_user.first_name,
@@ -80,7 +86,10 @@ func GetAllUsersOfEvent(db *sql.DB, eventID int64) ([]User, error) {
 			wn_user.faculty,
 			wn_user.email,
 			wn_user.user_role,
-			wn_user.password_hash
+			wn_user.password_hash,
+			wn_user.verified,
+			wn_user.suspended,
+			wn_user.email_verified
 		FROM wn_user_event JOIN wn_user 
 		ON wn_user_event.user_id = wn_user.id 
 		WHERE wn_user_event.event_id = $1`, 
@@ -92,8 +101,8 @@ func GetAllUsersOfEvent(db *sql.DB, eventID int64) ([]User, error) {
 	return users, nil
 }
 
-func GetAllUsers(db *sql.DB) ([]User, error) {
-	rows, err := db.Query("SELECT * FROM wn_user;")
+func GetAllUsers(ctx context.Context, db DBTX) ([]User, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_user;")
 	if err != nil { return nil, err }
 	defer rows.Close()
 	users, err := readUsers(rows)
@@ -101,21 +110,22 @@ func GetAllUsers(db *sql.DB) ([]User, error) {
 	return users, nil
 }
 
-func GetAllUsersConditional(db *sql.DB, condition string) ([]User, error) {
-	query := fmt.Sprintf("SELECT * FROM wn_user WHERE %s", condition)
-	// Unsafe query
-	rows, err := db.Query(query)
-	if err != nil { return nil, err }
-	defer rows.Close()
-	users, err := readUsers(rows)
-	if err != nil { return nil, err}
-	return users, nil
+// Returns the page of the users matching filter that page asks for
+func SearchUsers(ctx context.Context, db DBTX, filter UserFilter, page PageQuery) (Page[User], error) {
+	filter, err := filter.Normalize()
+	if err != nil { return Page[User]{}, err }
+	conditions, args := filter.conditions()
+	return QueryPage(ctx, db, UserSorting, page, "SELECT * FROM wn_user", conditions, args, readUsers)
 }
 
-func AddUser(db *sql.DB, newUser User) (User, error) {
+func AddUser(ctx context.Context, db DBTX, newUser User) (User, error) {
 	newUser, err := newUser.HashPassword()
 	if err != nil { return User{}, err }
-	_, err = db.Exec(
+	// New users always start unverified and active
+	newUser.Verified = false
+	newUser.Suspended = false
+	newUser.EmailVerified = false
+	err = db.QueryRowContext(ctx,
 		`INSERT INTO wn_user (
 			first_name, 
 			last_name, 
@@ -124,51 +134,50 @@ func AddUser(db *sql.DB, newUser User) (User, error) {
 			email, 
 			user_role, 
 			password_hash
-		) VALUES ($1, $2, $3, $4, $5, $6, $7);`,
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;`,
 		newUser.FirstName,
 		newUser.LastName,
 		newUser.Gender,
 		newUser.Faculty,
 		newUser.Email,
 		newUser.UserRole,
-		newUser.PasswordHash)
-	if err != nil { return User{}, err }
-	newUser, err = newUser.LoadLastUserID(db)
+		newUser.PasswordHash).Scan(&newUser.ID)
 	if err != nil { return User{}, err }
 	return newUser, nil
 }
 
-func UpdateUser(db *sql.DB, updatedUser User, id int64) (User, error) {
-	targetUser, err := GetUser(db, id)
+func UpdateUser(ctx context.Context, db DBTX, updatedUser User, id int64) (User, error) {
+	targetUser, err := GetUser(ctx, db, id)
 	if err != nil { return User{}, err }
 
 	updatedUser, err = updatedUser.MergeUser(targetUser)
 	if err != nil { return User{}, err }
 
-	_, err = db.Exec(
+	_, err = db.ExecContext(ctx,
 		`UPDATE wn_user SET 
 			first_name = $1, 
 			last_name = $2, 
 			gender = $3, 
 			faculty= $4, 
 			email = $5, 
-			user_role = $6, 
-			password_hash = $7 
+			password_hash = $6,
+			email_verified = $7
 		WHERE id = $8;`,
 		updatedUser.FirstName,
 		updatedUser.LastName,
 		updatedUser.Gender,
 		updatedUser.Faculty,
 		updatedUser.Email,
-		updatedUser.UserRole,
 		updatedUser.PasswordHash,
+		updatedUser.EmailVerified,
 		id)
 	if err != nil { return User{}, err }
 	return updatedUser, nil;
 }
 
-func FindUser(db *sql.DB, email string) (User, error) {
-	rows, err := db.Query("SELECT * FROM wn_user WHERE email = $1;", email)
+func FindUser(ctx context.Context, db DBTX, email string) (User, error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM wn_user WHERE email = $1;", email)
 	if err != nil { return User{}, err }
 	defer rows.Close()
 	users, err := readUsers(rows)
@@ -177,8 +186,17 @@ func FindUser(db *sql.DB, email string) (User, error) {
 	return users[0], nil
 }
 
-func DeleteUser(db *sql.DB, id int64) (User, error) {
-	if _, err := db.Exec("DELETE FROM wn_user WHERE id = $1", id); err != nil {
+// Leaves every group of the user, passing on those they own, and deletes them in one transaction,
+// so a user who cannot be deleted keeps their groups
+func DeleteUser(ctx context.Context, db *sql.DB, id int64) (User, error) {
+	return WithTx(ctx, db, func(tx DBTX) (User, error) {
+		if _, err := leaveAllGroups(ctx, tx, id); err != nil { return User{}, err }
+		return deleteUser(ctx, tx, id)
+	})
+}
+
+func deleteUser(ctx context.Context, db DBTX, id int64) (User, error) {
+	if _, err := db.ExecContext(ctx, "DELETE FROM wn_user WHERE id = $1", id); err != nil {
 		return User{}, err
 	}
 	return User{ID: id}, nil
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	refGender            = []string{"M", "F"}
+	refFaculty           = []string{"CHS", "BUSINESS", "COMPUTING", "DENTISTRY", "CDE", "LAW", "MEDICINE", "NURSING", "PHARMACY", "MUSIC"}
+	refUserRole          = []string{"MEMBER", "VOLUNTEER", "COUNSELLOR", "ADMIN"}
+	refReportTarget      = []string{"USER", "GROUP", "EVENT"}
+	refCategory          = []string{"COUNSEL", "SUPPORT", "CUSTOM"}
+	refAccess            = []string{"PUBLIC", "PRIVATE"}
+	refFacultyPreference = []string{"MIX", "SAME", "NONE"}
+	refGenderPreference  = []string{"MIX", "SAME", "NONE"}
+	refHobbies           = []string{"GAMING", "SINGING", "DANCING", "MUSIC", "SPORTS", "OUTDOOR", "BOOK", "ANIME", "MOVIES", "TV", "ART", "STUDY"}
+	refMBTI              = []string{"ISTJ", "ISFJ", "INFJ", "INTJ", "ISTP", "ISFP", "INFP", "INTP", "ESTP", "ESFP", "ENFP", "ENTP", "ESTJ", "ESFJ", "ENFJ", "ENTJ"}
+	refTopics            = []string{"Anxiety", "OffMyChest", "SelfHarm", "Depression", "SelfEsteem", "Stress", "Casual", "Therapy", "BadHabits", "Rehabilitation", "Addiction", "Family", "Trauma", "Career", "Abandonment", "Relationships", "Identity", "LGBT"}
+)
+
+type membership struct {
+	userID int64
+	itemID int64
+}
+
+// MemoryStore implements Store in process. Constraints of the SQL schema are enforced
+// and reported with the same pq errors so that handlers behave as they do against postgres.
+type MemoryStore struct {
+	mu sync.Mutex
+
+	// Clock used for session expiry. Tests may replace it to move time forward
+	Now func() time.Time
+
+	matching config.MatchingConfig
+
+	lastUserID        int64
+	lastGroupID       int64
+	lastJoinRequestID int64
+	lastEventID       int64
+	lastBookingID     int64
+	lastReportID      int64
+	lastSessionID     int64
+	lastMatchingRunID int64
+
+	users            map[int64]User
+	sessions         map[string]Session   // keyed by session key hash
+	tokens           map[string]UserToken // keyed by token hash
+	groups           map[int64]Group
+	userGroups       []membership
+	joinRequests     map[int64]JoinRequest
+	messages         []Message
+	matchSettings    map[int64]MatchSetting
+	matchRequests    []MatchRequest
+	providerSettings map[int64]ProviderSetting
+	counselRequests  map[int64]CounselRequest
+	events           map[int64]Event
+	userEvents       []membership
+	bookings         map[int64]Booking
+	reports          map[int64]Report
+	matchingRuns     map[int64]MatchingRun
+}
+
+func NewMemoryStore(matching config.MatchingConfig) *MemoryStore {
+	return &MemoryStore{
+		Now:              time.Now,
+		matching:         matching,
+		users:            make(map[int64]User),
+		sessions:         make(map[string]Session),
+		tokens:           make(map[string]UserToken),
+		groups:           make(map[int64]Group),
+		joinRequests:     make(map[int64]JoinRequest),
+		matchSettings:    make(map[int64]MatchSetting),
+		providerSettings: make(map[int64]ProviderSetting),
+		counselRequests:  make(map[int64]CounselRequest),
+		events:           make(map[int64]Event),
+		bookings:         make(map[int64]Booking),
+		reports:          make(map[int64]Report),
+		matchingRuns:     make(map[int64]MatchingRun),
+	}
+}
+
+// Helper functions
+
+func checkViolation(table string, column string) error {
+	return &pq.Error{
+		Code:       "23514",
+		Message:    fmt.Sprintf("new row for relation \"%s\" violates check constraint \"%s_%s_check\"", table, table, column),
+		Table:      table,
+		Column:     column,
+		Constraint: fmt.Sprintf("%s_%s_check", table, column),
+	}
+}
+
+func uniqueViolation(table string, columns ...string) error {
+	constraint := fmt.Sprintf("%s_%s_key", table, strings.Join(columns, "_"))
+	return &pq.Error{
+		Code:       "23505",
+		Message:    fmt.Sprintf("duplicate key value violates unique constraint \"%s\"", constraint),
+		Detail:     fmt.Sprintf("Key (%s) already exists.", strings.Join(columns, ", ")),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table string, column string) error {
+	constraint := fmt.Sprintf("%s_%s_fkey", table, column)
+	return &pq.Error{
+		Code:       "23503",
+		Message:    fmt.Sprintf("insert or update on table \"%s\" violates foreign key constraint \"%s\"", table, constraint),
+		Table:      table,
+		Column:     column,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyDeleteViolation(table string, referencingTable string, column string) error {
+	constraint := fmt.Sprintf("%s_%s_fkey", referencingTable, column)
+	return &pq.Error{
+		Code:       "23503",
+		Message:    fmt.Sprintf("update or delete on table \"%s\" violates foreign key constraint \"%s\" on table \"%s\"", table, constraint, referencingTable),
+		Detail:     fmt.Sprintf("Key (id) is still referenced from table \"%s\".", referencingTable),
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func contains(ref []string, s string) bool {
+	for _, r := range ref {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(ref []string, ss []string) bool {
+	for _, s := range ss {
+		if !contains(ref, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func hasMembership(memberships []membership, userID int64, itemID int64) bool {
+	for _, m := range memberships {
+		if m.userID == userID && m.itemID == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+func removeMemberships(memberships []membership, keep func(membership) bool) []membership {
+	kept := make([]membership, 0, len(memberships))
+	for _, m := range memberships {
+		if keep(m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"time"
+)
+
+// Helper functions
+
+func (s *MemoryStore) checkReportTarget(report Report) error {
+	var err error
+	switch report.TargetType {
+	case "USER":
+		_, err = s.getUser(report.TargetID)
+	case "GROUP":
+		_, err = s.getGroup(report.TargetID)
+	case "EVENT":
+		_, err = s.getEvent(report.TargetID)
+	}
+	return err
+}
+
+func (s *MemoryStore) updateUserAsAdmin(userID int64, action Action, adminID int64, update func(*User)) (User, error) {
+	if err := s.authorize(adminID, action, nil); err != nil {
+		return User{}, err
+	}
+	user, err := s.getUser(userID)
+	if err != nil {
+		return User{}, err
+	}
+	update(&user)
+	if err := s.putUser(user); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+// Main functions
+
+func (s *MemoryStore) VerifyUser(ctx context.Context, userID int64, verified bool, adminID int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateUserAsAdmin(userID, ActionVerifyUser, adminID, func(user *User) {
+		user.Verified = verified
+	})
+}
+
+func (s *MemoryStore) ChangeUserRole(ctx context.Context, userID int64, userRole string, adminID int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateUserAsAdmin(userID, ActionChangeUserRole, adminID, func(user *User) {
+		user.UserRole = userRole
+		user.Verified = true
+	})
+}
+
+func (s *MemoryStore) SuspendUser(ctx context.Context, userID int64, suspended bool, adminID int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, err := s.updateUserAsAdmin(userID, ActionSuspendUser, adminID, func(user *User) {
+		user.Suspended = suspended
+	})
+	if err != nil {
+		return User{}, err
+	}
+	if suspended {
+		s.deleteSessionsOfUser(userID)
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) ForceDeleteGroup(ctx context.Context, groupID int64, adminID int64) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionForceDeleteGroup, nil); err != nil {
+		return Group{}, err
+	}
+	group, err := s.getGroup(groupID)
+	if err != nil {
+		return Group{}, err
+	}
+	s.deleteGroup(groupID)
+	return group, nil
+}
+
+func (s *MemoryStore) ForceDeleteEvent(ctx context.Context, eventID int64, adminID int64) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionForceDeleteEvent, nil); err != nil {
+		return Event{}, err
+	}
+	event, err := s.getEvent(eventID)
+	if err != nil {
+		return Event{}, err
+	}
+	s.deleteEvent(eventID)
+	return event, nil
+}
+
+func (s *MemoryStore) GetReportsPage(ctx context.Context, adminID int64, page PageQuery) (Page[Report], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionListReports, nil); err != nil {
+		return Page[Report]{}, err
+	}
+	reports := make([]Report, 0, len(s.reports))
+	for _, id := range sortedIDs(s.reports) {
+		reports = append(reports, s.reports[id])
+	}
+	return PaginateItems(reports, ReportSorting, page)
+}
+
+func (s *MemoryStore) AddReport(ctx context.Context, report Report, userID int64) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[userID]; !ok {
+		return Report{}, foreignKeyViolation("wn_report", "reporter_id")
+	}
+	if err := s.checkReportTarget(report); err != nil {
+		return Report{}, err
+	}
+	if !contains(refReportTarget, report.TargetType) {
+		return Report{}, checkViolation("wn_report", "target_type")
+	}
+	if report.Reason == "" {
+		return Report{}, checkViolation("wn_report", "reason")
+	}
+	for _, r := range s.reports {
+		if r.ReporterID == userID && r.TargetType == report.TargetType && r.TargetID == report.TargetID {
+			return Report{}, uniqueViolation("wn_report", "reporter_id", "target_type", "target_id")
+		}
+	}
+	s.lastReportID++
+	report.ID = s.lastReportID
+	report.ReporterID = userID
+	report.TimeAdded = time.Now()
+	s.reports[report.ID] = report
+	return report, nil
+}
+
+func (s *MemoryStore) DeleteReport(ctx context.Context, reportID int64, adminID int64) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionDeleteReport, nil); err != nil {
+		return Report{}, err
+	}
+	report, ok := s.reports[reportID]
+	if !ok {
+		return Report{}, http_error.NotFoundError
+	}
+	delete(s.reports, reportID)
+	return report, nil
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"fmt"
+)
+
+func (s *MemoryStore) getBooking(bookingID int64) (Booking, error) {
+	booking, ok := s.bookings[bookingID]
+	if !ok {
+		return Booking{}, http_error.NotFoundError
+	}
+	return booking, nil
+}
+
+func (s *MemoryStore) getBookingUsersWhere(keep func(Booking) bool) []BookingUser {
+	bookingUsers := make([]BookingUser, 0)
+	for _, id := range sortedIDs(s.bookings) {
+		if booking := s.bookings[id]; keep(booking) {
+			bookingUsers = append(bookingUsers, BookingUser{Booking: booking, User: s.users[booking.ProviderID].Public()})
+		}
+	}
+	return bookingUsers
+}
+
+func (s *MemoryStore) GetBooking(ctx context.Context, bookingID int64) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getBooking(bookingID)
+}
+
+func (s *MemoryStore) GetBookingProvider(ctx context.Context, bookingID int64, userID int64) (BookingProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booking, err := s.getBooking(bookingID)
+	if err != nil {
+		return BookingProvider{}, err
+	}
+	if err := s.authorize(userID, ActionViewBooking, BookingRelations(userID, booking)); err != nil {
+		return BookingProvider{}, err
+	}
+	provider, err := s.getProvider(booking.ProviderID)
+	if err != nil {
+		return BookingProvider{}, err
+	}
+	return BookingProvider{Booking: booking, Provider: provider}, nil
+}
+
+func (s *MemoryStore) GetBookingUsersPageOfUser(ctx context.Context, userID int64, scope string, page PageQuery) (Page[BookingUser], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bookingUsers := s.getBookingUsersWhere(func(b Booking) bool {
+		switch scope {
+		case BookingsReceived:
+			return b.ProviderID == userID
+		case BookingsSent:
+			return b.RecipientID == userID
+		case BookingsRequired:
+			return b.ApproveBy == userID
+		}
+		return b.RecipientID == userID || b.ProviderID == userID
+	})
+	return PaginateItems(bookingUsers, BookingUserSorting, page)
+}
+
+func (s *MemoryStore) AddBooking(ctx context.Context, booking Booking, providerID int64, recipientID int64) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(providerID, ActionProvideBooking, nil); err != nil {
+		return Booking{}, err
+	}
+	booking.RecipientID = recipientID
+	booking.ProviderID = providerID
+	booking.ApproveBy = providerID
+	s.lastBookingID++
+	booking.ID = s.lastBookingID
+	if _, ok := s.users[recipientID]; !ok {
+		return Booking{}, foreignKeyViolation("wn_booking", "recipient_id")
+	}
+	for _, b := range s.bookings {
+		if b.RecipientID == recipientID && b.ProviderID == providerID {
+			return Booking{}, uniqueViolation("wn_booking", "recipient_id", "provider_id")
+		}
+	}
+	s.bookings[booking.ID] = booking
+	return booking, nil
+}
+
+func (s *MemoryStore) UpdateBooking(ctx context.Context, updatedBooking Booking, bookingID int64, userID int64) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetBooking, err := s.getBooking(bookingID)
+	if err != nil {
+		return Booking{}, err
+	}
+	if err := s.authorize(userID, ActionUpdateBooking, BookingRelations(userID, targetBooking)); err != nil {
+		return Booking{}, err
+	}
+	updatedBooking = updatedBooking.MergeBooking(targetBooking)
+	s.bookings[bookingID] = updatedBooking
+	return updatedBooking, nil
+}
+
+func (s *MemoryStore) RespondBooking(ctx context.Context, bookingRespond BookingRespond, bookingID int64, userID int64) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	booking, err := s.getBooking(bookingID)
+	if err != nil {
+		return BookingRespond{}, err
+	}
+	if err := s.authorize(userID, ActionRespondBooking, BookingRelations(userID, booking)); err != nil {
+		return BookingRespond{}, err
+	}
+	if bookingRespond.Approve {
+		nickname := booking.Nickname
+		providerName := s.users[booking.ProviderID].FirstName
+		event := Event{
+			EventName:        fmt.Sprintf("%s and %s Counsel Session", nickname, providerName),
+			EventDescription: fmt.Sprintf("Counsel Session for %s by %s", nickname, providerName),
+			StartTime:        booking.StartTime,
+			EndTime:          booking.EndTime,
+			Access:           "PRIVATE",
+			Category:         "COUNSEL",
+		}
+		eventWithUsers, err := s.addEventWithUserIDs(event, []int64{booking.ProviderID, booking.RecipientID})
+		if err != nil {
+			return BookingRespond{}, err
+		}
+		delete(s.bookings, bookingID)
+		return eventWithUsers, nil
+	}
+	updatedBooking := bookingRespond.Booking.MergeBooking(booking)
+	updatedBooking.ApproveBy = booking.FlippedApproveBy()
+	s.bookings[bookingID] = updatedBooking
+	bookingRespond.Booking = updatedBooking
+	return bookingRespond, nil
+}
+
+func (s *MemoryStore) DeleteBookingAuthorized(ctx context.Context, bookingID int64, userID int64) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetBooking, err := s.getBooking(bookingID)
+	if err != nil {
+		return Booking{}, err
+	}
+	if err := s.authorize(userID, ActionDeleteBooking, BookingRelations(userID, targetBooking)); err != nil {
+		return Booking{}, err
+	}
+	delete(s.bookings, bookingID)
+	return Booking{ID: bookingID}, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+
+	"context"
+	"sort"
+	"time"
+)
+
+func (s *MemoryStore) GetMessagesChunkOfGroupCustomise(ctx context.Context, groupID int64, latestTime time.Time, limit int64) (MessagesChunk, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]Message, 0)
+	for _, message := range s.messages {
+		if message.GroupID == groupID && message.TimeAdded.Before(latestTime) {
+			messages = append(messages, message)
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].TimeAdded.Before(messages[j].TimeAdded) })
+	if limit > 0 && int64(len(messages)) > limit {
+		messages = messages[int64(len(messages))-limit:]
+	}
+
+	messagePayloads := make([]MessagePayload, len(messages))
+	for i, message := range messages {
+		messagePayloads[i] = MessagePayload{
+			Tag:        MessageTag,
+			SenderName: s.users[message.UserID].FirstName,
+			GroupName:  s.groups[message.GroupID].GroupName,
+			Message:    message,
+		}
+	}
+	messagesChunk := MessagesChunk{MessagePayloads: messagePayloads}
+	if l := len(messagePayloads); l > 0 {
+		messagesChunk.EarliestTime = messagePayloads[0].Message.TimeAdded
+		messagesChunk.LatestTime = messagePayloads[l-1].Message.TimeAdded
+	}
+	return messagesChunk, nil
+}
+
+func (s *MemoryStore) GetMessagePayload(ctx context.Context, message Message) (MessagePayload, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	group, err := s.getGroup(message.GroupID)
+	if err != nil {
+		return MessagePayload{}, err
+	}
+	var senderName string
+	if message.IsServerMessage() {
+		senderName = "[WellNUS Server]"
+	} else {
+		sender, err := s.getUser(message.UserID)
+		if err != nil {
+			return MessagePayload{}, err
+		}
+		senderName = sender.FirstName
+	}
+	return MessagePayload{Tag: MessageTag, SenderName: senderName, GroupName: group.GroupName, Message: message}, nil
+}
+
+func (s *MemoryStore) AddMessage(ctx context.Context, message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if message.Msg == "" {
+		return checkViolation("wn_message", "msg")
+	}
+	if _, ok := s.users[message.UserID]; !ok {
+		return foreignKeyViolation("wn_message", "user_id")
+	}
+	if _, ok := s.groups[message.GroupID]; !ok {
+		return foreignKeyViolation("wn_message", "group_id")
+	}
+	s.messages = append(s.messages, message)
+	return nil
+}
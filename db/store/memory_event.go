@@ -0,0 +1,231 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"fmt"
+)
+
+// Helper functions
+
+func validateEvent(event Event) error {
+	if !contains(refAccess, event.Access) {
+		return checkViolation("wn_event", "access")
+	}
+	if !contains(refCategory, event.Category) {
+		return checkViolation("wn_event", "category")
+	}
+	if event.EventName == "" {
+		return checkViolation("wn_event", "event_name")
+	}
+	return nil
+}
+
+func (s *MemoryStore) putEvent(event Event) error {
+	if err := validateEvent(event); err != nil {
+		return err
+	}
+	if _, ok := s.users[event.OwnerID]; !ok {
+		return foreignKeyViolation("wn_event", "owner_id")
+	}
+	s.events[event.ID] = event
+	return nil
+}
+
+func (s *MemoryStore) getEvent(eventID int64) (Event, error) {
+	event, ok := s.events[eventID]
+	if !ok {
+		return Event{}, http_error.NotFoundError
+	}
+	return event, nil
+}
+
+func (s *MemoryStore) getEventWithUsers(eventID int64) (EventWithUsers, error) {
+	event, err := s.getEvent(eventID)
+	if err != nil {
+		return EventWithUsers{}, err
+	}
+	return EventWithUsers{Event: event, Users: PublicUsers(s.getUsersOfMemberships(s.userEvents, eventID))}, nil
+}
+
+func (s *MemoryStore) getAllEventsOfUser(userID int64) []Event {
+	events := make([]Event, 0)
+	for _, m := range s.userEvents {
+		if m.userID == userID {
+			events = append(events, s.events[m.itemID])
+		}
+	}
+	return events
+}
+
+func (s *MemoryStore) addUserToEvent(eventID int64, userID int64) error {
+	if _, ok := s.users[userID]; !ok {
+		return foreignKeyViolation("wn_user_event", "user_id")
+	}
+	if _, ok := s.events[eventID]; !ok {
+		return foreignKeyViolation("wn_user_event", "event_id")
+	}
+	if hasMembership(s.userEvents, userID, eventID) {
+		return uniqueViolation("wn_user_event", "event_id", "user_id")
+	}
+	s.userEvents = append(s.userEvents, membership{userID: userID, itemID: eventID})
+	return nil
+}
+
+func (s *MemoryStore) addEventWithUserIDs(event Event, userIDs []int64) (EventWithUsers, error) {
+	userIDs = DistinctIDs(userIDs)
+	if len(userIDs) == 0 {
+		return EventWithUsers{}, NoMembersError
+	}
+	event.OwnerID = userIDs[0]
+	s.lastEventID++
+	event.ID = s.lastEventID
+	if err := s.putEvent(event); err != nil {
+		return EventWithUsers{}, err
+	}
+	for _, userID := range userIDs {
+		if err := s.addUserToEvent(event.ID, userID); err != nil {
+			s.deleteEvent(event.ID)
+			return EventWithUsers{}, err
+		}
+	}
+	return s.getEventWithUsers(event.ID)
+}
+
+func (s *MemoryStore) deleteEvent(eventID int64) {
+	s.userEvents = removeMemberships(s.userEvents, func(m membership) bool { return m.itemID != eventID })
+	delete(s.events, eventID)
+}
+
+func (s *MemoryStore) leaveDeleteEvent(eventID int64, userID int64) (EventWithUsers, error) {
+	targetEvent, err := s.getEvent(eventID)
+	if err != nil {
+		return EventWithUsers{}, err
+	}
+	if targetEvent.OwnerID == userID {
+		s.deleteEvent(eventID)
+		return EventWithUsers{Event: Event{ID: eventID}}, nil
+	}
+	s.userEvents = removeMemberships(s.userEvents, func(m membership) bool {
+		return m.userID != userID || m.itemID != eventID
+	})
+	return EventWithUsers{Event: targetEvent, Users: PublicUsers(s.getUsersOfMemberships(s.userEvents, eventID))}, nil
+}
+
+// Event
+
+func (s *MemoryStore) GetEvent(ctx context.Context, eventID int64) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getEvent(eventID)
+}
+
+func (s *MemoryStore) GetEventWithUsers(ctx context.Context, eventID int64) (EventWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getEventWithUsers(eventID)
+}
+
+func (s *MemoryStore) GetAllEventsOfUser(ctx context.Context, userID int64) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getAllEventsOfUser(userID), nil
+}
+
+func (s *MemoryStore) GetEventsPageOfUser(ctx context.Context, userID int64, page PageQuery) (Page[Event], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return PaginateItems(s.getAllEventsOfUser(userID), EventSorting, page)
+}
+
+func (s *MemoryStore) AddEventWithUserIDs(ctx context.Context, event Event, userIDs []int64) (EventWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addEventWithUserIDs(event, userIDs)
+}
+
+func (s *MemoryStore) AddUserToEventAuthorized(ctx context.Context, userID int64, eventID int64, adderID int64) (EventWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetEvent, err := s.getEvent(eventID)
+	if err != nil {
+		return EventWithUsers{}, err
+	}
+	action := ActionJoinPublicEvent
+	if targetEvent.Access == "PRIVATE" {
+		action = ActionAddUserToPrivateEvent
+	}
+	if err := s.authorize(adderID, action, EventRelations(adderID, targetEvent)); err != nil {
+		return EventWithUsers{}, err
+	}
+	if err := s.addUserToEvent(eventID, userID); err != nil {
+		return EventWithUsers{}, err
+	}
+	return s.getEventWithUsers(eventID)
+}
+
+func (s *MemoryStore) UpdateEvent(ctx context.Context, updatedEvent Event, eventID int64, userID int64) (Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetEvent, err := s.getEvent(eventID)
+	if err != nil {
+		return Event{}, err
+	}
+	if err := s.authorize(userID, ActionUpdateEvent, EventRelations(userID, targetEvent)); err != nil {
+		return Event{}, err
+	}
+	updatedEvent = updatedEvent.MergeEvent(targetEvent)
+	if err := s.putEvent(updatedEvent); err != nil {
+		return Event{}, err
+	}
+	return updatedEvent, nil
+}
+
+func (s *MemoryStore) LeaveDeleteEvent(ctx context.Context, eventID int64, userID int64) (EventWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leaveDeleteEvent(eventID, userID)
+}
+
+func (s *MemoryStore) LeaveDeleteAllEvents(ctx context.Context, userID int64) ([]EventWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	eventsWithUsers := make([]EventWithUsers, 0)
+	for _, event := range s.getAllEventsOfUser(userID) {
+		eventWithUsers, err := s.leaveDeleteEvent(event.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		eventsWithUsers = append(eventsWithUsers, eventWithUsers)
+	}
+	return eventsWithUsers, nil
+}
+
+func (s *MemoryStore) CreateGroupDeleteEvent(ctx context.Context, eventID int64, userID int64) (GroupWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetEventWithUsers, err := s.getEventWithUsers(eventID)
+	if err != nil {
+		return GroupWithUsers{}, err
+	}
+	if err := s.authorize(userID, ActionStartEvent, EventRelations(userID, targetEventWithUsers.Event)); err != nil {
+		return GroupWithUsers{}, err
+	}
+	group := Group{
+		GroupName:        fmt.Sprintf("%s Room", targetEventWithUsers.Event.EventName),
+		GroupDescription: targetEventWithUsers.Event.EventDescription,
+		Category:         targetEventWithUsers.Event.Category,
+	}
+	users := []int64{userID}
+	for _, user := range targetEventWithUsers.Users {
+		users = append(users, user.ID)
+	}
+	groupWithUsers, err := s.addGroupWithUserIDs(group, users)
+	if err != nil {
+		return GroupWithUsers{}, err
+	}
+	s.deleteEvent(eventID)
+	return groupWithUsers, nil
+}
@@ -0,0 +1,322 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+)
+
+// Helper functions
+
+func validateGroup(group Group) error {
+	if !contains(refCategory, group.Category) {
+		return checkViolation("wn_group", "category")
+	}
+	if group.GroupName == "" {
+		return checkViolation("wn_group", "group_name")
+	}
+	return nil
+}
+
+func (s *MemoryStore) putGroup(group Group) error {
+	if err := validateGroup(group); err != nil {
+		return err
+	}
+	if _, ok := s.users[group.OwnerID]; !ok {
+		return foreignKeyViolation("wn_group", "owner_id")
+	}
+	s.groups[group.ID] = group
+	return nil
+}
+
+func (s *MemoryStore) getGroup(groupID int64) (Group, error) {
+	group, ok := s.groups[groupID]
+	if !ok {
+		return Group{}, http_error.NotFoundError
+	}
+	return group, nil
+}
+
+func (s *MemoryStore) getGroupWithUsers(groupID int64) (GroupWithUsers, error) {
+	group, err := s.getGroup(groupID)
+	if err != nil {
+		return GroupWithUsers{}, err
+	}
+	return GroupWithUsers{Group: group, Users: PublicUsers(s.getUsersOfMemberships(s.userGroups, groupID))}, nil
+}
+
+func (s *MemoryStore) getAllGroupsOfUser(userID int64) []Group {
+	groups := make([]Group, 0)
+	for _, m := range s.userGroups {
+		if m.userID == userID {
+			groups = append(groups, s.groups[m.itemID])
+		}
+	}
+	return groups
+}
+
+func (s *MemoryStore) addUserToGroup(groupID int64, userID int64) error {
+	if _, ok := s.users[userID]; !ok {
+		return foreignKeyViolation("wn_user_group", "user_id")
+	}
+	if _, ok := s.groups[groupID]; !ok {
+		return foreignKeyViolation("wn_user_group", "group_id")
+	}
+	if hasMembership(s.userGroups, userID, groupID) {
+		return uniqueViolation("wn_user_group", "user_id", "group_id")
+	}
+	s.userGroups = append(s.userGroups, membership{userID: userID, itemID: groupID})
+	return nil
+}
+
+func (s *MemoryStore) addGroupWithUserIDs(group Group, userIDs []int64) (GroupWithUsers, error) {
+	userIDs = DistinctIDs(userIDs)
+	if len(userIDs) == 0 {
+		return GroupWithUsers{}, NoMembersError
+	}
+	group.OwnerID = userIDs[0]
+	s.lastGroupID++
+	group.ID = s.lastGroupID
+	if err := s.putGroup(group); err != nil {
+		return GroupWithUsers{}, err
+	}
+	for _, userID := range userIDs {
+		if err := s.addUserToGroup(group.ID, userID); err != nil {
+			s.deleteGroup(group.ID)
+			return GroupWithUsers{}, err
+		}
+	}
+	return s.getGroupWithUsers(group.ID)
+}
+
+func (s *MemoryStore) deleteGroup(groupID int64) {
+	s.userGroups = removeMemberships(s.userGroups, func(m membership) bool { return m.itemID != groupID })
+	for id, joinRequest := range s.joinRequests {
+		if joinRequest.GroupID == groupID {
+			delete(s.joinRequests, id)
+		}
+	}
+	messages := make([]Message, 0, len(s.messages))
+	for _, message := range s.messages {
+		if message.GroupID != groupID {
+			messages = append(messages, message)
+		}
+	}
+	s.messages = messages
+	delete(s.groups, groupID)
+}
+
+func (s *MemoryStore) leaveGroup(groupID int64, userID int64) (GroupWithUsers, error) {
+	targetGroupWithUsers, err := s.getGroupWithUsers(groupID)
+	if err != nil {
+		return GroupWithUsers{}, err
+	}
+	if targetGroupWithUsers.Group.OwnerID == userID {
+		newOwnerID := targetGroupWithUsers.GetNewOwnerID()
+		if newOwnerID == 0 {
+			s.deleteGroup(groupID)
+			return GroupWithUsers{Group: Group{ID: groupID}}, nil
+		}
+		targetGroupWithUsers.Group.OwnerID = newOwnerID
+		s.groups[groupID] = targetGroupWithUsers.Group
+	}
+	s.userGroups = removeMemberships(s.userGroups, func(m membership) bool {
+		return m.userID != userID || m.itemID != groupID
+	})
+	targetGroupWithUsers.Users = PublicUsers(s.getUsersOfMemberships(s.userGroups, groupID))
+	return targetGroupWithUsers, nil
+}
+
+// Main functions
+
+func (s *MemoryStore) GetGroup(ctx context.Context, groupID int64) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getGroup(groupID)
+}
+
+func (s *MemoryStore) GetGroupWithUsers(ctx context.Context, groupID int64) (GroupWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getGroupWithUsers(groupID)
+}
+
+func (s *MemoryStore) GetAllGroupsOfUser(ctx context.Context, userID int64) ([]Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getAllGroupsOfUser(userID), nil
+}
+
+func (s *MemoryStore) GetGroupsPageOfUser(ctx context.Context, userID int64, page PageQuery) (Page[Group], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return PaginateItems(s.getAllGroupsOfUser(userID), GroupSorting, page)
+}
+
+func (s *MemoryStore) AddGroupWithUserIDs(ctx context.Context, group Group, userIDs []int64) (GroupWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addGroupWithUserIDs(group, userIDs)
+}
+
+func (s *MemoryStore) AddUserToGroup(ctx context.Context, groupID int64, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUserToGroup(groupID, userID)
+}
+
+func (s *MemoryStore) UpdateGroup(ctx context.Context, updatedGroup Group, groupID int64, userID int64) (Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetGroup, err := s.getGroup(groupID)
+	if err != nil {
+		return Group{}, err
+	}
+	if err := s.authorize(userID, ActionUpdateGroup, GroupRelations(userID, targetGroup, false)); err != nil {
+		return Group{}, err
+	}
+	updatedGroup = updatedGroup.MergeGroup(targetGroup)
+	if !hasMembership(s.userGroups, updatedGroup.OwnerID, updatedGroup.ID) {
+		return Group{}, OwnerNotMemberError
+	}
+	if err := s.putGroup(updatedGroup); err != nil {
+		return Group{}, err
+	}
+	return updatedGroup, nil
+}
+
+func (s *MemoryStore) LeaveGroup(ctx context.Context, groupID int64, userID int64) (GroupWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leaveGroup(groupID, userID)
+}
+
+func (s *MemoryStore) LeaveAllGroups(ctx context.Context, userID int64) ([]GroupWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	groupsWithUsers := make([]GroupWithUsers, 0)
+	for _, group := range s.getAllGroupsOfUser(userID) {
+		groupWithUsers, err := s.leaveGroup(group.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		groupsWithUsers = append(groupsWithUsers, groupWithUsers)
+	}
+	return groupsWithUsers, nil
+}
+
+func (s *MemoryStore) IsUserInGroup(ctx context.Context, userID int64, groupID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hasMembership(s.userGroups, userID, groupID), nil
+}
+
+// Join
+
+func (s *MemoryStore) getLoadedJoinRequestsWhere(keep func(LoadedJoinRequest) bool) []LoadedJoinRequest {
+	loadedJoinRequests := make([]LoadedJoinRequest, 0)
+	for _, id := range sortedIDs(s.joinRequests) {
+		joinRequest := s.joinRequests[id]
+		loadedJoinRequest := LoadedJoinRequest{
+			JoinRequest: joinRequest,
+			User:        s.users[joinRequest.UserID].Public(),
+			Group:       s.groups[joinRequest.GroupID],
+		}
+		if keep(loadedJoinRequest) {
+			loadedJoinRequests = append(loadedJoinRequests, loadedJoinRequest)
+		}
+	}
+	return loadedJoinRequests
+}
+
+func (s *MemoryStore) getLoadedJoinRequest(joinRequestID int64) (LoadedJoinRequest, error) {
+	loadedJoinRequests := s.getLoadedJoinRequestsWhere(func(ljr LoadedJoinRequest) bool {
+		return ljr.JoinRequest.ID == joinRequestID
+	})
+	if len(loadedJoinRequests) == 0 {
+		return LoadedJoinRequest{}, http_error.NotFoundError
+	}
+	return loadedJoinRequests[0], nil
+}
+
+func (s *MemoryStore) GetLoadedJoinRequest(ctx context.Context, joinRequestID int64, userID int64) (LoadedJoinRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loadedJoinRequest, err := s.getLoadedJoinRequest(joinRequestID)
+	if err != nil {
+		return LoadedJoinRequest{}, err
+	}
+	if err := s.authorize(userID, ActionViewJoinRequest, JoinRequestRelations(userID, loadedJoinRequest)); err != nil {
+		return LoadedJoinRequest{}, err
+	}
+	return loadedJoinRequest, nil
+}
+
+func (s *MemoryStore) GetLoadedJoinRequestsPageOfUser(ctx context.Context, userID int64, scope string, page PageQuery) (Page[LoadedJoinRequest], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loadedJoinRequests := s.getLoadedJoinRequestsWhere(func(ljr LoadedJoinRequest) bool {
+		switch scope {
+		case JoinRequestsReceived:
+			return ljr.Group.OwnerID == userID
+		case JoinRequestsSent:
+			return ljr.JoinRequest.UserID == userID
+		}
+		return ljr.Group.OwnerID == userID || ljr.JoinRequest.UserID == userID
+	})
+	return PaginateItems(loadedJoinRequests, LoadedJoinRequestSorting, page)
+}
+
+func (s *MemoryStore) AddJoinRequest(ctx context.Context, groupID int64, userID int64) (JoinRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastJoinRequestID++
+	joinRequest := JoinRequest{ID: s.lastJoinRequestID, UserID: userID, GroupID: groupID}
+	if _, ok := s.users[userID]; !ok {
+		return JoinRequest{}, foreignKeyViolation("wn_join_request", "user_id")
+	}
+	if _, ok := s.groups[groupID]; !ok {
+		return JoinRequest{}, foreignKeyViolation("wn_join_request", "group_id")
+	}
+	for _, jr := range s.joinRequests {
+		if jr.UserID == userID && jr.GroupID == groupID {
+			return JoinRequest{}, uniqueViolation("wn_join_request", "user_id", "group_id")
+		}
+	}
+	s.joinRequests[joinRequest.ID] = joinRequest
+	return joinRequest, nil
+}
+
+func (s *MemoryStore) RespondJoinRequest(ctx context.Context, joinRequestID int64, userID int64, approve bool) (JoinRequestRespond, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loadedJoinRequest, err := s.getLoadedJoinRequest(joinRequestID)
+	if err != nil {
+		return JoinRequestRespond{}, nil
+	}
+	if err := s.authorize(userID, ActionRespondJoinRequest, GroupRelations(userID, loadedJoinRequest.Group, false)); err != nil {
+		return JoinRequestRespond{}, err
+	}
+	if approve {
+		if err := s.addUserToGroup(loadedJoinRequest.Group.ID, loadedJoinRequest.JoinRequest.UserID); err != nil {
+			return JoinRequestRespond{}, err
+		}
+	}
+	delete(s.joinRequests, joinRequestID)
+	return JoinRequestRespond{Approve: approve}, nil
+}
+
+func (s *MemoryStore) DeleteJoinRequest(ctx context.Context, joinRequestID int64, userID int64) (JoinRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	joinRequest, ok := s.joinRequests[joinRequestID]
+	if !ok {
+		return JoinRequest{}, http_error.NotFoundError
+	}
+	if err := s.authorize(userID, ActionDeleteJoinRequest, UserRelations(userID, joinRequest.UserID)); err != nil {
+		return JoinRequest{}, err
+	}
+	delete(s.joinRequests, joinRequestID)
+	return JoinRequest{ID: joinRequestID}, nil
+}
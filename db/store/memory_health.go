@@ -0,0 +1,20 @@
+package store
+
+import (
+	"wellnus/backend/db/migration"
+
+	"context"
+)
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// The in memory store has no schema, so it is always at the latest migration
+func (s *MemoryStore) GetSchemaStatus(ctx context.Context) (migration.Status, error) {
+	latest, err := migration.Latest()
+	if err != nil {
+		return migration.Status{}, err
+	}
+	return migration.Status{Version: latest, Latest: latest}, nil
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"sort"
+	"time"
+)
+
+// Helper functions
+
+func validateMatchSetting(matchSetting MatchSetting) error {
+	if !contains(refFacultyPreference, matchSetting.FacultyPreference) {
+		return checkViolation("wn_match_setting", "faculty_preference")
+	}
+	if !containsAll(refHobbies, matchSetting.Hobbies) || len(matchSetting.Hobbies) > 4 {
+		return checkViolation("wn_match_setting", "hobbies")
+	}
+	if !contains(refMBTI, matchSetting.MBTI) {
+		return checkViolation("wn_match_setting", "mbti")
+	}
+	if !contains(refGenderPreference, matchSetting.GenderPreference) {
+		return checkViolation("wn_match_setting", "gender_preference")
+	}
+	if matchSetting.YearOfStudy < 0 || matchSetting.YearOfStudy > 6 {
+		return checkViolation("wn_match_setting", "year_of_study")
+	}
+	if !containsAll(Availabilities, matchSetting.Availability) {
+		return checkViolation("wn_match_setting", "availability")
+	}
+	if matchSetting.PreferredGroupSize != 0 && (matchSetting.PreferredGroupSize < 2 || matchSetting.PreferredGroupSize > 10) {
+		return checkViolation("wn_match_setting", "preferred_group_size")
+	}
+	return nil
+}
+
+func (s *MemoryStore) deleteMatchSetting(userID int64) {
+	s.deleteMatchRequest(userID)
+	delete(s.matchSettings, userID)
+}
+
+func (s *MemoryStore) deleteMatchRequest(userID int64) {
+	matchRequests := make([]MatchRequest, 0, len(s.matchRequests))
+	for _, matchRequest := range s.matchRequests {
+		if matchRequest.UserID != userID {
+			matchRequests = append(matchRequests, matchRequest)
+		}
+	}
+	s.matchRequests = matchRequests
+}
+
+func (s *MemoryStore) loadMatchRequest(matchRequest MatchRequest) LoadedMatchRequest {
+	return LoadedMatchRequest{
+		MatchRequest: matchRequest,
+		User:         s.users[matchRequest.UserID].Public(),
+		MatchSetting: s.matchSettings[matchRequest.UserID],
+	}
+}
+
+// Mirrors model.GetAllOpenGroups on the groups held in memory
+func (s *MemoryStore) getAllOpenGroups(groupSize int) []OpenGroup {
+	matched := make(map[int64]bool)
+	for _, run := range s.matchingRuns {
+		for _, groupID := range run.GroupIDs {
+			matched[groupID] = true
+		}
+	}
+	openGroups := make([]OpenGroup, 0)
+	for _, group := range s.groups {
+		if group.Category != "SUPPORT" || !matched[group.ID] {
+			continue
+		}
+		users := s.getUsersOfMemberships(s.userGroups, group.ID)
+		if len(users) == 0 || len(users) >= groupSize {
+			continue
+		}
+		members := make([]LoadedMatchRequest, len(users))
+		for i, user := range users {
+			members[i] = LoadedMatchRequest{User: user.Public(), MatchSetting: s.matchSettings[user.ID]}
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].User.ID < members[j].User.ID })
+		openGroups = append(openGroups, OpenGroup{Group: group, Members: members})
+	}
+	sort.Slice(openGroups, func(i, j int) bool {
+		if len(openGroups[i].Members) != len(openGroups[j].Members) {
+			return len(openGroups[i].Members) < len(openGroups[j].Members)
+		}
+		return openGroups[i].Group.ID < openGroups[j].Group.ID
+	})
+	return openGroups
+}
+
+// Mirrors the group forming and filling of model.RunMatching on the requests held in memory
+func (s *MemoryStore) performMatching(plan MatchingPlan, loadedMatchRequests []LoadedMatchRequest) ([]GroupWithUsers, error) {
+	// Restores the queue and memberships and removes the groups formed so far, as the rolled back transaction would
+	queuedMatchRequests := append([]MatchRequest(nil), s.matchRequests...)
+	userGroups := append([]membership(nil), s.userGroups...)
+	groupsWithUsers := make([]GroupWithUsers, 0)
+	rollback := func() {
+		for _, groupWithUsers := range groupsWithUsers {
+			s.deleteGroup(groupWithUsers.Group.ID)
+		}
+		s.matchRequests = queuedMatchRequests
+		s.userGroups = userGroups
+	}
+	group := Group{
+		GroupName:        "Support Group",
+		GroupDescription: "Welcome to your new Support Group",
+		Category:         "SUPPORT",
+	}
+
+	for _, groupingIndices := range plan.Groups {
+		groupingUserIDs := make([]int64, len(groupingIndices))
+		for i, index := range groupingIndices {
+			userID := loadedMatchRequests[index].MatchRequest.UserID
+			groupingUserIDs[i] = userID
+			s.deleteMatchRequest(userID)
+		}
+
+		groupWithUsers, err := s.addGroupWithUserIDs(group, groupingUserIDs)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		groupsWithUsers = append(groupsWithUsers, groupWithUsers)
+	}
+
+	for _, fill := range plan.Fills {
+		for _, index := range fill.Indices {
+			userID := loadedMatchRequests[index].MatchRequest.UserID
+			s.deleteMatchRequest(userID)
+			if err := s.addUserToGroup(fill.GroupID, userID); err != nil {
+				rollback()
+				return nil, err
+			}
+		}
+	}
+	return groupsWithUsers, nil
+}
+
+// Match setting
+
+func (s *MemoryStore) GetMatchSettingOfUser(ctx context.Context, userID int64) (MatchSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matchSetting, ok := s.matchSettings[userID]
+	if !ok {
+		return MatchSetting{}, http_error.NotFoundError
+	}
+	return matchSetting, nil
+}
+
+func (s *MemoryStore) AddUpdateMatchSettingOfUser(ctx context.Context, matchSetting MatchSetting, userID int64) (MatchSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matchSetting.UserID = userID
+	if err := validateMatchSetting(matchSetting); err != nil {
+		return MatchSetting{}, err
+	}
+	if _, ok := s.users[userID]; !ok {
+		return MatchSetting{}, foreignKeyViolation("wn_match_setting", "user_id")
+	}
+	s.matchSettings[userID] = matchSetting
+	return matchSetting, nil
+}
+
+func (s *MemoryStore) DeleteMatchSettingOfUser(ctx context.Context, userID int64) (MatchSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteMatchSetting(userID)
+	return MatchSetting{UserID: userID}, nil
+}
+
+// Match Request
+
+func (s *MemoryStore) GetMatchRequestCount(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return int64(len(s.matchRequests)), nil
+}
+
+func (s *MemoryStore) GetQueuedMatchRequestOfUser(ctx context.Context, userID int64) (QueuedMatchRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, matchRequest := range s.matchRequests {
+		if matchRequest.UserID == userID {
+			qmr := QueuedMatchRequest{
+				LoadedMatchRequest: s.loadMatchRequest(matchRequest),
+				QueuePosition:      i + 1,
+				QueueSize:          len(s.matchRequests),
+			}
+			lastRun := matchRequest.TimeAdded
+			if run, ok := s.matchingRuns[s.lastMatchingRunID]; ok {
+				lastRun = run.TimeStarted
+			}
+			return qmr.Estimate(s.matching, lastRun, time.Now()), nil
+		}
+	}
+	return QueuedMatchRequest{}, http_error.NotFoundError
+}
+
+func (s *MemoryStore) AddMatchRequest(ctx context.Context, userID int64) (MatchRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matchRequest := MatchRequest{UserID: userID, TimeAdded: time.Now()}
+	if _, ok := s.matchSettings[userID]; !ok {
+		return MatchRequest{}, foreignKeyViolation("wn_match_request", "user_id")
+	}
+	for _, mr := range s.matchRequests {
+		if mr.UserID == userID {
+			return MatchRequest{}, uniqueViolation("wn_match_request", "user_id")
+		}
+	}
+	s.matchRequests = append(s.matchRequests, matchRequest)
+	return matchRequest, nil
+}
+
+func (s *MemoryStore) DeleteMatchRequestOfUser(ctx context.Context, userID int64) (MatchRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteMatchRequest(userID)
+	return MatchRequest{UserID: userID}, nil
+}
+
+func (s *MemoryStore) GetCompatibilityBreakdown(ctx context.Context, groupID int64, userID int64) (CompatibilityBreakdown, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	groupWithUsers, err := s.getGroupWithUsers(groupID)
+	if err != nil {
+		return CompatibilityBreakdown{}, err
+	}
+	relations := GroupRelations(userID, groupWithUsers.Group, hasMembership(s.userGroups, userID, groupID))
+	if err := s.authorize(userID, ActionViewCompatibility, relations); err != nil {
+		return CompatibilityBreakdown{}, err
+	}
+	members := make([]LoadedMatchRequest, 0, len(groupWithUsers.Users))
+	for _, user := range groupWithUsers.Users {
+		if matchSetting, ok := s.matchSettings[user.ID]; ok {
+			members = append(members, LoadedMatchRequest{User: user, MatchSetting: matchSetting})
+		}
+	}
+	return BreakDownCompatibility(groupID, userID, members, s.matching.Weights)
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"time"
+)
+
+// Helper functions
+
+// Mirrors model.RunMatching on the requests held in memory. Holding the lock of the store
+// stands in for the advisory lock, so runs never overlap
+func (s *MemoryStore) runMatching(ctx context.Context, trigger string, triggeredBy int64) (MatchingRun, error) {
+	run := MatchingRun{
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		GroupSize:   s.matching.GroupSize,
+		Threshold:   s.matching.Threshold,
+		TimeStarted: time.Now(),
+	}
+	loadedMatchRequests := s.getAllLoadedMatchRequests()
+	run.UserIDs = make([]int64, len(loadedMatchRequests))
+	for i, loadedMatchRequest := range loadedMatchRequests {
+		run.UserIDs[i] = loadedMatchRequest.MatchRequest.UserID
+	}
+	run.QueueSize = len(loadedMatchRequests)
+	run.GroupIDs = make([]int64, 0)
+	run.FilledGroupIDs = make([]int64, 0)
+
+	var err error
+	run.Outcome = MatchingOutcomeBelowThreshold
+	if run.QueueSize >= run.MinimumQueueSize() {
+		plan := PlanMatching(NewMatcher(s.matching), loadedMatchRequests, s.getOpenGroupsToFill(s.matching), s.matching)
+		var groupsWithUsers []GroupWithUsers
+		groupsWithUsers, err = s.performMatching(plan, loadedMatchRequests)
+		for _, groupWithUsers := range groupsWithUsers {
+			run.GroupIDs = append(run.GroupIDs, groupWithUsers.Group.ID)
+		}
+		if err == nil {
+			for _, fill := range plan.Fills {
+				run.FilledGroupIDs = append(run.FilledGroupIDs, fill.GroupID)
+			}
+		}
+		if len(run.GroupIDs) > 0 || len(run.FilledGroupIDs) > 0 {
+			run.Outcome = MatchingOutcomeMatched
+		}
+	}
+	if err != nil {
+		run.Outcome = MatchingOutcomeFailed
+		run.Error = err.Error()
+	}
+	run.TimeFinished = time.Now()
+
+	s.lastMatchingRunID++
+	run.ID = s.lastMatchingRunID
+	s.matchingRuns[run.ID] = run
+	LogMatchingRun(ctx, run, err)
+	return run, err
+}
+
+// The queue a run is given, oldest first
+func (s *MemoryStore) getAllLoadedMatchRequests() []LoadedMatchRequest {
+	loadedMatchRequests := make([]LoadedMatchRequest, len(s.matchRequests))
+	for i, matchRequest := range s.matchRequests {
+		loadedMatchRequests[i] = s.loadMatchRequest(matchRequest)
+	}
+	return loadedMatchRequests
+}
+
+func (s *MemoryStore) getOpenGroupsToFill(matching config.MatchingConfig) []OpenGroup {
+	if !matching.FillGroups {
+		return nil
+	}
+	return s.getAllOpenGroups(matching.GroupSize)
+}
+
+// Main functions
+
+func (s *MemoryStore) RunMatching(ctx context.Context, trigger string) (MatchingRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runMatching(ctx, trigger, 0)
+}
+
+func (s *MemoryStore) TriggerMatching(ctx context.Context, adminID int64) (MatchingRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionRunMatching, nil); err != nil {
+		return MatchingRun{}, err
+	}
+	return s.runMatching(ctx, MatchingTriggerManual, adminID)
+}
+
+func (s *MemoryStore) GetLatestMatchingRun(ctx context.Context) (MatchingRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.matchingRuns[s.lastMatchingRunID]
+	if !ok {
+		return MatchingRun{}, http_error.NotFoundError
+	}
+	return run, nil
+}
+
+func (s *MemoryStore) GetMatchingRunsPage(ctx context.Context, adminID int64, page PageQuery) (Page[MatchingRun], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionListMatchingRuns, nil); err != nil {
+		return Page[MatchingRun]{}, err
+	}
+	runs := make([]MatchingRun, 0, len(s.matchingRuns))
+	for _, id := range sortedIDs(s.matchingRuns) {
+		runs = append(runs, s.matchingRuns[id])
+	}
+	return PaginateItems(runs, MatchingRunSorting, page)
+}
+
+func (s *MemoryStore) DryRunMatching(ctx context.Context, adminID int64, strategy string) (MatchingDryRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(adminID, ActionDryRunMatching, nil); err != nil {
+		return MatchingDryRun{}, err
+	}
+	matching := s.matching
+	if strategy != "" {
+		matching.Strategy = strategy
+	}
+	return DryRunPlan(s.getAllLoadedMatchRequests(), s.getOpenGroupsToFill(matching), matching), nil
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"time"
+)
+
+// Helper functions
+
+func validateTopics(table string, topics []string) error {
+	if len(topics) == 0 || !containsAll(refTopics, topics) {
+		return checkViolation(table, "topics")
+	}
+	return nil
+}
+
+// Mirrors model.AuthorizeUserID. Missing users have no role and are only allowed through relations
+func (s *MemoryStore) authorize(userID int64, action Action, relations []Relation) error {
+	user, ok := s.users[userID]
+	if !ok {
+		user = User{ID: userID}
+	}
+	return Authorize(user, action, relations)
+}
+
+func (s *MemoryStore) getProvider(userID int64) (Provider, error) {
+	providerSetting, ok := s.providerSettings[userID]
+	if !ok {
+		return Provider{}, http_error.NotFoundError
+	}
+	user, err := s.getUser(userID)
+	if err != nil {
+		return Provider{}, err
+	}
+	return Provider{User: user.Public(), Setting: providerSetting}, nil
+}
+
+// Counsel
+
+func (s *MemoryStore) GetCounselRequestsPage(ctx context.Context, topics []string, userID int64, page PageQuery) (Page[CounselRequest], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(userID, ActionListCounselRequests, nil); err != nil {
+		return Page[CounselRequest]{}, err
+	}
+	counselRequests := make([]CounselRequest, 0)
+	for _, id := range sortedIDs(s.counselRequests) {
+		if counselRequest := s.counselRequests[id]; containsAll(counselRequest.Topics, topics) {
+			counselRequests = append(counselRequests, counselRequest)
+		}
+	}
+	return PaginateItems(counselRequests, CounselRequestSorting, page)
+}
+
+func (s *MemoryStore) GetCounselRequest(ctx context.Context, recipientUserID int64, userID int64) (CounselRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(userID, ActionViewCounselRequest, CounselRequestRelations(userID, recipientUserID)); err != nil {
+		return CounselRequest{}, err
+	}
+	counselRequest, ok := s.counselRequests[recipientUserID]
+	if !ok {
+		return CounselRequest{}, http_error.NotFoundError
+	}
+	return counselRequest, nil
+}
+
+func (s *MemoryStore) AddUpdateCounselRequest(ctx context.Context, counselRequest CounselRequest, userID int64) (CounselRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counselRequest.UserID = userID
+	counselRequest.LastUpdated = time.Now()
+	if err := validateTopics("wn_counsel_request", counselRequest.Topics); err != nil {
+		return CounselRequest{}, err
+	}
+	if _, ok := s.users[userID]; !ok {
+		return CounselRequest{}, foreignKeyViolation("wn_counsel_request", "user_id")
+	}
+	s.counselRequests[userID] = counselRequest
+	return counselRequest, nil
+}
+
+func (s *MemoryStore) DeleteCounselRequest(ctx context.Context, userID int64) (CounselRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counselRequests, userID)
+	return CounselRequest{UserID: userID}, nil
+}
+
+func (s *MemoryStore) AcceptCounselRequest(ctx context.Context, recipientUserID int64, providerUserID int64) (GroupWithUsers, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.authorize(providerUserID, ActionAcceptCounselRequest, nil); err != nil {
+		return GroupWithUsers{}, err
+	}
+	if _, ok := s.counselRequests[recipientUserID]; !ok {
+		return GroupWithUsers{}, http_error.NotFoundError
+	}
+	group := Group{
+		GroupName:        "Counsel Room",
+		GroupDescription: "Welcome to your new Counsel Room",
+		Category:         "COUNSEL",
+	}
+	groupWithUsers, err := s.addGroupWithUserIDs(group, []int64{providerUserID, recipientUserID})
+	if err != nil {
+		return GroupWithUsers{}, err
+	}
+	delete(s.counselRequests, recipientUserID)
+	return groupWithUsers, nil
+}
+
+// Provider
+
+func (s *MemoryStore) GetProviderSetting(ctx context.Context, userID int64) (ProviderSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	providerSetting, ok := s.providerSettings[userID]
+	if !ok {
+		return ProviderSetting{}, http_error.NotFoundError
+	}
+	return providerSetting, nil
+}
+
+func (s *MemoryStore) GetProvidersPage(ctx context.Context, topics []string, page PageQuery) (Page[Provider], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	providers := make([]Provider, 0)
+	for _, id := range sortedIDs(s.providerSettings) {
+		provider, err := s.getProvider(id)
+		if err != nil {
+			return Page[Provider]{}, err
+		}
+		if IsProvider(s.users[id]) && containsAll(provider.Setting.Topics, topics) {
+			providers = append(providers, provider)
+		}
+	}
+	return PaginateItems(providers, ProviderSorting, page)
+}
+
+func (s *MemoryStore) GetProviderWithEvents(ctx context.Context, userID int64) (ProviderWithEvents, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	provider, err := s.getProvider(userID)
+	if err != nil {
+		return ProviderWithEvents{}, err
+	}
+	return ProviderWithEvents{Provider: provider, Events: s.getAllEventsOfUser(userID)}, nil
+}
+
+func (s *MemoryStore) AddUpdateProviderSettingOfUser(ctx context.Context, providerSetting ProviderSetting, userID int64) (ProviderSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	providerSetting.UserID = userID
+	if err := s.authorize(userID, ActionUpdateProviderSetting, nil); err != nil {
+		return ProviderSetting{}, err
+	}
+	if err := validateTopics("wn_provider_setting", providerSetting.Topics); err != nil {
+		return ProviderSetting{}, err
+	}
+	s.providerSettings[userID] = providerSetting
+	return providerSetting, nil
+}
+
+func (s *MemoryStore) DeleteProviderSettingOfUser(ctx context.Context, userID int64) (ProviderSetting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.providerSettings, userID)
+	return ProviderSetting{UserID: userID}, nil
+}
@@ -0,0 +1,447 @@
+package store
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"sort"
+	"strings"
+)
+
+// Helper functions
+
+func validateUser(user User) error {
+	if !strings.HasSuffix(user.Email, "@u.nus.edu") {
+		return checkViolation("wn_user", "email")
+	}
+	if !contains(refFaculty, user.Faculty) {
+		return checkViolation("wn_user", "faculty")
+	}
+	if user.FirstName == "" {
+		return checkViolation("wn_user", "first_name")
+	}
+	if !contains(refGender, user.Gender) {
+		return checkViolation("wn_user", "gender")
+	}
+	if user.LastName == "" {
+		return checkViolation("wn_user", "last_name")
+	}
+	if user.PasswordHash == "" {
+		return checkViolation("wn_user", "password_hash")
+	}
+	if !contains(refUserRole, user.UserRole) {
+		return checkViolation("wn_user", "user_role")
+	}
+	return nil
+}
+
+func (s *MemoryStore) putUser(user User) error {
+	if err := validateUser(user); err != nil {
+		return err
+	}
+	for id, u := range s.users {
+		if id != user.ID && u.Email == user.Email {
+			return uniqueViolation("wn_user", "email")
+		}
+	}
+	s.users[user.ID] = user
+	return nil
+}
+
+func (s *MemoryStore) getUser(userID int64) (User, error) {
+	user, ok := s.users[userID]
+	if !ok {
+		return User{}, http_error.NotFoundError
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) getAllUsersWhere(keep func(User) bool) []User {
+	users := make([]User, 0)
+	for _, id := range sortedIDs(s.users) {
+		if user := s.users[id]; keep(user) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func (s *MemoryStore) getUsersOfMemberships(memberships []membership, itemID int64) []User {
+	users := make([]User, 0)
+	for _, m := range memberships {
+		if m.itemID == itemID {
+			users = append(users, s.users[m.userID])
+		}
+	}
+	return users
+}
+
+func (s *MemoryStore) checkOwnsNoEvent(userID int64) error {
+	for _, id := range sortedIDs(s.events) {
+		if s.events[id].OwnerID == userID {
+			return foreignKeyDeleteViolation("wn_user", "wn_event", "owner_id")
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) deleteUser(userID int64) error {
+	for _, id := range sortedIDs(s.groups) {
+		if s.groups[id].OwnerID == userID {
+			return foreignKeyDeleteViolation("wn_user", "wn_group", "owner_id")
+		}
+	}
+	if err := s.checkOwnsNoEvent(userID); err != nil {
+		return err
+	}
+	s.deleteSessionsOfUser(userID)
+	s.deleteTokensOfUser(userID, "")
+	s.userGroups = removeMemberships(s.userGroups, func(m membership) bool { return m.userID != userID })
+	s.userEvents = removeMemberships(s.userEvents, func(m membership) bool { return m.userID != userID })
+	for id, joinRequest := range s.joinRequests {
+		if joinRequest.UserID == userID {
+			delete(s.joinRequests, id)
+		}
+	}
+	messages := make([]Message, 0, len(s.messages))
+	for _, message := range s.messages {
+		if message.UserID != userID {
+			messages = append(messages, message)
+		}
+	}
+	s.messages = messages
+	s.deleteMatchSetting(userID)
+	delete(s.providerSettings, userID)
+	delete(s.counselRequests, userID)
+	for id, booking := range s.bookings {
+		if booking.RecipientID == userID || booking.ProviderID == userID || booking.ApproveBy == userID {
+			delete(s.bookings, id)
+		}
+	}
+	for id, report := range s.reports {
+		if report.ReporterID == userID {
+			delete(s.reports, id)
+		}
+	}
+	for id, run := range s.matchingRuns {
+		if run.TriggeredBy == userID {
+			run.TriggeredBy = 0
+			s.matchingRuns[id] = run
+		}
+	}
+	delete(s.users, userID)
+	return nil
+}
+
+// Main functions
+
+func (s *MemoryStore) GetUser(ctx context.Context, userID int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getUser(userID)
+}
+
+func (s *MemoryStore) GetUserWithGroups(ctx context.Context, userID int64) (UserWithGroups, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, err := s.getUser(userID)
+	if err != nil {
+		return UserWithGroups{}, err
+	}
+	return UserWithGroups{User: user.Public(), Groups: s.getAllGroupsOfUser(userID)}, nil
+}
+
+func (s *MemoryStore) GetAllUsers(ctx context.Context) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getAllUsersWhere(func(User) bool { return true }), nil
+}
+
+func (s *MemoryStore) SearchUsers(ctx context.Context, filter UserFilter, page PageQuery) (Page[User], error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return Page[User]{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := s.getAllUsersWhere(func(user User) bool {
+		if !filter.MatchUser(user) {
+			return false
+		}
+		if len(filter.Topics) == 0 {
+			return true
+		}
+		providerSetting, ok := s.providerSettings[user.ID]
+		return ok && containsAll(providerSetting.Topics, filter.Topics)
+	})
+	return PaginateItems(users, UserSorting, page)
+}
+
+func (s *MemoryStore) GetAllUsersOfGroup(ctx context.Context, groupID int64) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getUsersOfMemberships(s.userGroups, groupID), nil
+}
+
+func (s *MemoryStore) GetAllUsersOfEvent(ctx context.Context, eventID int64) ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getUsersOfMemberships(s.userEvents, eventID), nil
+}
+
+func (s *MemoryStore) FindUser(ctx context.Context, email string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := s.getAllUsersWhere(func(user User) bool { return user.Email == email })
+	if len(users) == 0 {
+		return User{}, http_error.NotFoundError
+	}
+	return users[0], nil
+}
+
+func (s *MemoryStore) AddUser(ctx context.Context, newUser User) (User, error) {
+	newUser, err := newUser.HashPassword()
+	if err != nil {
+		return User{}, err
+	}
+	newUser.Verified = false
+	newUser.Suspended = false
+	newUser.EmailVerified = false
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUserID++
+	newUser.ID = s.lastUserID
+	if err := s.putUser(newUser); err != nil {
+		return User{}, err
+	}
+	return newUser, nil
+}
+
+func (s *MemoryStore) UpdateUser(ctx context.Context, updatedUser User, userID int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	targetUser, err := s.getUser(userID)
+	if err != nil {
+		return User{}, err
+	}
+	updatedUser, err = updatedUser.MergeUser(targetUser)
+	if err != nil {
+		return User{}, err
+	}
+	if err := s.putUser(updatedUser); err != nil {
+		return User{}, err
+	}
+	return updatedUser, nil
+}
+
+// Mirrors model.DeleteUser. Owned events are all that can stop the deletion once the groups are
+// left, so they are checked first to leave the groups untouched, as the rolled back transaction does
+func (s *MemoryStore) DeleteUser(ctx context.Context, userID int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOwnsNoEvent(userID); err != nil {
+		return User{}, err
+	}
+	for _, group := range s.getAllGroupsOfUser(userID) {
+		if _, err := s.leaveGroup(group.ID, userID); err != nil {
+			return User{}, err
+		}
+	}
+	if err := s.deleteUser(userID); err != nil {
+		return User{}, err
+	}
+	return User{ID: userID}, nil
+}
+
+// Session
+
+func (s *MemoryStore) deleteSessionsOfUser(userID int64) {
+	for key, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, key)
+		}
+	}
+}
+
+func (s *MemoryStore) GetUserIDFromSessionKey(ctx context.Context, sessionKey string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sessionKeyHash := HashSessionKey(sessionKey)
+	session, ok := s.sessions[sessionKeyHash]
+	if !ok {
+		return 0, http_error.UnauthorizedError
+	}
+	now := s.Now()
+	if session.Expired(now) {
+		delete(s.sessions, sessionKeyHash)
+		return 0, http_error.UnauthorizedError
+	}
+	if session.NeedsRefresh(now) {
+		session.LastSeen = now
+		session.ExpiresAt = now.Add(SessionTTL)
+		s.sessions[sessionKeyHash] = session
+	}
+	return session.UserID, nil
+}
+
+func (s *MemoryStore) GetAllSessionsOfUser(ctx context.Context, userID int64) ([]Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.Now()
+	sessions := make([]Session, 0)
+	for _, session := range s.sessions {
+		if session.UserID == userID && !session.Expired(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeen.Equal(sessions[j].LastSeen) {
+			return sessions[i].LastSeen.After(sessions[j].LastSeen)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+func (s *MemoryStore) CreateNewSession(ctx context.Context, userID int64, deviceLabel string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[userID]; !ok {
+		return "", foreignKeyViolation("wn_session", "user_id")
+	}
+	newSessionKey, err := GenerateNewSessionKey()
+	if err != nil {
+		return "", err
+	}
+	now := s.Now()
+	s.lastSessionID++
+	newSession := Session{
+		ID:             s.lastSessionID,
+		SessionKeyHash: HashSessionKey(newSessionKey),
+		UserID:         userID,
+		DeviceLabel:    TruncateDeviceLabel(deviceLabel),
+		CreatedAt:      now,
+		LastSeen:       now,
+		ExpiresAt:      now.Add(SessionTTL),
+	}
+	s.sessions[newSession.SessionKeyHash] = newSession
+	return newSessionKey, nil
+}
+
+func (s *MemoryStore) DeleteSessionWithSessionKey(ctx context.Context, sessionKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, HashSessionKey(sessionKey))
+	return nil
+}
+
+func (s *MemoryStore) DeleteSessionOfUser(ctx context.Context, sessionID int64, userID int64) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, session := range s.sessions {
+		if session.ID == sessionID && session.UserID == userID {
+			delete(s.sessions, key)
+			return session, nil
+		}
+	}
+	return Session{}, http_error.NotFoundError
+}
+
+func (s *MemoryStore) DeleteAllSessionsOfUser(ctx context.Context, userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleteSessionsOfUser(userID)
+	return nil
+}
+
+// Token
+
+// Deletes the tokens of the user with purpose, or all of them if purpose is empty
+func (s *MemoryStore) deleteTokensOfUser(userID int64, purpose string) {
+	for key, token := range s.tokens {
+		if token.UserID == userID && (purpose == "" || token.Purpose == purpose) {
+			delete(s.tokens, key)
+		}
+	}
+}
+
+// Tokens are only deleted once the step they authorize has succeeded, matching the
+// rollback of the postgres transaction. Expired tokens are deleted as soon as they are used
+func (s *MemoryStore) getUserToken(token string, purpose string) (UserToken, error) {
+	userToken, ok := s.tokens[HashToken(token)]
+	if !ok || userToken.Purpose != purpose {
+		return UserToken{}, InvalidTokenError
+	}
+	if !s.Now().Before(userToken.ExpiresAt) {
+		delete(s.tokens, userToken.TokenHash)
+		return UserToken{}, InvalidTokenError
+	}
+	return userToken, nil
+}
+
+func (s *MemoryStore) CreateUserToken(ctx context.Context, userID int64, purpose string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[userID]; !ok {
+		return "", foreignKeyViolation("wn_user_token", "user_id")
+	}
+	if _, ok := TokenTTL[purpose]; !ok {
+		return "", checkViolation("wn_user_token", "purpose")
+	}
+	token, err := GenerateToken(TokenBytes)
+	if err != nil {
+		return "", err
+	}
+	s.deleteTokensOfUser(userID, purpose)
+	userToken := UserToken{
+		TokenHash: HashToken(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: s.Now().Add(TokenTTL[purpose]),
+	}
+	s.tokens[userToken.TokenHash] = userToken
+	return token, nil
+}
+
+func (s *MemoryStore) VerifyEmail(ctx context.Context, token string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userToken, err := s.getUserToken(token, TokenVerifyEmail)
+	if err != nil {
+		return User{}, err
+	}
+	user, err := s.getUser(userToken.UserID)
+	if err != nil {
+		return User{}, err
+	}
+	delete(s.tokens, userToken.TokenHash)
+	user.EmailVerified = true
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) ResetPassword(ctx context.Context, token string, password string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userToken, err := s.getUserToken(token, TokenResetPassword)
+	if err != nil {
+		return User{}, err
+	}
+	if password == "" {
+		return User{}, http_error.NewValidationError("password", "is required")
+	}
+	user, err := s.getUser(userToken.UserID)
+	if err != nil {
+		return User{}, err
+	}
+	user.Password = password
+	user, err = user.HashPassword()
+	if err != nil {
+		return User{}, err
+	}
+	delete(s.tokens, userToken.TokenHash)
+	s.users[user.ID] = user
+	s.deleteSessionsOfUser(user.ID)
+	return user, nil
+}
@@ -0,0 +1,595 @@
+package store
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/db/migration"
+	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/metrics"
+
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresStore implements Store with the query functions of db/model. Every method records
+// how long its queries took in metrics.DBQueryDuration, and gives up once its context is done
+// or StatementTimeout has passed, whichever comes first
+type PostgresStore struct {
+	DB               *sql.DB
+	StatementTimeout time.Duration // 0 leaves queries to the deadline of their context
+	Matching         config.MatchingConfig
+}
+
+func NewPostgresStore(db *sql.DB, statementTimeout time.Duration, matching config.MatchingConfig) *PostgresStore {
+	return &PostgresStore{DB: db, StatementTimeout: statementTimeout, Matching: matching}
+}
+
+// Starts the store operation query, returning the context its queries run with and a function
+// to call once it is done
+func (s *PostgresStore) begin(ctx context.Context, query string) (context.Context, func()) {
+	return s.beginWithTimeout(ctx, query, s.StatementTimeout)
+}
+
+// Same as begin, but for operations such as matching runs that take longer than StatementTimeout
+func (s *PostgresStore) beginWithTimeout(ctx context.Context, query string, timeout time.Duration) (context.Context, func()) {
+	start := time.Now()
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {
+		cancel()
+		metrics.ObserveQuery(query, start)
+	}
+}
+
+// Health
+
+func (s *PostgresStore) Ping(ctx context.Context) error {
+	ctx, done := s.begin(ctx, "Ping")
+	defer done()
+	return s.DB.PingContext(ctx)
+}
+
+func (s *PostgresStore) GetSchemaStatus(ctx context.Context) (migration.Status, error) {
+	ctx, done := s.begin(ctx, "GetSchemaStatus")
+	defer done()
+	return migration.GetStatus(ctx, s.DB)
+}
+
+// User
+
+func (s *PostgresStore) GetUser(ctx context.Context, userID int64) (User, error) {
+	ctx, done := s.begin(ctx, "GetUser")
+	defer done()
+	return model.GetUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetUserWithGroups(ctx context.Context, userID int64) (UserWithGroups, error) {
+	ctx, done := s.begin(ctx, "GetUserWithGroups")
+	defer done()
+	return model.GetUserWithGroups(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetAllUsers(ctx context.Context) ([]User, error) {
+	ctx, done := s.begin(ctx, "GetAllUsers")
+	defer done()
+	return model.GetAllUsers(ctx, s.DB)
+}
+
+func (s *PostgresStore) SearchUsers(ctx context.Context, filter UserFilter, page PageQuery) (Page[User], error) {
+	ctx, done := s.begin(ctx, "SearchUsers")
+	defer done()
+	return model.SearchUsers(ctx, s.DB, filter, page)
+}
+
+func (s *PostgresStore) GetAllUsersOfGroup(ctx context.Context, groupID int64) ([]User, error) {
+	ctx, done := s.begin(ctx, "GetAllUsersOfGroup")
+	defer done()
+	return model.GetAllUsersOfGroup(ctx, s.DB, groupID)
+}
+
+func (s *PostgresStore) GetAllUsersOfEvent(ctx context.Context, eventID int64) ([]User, error) {
+	ctx, done := s.begin(ctx, "GetAllUsersOfEvent")
+	defer done()
+	return model.GetAllUsersOfEvent(ctx, s.DB, eventID)
+}
+
+func (s *PostgresStore) FindUser(ctx context.Context, email string) (User, error) {
+	ctx, done := s.begin(ctx, "FindUser")
+	defer done()
+	return model.FindUser(ctx, s.DB, email)
+}
+
+func (s *PostgresStore) AddUser(ctx context.Context, newUser User) (User, error) {
+	ctx, done := s.begin(ctx, "AddUser")
+	defer done()
+	return model.AddUser(ctx, s.DB, newUser)
+}
+
+func (s *PostgresStore) UpdateUser(ctx context.Context, updatedUser User, userID int64) (User, error) {
+	ctx, done := s.begin(ctx, "UpdateUser")
+	defer done()
+	return model.UpdateUser(ctx, s.DB, updatedUser, userID)
+}
+
+func (s *PostgresStore) DeleteUser(ctx context.Context, userID int64) (User, error) {
+	ctx, done := s.begin(ctx, "DeleteUser")
+	defer done()
+	return model.DeleteUser(ctx, s.DB, userID)
+}
+
+// Session
+
+func (s *PostgresStore) GetUserIDFromSessionKey(ctx context.Context, sessionKey string) (int64, error) {
+	ctx, done := s.begin(ctx, "GetUserIDFromSessionKey")
+	defer done()
+	return model.GetUserIDFromSessionKey(ctx, s.DB, sessionKey)
+}
+
+func (s *PostgresStore) GetAllSessionsOfUser(ctx context.Context, userID int64) ([]Session, error) {
+	ctx, done := s.begin(ctx, "GetAllSessionsOfUser")
+	defer done()
+	return model.GetAllSessionsOfUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) CreateNewSession(ctx context.Context, userID int64, deviceLabel string) (string, error) {
+	ctx, done := s.begin(ctx, "CreateNewSession")
+	defer done()
+	return model.CreateNewSession(ctx, s.DB, userID, deviceLabel)
+}
+
+func (s *PostgresStore) DeleteSessionWithSessionKey(ctx context.Context, sessionKey string) error {
+	ctx, done := s.begin(ctx, "DeleteSessionWithSessionKey")
+	defer done()
+	return model.DeleteSessionWithSessionKey(ctx, s.DB, sessionKey)
+}
+
+func (s *PostgresStore) DeleteSessionOfUser(ctx context.Context, sessionID int64, userID int64) (Session, error) {
+	ctx, done := s.begin(ctx, "DeleteSessionOfUser")
+	defer done()
+	return model.DeleteSessionOfUser(ctx, s.DB, sessionID, userID)
+}
+
+func (s *PostgresStore) DeleteAllSessionsOfUser(ctx context.Context, userID int64) error {
+	ctx, done := s.begin(ctx, "DeleteAllSessionsOfUser")
+	defer done()
+	return model.DeleteAllSessionsOfUser(ctx, s.DB, userID)
+}
+
+// Token
+
+func (s *PostgresStore) CreateUserToken(ctx context.Context, userID int64, purpose string) (string, error) {
+	ctx, done := s.begin(ctx, "CreateUserToken")
+	defer done()
+	return model.CreateUserToken(ctx, s.DB, userID, purpose)
+}
+
+func (s *PostgresStore) VerifyEmail(ctx context.Context, token string) (User, error) {
+	ctx, done := s.begin(ctx, "VerifyEmail")
+	defer done()
+	return model.VerifyEmail(ctx, s.DB, token)
+}
+
+func (s *PostgresStore) ResetPassword(ctx context.Context, token string, password string) (User, error) {
+	ctx, done := s.begin(ctx, "ResetPassword")
+	defer done()
+	return model.ResetPassword(ctx, s.DB, token, password)
+}
+
+// Group
+
+func (s *PostgresStore) GetGroup(ctx context.Context, groupID int64) (Group, error) {
+	ctx, done := s.begin(ctx, "GetGroup")
+	defer done()
+	return model.GetGroup(ctx, s.DB, groupID)
+}
+
+func (s *PostgresStore) GetGroupWithUsers(ctx context.Context, groupID int64) (GroupWithUsers, error) {
+	ctx, done := s.begin(ctx, "GetGroupWithUsers")
+	defer done()
+	return model.GetGroupWithUsers(ctx, s.DB, groupID)
+}
+
+func (s *PostgresStore) GetAllGroupsOfUser(ctx context.Context, userID int64) ([]Group, error) {
+	ctx, done := s.begin(ctx, "GetAllGroupsOfUser")
+	defer done()
+	return model.GetAllGroupsOfUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetGroupsPageOfUser(ctx context.Context, userID int64, page PageQuery) (Page[Group], error) {
+	ctx, done := s.begin(ctx, "GetGroupsPageOfUser")
+	defer done()
+	return model.GetGroupsPageOfUser(ctx, s.DB, userID, page)
+}
+
+func (s *PostgresStore) AddGroupWithUserIDs(ctx context.Context, group Group, userIDs []int64) (GroupWithUsers, error) {
+	ctx, done := s.begin(ctx, "AddGroupWithUserIDs")
+	defer done()
+	return model.AddGroupWithUserIDs(ctx, s.DB, group, userIDs)
+}
+
+func (s *PostgresStore) AddUserToGroup(ctx context.Context, groupID int64, userID int64) error {
+	ctx, done := s.begin(ctx, "AddUserToGroup")
+	defer done()
+	return model.AddUserToGroup(ctx, s.DB, groupID, userID)
+}
+
+func (s *PostgresStore) UpdateGroup(ctx context.Context, updatedGroup Group, groupID int64, userID int64) (Group, error) {
+	ctx, done := s.begin(ctx, "UpdateGroup")
+	defer done()
+	return model.UpdateGroup(ctx, s.DB, updatedGroup, groupID, userID)
+}
+
+func (s *PostgresStore) LeaveGroup(ctx context.Context, groupID int64, userID int64) (GroupWithUsers, error) {
+	ctx, done := s.begin(ctx, "LeaveGroup")
+	defer done()
+	return model.LeaveGroup(ctx, s.DB, groupID, userID)
+}
+
+func (s *PostgresStore) LeaveAllGroups(ctx context.Context, userID int64) ([]GroupWithUsers, error) {
+	ctx, done := s.begin(ctx, "LeaveAllGroups")
+	defer done()
+	return model.LeaveAllGroups(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) IsUserInGroup(ctx context.Context, userID int64, groupID int64) (bool, error) {
+	ctx, done := s.begin(ctx, "IsUserInGroup")
+	defer done()
+	return model.IsUserInGroup(ctx, s.DB, userID, groupID)
+}
+
+// Join
+
+func (s *PostgresStore) GetLoadedJoinRequest(ctx context.Context, joinRequestID int64, userID int64) (LoadedJoinRequest, error) {
+	ctx, done := s.begin(ctx, "GetLoadedJoinRequest")
+	defer done()
+	return model.GetLoadedJoinRequest(ctx, s.DB, joinRequestID, userID)
+}
+
+func (s *PostgresStore) GetLoadedJoinRequestsPageOfUser(ctx context.Context, userID int64, scope string, page PageQuery) (Page[LoadedJoinRequest], error) {
+	ctx, done := s.begin(ctx, "GetLoadedJoinRequestsPageOfUser")
+	defer done()
+	return model.GetLoadedJoinRequestsPageOfUser(ctx, s.DB, userID, scope, page)
+}
+
+func (s *PostgresStore) AddJoinRequest(ctx context.Context, groupID int64, userID int64) (JoinRequest, error) {
+	ctx, done := s.begin(ctx, "AddJoinRequest")
+	defer done()
+	return model.AddJoinRequest(ctx, s.DB, groupID, userID)
+}
+
+func (s *PostgresStore) RespondJoinRequest(ctx context.Context, joinRequestID int64, userID int64, approve bool) (JoinRequestRespond, error) {
+	ctx, done := s.begin(ctx, "RespondJoinRequest")
+	defer done()
+	return model.RespondJoinRequest(ctx, s.DB, joinRequestID, userID, approve)
+}
+
+func (s *PostgresStore) DeleteJoinRequest(ctx context.Context, joinRequestID int64, userID int64) (JoinRequest, error) {
+	ctx, done := s.begin(ctx, "DeleteJoinRequest")
+	defer done()
+	return model.DeleteJoinRequest(ctx, s.DB, joinRequestID, userID)
+}
+
+// Match
+
+func (s *PostgresStore) GetMatchSettingOfUser(ctx context.Context, userID int64) (MatchSetting, error) {
+	ctx, done := s.begin(ctx, "GetMatchSettingOfUser")
+	defer done()
+	return model.GetMatchSettingOfUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) AddUpdateMatchSettingOfUser(ctx context.Context, matchSetting MatchSetting, userID int64) (MatchSetting, error) {
+	ctx, done := s.begin(ctx, "AddUpdateMatchSettingOfUser")
+	defer done()
+	return model.AddUpdateMatchSettingOfUser(ctx, s.DB, matchSetting, userID)
+}
+
+func (s *PostgresStore) DeleteMatchSettingOfUser(ctx context.Context, userID int64) (MatchSetting, error) {
+	ctx, done := s.begin(ctx, "DeleteMatchSettingOfUser")
+	defer done()
+	return model.DeleteMatchSettingOfUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetMatchRequestCount(ctx context.Context) (int64, error) {
+	ctx, done := s.begin(ctx, "GetMatchRequestCount")
+	defer done()
+	return model.GetMatchRequestCount(ctx, s.DB)
+}
+
+func (s *PostgresStore) GetQueuedMatchRequestOfUser(ctx context.Context, userID int64) (QueuedMatchRequest, error) {
+	ctx, done := s.begin(ctx, "GetQueuedMatchRequestOfUser")
+	defer done()
+	return model.GetQueuedMatchRequestOfUser(ctx, s.DB, s.Matching, userID)
+}
+
+func (s *PostgresStore) AddMatchRequest(ctx context.Context, userID int64) (MatchRequest, error) {
+	ctx, done := s.begin(ctx, "AddMatchRequest")
+	defer done()
+	return model.AddMatchRequest(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) DeleteMatchRequestOfUser(ctx context.Context, userID int64) (MatchRequest, error) {
+	ctx, done := s.begin(ctx, "DeleteMatchRequestOfUser")
+	defer done()
+	return model.DeleteMatchRequestOfUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetCompatibilityBreakdown(ctx context.Context, groupID int64, userID int64) (CompatibilityBreakdown, error) {
+	ctx, done := s.begin(ctx, "GetCompatibilityBreakdown")
+	defer done()
+	return model.GetCompatibilityBreakdown(ctx, s.DB, s.Matching.Weights, groupID, userID)
+}
+
+// Matching
+
+func (s *PostgresStore) RunMatching(ctx context.Context, trigger string) (MatchingRun, error) {
+	ctx, done := s.beginWithTimeout(ctx, "RunMatching", s.Matching.RunTimeout)
+	defer done()
+	return model.RunMatching(ctx, s.DB, s.Matching, trigger, 0)
+}
+
+func (s *PostgresStore) TriggerMatching(ctx context.Context, adminID int64) (MatchingRun, error) {
+	ctx, done := s.beginWithTimeout(ctx, "TriggerMatching", s.Matching.RunTimeout)
+	defer done()
+	return model.TriggerMatching(ctx, s.DB, s.Matching, adminID)
+}
+
+func (s *PostgresStore) GetLatestMatchingRun(ctx context.Context) (MatchingRun, error) {
+	ctx, done := s.begin(ctx, "GetLatestMatchingRun")
+	defer done()
+	return model.GetLatestMatchingRun(ctx, s.DB)
+}
+
+func (s *PostgresStore) GetMatchingRunsPage(ctx context.Context, adminID int64, page PageQuery) (Page[MatchingRun], error) {
+	ctx, done := s.begin(ctx, "GetMatchingRunsPage")
+	defer done()
+	return model.GetMatchingRunsPage(ctx, s.DB, adminID, page)
+}
+
+func (s *PostgresStore) DryRunMatching(ctx context.Context, adminID int64, strategy string) (MatchingDryRun, error) {
+	ctx, done := s.beginWithTimeout(ctx, "DryRunMatching", s.Matching.RunTimeout)
+	defer done()
+	return model.TriggerDryRunMatching(ctx, s.DB, s.Matching, adminID, strategy)
+}
+
+// Counsel
+
+func (s *PostgresStore) GetCounselRequestsPage(ctx context.Context, topics []string, userID int64, page PageQuery) (Page[CounselRequest], error) {
+	ctx, done := s.begin(ctx, "GetCounselRequestsPage")
+	defer done()
+	return model.GetCounselRequestsPage(ctx, s.DB, topics, userID, page)
+}
+
+func (s *PostgresStore) GetCounselRequest(ctx context.Context, recipientUserID int64, userID int64) (CounselRequest, error) {
+	ctx, done := s.begin(ctx, "GetCounselRequest")
+	defer done()
+	return model.GetCounselRequest(ctx, s.DB, recipientUserID, userID)
+}
+
+func (s *PostgresStore) AddUpdateCounselRequest(ctx context.Context, counselRequest CounselRequest, userID int64) (CounselRequest, error) {
+	ctx, done := s.begin(ctx, "AddUpdateCounselRequest")
+	defer done()
+	return model.AddUpdateCounselRequest(ctx, s.DB, counselRequest, userID)
+}
+
+func (s *PostgresStore) DeleteCounselRequest(ctx context.Context, userID int64) (CounselRequest, error) {
+	ctx, done := s.begin(ctx, "DeleteCounselRequest")
+	defer done()
+	return model.DeleteCounselRequest(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) AcceptCounselRequest(ctx context.Context, recipientUserID int64, providerUserID int64) (GroupWithUsers, error) {
+	ctx, done := s.begin(ctx, "AcceptCounselRequest")
+	defer done()
+	return model.AcceptCounselRequest(ctx, s.DB, recipientUserID, providerUserID)
+}
+
+// Event
+
+func (s *PostgresStore) GetEvent(ctx context.Context, eventID int64) (Event, error) {
+	ctx, done := s.begin(ctx, "GetEvent")
+	defer done()
+	return model.GetEvent(ctx, s.DB, eventID)
+}
+
+func (s *PostgresStore) GetEventWithUsers(ctx context.Context, eventID int64) (EventWithUsers, error) {
+	ctx, done := s.begin(ctx, "GetEventWithUsers")
+	defer done()
+	return model.GetEventWithUsers(ctx, s.DB, eventID)
+}
+
+func (s *PostgresStore) GetAllEventsOfUser(ctx context.Context, userID int64) ([]Event, error) {
+	ctx, done := s.begin(ctx, "GetAllEventsOfUser")
+	defer done()
+	return model.GetAllEventsOfUser(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetEventsPageOfUser(ctx context.Context, userID int64, page PageQuery) (Page[Event], error) {
+	ctx, done := s.begin(ctx, "GetEventsPageOfUser")
+	defer done()
+	return model.GetEventsPageOfUser(ctx, s.DB, userID, page)
+}
+
+func (s *PostgresStore) AddEventWithUserIDs(ctx context.Context, event Event, userIDs []int64) (EventWithUsers, error) {
+	ctx, done := s.begin(ctx, "AddEventWithUserIDs")
+	defer done()
+	return model.AddEventWithUserIDs(ctx, s.DB, event, userIDs)
+}
+
+func (s *PostgresStore) AddUserToEventAuthorized(ctx context.Context, userID int64, eventID int64, adderID int64) (EventWithUsers, error) {
+	ctx, done := s.begin(ctx, "AddUserToEventAuthorized")
+	defer done()
+	return model.AddUserToEventAuthorized(ctx, s.DB, userID, eventID, adderID)
+}
+
+func (s *PostgresStore) UpdateEvent(ctx context.Context, updatedEvent Event, eventID int64, userID int64) (Event, error) {
+	ctx, done := s.begin(ctx, "UpdateEvent")
+	defer done()
+	return model.UpdateEvent(ctx, s.DB, updatedEvent, eventID, userID)
+}
+
+func (s *PostgresStore) LeaveDeleteEvent(ctx context.Context, eventID int64, userID int64) (EventWithUsers, error) {
+	ctx, done := s.begin(ctx, "LeaveDeleteEvent")
+	defer done()
+	return model.LeaveDeleteEvent(ctx, s.DB, eventID, userID)
+}
+
+func (s *PostgresStore) LeaveDeleteAllEvents(ctx context.Context, userID int64) ([]EventWithUsers, error) {
+	ctx, done := s.begin(ctx, "LeaveDeleteAllEvents")
+	defer done()
+	return model.LeaveDeleteAllEvents(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) CreateGroupDeleteEvent(ctx context.Context, eventID int64, userID int64) (GroupWithUsers, error) {
+	ctx, done := s.begin(ctx, "CreateGroupDeleteEvent")
+	defer done()
+	return model.CreateGroupDeleteEvent(ctx, s.DB, eventID, userID)
+}
+
+// Provider
+
+func (s *PostgresStore) GetProviderSetting(ctx context.Context, userID int64) (ProviderSetting, error) {
+	ctx, done := s.begin(ctx, "GetProviderSetting")
+	defer done()
+	return model.GetProviderSetting(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) GetProvidersPage(ctx context.Context, topics []string, page PageQuery) (Page[Provider], error) {
+	ctx, done := s.begin(ctx, "GetProvidersPage")
+	defer done()
+	return model.GetProvidersPage(ctx, s.DB, topics, page)
+}
+
+func (s *PostgresStore) GetProviderWithEvents(ctx context.Context, userID int64) (ProviderWithEvents, error) {
+	ctx, done := s.begin(ctx, "GetProviderWithEvents")
+	defer done()
+	return model.GetProviderWithEvents(ctx, s.DB, userID)
+}
+
+func (s *PostgresStore) AddUpdateProviderSettingOfUser(ctx context.Context, providerSetting ProviderSetting, userID int64) (ProviderSetting, error) {
+	ctx, done := s.begin(ctx, "AddUpdateProviderSettingOfUser")
+	defer done()
+	return model.AddUpdateProviderSettingOfUser(ctx, s.DB, providerSetting, userID)
+}
+
+func (s *PostgresStore) DeleteProviderSettingOfUser(ctx context.Context, userID int64) (ProviderSetting, error) {
+	ctx, done := s.begin(ctx, "DeleteProviderSettingOfUser")
+	defer done()
+	return model.DeleteProviderSettingOfUser(ctx, s.DB, userID)
+}
+
+// Booking
+
+func (s *PostgresStore) GetBooking(ctx context.Context, bookingID int64) (Booking, error) {
+	ctx, done := s.begin(ctx, "GetBooking")
+	defer done()
+	return model.GetBooking(ctx, s.DB, bookingID)
+}
+
+func (s *PostgresStore) GetBookingProvider(ctx context.Context, bookingID int64, userID int64) (BookingProvider, error) {
+	ctx, done := s.begin(ctx, "GetBookingProvider")
+	defer done()
+	return model.GetBookingProvider(ctx, s.DB, bookingID, userID)
+}
+
+func (s *PostgresStore) GetBookingUsersPageOfUser(ctx context.Context, userID int64, scope string, page PageQuery) (Page[BookingUser], error) {
+	ctx, done := s.begin(ctx, "GetBookingUsersPageOfUser")
+	defer done()
+	return model.GetBookingUsersPageOfUser(ctx, s.DB, userID, scope, page)
+}
+
+func (s *PostgresStore) AddBooking(ctx context.Context, booking Booking, providerID int64, recipientID int64) (Booking, error) {
+	ctx, done := s.begin(ctx, "AddBooking")
+	defer done()
+	return model.AddBooking(ctx, s.DB, booking, providerID, recipientID)
+}
+
+func (s *PostgresStore) UpdateBooking(ctx context.Context, updatedBooking Booking, bookingID int64, userID int64) (Booking, error) {
+	ctx, done := s.begin(ctx, "UpdateBooking")
+	defer done()
+	return model.UpdateBooking(ctx, s.DB, updatedBooking, bookingID, userID)
+}
+
+func (s *PostgresStore) RespondBooking(ctx context.Context, bookingRespond BookingRespond, bookingID int64, userID int64) (interface{}, error) {
+	ctx, done := s.begin(ctx, "RespondBooking")
+	defer done()
+	return model.RespondBooking(ctx, s.DB, bookingRespond, bookingID, userID)
+}
+
+func (s *PostgresStore) DeleteBookingAuthorized(ctx context.Context, bookingID int64, userID int64) (Booking, error) {
+	ctx, done := s.begin(ctx, "DeleteBookingAuthorized")
+	defer done()
+	return model.DeleteBookingAuthorized(ctx, s.DB, bookingID, userID)
+}
+
+// Chat
+
+func (s *PostgresStore) GetMessagesChunkOfGroupCustomise(ctx context.Context, groupID int64, latestTime time.Time, limit int64) (MessagesChunk, error) {
+	ctx, done := s.begin(ctx, "GetMessagesChunkOfGroupCustomise")
+	defer done()
+	return model.GetMessagesChunkOfGroupCustomise(ctx, s.DB, groupID, latestTime, limit)
+}
+
+func (s *PostgresStore) GetMessagePayload(ctx context.Context, message Message) (MessagePayload, error) {
+	ctx, done := s.begin(ctx, "GetMessagePayload")
+	defer done()
+	return message.Payload(ctx, s.DB)
+}
+
+func (s *PostgresStore) AddMessage(ctx context.Context, message Message) error {
+	ctx, done := s.begin(ctx, "AddMessage")
+	defer done()
+	return model.AddMessage(ctx, s.DB, message)
+}
+
+// Admin
+
+func (s *PostgresStore) VerifyUser(ctx context.Context, userID int64, verified bool, adminID int64) (User, error) {
+	ctx, done := s.begin(ctx, "VerifyUser")
+	defer done()
+	return model.VerifyUser(ctx, s.DB, userID, verified, adminID)
+}
+
+func (s *PostgresStore) ChangeUserRole(ctx context.Context, userID int64, userRole string, adminID int64) (User, error) {
+	ctx, done := s.begin(ctx, "ChangeUserRole")
+	defer done()
+	return model.ChangeUserRole(ctx, s.DB, userID, userRole, adminID)
+}
+
+func (s *PostgresStore) SuspendUser(ctx context.Context, userID int64, suspended bool, adminID int64) (User, error) {
+	ctx, done := s.begin(ctx, "SuspendUser")
+	defer done()
+	return model.SuspendUser(ctx, s.DB, userID, suspended, adminID)
+}
+
+func (s *PostgresStore) ForceDeleteGroup(ctx context.Context, groupID int64, adminID int64) (Group, error) {
+	ctx, done := s.begin(ctx, "ForceDeleteGroup")
+	defer done()
+	return model.ForceDeleteGroup(ctx, s.DB, groupID, adminID)
+}
+
+func (s *PostgresStore) ForceDeleteEvent(ctx context.Context, eventID int64, adminID int64) (Event, error) {
+	ctx, done := s.begin(ctx, "ForceDeleteEvent")
+	defer done()
+	return model.ForceDeleteEvent(ctx, s.DB, eventID, adminID)
+}
+
+func (s *PostgresStore) GetReportsPage(ctx context.Context, adminID int64, page PageQuery) (Page[Report], error) {
+	ctx, done := s.begin(ctx, "GetReportsPage")
+	defer done()
+	return model.GetReportsPage(ctx, s.DB, adminID, page)
+}
+
+func (s *PostgresStore) AddReport(ctx context.Context, report Report, userID int64) (Report, error) {
+	ctx, done := s.begin(ctx, "AddReport")
+	defer done()
+	return model.AddReport(ctx, s.DB, report, userID)
+}
+
+func (s *PostgresStore) DeleteReport(ctx context.Context, reportID int64, adminID int64) (Report, error) {
+	ctx, done := s.begin(ctx, "DeleteReport")
+	defer done()
+	return model.DeleteReport(ctx, s.DB, reportID, adminID)
+}
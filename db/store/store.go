@@ -0,0 +1,169 @@
+package store
+
+import (
+	"wellnus/backend/db/migration"
+	. "wellnus/backend/db/model"
+
+	"context"
+	"time"
+)
+
+// Repository interfaces that the router packages depend on.
+// PostgresStore backs them with the query functions in db/model while MemoryStore keeps
+// everything in process so that handlers can be exercised without a running database.
+
+type UserStore interface {
+	GetUser(ctx context.Context, userID int64) (User, error)
+	GetUserWithGroups(ctx context.Context, userID int64) (UserWithGroups, error)
+	GetAllUsers(ctx context.Context) ([]User, error)
+	SearchUsers(ctx context.Context, filter UserFilter, page PageQuery) (Page[User], error)
+	GetAllUsersOfGroup(ctx context.Context, groupID int64) ([]User, error)
+	GetAllUsersOfEvent(ctx context.Context, eventID int64) ([]User, error)
+	FindUser(ctx context.Context, email string) (User, error)
+	AddUser(ctx context.Context, newUser User) (User, error)
+	UpdateUser(ctx context.Context, updatedUser User, userID int64) (User, error)
+	DeleteUser(ctx context.Context, userID int64) (User, error)
+}
+
+type SessionStore interface {
+	GetUserIDFromSessionKey(ctx context.Context, sessionKey string) (int64, error)
+	GetAllSessionsOfUser(ctx context.Context, userID int64) ([]Session, error)
+	CreateNewSession(ctx context.Context, userID int64, deviceLabel string) (string, error)
+	DeleteSessionWithSessionKey(ctx context.Context, sessionKey string) error
+	DeleteSessionOfUser(ctx context.Context, sessionID int64, userID int64) (Session, error)
+	DeleteAllSessionsOfUser(ctx context.Context, userID int64) error
+}
+
+type TokenStore interface {
+	CreateUserToken(ctx context.Context, userID int64, purpose string) (string, error)
+	VerifyEmail(ctx context.Context, token string) (User, error)
+	ResetPassword(ctx context.Context, token string, password string) (User, error)
+}
+
+type GroupStore interface {
+	GetGroup(ctx context.Context, groupID int64) (Group, error)
+	GetGroupWithUsers(ctx context.Context, groupID int64) (GroupWithUsers, error)
+	GetAllGroupsOfUser(ctx context.Context, userID int64) ([]Group, error)
+	GetGroupsPageOfUser(ctx context.Context, userID int64, page PageQuery) (Page[Group], error)
+	AddGroupWithUserIDs(ctx context.Context, group Group, userIDs []int64) (GroupWithUsers, error)
+	AddUserToGroup(ctx context.Context, groupID int64, userID int64) error
+	UpdateGroup(ctx context.Context, updatedGroup Group, groupID int64, userID int64) (Group, error)
+	LeaveGroup(ctx context.Context, groupID int64, userID int64) (GroupWithUsers, error)
+	LeaveAllGroups(ctx context.Context, userID int64) ([]GroupWithUsers, error)
+	IsUserInGroup(ctx context.Context, userID int64, groupID int64) (bool, error)
+}
+
+type JoinStore interface {
+	GetLoadedJoinRequest(ctx context.Context, joinRequestID int64, userID int64) (LoadedJoinRequest, error)
+	GetLoadedJoinRequestsPageOfUser(ctx context.Context, userID int64, scope string, page PageQuery) (Page[LoadedJoinRequest], error)
+	AddJoinRequest(ctx context.Context, groupID int64, userID int64) (JoinRequest, error)
+	RespondJoinRequest(ctx context.Context, joinRequestID int64, userID int64, approve bool) (JoinRequestRespond, error)
+	DeleteJoinRequest(ctx context.Context, joinRequestID int64, userID int64) (JoinRequest, error)
+}
+
+type MatchStore interface {
+	GetMatchSettingOfUser(ctx context.Context, userID int64) (MatchSetting, error)
+	AddUpdateMatchSettingOfUser(ctx context.Context, matchSetting MatchSetting, userID int64) (MatchSetting, error)
+	DeleteMatchSettingOfUser(ctx context.Context, userID int64) (MatchSetting, error)
+	GetMatchRequestCount(ctx context.Context) (int64, error)
+	GetQueuedMatchRequestOfUser(ctx context.Context, userID int64) (QueuedMatchRequest, error)
+	AddMatchRequest(ctx context.Context, userID int64) (MatchRequest, error)
+	DeleteMatchRequestOfUser(ctx context.Context, userID int64) (MatchRequest, error)
+	GetCompatibilityBreakdown(ctx context.Context, groupID int64, userID int64) (CompatibilityBreakdown, error)
+}
+
+// Matching runs and their history. RunMatching is what the scheduler calls when a trigger is due,
+// and fails with MatchingInProgressError while another run holds the lock. DryRunMatching works out
+// what a run would do without changing anything, with strategy in place of MATCH_STRATEGY unless empty
+type MatchingStore interface {
+	RunMatching(ctx context.Context, trigger string) (MatchingRun, error)
+	TriggerMatching(ctx context.Context, adminID int64) (MatchingRun, error)
+	GetLatestMatchingRun(ctx context.Context) (MatchingRun, error)
+	GetMatchingRunsPage(ctx context.Context, adminID int64, page PageQuery) (Page[MatchingRun], error)
+	DryRunMatching(ctx context.Context, adminID int64, strategy string) (MatchingDryRun, error)
+}
+
+type CounselStore interface {
+	GetCounselRequestsPage(ctx context.Context, topics []string, userID int64, page PageQuery) (Page[CounselRequest], error)
+	GetCounselRequest(ctx context.Context, recipientUserID int64, userID int64) (CounselRequest, error)
+	AddUpdateCounselRequest(ctx context.Context, counselRequest CounselRequest, userID int64) (CounselRequest, error)
+	DeleteCounselRequest(ctx context.Context, userID int64) (CounselRequest, error)
+	AcceptCounselRequest(ctx context.Context, recipientUserID int64, providerUserID int64) (GroupWithUsers, error)
+}
+
+type EventStore interface {
+	GetEvent(ctx context.Context, eventID int64) (Event, error)
+	GetEventWithUsers(ctx context.Context, eventID int64) (EventWithUsers, error)
+	GetAllEventsOfUser(ctx context.Context, userID int64) ([]Event, error)
+	GetEventsPageOfUser(ctx context.Context, userID int64, page PageQuery) (Page[Event], error)
+	AddEventWithUserIDs(ctx context.Context, event Event, userIDs []int64) (EventWithUsers, error)
+	AddUserToEventAuthorized(ctx context.Context, userID int64, eventID int64, adderID int64) (EventWithUsers, error)
+	UpdateEvent(ctx context.Context, updatedEvent Event, eventID int64, userID int64) (Event, error)
+	LeaveDeleteEvent(ctx context.Context, eventID int64, userID int64) (EventWithUsers, error)
+	LeaveDeleteAllEvents(ctx context.Context, userID int64) ([]EventWithUsers, error)
+	CreateGroupDeleteEvent(ctx context.Context, eventID int64, userID int64) (GroupWithUsers, error)
+}
+
+type ProviderStore interface {
+	GetProviderSetting(ctx context.Context, userID int64) (ProviderSetting, error)
+	GetProvidersPage(ctx context.Context, topics []string, page PageQuery) (Page[Provider], error)
+	GetProviderWithEvents(ctx context.Context, userID int64) (ProviderWithEvents, error)
+	AddUpdateProviderSettingOfUser(ctx context.Context, providerSetting ProviderSetting, userID int64) (ProviderSetting, error)
+	DeleteProviderSettingOfUser(ctx context.Context, userID int64) (ProviderSetting, error)
+}
+
+type BookingStore interface {
+	GetBooking(ctx context.Context, bookingID int64) (Booking, error)
+	GetBookingProvider(ctx context.Context, bookingID int64, userID int64) (BookingProvider, error)
+	GetBookingUsersPageOfUser(ctx context.Context, userID int64, scope string, page PageQuery) (Page[BookingUser], error)
+	AddBooking(ctx context.Context, booking Booking, providerID int64, recipientID int64) (Booking, error)
+	UpdateBooking(ctx context.Context, updatedBooking Booking, bookingID int64, userID int64) (Booking, error)
+	RespondBooking(ctx context.Context, bookingRespond BookingRespond, bookingID int64, userID int64) (interface{}, error)
+	DeleteBookingAuthorized(ctx context.Context, bookingID int64, userID int64) (Booking, error)
+}
+
+type ChatStore interface {
+	GetMessagesChunkOfGroupCustomise(ctx context.Context, groupID int64, latestTime time.Time, limit int64) (MessagesChunk, error)
+	GetMessagePayload(ctx context.Context, message Message) (MessagePayload, error)
+	AddMessage(ctx context.Context, message Message) error
+}
+
+type AdminStore interface {
+	VerifyUser(ctx context.Context, userID int64, verified bool, adminID int64) (User, error)
+	ChangeUserRole(ctx context.Context, userID int64, userRole string, adminID int64) (User, error)
+	SuspendUser(ctx context.Context, userID int64, suspended bool, adminID int64) (User, error)
+	ForceDeleteGroup(ctx context.Context, groupID int64, adminID int64) (Group, error)
+	ForceDeleteEvent(ctx context.Context, eventID int64, adminID int64) (Event, error)
+	GetReportsPage(ctx context.Context, adminID int64, page PageQuery) (Page[Report], error)
+	AddReport(ctx context.Context, report Report, userID int64) (Report, error)
+	DeleteReport(ctx context.Context, reportID int64, adminID int64) (Report, error)
+}
+
+// Reports whether the storage behind the other repositories can serve requests
+type HealthStore interface {
+	Ping(ctx context.Context) error
+	GetSchemaStatus(ctx context.Context) (migration.Status, error)
+}
+
+// Store is the full set of repositories needed to serve the API
+type Store interface {
+	HealthStore
+	UserStore
+	SessionStore
+	TokenStore
+	GroupStore
+	JoinStore
+	MatchStore
+	MatchingStore
+	CounselStore
+	EventStore
+	ProviderStore
+	BookingStore
+	ChatStore
+	AdminStore
+}
+
+var (
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"wellnus/backend/config"
+
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Replaces the values of secrets in log lines
+const Redacted = "[REDACTED]"
+
+var (
+	mu     sync.RWMutex
+	output slog.Handler = newJSONHandler(os.Stderr)
+	level  slog.Level   = slog.LevelInfo
+	levels              = make(map[string]slog.Level)
+)
+
+func newJSONHandler(w io.Writer) slog.Handler {
+	// Levels are checked per package by handler, so the output writes everything it is given
+	return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: redact})
+}
+
+// Writes JSON log lines to w from now on, at the levels of cfg, and makes the logger of the
+// log/slog and log packages write through it too
+func Setup(cfg config.LogConfig, w io.Writer) {
+	mu.Lock()
+	output = newJSONHandler(w)
+	level = cfg.DefaultLevel()
+	levels = cfg.PackageLevels()
+	mu.Unlock()
+	slog.SetDefault(For(""))
+}
+
+// Returns the logger of the package pkg, whose lines are tagged with it and written at the
+// level set for it in LOG_LEVELS, or LOG_LEVEL otherwise. Loggers may be made before Setup
+// runs, as they read the settings as they log
+func For(pkg string) *slog.Logger {
+	return slog.New(&handler{pkg: pkg})
+}
+
+func levelOf(pkg string) slog.Level {
+	mu.RLock()
+	defer mu.RUnlock()
+	if l, ok := levels[pkg]; ok {
+		return l
+	}
+	return level
+}
+
+// Request IDs
+
+type requestIDKey struct{}
+
+// Returns ctx carrying the ID of the request it belongs to, which is added to every line logged with it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Handler of the loggers of For. The attributes and groups added with With and WithGroup are
+// kept as steps, as the output they are applied to may change with Setup
+type handler struct {
+	pkg   string
+	steps []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= levelOf(h.pkg)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	mu.RLock()
+	out := output
+	mu.RUnlock()
+	attrs := make([]slog.Attr, 0, 2)
+	if h.pkg != "" {
+		attrs = append(attrs, slog.String("package", h.pkg))
+	}
+	if requestID := RequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if len(attrs) > 0 {
+		out = out.WithAttrs(attrs)
+	}
+	for _, step := range h.steps {
+		out = step(out)
+	}
+	return out.Handle(ctx, record)
+}
+
+func (h *handler) with(step func(slog.Handler) slog.Handler) *handler {
+	steps := make([]func(slog.Handler) slog.Handler, len(h.steps), len(h.steps)+1)
+	copy(steps, h.steps)
+	return &handler{pkg: h.pkg, steps: append(steps, step)}
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(out slog.Handler) slog.Handler { return out.WithGroup(name) })
+}
+
+// Redaction
+
+// Keys whose values are never logged, compared in lower case. Any key with password in it is redacted too
+var secretKeys = map[string]bool{
+	"session_key":   true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"authorization": true,
+	"token":         true,
+}
+
+var secretPatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	// session_key=... in cookie headers and query strings, and "session_key":"..." in JSON
+	{regexp.MustCompile(`(session_key"?[=:]"?)[^;&\s"]+`), "${1}" + Redacted},
+	// token=... in the links of account emails and "token":"..." in JSON
+	{regexp.MustCompile(`(token"?[=:]"?)[^;&\s"]+`), "${1}" + Redacted},
+	// "password":"..." and password=... in request bodies
+	{regexp.MustCompile(`(?i)("?\w*password\w*"?[:=])("[^"]*"|[^&\s,}]+)`), "${1}" + Redacted},
+	// user:password@ in connection strings
+	{regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`), "${1}" + Redacted + "@"},
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	return secretKeys[key] || strings.Contains(key, "password")
+}
+
+// Removes secrets from s
+func RedactString(s string) string {
+	for _, secret := range secretPatterns {
+		s = secret.pattern.ReplaceAllString(s, secret.replacement)
+	}
+	return s
+}
+
+func redact(groups []string, a slog.Attr) slog.Attr {
+	if isSecretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, RedactString(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, RedactString(err.Error()))
+		}
+	}
+	return a
+}
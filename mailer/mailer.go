@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/logger"
+
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends account emails such as verification links and password resets.
+// SMTPMailer delivers them for real while FileMailer and LogMailer keep them local
+// for development and tests.
+type Mailer interface {
+	Send(message Message) error
+}
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPMailer(host string, port string, username string, password string, from string) *SMTPMailer {
+	return &SMTPMailer{Host: host, Port: port, Username: username, Password: password, From: from}
+}
+
+func (m *SMTPMailer) Send(message Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{message.To}, format(m.From, message))
+}
+
+// FileMailer writes every message to its own file in Dir
+type FileMailer struct {
+	Dir string
+
+	mu    sync.Mutex
+	count int
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{Dir: dir}
+}
+
+func (m *FileMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	m.count++
+	name := fmt.Sprintf("%d-%04d-%s.eml", time.Now().UnixNano(), m.count, sanitise(message.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), format("wellnus@localhost", message), 0o644)
+}
+
+// Returns the messages sent to the address, oldest first
+func (m *FileMailer) Messages(to string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(m.Dir, "*-"+sanitise(to)+".eml"))
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]string, 0, len(paths))
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, string(b))
+	}
+	return messages, nil
+}
+
+var log = logger.For("mailer")
+
+// LogMailer logs every message. The tokens in its links are redacted by the logger, so it only shows
+// that a message was sent
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(message Message) error {
+	log.Info("mail", "to", message.To, "subject", message.Subject, "body", message.Body)
+	return nil
+}
+
+// Picks the mailer named by cfg.Mailer, logging messages when it is not set
+func NewFromConfig(cfg config.MailConfig) Mailer {
+	switch cfg.Mailer {
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	case "file":
+		return NewFileMailer(cfg.Dir)
+	default:
+		return NewLogMailer()
+	}
+}
+
+// Helper functions
+
+func format(from string, message Message) []byte {
+	return []byte(fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		headerValue(from), headerValue(message.To), headerValue(message.Subject), message.Body))
+}
+
+// Strips line breaks so that a value cannot add headers of its own
+func headerValue(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]`)
+
+func sanitise(s string) string {
+	return unsafeFileChars.ReplaceAllString(strings.ToLower(s), "_")
+}
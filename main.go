@@ -2,21 +2,129 @@ package main
 
 import (
 	"wellnus/backend/config"
+	"wellnus/backend/logger"
 
 	"wellnus/backend/db"
+	"wellnus/backend/db/migration"
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
 	"wellnus/backend/router"
 	"wellnus/backend/router/ws"
+	"wellnus/backend/scheduler"
+	"wellnus/backend/simulation"
+
+	"context"
+	"database/sql"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+var log = logger.For("main")
+
+// Logs err and exits
+func fatal(err error) {
+	log.Error(err.Error())
+	os.Exit(1)
+}
+
 func main() {
-	config.LoadENV("./.env")
+	// Settings come from flags, the environment and ./.env. Arguments after the flags are a subcommand
+	Config, args, err := config.Load("./.env", os.Args[1:])
+	if err != nil {
+		fatal(err)
+	}
+	logger.Setup(Config.Log, os.Stderr)
+
+	// `main [flags] simulate [queue size...]` compares the matchers on synthetic queues without a database
+	if len(args) > 0 && args[0] == "simulate" {
+		if err := simulation.Run(Config.Matching, args[1:], os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
 
 	// Runtime global instances
-	DB := db.ConnectDB()
-	WSHub := ws.NewHub(DB)
+	DB, err := db.ConnectDB(Config.DB)
+	if err != nil {
+		fatal(err)
+	}
+
+	// `main [flags] migrate up | down [steps] | status` manages the schema without starting the server
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migration.Run(DB, args[1:], os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	// `main [flags] dry-run [strategy]` prints the groups a matching run would form now, without forming them
+	if len(args) > 0 && args[0] == "dry-run" {
+		if err := simulation.DryRun(context.Background(), DB, Config.Matching, args[1:], os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	applied, err := migration.Up(DB)
+	if err != nil {
+		fatal(err)
+	}
+	log.Info("applied migrations", "count", applied)
+
+	Store := store.NewPostgresStore(DB, Config.DB.StatementTimeout, Config.Matching)
+	WSHub := ws.NewHub(Store, Config.CORS)
+	Scheduler := scheduler.NewScheduler(Store, Config.Matching)
+	Mailer := mailer.NewFromConfig(Config.Mail)
+
+	Router := router.SetupRouter(Config, Store, WSHub, Mailer)
+	Server := &http.Server{Addr: Config.Server.Address, Handler: Router}
+
+	// SIGTERM is what docker and heroku send before a redeploy
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	hubCtx, stopHub := context.WithCancel(context.Background())
+	go WSHub.Run(hubCtx)
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	go Scheduler.Run(schedulerCtx)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Info("listening", "address", Config.Server.Address)
+		serveErr <- Server.ListenAndServe()
+	}()
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		log.Info("shutting down")
+	}
+	shutdown(Config.Server.ShutdownTimeout, Server, stopHub, WSHub, stopScheduler, Scheduler, DB)
+	if err != nil {
+		fatal(err)
+	}
+}
 
-	go WSHub.Run()
-	Router := router.SetupRouter(DB, WSHub)
+// Stops accepting requests and waits for the ones in flight, then closes every websocket
+// client, lets a matching run in progress finish and finally closes the database, all within timeout
+func shutdown(timeout time.Duration, server *http.Server, stopHub context.CancelFunc, hub *ws.Hub, stopScheduler context.CancelFunc, sch *scheduler.Scheduler, db *sql.DB) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	Router.Run(config.SERVER_ADDRESS)
-}
\ No newline at end of file
+	if err := server.Shutdown(ctx); err != nil {
+		log.Warn("requests were still in flight at the shutdown deadline", "error", err)
+	}
+	stopHub()
+	if err := hub.Wait(ctx); err != nil {
+		log.Warn("websocket clients were still closing at the shutdown deadline", "error", err)
+	}
+	stopScheduler()
+	if err := sch.Wait(ctx); err != nil {
+		log.Warn("a matching run was still in progress at the shutdown deadline", "error", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Error("failed to close the database", "error", err)
+	}
+	log.Info("shut down")
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HTTP
+
+var HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "wellnus_http_requests_total",
+	Help: "HTTP requests by method, route and status.",
+}, []string{"method", "route", "status"})
+
+var HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "wellnus_http_request_duration_seconds",
+	Help:    "Time taken to handle HTTP requests by method and route.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method", "route"})
+
+// Database
+
+var DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "wellnus_db_query_duration_seconds",
+	Help:    "Time taken by the database queries of each store operation.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"query"})
+
+// Records the time taken by the store operation query since start
+func ObserveQuery(query string, start time.Time) {
+	DBQueryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+}
+
+// Websocket hub
+
+var WSClients = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "wellnus_ws_clients",
+	Help: "Connected websocket clients by the group whose chat they are in.",
+}, []string{"group"})
+
+var WSDroppedClients = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "wellnus_ws_dropped_clients_total",
+	Help: "Websocket clients dropped because they did not keep up with their messages.",
+})
+
+var WSBroadcastQueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "wellnus_ws_broadcast_queue_length",
+	Help: "Chat messages waiting to be broadcast by the hub.",
+})
+
+// Matching
+
+const (
+	MatchingBelowThreshold = "below_threshold"
+	MatchingMatched        = "matched"
+	MatchingFailed         = "failed"
+)
+
+var MatchingRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "wellnus_matching_runs_total",
+	Help: "Matching runs by outcome: below_threshold, matched or failed.",
+}, []string{"outcome"})
+
+var MatchingGroupsCreated = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "wellnus_matching_groups_created_total",
+	Help: "Support groups formed by matching.",
+})
+
+var MatchingGroupsFilled = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "wellnus_matching_groups_filled_total",
+	Help: "Under-filled groups that matching placed match requests into.",
+})
+
+var MatchingRunDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "wellnus_matching_run_duration_seconds",
+	Help:    "Time taken by matching runs.",
+	Buckets: prometheus.DefBuckets,
+})
+
+// Records a matching run that started at start with outcome, one of the Matching labels, and
+// formed groups and filled filledGroups
+func ObserveMatchingRun(start time.Time, outcome string, groups int, filledGroups int) {
+	MatchingRuns.WithLabelValues(outcome).Inc()
+	MatchingGroupsCreated.Add(float64(groups))
+	MatchingGroupsFilled.Add(float64(filledGroups))
+	MatchingRunDuration.Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,141 @@
+package account
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/logger"
+	"wellnus/backend/mailer"
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/session"
+
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Response of the resend and forgot endpoints. It is the same whether or not the email
+// belongs to an account so that they cannot be used to find out who has signed up
+type SentResponse struct {
+	Message string `json:"message"`
+}
+
+var log = logger.For("account")
+
+var sentResponse = SentResponse{Message: "If the email belongs to an account, a message has been sent to it"}
+
+// Helper functions
+
+// Mails the user a link to path of the frontend at frontendAddress, carrying a new token for purpose
+func sendToken(ctx context.Context, s store.TokenStore, m mailer.Mailer, frontendAddress string, user User, purpose string, path string, subject string, body string) error {
+	token, err := s.CreateUserToken(ctx, user.ID, purpose)
+	if err != nil {
+		return err
+	}
+	link := fmt.Sprintf("%s/%s?token=%s", frontendAddress, path, url.QueryEscape(token))
+	return m.Send(mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf(body, user.FirstName, link),
+	})
+}
+
+func SendVerificationEmail(ctx context.Context, s store.TokenStore, m mailer.Mailer, frontendAddress string, user User) error {
+	return sendToken(ctx, s, m, frontendAddress, user, TokenVerifyEmail, "verify", "Verify your WellNUS email",
+		"Hi %s,\n\nConfirm your email address to start using WellNUS:\n%s\n\nThe link expires in 24 hours.")
+}
+
+func SendPasswordResetEmail(ctx context.Context, s store.TokenStore, m mailer.Mailer, frontendAddress string, user User) error {
+	return sendToken(ctx, s, m, frontendAddress, user, TokenResetPassword, "reset", "Reset your WellNUS password",
+		"Hi %s,\n\nChoose a new password for WellNUS:\n%s\n\nThe link expires in 1 hour. If you did not ask for this, you can ignore this email.")
+}
+
+// Mail is sent after the request has been handled, so a failure is logged instead of
+// failing a request that has already changed the account
+func LogSendError(ctx context.Context, err error, user User) {
+	if err != nil {
+		log.ErrorContext(ctx, "failed to send account email", "user_id", user.ID, "error", err)
+	}
+}
+
+// Main functions
+
+// Verifies the email of the account the token was sent to and logs it in
+func VerifyEmailHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		tokenBody, err := http_helper.GetTokenBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		user, err := s.VerifyEmail(c.Request.Context(), tokenBody.Token)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		if !user.Suspended {
+			if err := session.CreateNewSessionCookie(s, c, user.ID); err != nil {
+				http_helper.WriteError(c, err)
+				return
+			}
+		}
+		c.JSON(http_error.GetStatusCode(err), SessionResponse{LoggedIn: !user.Suspended, User: user.Private()})
+	}
+}
+
+func ResendVerificationHandler(s store.Store, m mailer.Mailer) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		emailBody, err := http_helper.GetEmailBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		if user, err := s.FindUser(c.Request.Context(), emailBody.Email); err == nil && !user.EmailVerified {
+			LogSendError(c.Request.Context(), SendVerificationEmail(c.Request.Context(), s, m, http_helper.GetConfig(c).Server.FrontendAddress, user), user)
+		}
+		c.JSON(http_error.GetStatusCode(nil), sentResponse)
+	}
+}
+
+func ForgotPasswordHandler(s store.Store, m mailer.Mailer) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		emailBody, err := http_helper.GetEmailBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		if user, err := s.FindUser(c.Request.Context(), emailBody.Email); err == nil {
+			LogSendError(c.Request.Context(), SendPasswordResetEmail(c.Request.Context(), s, m, http_helper.GetConfig(c).Server.FrontendAddress, user), user)
+		}
+		c.JSON(http_error.GetStatusCode(nil), sentResponse)
+	}
+}
+
+// Sets a new password with the token sent by ForgotPasswordHandler. Every session of the
+// user is ended, so they have to log in again with the new password
+func ResetPasswordHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		tokenBody, err := http_helper.GetTokenBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		user, err := s.ResetPassword(c.Request.Context(), tokenBody.Token, tokenBody.Password)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		http_helper.SetSessionCookie(c, "", -1)
+		c.JSON(http_error.GetStatusCode(err), user.Private())
+	}
+}
@@ -0,0 +1,275 @@
+package admin
+
+import (
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"github.com/gin-gonic/gin"
+)
+
+func VerifyUserHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		userIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		verifiedBody, err := http_helper.GetVerifiedBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		user, err := s.VerifyUser(c.Request.Context(), userIDParam, verifiedBody.Verified, adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), user.Private())
+	}
+}
+
+func ChangeUserRoleHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		userIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		userRoleBody, err := http_helper.GetUserRoleBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		user, err := s.ChangeUserRole(c.Request.Context(), userIDParam, userRoleBody.UserRole, adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), user.Private())
+	}
+}
+
+func SuspendUserHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		userIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		suspendedBody, err := http_helper.GetSuspendedBodyFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		user, err := s.SuspendUser(c.Request.Context(), userIDParam, suspendedBody.Suspended, adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), user.Private())
+	}
+}
+
+func ForceDeleteGroupHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		groupIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		group, err := s.ForceDeleteGroup(c.Request.Context(), groupIDParam, adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), group)
+	}
+}
+
+func ForceDeleteEventHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		eventIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		event, err := s.ForceDeleteEvent(c.Request.Context(), eventIDParam, adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), event)
+	}
+}
+
+func GetAllReportsHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		reports, err := s.GetReportsPage(c.Request.Context(), adminID, page)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), reports)
+	}
+}
+
+func DeleteReportHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		reportIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		report, err := s.DeleteReport(c.Request.Context(), reportIDParam, adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), report)
+	}
+}
+
+// Runs matching on whoever is queued without waiting for the scheduler
+func RunMatchingHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		run, err := s.TriggerMatching(c.Request.Context(), adminID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), run)
+	}
+}
+
+func GetAllMatchingRunsHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		runs, err := s.GetMatchingRunsPage(c.Request.Context(), adminID, page)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), runs)
+	}
+}
+
+// Proposes the groups a matching run would form now, without forming them
+func DryRunMatchingHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		adminID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		strategy, err := http_helper.GetStrategyQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		dryRun, err := s.DryRunMatching(c.Request.Context(), adminID, strategy)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), dryRun)
+	}
+}
+
+// Any logged in user may report a user, group or event for an admin to review
+func AddReportHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		report, err := http_helper.GetReportFromContext(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		report, err = s.AddReport(c.Request.Context(), report, userID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), report)
+	}
+}
@@ -1,182 +1,168 @@
 package booking
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	BOOKING_RECEIVED 	= 0
-	BOOKING_SENT 		= 1
-	BOOKING_REQUIRED 	= 2
-	BOOKING_ALL 		= 3
-)
-
 // Helper functions
 
-func getBookingQuery(c *gin.Context) int {
-	if s := c.Query("booking"); s == "RECEIVED" {
-		return BOOKING_RECEIVED
-	} else if s == "SENT" {
-		return BOOKING_SENT
-	} else if s == "REQUIRED" {
-		return BOOKING_REQUIRED
-	} else {
-		return BOOKING_ALL
+func getBookingQuery(c *gin.Context) string {
+	if s := c.Query("booking"); s == BookingsReceived || s == BookingsSent || s == BookingsRequired {
+		return s
 	}
+	return BookingsAll
 }
 
 // Main functions
 
-func GetAllBookingUsersHandler(db *sql.DB) func(*gin.Context){
+func GetAllBookingUsersHandler(s store.Store) func(*gin.Context){
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		request := getBookingQuery(c)
-		if request == BOOKING_RECEIVED {
-			bookingUsers, err := model.GetAllBookingUsersReceivedOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), bookingUsers)
-		} else if request == BOOKING_SENT {
-			bookingUsers, err := model.GetAllBookingUsersSentOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), bookingUsers)
-		} else if request == BOOKING_REQUIRED {
-			bookingUsers, err := model.GetAllBookingUsersRequiredOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), bookingUsers)
-		} else {
-			bookingUsers, err := model.GetAllBookingUsersOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), bookingUsers)
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		bookingUsers, err := s.GetBookingUsersPageOfUser(c.Request.Context(), userID, getBookingQuery(c), page)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
 		}
+		c.JSON(http_error.GetStatusCode(err), bookingUsers)
 	}
 }
 
-func GetBookingProviderHandler(db *sql.DB) func(*gin.Context) {
+func GetBookingProviderHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
 		bookingIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		bookingProvider, err := model.GetBookingProvider(db, bookingIDParam)
+		bookingProvider, err := s.GetBookingProvider(c.Request.Context(), bookingIDParam, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), bookingProvider)
 	}
 }
 
-func AddBookingHandler(db *sql.DB) func(*gin.Context) {
+func AddBookingHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		booking, err := http_helper.GetBookingFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		booking, err = model.AddBooking(db, booking, booking.ProviderID, userID)
+		booking, err = s.AddBooking(c.Request.Context(), booking, booking.ProviderID, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), booking)
 	}
 }
 
-func UpdateBookingHandler(db *sql.DB) func(*gin.Context) {	
+func UpdateBookingHandler(s store.Store) func(*gin.Context) {	
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
 		bookingIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		updatedBooking, err := http_helper.GetBookingFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		updatedBooking, err = model.UpdateBooking(db, updatedBooking, bookingIDParam, userID)
+		updatedBooking, err = s.UpdateBooking(c.Request.Context(), updatedBooking, bookingIDParam, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), updatedBooking)
 	}	
 }
 
-func RespondBookingHandler(db *sql.DB) func(*gin.Context) {	
+func RespondBookingHandler(s store.Store) func(*gin.Context) {	
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
 		bookingIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		bookingRespond, err := http_helper.GetBookingRespondFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		// Either eventWithUsers or BookingRespond
-		response, err := model.RespondBooking(db, bookingRespond, bookingIDParam, userID)
+		response, err := s.RespondBooking(c.Request.Context(), bookingRespond, bookingIDParam, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), response)
 	}	
 }
 
-func DeleteBookingHandler(db *sql.DB) func(*gin.Context) {
+func DeleteBookingHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		bookingIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		booking, err := model.DeleteBookingAuthorized(db, bookingIDParam, userID)
+		booking, err := s.DeleteBookingAuthorized(c.Request.Context(), bookingIDParam, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), booking)
@@ -1,11 +1,11 @@
 package chat
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"strconv"
 	"time"
 
@@ -17,7 +17,11 @@ func getLatestQuery(c *gin.Context) (time.Time, error) {
 	if stime := c.Query("latest"); stime == "" {
 		return time.Now(), nil
 	} else {
-		return time.Parse(time.RFC3339Nano, stime)
+		latest, err := time.Parse(time.RFC3339Nano, stime)
+		if err != nil {
+			return time.Time{}, http_error.NewValidationError("latest", "must be an RFC3339 time")
+		}
+		return latest, nil
 	}
 }
 
@@ -25,38 +29,41 @@ func getLimitQuery(c *gin.Context) (int64, error) {
 	return strconv.ParseInt(c.Query("limit"), 0, 64)
 }
 
-func GetMessagesChunkOfGroupHandler(db *sql.DB) func(*gin.Context) {
+func GetMessagesChunkOfGroupHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		groupID, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		inGroup, err := model.IsUserInGroup(db, userID, groupID)
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		inGroup, err := s.IsUserInGroup(c.Request.Context(), userID, groupID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		if !inGroup {
-			err = http_error.UnauthorizedError
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+		if err := Authorize(User{ID: userID}, ActionReadGroupMessages, GroupRelations(userID, Group{ID: groupID}, inGroup)); err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
 
 		limit, _ := getLimitQuery(c)
 		latestTime, err := getLatestQuery(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		messagesChunk, err := model.GetMessagesChunkOfGroupCustomise(db, groupID, latestTime, limit)
+		messagesChunk, err := s.GetMessagesChunkOfGroupCustomise(c.Request.Context(), groupID, latestTime, limit)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), messagesChunk)
@@ -1,117 +1,121 @@
 package counsel
 
 import (
-	"wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
 	"github.com/gin-gonic/gin"
-	"database/sql"
 )
 
-func GetAllCounselRequestsHandler(db *sql.DB) func(*gin.Context) {
+func GetAllCounselRequestsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
 		topics, _ := c.GetQueryArray("topic")
-		counselRequests, err := model.GetAllCounselRequests(db, topics, userID)
+		counselRequests, err := s.GetCounselRequestsPage(c.Request.Context(), topics, userID, page)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), counselRequests)
 	}
 }
 
-func GetCounselRequestHandler(db *sql.DB) func(*gin.Context) {
+func GetCounselRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		userIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		counselRequest, err := model.GetCounselRequest(db, userIDParam, userIDCookie)
+		counselRequest, err := s.GetCounselRequest(c.Request.Context(), userIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), counselRequest)
 	}
 }
 
-func AddUpdateCounselRequestHandler(db *sql.DB) func(*gin.Context) {
+func AddUpdateCounselRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
 		counselRequest, err := http_helper.GetCounselRequestFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		counselRequest, err = model.AddUpdateCounselRequest(db, counselRequest, userID)
+		counselRequest, err = s.AddUpdateCounselRequest(c.Request.Context(), counselRequest, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), counselRequest)
 	}
 }
 
-func DeleteCounselRequestHandler(db *sql.DB) func(*gin.Context) {
+func DeleteCounselRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {	
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		counselRequest, err := model.DeleteCounselRequest(db, userID)
+		counselRequest, err := s.DeleteCounselRequest(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), counselRequest)
 	}
 }
 
-func AcceptCounselRequestHandler(db *sql.DB) func(*gin.Context) {
+func AcceptCounselRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 		
 		userIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		groupWithUsers, err := model.AcceptCounselRequest(db, userIDParam, userIDCookie)
+		groupWithUsers, err := s.AcceptCounselRequest(c.Request.Context(), userIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groupWithUsers)
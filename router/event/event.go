@@ -1,189 +1,197 @@
 package event
 
 import (
-	"wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
 	"github.com/gin-gonic/gin"
-	"database/sql"
 )
 
 // Main functions
-func GetAllEventsHandler(db *sql.DB) func(*gin.Context) {
+func GetAllEventsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		events, err := model.GetAllEventsOfUser(db, userID)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		events, err := s.GetEventsPageOfUser(c.Request.Context(), userID, page)
+		if err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), events)
 	}
 }
 
-func GetEventHandler(db *sql.DB) func(*gin.Context) {
+func GetEventHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		eventIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		eventWithUsers, err := model.GetEventWithUsers(db, eventIDParam)
+		eventWithUsers, err := s.GetEventWithUsers(c.Request.Context(), eventIDParam)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), eventWithUsers)
 	}
 }
 
-func AddEventHandler(db *sql.DB) func(*gin.Context) {
+func AddEventHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		newEvent, err := http_helper.GetEventFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		eventWithUsers, err := model.AddEventWithUserIDs(db, newEvent, []int64{userID}) // Can throw a fatal error
+		eventWithUsers, err := s.AddEventWithUserIDs(c.Request.Context(), newEvent, []int64{userID})
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), eventWithUsers)
 	}
 }
 
-func UpdateEventHandler(db *sql.DB) func(*gin.Context) {
+func UpdateEventHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		eventIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		updatedEvent, err := http_helper.GetEventFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		updatedEvent, err = model.UpdateEvent(db, updatedEvent, eventIDParam, userIDCookie)
+		updatedEvent, err = s.UpdateEvent(c.Request.Context(), updatedEvent, eventIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), updatedEvent)
 	}
 }
 
-func LeaveDeleteEventHandler(db *sql.DB) func(*gin.Context) {
+func LeaveDeleteEventHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		eventIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		eventWithUsers, err := model.LeaveDeleteEvent(db, eventIDParam, userIDCookie)
+		eventWithUsers, err := s.LeaveDeleteEvent(c.Request.Context(), eventIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), eventWithUsers)
 	}
 }
 
-func LeaveDeleteAllEventsHandler(db *sql.DB) func(*gin.Context) {
+func LeaveDeleteAllEventsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		eventsWithUsers, err := model.LeaveDeleteAllEvents(db, userIDCookie)
+		eventsWithUsers, err := s.LeaveDeleteAllEvents(c.Request.Context(), userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), eventsWithUsers)
 	}
 }
 
-func AddUserToEventHandler(db *sql.DB) func(*gin.Context) {
+func AddUserToEventHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		userIDAdded, err := http_helper.GetUserIDFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		eventIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		eventWithUsers, err := model.AddUserToEventAuthorized(db, userIDAdded, eventIDParam, userIDCookie)
+		eventWithUsers, err := s.AddUserToEventAuthorized(c.Request.Context(), userIDAdded, eventIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), eventWithUsers)
 	}
 }
 
-func CreateGroupDeleteEventHandler(db *sql.DB) func(*gin.Context) {
+func CreateGroupDeleteEventHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
 		eventIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		groupWithUsers, err := model.CreateGroupDeleteEvent(db, eventIDParam, userIDCookie)
+		groupWithUsers, err := s.CreateGroupDeleteEvent(c.Request.Context(), eventIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groupWithUsers)
@@ -1,136 +1,144 @@
 package group
 
 import (
-	"wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
 	"github.com/gin-gonic/gin"
-	"database/sql"
 )
 
 // Main functions
-func GetAllGroupsHandler(db *sql.DB) func(*gin.Context) {
+func GetAllGroupsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		groups, err := model.GetAllGroupsOfUser(db, userID)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		groups, err := s.GetGroupsPageOfUser(c.Request.Context(), userID, page)
+		if err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groups)
 	}
 }
 
-func GetGroupHandler(db *sql.DB) func(*gin.Context) {
+func GetGroupHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		groupIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		groupWithUsers, err := model.GetGroupWithUsers(db, groupIDParam)
+		groupWithUsers, err := s.GetGroupWithUsers(c.Request.Context(), groupIDParam)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groupWithUsers)
 	}
 }
 
-func AddGroupHandler(db *sql.DB) func(*gin.Context) {
+func AddGroupHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		newGroup, err := http_helper.GetGroupFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		newGroup.Category = "CUSTOM"
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		groupWithUsers, err := model.AddGroupWithUserIDs(db, newGroup, []int64{userID}) // Can throw a fatal error
+		groupWithUsers, err := s.AddGroupWithUserIDs(c.Request.Context(), newGroup, []int64{userID})
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groupWithUsers)
 	}
 }
 
-func UpdateGroupHandler(db *sql.DB) func(*gin.Context) {
+func UpdateGroupHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		groupIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		updatedGroup, err := http_helper.GetGroupFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		updatedGroup, err = model.UpdateGroup(db, updatedGroup, groupIDParam, userIDCookie)
+		updatedGroup, err = s.UpdateGroup(c.Request.Context(), updatedGroup, groupIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), updatedGroup)
 	}
 }
 
-func LeaveGroupHandler(db *sql.DB) func(*gin.Context) {
+func LeaveGroupHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		groupIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		groupWithUsers, err := model.LeaveGroup(db, groupIDParam, userIDCookie)
+		groupWithUsers, err := s.LeaveGroup(c.Request.Context(), groupIDParam, userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groupWithUsers)
 	}
 }
 
-func LeaveAllGroupsHandler(db *sql.DB) func(*gin.Context) {
+func LeaveAllGroupsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userIDCookie, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userIDCookie, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		groupsWithUsers, err := model.LeaveAllGroups(db, userIDCookie)
+		groupsWithUsers, err := s.LeaveAllGroups(c.Request.Context(), userIDCookie)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), groupsWithUsers)
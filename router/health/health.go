@@ -0,0 +1,166 @@
+package health
+
+import (
+	"wellnus/backend/db/store"
+	"wellnus/backend/logger"
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/ws"
+
+	"context"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Set at build time with -ldflags "-X wellnus/backend/router/health.Commit=... -X wellnus/backend/router/health.BuildTime=...".
+// Otherwise they are read from the version control information go build stamps into the binary
+var Commit, BuildTime string
+
+var log = logger.For("health")
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// Reasons a readiness check gives for failing. The error behind them is only logged, as
+// /readyz is open to anyone
+const (
+	ReasonUnreachable = "unreachable"
+	ReasonQueryFailed = "query failed"
+)
+
+// Time a readiness check may take before it counts as failed
+const checkTimeout = 2 * time.Second
+
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// Checks maps the name of each check to ok or to the reason it failed
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type VersionResponse struct {
+	Commit              string `json:"commit"`
+	BuildTime           string `json:"build_time"`
+	GoVersion           string `json:"go_version"`
+	SchemaVersion       int64  `json:"schema_version"`
+	LatestSchemaVersion int64  `json:"latest_schema_version"`
+}
+
+// Helper functions
+
+// Commit and build time given at build time, falling back to the ones stamped by go build
+func buildInfo() (string, string) {
+	commit, buildTime := Commit, BuildTime
+	if info, ok := debug.ReadBuildInfo(); ok {
+		modified := false
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = setting.Value
+				}
+			case "vcs.time":
+				if buildTime == "" {
+					buildTime = setting.Value
+				}
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		if modified && Commit == "" && commit != "" {
+			commit += "-dirty"
+		}
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	return commit, buildTime
+}
+
+func checkDatabase(ctx context.Context, s store.HealthStore) string {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	if err := s.Ping(ctx); err != nil {
+		log.WarnContext(ctx, "database readiness check failed", "error", err)
+		return ReasonUnreachable
+	}
+	return StatusOK
+}
+
+func checkMigrations(ctx context.Context, s store.HealthStore) string {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	status, err := s.GetSchemaStatus(ctx)
+	if err != nil {
+		log.WarnContext(ctx, "migrations readiness check failed", "error", err)
+		return ReasonQueryFailed
+	}
+	if status.Dirty || status.Version != status.Latest {
+		return status.String()
+	}
+	return StatusOK
+}
+
+func checkHub(hub *ws.Hub) string {
+	if !hub.Running() {
+		return "websocket hub is not running"
+	}
+	return StatusOK
+}
+
+// Main functions
+
+// Responds 200 for as long as the process can serve requests at all
+func LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, LivenessResponse{Status: StatusOK})
+}
+
+// Responds 200 when the database answers, its schema is at the latest migration of this build
+// and the websocket hub is running. Otherwise responds 503 naming the checks that failed
+func ReadinessHandler(s store.HealthStore, hub *ws.Hub) func(*gin.Context) {
+	return func(c *gin.Context) {
+		response := ReadinessResponse{
+			Status: StatusOK,
+			Checks: map[string]string{
+				"database":      checkDatabase(c.Request.Context(), s),
+				"migrations":    checkMigrations(c.Request.Context(), s),
+				"websocket_hub": checkHub(hub),
+			},
+		}
+		status := http.StatusOK
+		for _, result := range response.Checks {
+			if result != StatusOK {
+				response.Status = StatusUnavailable
+				status = http.StatusServiceUnavailable
+			}
+		}
+		c.JSON(status, response)
+	}
+}
+
+func VersionHandler(s store.HealthStore) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+		status, err := s.GetSchemaStatus(c.Request.Context())
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		commit, buildTime := buildInfo()
+		c.JSON(http.StatusOK, VersionResponse{
+			Commit:              commit,
+			BuildTime:           buildTime,
+			GoVersion:           runtime.Version(),
+			SchemaVersion:       status.Version,
+			LatestSchemaVersion: status.Latest,
+		})
+	}
+}
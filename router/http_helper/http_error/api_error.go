@@ -0,0 +1,163 @@
+package http_error
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	CodeBadRequest       = "BAD_REQUEST"
+	CodeUnauthorized     = "UNAUTHORIZED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeConflict         = "CONFLICT"
+	CodeValidationFailed = "VALIDATION_FAILED"
+	CodeRateLimited      = "RATE_LIMITED"
+	CodeTimeout          = "TIMEOUT"
+	CodeInternal         = "INTERNAL"
+)
+
+// Postgres error codes of the integrity constraint violation class
+const (
+	pqNotNullViolation    = "23502"
+	pqForeignKeyViolation = "23503"
+	pqUniqueViolation     = "23505"
+	pqCheckViolation      = "23514"
+)
+
+// Postgres error code of a statement cancelled because its context was done
+const pqQueryCanceled = "57014"
+
+// APIError is the error returned to clients by every handler
+type APIError struct {
+	Status  int           `json:"-"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []FieldDetail `json:"details,omitempty"`
+}
+
+// FieldDetail names the field of the request that caused an APIError
+type FieldDetail struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// ErrorResponse is the JSON envelope every error response is wrapped in
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func NewAPIError(status int, code string, message string, details ...FieldDetail) *APIError {
+	return &APIError{Status: status, Code: code, Message: message, Details: details}
+}
+
+func NewValidationError(field string, reason string) *APIError {
+	return NewAPIError(
+		http.StatusUnprocessableEntity,
+		CodeValidationFailed,
+		fmt.Sprintf("%s %s", field, reason),
+		FieldDetail{Field: field, Reason: reason})
+}
+
+// Translates any error returned by the store or a handler into an APIError
+func FromError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	switch err {
+	case NotFoundError:
+		return NewAPIError(http.StatusNotFound, CodeNotFound, err.Error())
+	case UnauthorizedError:
+		return NewAPIError(http.StatusUnauthorized, CodeUnauthorized, err.Error())
+	}
+	// Queries are cancelled once the request is abandoned or DB_STATEMENT_TIMEOUT passes
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return timeoutError()
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return fromPQError(pqErr)
+	}
+	// Anything else is a fault of the server, whose text is only logged
+	return internalError()
+}
+
+// The body of a request that could not be read as the JSON it should be, naming the field of the
+// wrong type when there is one
+func NewBodyError(err error) *APIError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return NewAPIError(http.StatusBadRequest, CodeBadRequest, "Request body is invalid",
+			FieldDetail{Field: typeErr.Field, Reason: "has the wrong type"})
+	}
+	return NewAPIError(http.StatusBadRequest, CodeBadRequest, "Request body is invalid")
+}
+
+func internalError() *APIError {
+	return NewAPIError(http.StatusInternalServerError, CodeInternal, "Internal server error")
+}
+
+func timeoutError() *APIError {
+	return NewAPIError(http.StatusServiceUnavailable, CodeTimeout, "Request timed out")
+}
+
+func fromPQError(err *pq.Error) *APIError {
+	field := constraintField(err)
+	switch err.Code {
+	case pqQueryCanceled:
+		return timeoutError()
+	case pqUniqueViolation:
+		return NewAPIError(
+			http.StatusConflict,
+			CodeConflict,
+			fmt.Sprintf("%s already exists", field),
+			FieldDetail{Field: field, Reason: "already exists"})
+	case pqCheckViolation:
+		return NewValidationError(field, "is invalid")
+	case pqNotNullViolation:
+		return NewValidationError(field, "is required")
+	case pqForeignKeyViolation:
+		// Deleting a row that is still referenced conflicts with the existing state,
+		// while inserting a reference to a missing row is a problem with the request
+		if strings.HasPrefix(err.Message, "update or delete") {
+			return NewAPIError(
+				http.StatusConflict,
+				CodeConflict,
+				fmt.Sprintf("%s is still referenced by another record", field),
+				FieldDetail{Field: field, Reason: "is still referenced"})
+		}
+		return NewValidationError(field, "refers to a record that does not exist")
+	}
+	return internalError()
+}
+
+var (
+	detailKeyPattern   = regexp.MustCompile(`^Key \(([^)]+)\)`)
+	constraintSuffixes = regexp.MustCompile(`_(check|key|fkey|pkey)\d*$`)
+)
+
+// Finds the column responsible for a constraint violation. Postgres reports it in the
+// detail for unique and foreign key violations; otherwise it is recovered from the
+// default constraint name of <table>_<column>_<suffix>
+func constraintField(err *pq.Error) string {
+	if err.Column != "" {
+		return err.Column
+	}
+	if match := detailKeyPattern.FindStringSubmatch(err.Detail); match != nil {
+		return match[1]
+	}
+	field := strings.TrimPrefix(err.Constraint, err.Table+"_")
+	return constraintSuffixes.ReplaceAllString(field, "")
+}
@@ -19,6 +19,6 @@ func GetStatusCode(err error) int {
 	case UnauthorizedError:
 		return http.StatusUnauthorized
 	default:
-		return http.StatusBadRequest
+		return FromError(err).Status
 	}
 }
\ No newline at end of file
@@ -1,23 +1,77 @@
 package http_helper
 
 import (
-	"database/sql"
-	"log"
+	"errors"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 	"wellnus/backend/config"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/logger"
 	"wellnus/backend/router/http_helper/http_error"
 
 	"github.com/gin-gonic/gin"
 )
 
+var log = logger.For("http")
+
+// Key under which the config middleware stores the server config
+const configKey = "config"
+
+func SetConfig(c *gin.Context, cfg config.Config) {
+	c.Set(configKey, cfg)
+}
+
+// Returns the config of the server, or the defaults when the router was set up without one
+func GetConfig(c *gin.Context) config.Config {
+	if value, ok := c.Get(configKey); ok {
+		if cfg, ok := value.(config.Config); ok {
+			return cfg
+		}
+	}
+	return config.Default()
+}
+
+// Allows the request origin when it is one of the allowed origins. Other origins are answered
+// with the first allowed origin, which browsers reject
 func SetHeaders(c *gin.Context) {
-	c.Header("Access-Control-Allow-Origin", config.FRONTEND_ADDRESS)
+	cors := GetConfig(c).CORS
+	origin := c.GetHeader("Origin")
+	if !cors.Allows(origin) && len(cors.AllowedOrigins) > 0 {
+		origin = cors.AllowedOrigins[0]
+	}
+	c.Header("Access-Control-Allow-Origin", origin)
+	c.Header("Vary", "Origin")
 	c.Header("Access-Control-Allow-Methods", "PATCH, POST, GET, DELETE, OPTIONS")
-	c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept")
+	c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, X-Device-Label, X-Request-ID")
+	c.Header("Access-Control-Expose-Headers", "X-Request-ID")
 	c.Header("Access-Control-Allow-Credentials", "true")
 }
 
+// Responds with err wrapped in the error envelope shared by every handler. Server errors, and
+// errors that did not come from the handlers such as those of the database, are logged with
+// the request ID
+func WriteError(c *gin.Context, err error) {
+	apiErr := http_error.FromError(err)
+	var handlerErr *http_error.APIError
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.ErrorContext(c.Request.Context(), "request failed", "status", apiErr.Status, "error", err)
+	} else if !errors.As(err, &handlerErr) && err != http_error.NotFoundError && err != http_error.UnauthorizedError {
+		log.WarnContext(c.Request.Context(), "request failed", "status", apiErr.Status, "error", err)
+	}
+	c.JSON(apiErr.Status, http_error.ErrorResponse{Error: apiErr})
+}
+
+// Reads the body of the request into v, failing with a 400 that does not echo the decoder
+func bindJSON(c *gin.Context, v interface{}) error {
+	if err := c.ShouldBindJSON(v); err != nil {
+		return http_error.NewBodyError(err)
+	}
+	return nil
+}
+
 func GetIDParams(c *gin.Context) (int64, error) {
 	id, err := strconv.ParseInt(c.Param("id"), 0, 64)
 	if err != nil {
@@ -26,30 +80,84 @@ func GetIDParams(c *gin.Context) (int64, error) {
 	return id, nil
 }
 
-func GetUserIDFromSessionCookie(db *sql.DB, c *gin.Context) (int64, error) {
-	sessionKey, err := c.Cookie("session_key")
+func GetUserIDFromSessionCookie(s store.SessionStore, c *gin.Context) (int64, error) {
+	sessionKey, err := c.Cookie(SessionCookieName)
 	if err != nil {
-		log.Printf("Error while getting UserID from cookie: %v", err)
+		log.DebugContext(c.Request.Context(), "request has no session cookie", "error", err)
 		return 0, http_error.UnauthorizedError
 	}
-	userID, err := GetUserIDFromSessionKey(db, sessionKey)
+	userID, err := s.GetUserIDFromSessionKey(c.Request.Context(), sessionKey)
 	if err != nil {
 		return 0, err
 	}
 	return userID, nil
 }
 
+const SessionCookieName = "session_key"
+
+// Sets the session cookie to expire after maxAge, replacing any session cookie already set on
+// this response. A negative maxAge removes the cookie
+func SetSessionCookie(c *gin.Context, sessionKey string, maxAge time.Duration) {
+	header := c.Writer.Header()
+	cookies := header.Values("Set-Cookie")
+	header.Del("Set-Cookie")
+	for _, cookie := range cookies {
+		if !strings.HasPrefix(cookie, SessionCookieName+"=") {
+			header.Add("Set-Cookie", cookie)
+		}
+	}
+	cookie := GetConfig(c).Cookie
+	c.SetSameSite(cookie.SameSiteMode())
+	c.SetCookie(SessionCookieName, sessionKey, int(maxAge.Seconds()), "/", cookie.Domain, cookie.Secure, true)
+}
+
+// Labels a new session with the X-Device-Label header, falling back to the user agent
+func GetDeviceLabel(c *gin.Context) string {
+	if deviceLabel := c.GetHeader("X-Device-Label"); deviceLabel != "" {
+		return deviceLabel
+	}
+	return c.Request.UserAgent()
+}
+
+// Key under which the authentication middleware stores the logged in user
+const authUserKey = "auth_user"
+
+func SetAuthUser(c *gin.Context, user User) {
+	c.Set(authUserKey, user)
+}
+
+// Returns the user resolved by the authentication middleware
+func GetAuthUser(c *gin.Context) (User, error) {
+	value, ok := c.Get(authUserKey)
+	if !ok {
+		return User{}, http_error.UnauthorizedError
+	}
+	user, ok := value.(User)
+	if !ok {
+		return User{}, http_error.UnauthorizedError
+	}
+	return user, nil
+}
+
+func GetAuthUserID(c *gin.Context) (int64, error) {
+	user, err := GetAuthUser(c)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
 func GetUserFromContext(c *gin.Context) (User, error) {
 	var user User
-	if err := c.BindJSON(&user); err != nil {
-		return User{}, nil
+	if err := bindJSON(c, &user); err != nil {
+		return User{}, err
 	}
 	return user, nil
 }
 
 func GetJoinRequestFromContext(c *gin.Context) (JoinRequest, error) {
 	var joinRequest JoinRequest
-	if err := c.BindJSON(&joinRequest); err != nil {
+	if err := bindJSON(c, &joinRequest); err != nil {
 		return JoinRequest{}, err
 	}
 	return joinRequest, nil
@@ -57,7 +165,7 @@ func GetJoinRequestFromContext(c *gin.Context) (JoinRequest, error) {
 
 func GetGroupFromContext(c *gin.Context) (Group, error) {
 	var group Group
-	if err := c.BindJSON(&group); err != nil {
+	if err := bindJSON(c, &group); err != nil {
 		return Group{}, err
 	}
 	return group, nil
@@ -65,7 +173,7 @@ func GetGroupFromContext(c *gin.Context) (Group, error) {
 
 func GetJoinRequestRespondFromContext(c *gin.Context) (JoinRequestRespond, error) {
 	var resp JoinRequestRespond
-	if err := c.BindJSON(&resp); err != nil {
+	if err := bindJSON(c, &resp); err != nil {
 		return JoinRequestRespond{}, err
 	}
 	return resp, nil
@@ -73,15 +181,16 @@ func GetJoinRequestRespondFromContext(c *gin.Context) (JoinRequestRespond, error
 
 func GetProviderSettingFromContext(c *gin.Context) (ProviderSetting, error) {
 	var providerSetting ProviderSetting
-	if err := c.BindJSON(&providerSetting); err != nil {
+	if err := bindJSON(c, &providerSetting); err != nil {
 		return ProviderSetting{}, err
 	}
 	return providerSetting, nil
 }
 
 func GetMatchSettingFromContext(c *gin.Context) (MatchSetting, error) {
-	var matchSetting MatchSetting
-	if err := c.BindJSON(&matchSetting); err != nil {
+	// Settings saved before gender preference existed are sent without one
+	matchSetting := MatchSetting{GenderPreference: "NONE"}
+	if err := bindJSON(c, &matchSetting); err != nil {
 		return MatchSetting{}, err
 	}
 	return matchSetting, nil
@@ -89,7 +198,7 @@ func GetMatchSettingFromContext(c *gin.Context) (MatchSetting, error) {
 
 func GetCounselRequestFromContext(c *gin.Context) (CounselRequest, error) {
 	var counselRequest CounselRequest
-	if err := c.BindJSON(&counselRequest); err != nil {
+	if err := bindJSON(c, &counselRequest); err != nil {
 		return CounselRequest{}, err
 	}
 	return counselRequest, nil
@@ -97,7 +206,7 @@ func GetCounselRequestFromContext(c *gin.Context) (CounselRequest, error) {
 
 func GetEventFromContext(c *gin.Context) (Event, error) {
 	var event Event
-	if err := c.BindJSON(&event); err != nil {
+	if err := bindJSON(c, &event); err != nil {
 		return Event{}, err
 	}
 	return event, nil
@@ -105,7 +214,7 @@ func GetEventFromContext(c *gin.Context) (Event, error) {
 
 func GetUserIDFromContext(c *gin.Context) (int64, error) {
 	var userIDBody UserIDBody
-	if err := c.BindJSON(&userIDBody); err != nil {
+	if err := bindJSON(c, &userIDBody); err != nil {
 		return 0, err
 	}
 	return userIDBody.UserID, nil
@@ -113,7 +222,7 @@ func GetUserIDFromContext(c *gin.Context) (int64, error) {
 
 func GetBookingFromContext(c *gin.Context) (Booking, error) {
 	var booking Booking
-	if err := c.BindJSON(&booking); err != nil {
+	if err := bindJSON(c, &booking); err != nil {
 		return Booking{}, err
 	}
 	return booking, nil
@@ -121,18 +230,120 @@ func GetBookingFromContext(c *gin.Context) (Booking, error) {
 
 func GetBookingRespondFromContext(c *gin.Context) (BookingRespond, error) {
 	var bookingRespond BookingRespond
-	if err := c.BindJSON(&bookingRespond); err != nil {
+	if err := bindJSON(c, &bookingRespond); err != nil {
 		return BookingRespond{}, err
 	}
 	return bookingRespond, nil
 }
 
+func GetReportFromContext(c *gin.Context) (Report, error) {
+	var report Report
+	if err := bindJSON(c, &report); err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+func GetUserRoleBodyFromContext(c *gin.Context) (UserRoleBody, error) {
+	var userRoleBody UserRoleBody
+	if err := bindJSON(c, &userRoleBody); err != nil {
+		return UserRoleBody{}, err
+	}
+	return userRoleBody, nil
+}
+
+func GetVerifiedBodyFromContext(c *gin.Context) (VerifiedBody, error) {
+	var verifiedBody VerifiedBody
+	if err := bindJSON(c, &verifiedBody); err != nil {
+		return VerifiedBody{}, err
+	}
+	return verifiedBody, nil
+}
+
+func GetSuspendedBodyFromContext(c *gin.Context) (SuspendedBody, error) {
+	var suspendedBody SuspendedBody
+	if err := bindJSON(c, &suspendedBody); err != nil {
+		return SuspendedBody{}, err
+	}
+	return suspendedBody, nil
+}
+
+func GetTokenBodyFromContext(c *gin.Context) (TokenBody, error) {
+	var tokenBody TokenBody
+	if err := bindJSON(c, &tokenBody); err != nil {
+		return TokenBody{}, err
+	}
+	return tokenBody, nil
+}
+
+func GetEmailBodyFromContext(c *gin.Context) (EmailBody, error) {
+	var emailBody EmailBody
+	if err := bindJSON(c, &emailBody); err != nil {
+		return EmailBody{}, err
+	}
+	return emailBody, nil
+}
+
+// Values of a query parameter given either repeated or comma separated
+func GetQueryList(c *gin.Context, key string) []string {
+	values := make([]string, 0)
+	for _, param := range c.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func GetInt64Query(c *gin.Context, key string) (int64, error) {
+	s := c.Query(key)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, http_error.NewValidationError(key, "must be an integer")
+	}
+	return n, nil
+}
+
+// Reads the strategy query parameter naming a matcher, empty when not given
+func GetStrategyQuery(c *gin.Context) (string, error) {
+	switch strategy := c.Query("strategy"); strategy {
+	case "", MatchStrategyGreedy, MatchStrategyAnnealing:
+		return strategy, nil
+	}
+	return "", http_error.NewValidationError("strategy", "must be greedy or annealing")
+}
+
+// Reads the limit, cursor, sort_by and order query parameters shared by every list endpoint
+func GetPageQuery(c *gin.Context) (PageQuery, error) {
+	page := PageQuery{
+		Cursor: c.Query("cursor"),
+		SortBy: c.Query("sort_by"),
+	}
+	switch order := c.Query("order"); order {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return PageQuery{}, http_error.NewValidationError("order", "must be asc or desc")
+	}
+	limit, err := GetInt64Query(c, "limit")
+	if err != nil {
+		return PageQuery{}, err
+	}
+	page.Limit = limit
+	return page, nil
+}
+
 func NoRouteHandler(c *gin.Context) {
 	if c.Request.Method == "OPTIONS" {
 		SetHeaders(c)
 		c.JSON(http_error.GetStatusCode(nil), nil)
 	} else {
-		err := http_error.NotFoundError
-		c.JSON(http_error.GetStatusCode(err), err.Error())
+		WriteError(c, http_error.NotFoundError)
 	}
 }
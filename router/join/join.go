@@ -1,147 +1,139 @@
 package join
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	REQUEST_RECEIVED = 0
-	REQUEST_SENT 	= 1
-	REQUEST_BOTH 	= 2
-)
-
 // Helper functions
 
-func getRequestQuery(c *gin.Context) int {
-	if s := c.Query("request"); s == "RECEIVED" {
-		return REQUEST_RECEIVED
-	} else if s == "SENT" {
-		return REQUEST_SENT
-	} else {
-		return REQUEST_BOTH
+func getRequestQuery(c *gin.Context) string {
+	if s := c.Query("request"); s == JoinRequestsReceived || s == JoinRequestsSent {
+		return s
 	}
+	return JoinRequestsAll
 }
 
 // Main functions
 
-func GetAllLoadedJoinRequestsHandler(db *sql.DB) func(*gin.Context){
+func GetAllLoadedJoinRequestsHandler(s store.Store) func(*gin.Context){
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		request := getRequestQuery(c)
-		if request == REQUEST_RECEIVED {
-			joinRequests, err := model.GetAllLoadedJoinRequestsReceivedOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), joinRequests)
-		} else if request == REQUEST_SENT {
-			joinRequests, err := model.GetAllLoadedJoinRequestsSentOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), joinRequests)
-		} else {
-			joinRequests, err := model.GetAllLoadedJoinRequestsOfUser(db, userID)
-			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
-				return
-			}
-			c.JSON(http_error.GetStatusCode(err), joinRequests)
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		joinRequests, err := s.GetLoadedJoinRequestsPageOfUser(c.Request.Context(), userID, getRequestQuery(c), page)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
 		}
+		c.JSON(http_error.GetStatusCode(err), joinRequests)
 	}
 }
 
-func GetLoadedJoinRequestHandler(db *sql.DB) func(*gin.Context) {
+func GetLoadedJoinRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
 		joinRequestIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		loadedJoinRequest, err := model.GetLoadedJoinRequest(db, joinRequestIDParam)
+		loadedJoinRequest, err := s.GetLoadedJoinRequest(c.Request.Context(), joinRequestIDParam, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), loadedJoinRequest)
 	}
 }
 
-func AddJoinRequestHandler(db *sql.DB) func(*gin.Context) {
+func AddJoinRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		joinRequest, err := http_helper.GetJoinRequestFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		joinRequest, err = model.AddJoinRequest(db, joinRequest.GroupID, userID)
+		joinRequest, err = s.AddJoinRequest(c.Request.Context(), joinRequest.GroupID, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), joinRequest)
 	}
 }
 
-func RespondJoinRequestHandler(db *sql.DB) func(*gin.Context) {	
+func RespondJoinRequestHandler(s store.Store) func(*gin.Context) {	
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
 		joinRequestIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		joinRequestRespond, err := http_helper.GetJoinRequestRespondFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		joinRequestRespond, err = model.RespondJoinRequest(db, joinRequestIDParam, userID, joinRequestRespond.Approve)
+		joinRequestRespond, err = s.RespondJoinRequest(c.Request.Context(), joinRequestIDParam, userID, joinRequestRespond.Approve)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), joinRequestRespond)
 	}	
 }
 
-func DeleteJoinRequestHandler(db *sql.DB) func(*gin.Context) {
+func DeleteJoinRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		joinRequestIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		joinRequest, err := model.DeleteJoinRequest(db, joinRequestIDParam, userID)
+		joinRequest, err := s.DeleteJoinRequest(c.Request.Context(), joinRequestIDParam, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), joinRequest)
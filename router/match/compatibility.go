@@ -0,0 +1,33 @@
+package match
+
+import (
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Explains how compatible the user is with each other member of a group they are in
+func GetCompatibilityBreakdownHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		groupIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		breakdown, err := s.GetCompatibilityBreakdown(c.Request.Context(), groupIDParam, userID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(err), breakdown)
+	}
+}
@@ -2,84 +2,87 @@ package match
 
 import (
 	"wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"github.com/gin-gonic/gin"
 
 )
 
-func GetMatchRequestCount(db *sql.DB) func(*gin.Context) {
+func GetMatchRequestCount(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		count, err := model.GetMatchRequestCount(db)
+		count, err := s.GetMatchRequestCount(c.Request.Context())
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), count)
 	}
 }
 
-func GetLoadedMatchRequestOfUserHandler(db *sql.DB) func(*gin.Context) {
+// The match request of the user comes with its place in the queue and estimated wait
+func GetQueuedMatchRequestOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		authUser, err := http_helper.GetAuthUser(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
+		userID := authUser.ID
 		paramID, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
 		}
-		if userID != paramID {
-			err = http_error.UnauthorizedError
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+		if err := model.Authorize(authUser, model.ActionViewMatchRequest, model.UserRelations(userID, paramID)); err != nil {
+			http_helper.WriteError(c, err)
+			return
 		}
 
-		loadedMatchRequest, err := model.GetLoadedMatchRequestOfUser(db, userID)
+		queuedMatchRequest, err := s.GetQueuedMatchRequestOfUser(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		c.JSON(http_error.GetStatusCode(err), loadedMatchRequest)
+		c.JSON(http_error.GetStatusCode(err), queuedMatchRequest)
 	}
 }
 
-func AddMatchRequestHandler(db *sql.DB) func(*gin.Context) {
+func AddMatchRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		matchRequest, err := model.AddMatchRequest(db, userID)
+		matchRequest, err := s.AddMatchRequest(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), matchRequest)
 	}
 }
 
-func DeleteMatchRequestOfUserHandler(db *sql.DB) func(*gin.Context) {
+func DeleteMatchRequestOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 		
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		matchRequest, err := model.DeleteMatchRequestOfUser(db, userID)
+		matchRequest, err := s.DeleteMatchRequestOfUser(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), matchRequest)
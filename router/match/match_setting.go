@@ -1,68 +1,67 @@
 package match
 
 import (
-	"wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"github.com/gin-gonic/gin"
 )
 
-func GetMatchSettingOfUserHandler(db *sql.DB) func(*gin.Context) {
+func GetMatchSettingOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		matchSetting, err := model.GetMatchSettingOfUser(db, userID)
+		matchSetting, err := s.GetMatchSettingOfUser(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), matchSetting)
 	}
 }
 
-func AddUpdateMatchSettingOfUserHandler(db *sql.DB) func(*gin.Context) {
+func AddUpdateMatchSettingOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		matchSetting, err := http_helper.GetMatchSettingFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		matchSetting, err = model.AddUpdateMatchSettingOfUser(db, matchSetting, userID)
+		matchSetting, err = s.AddUpdateMatchSettingOfUser(c.Request.Context(), matchSetting, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), matchSetting)
 	}
 }
 
-func DeleteMatchSettingOfUserHandler(db *sql.DB) func(*gin.Context) {
+func DeleteMatchSettingOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		matchSetting, err := model.DeleteMatchSettingOfUser(db, userID)
+		matchSetting, err := s.DeleteMatchSettingOfUser(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), matchSetting)
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/http_helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resolves the session cookie once per request and stores the logged in user in the context.
+// Requests without a valid session, or from a suspended user, continue anonymously so that
+// public routes still work.
+func Authenticate(s store.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if sessionKey, err := c.Cookie(http_helper.SessionCookieName); err == nil {
+			if user, err := getSessionUser(s, c); err == nil && !user.Suspended {
+				http_helper.SetAuthUser(c, user)
+				// Keep the cookie alive for as long as the sliding session expiry
+				http_helper.SetSessionCookie(c, sessionKey, SessionTTL)
+			}
+		}
+		c.Next()
+	}
+}
+
+// Rejects requests that Authenticate could not resolve to a user
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := http_helper.GetAuthUser(c); err != nil {
+			http_helper.SetHeaders(c)
+			http_helper.WriteError(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func getSessionUser(s store.Store, c *gin.Context) (User, error) {
+	userID, err := http_helper.GetUserIDFromSessionCookie(s, c)
+	if err != nil {
+		return User{}, err
+	}
+	return s.GetUser(c.Request.Context(), userID)
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/router/http_helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Makes the server config available to handlers through http_helper.GetConfig
+func Config(cfg config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		http_helper.SetConfig(c, cfg)
+		c.Next()
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"wellnus/backend/logger"
+	"wellnus/backend/router/http_helper"
+
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var log = logger.For("http")
+
+// Logs a line for every request once it is handled, at warn for client errors and error for
+// server errors. Only the path is logged, as query strings may carry tokens
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if user, err := http_helper.GetAuthUser(c); err == nil {
+			attrs = append(attrs, slog.Int64("user_id", user.ID))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		log.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Turns a panicking handler into a 500 in the error envelope and logs the panic with its stack
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		log.ErrorContext(c.Request.Context(), "handler panicked", "panic", recovered, "stack", string(debug.Stack()))
+		http_helper.WriteError(c, fmt.Errorf("handler panicked: %v", recovered))
+		c.Abort()
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"wellnus/backend/metrics"
+
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Counts every request and records how long it took, labelled with the route pattern rather
+// than the path so that IDs do not create a series each
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var RateLimitedError = http_error.NewAPIError(http.StatusTooManyRequests, http_error.CodeRateLimited, "Too many requests, try again later")
+
+// Token bucket of one client. It holds up to Burst tokens and gains RequestsPerMinute of them
+// every minute. Every request takes one
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter limits the requests of each client IP. The zero config, with RequestsPerMinute
+// of 0, lets every request through
+type RateLimiter struct {
+	mu      sync.Mutex
+	cfg     config.RateLimitConfig
+	buckets map[string]*bucket
+	swept   time.Time
+
+	// Clock of the limiter. Tests may replace it to move time forward
+	Now func() time.Time
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{cfg: cfg, buckets: make(map[string]*bucket), Now: time.Now}
+}
+
+func (l *RateLimiter) perSecond() float64 {
+	return float64(l.cfg.RequestsPerMinute) / 60
+}
+
+// Takes a token of the client and reports how long it has to wait when there is none
+func (l *RateLimiter) Allow(client string) (bool, time.Duration) {
+	if l.cfg.RequestsPerMinute <= 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: float64(l.cfg.Burst), updated: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(float64(l.cfg.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.perSecond())
+	b.updated = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.perSecond() * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Forgets clients whose buckets have filled up again, at most once a minute, so that the
+// limiter does not hold on to every IP it has seen
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	refill := time.Duration(float64(l.cfg.Burst) / l.perSecond() * float64(time.Second))
+	for client, b := range l.buckets {
+		if now.Sub(b.updated) >= refill {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// Rejects requests of clients over the limit with 429 and a Retry-After header
+func RateLimit(l *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, wait := l.Allow(c.ClientIP()); !ok {
+			http_helper.SetHeaders(c)
+			c.Header("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+			http_helper.WriteError(c, RateLimitedError)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
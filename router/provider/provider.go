@@ -1,22 +1,26 @@
 package provider
 
 import (
-	"wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"github.com/gin-gonic/gin"
 )
 
-func GetAllProvidersHandler(db *sql.DB) func(*gin.Context) {
+func GetAllProvidersHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
 		topics, _ := c.GetQueryArray("topic")
-		providers, err := model.GetAllProviders(db, topics)
+		providers, err := s.GetProvidersPage(c.Request.Context(), topics, page)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		
@@ -24,59 +28,59 @@ func GetAllProvidersHandler(db *sql.DB) func(*gin.Context) {
 	}
 }
 
-func GetProviderWithEventsHandler(db *sql.DB) func(*gin.Context) {
+func GetProviderWithEventsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		userID, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		providerWithEvents, err := model.GetProviderWithEvents(db, userID)
+		providerWithEvents, err := s.GetProviderWithEvents(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), providerWithEvents)
 	}
 }
 
-func AddUpdateProviderSettingOfUserHandler(db *sql.DB) func(*gin.Context) {
+func AddUpdateProviderSettingOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		providerSetting, err := http_helper.GetProviderSettingFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		providerSetting, err = model.AddUpdateProviderSettingOfUser(db, providerSetting, userID)
+		providerSetting, err = s.AddUpdateProviderSettingOfUser(c.Request.Context(), providerSetting, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), providerSetting)
 	}
 }
 
-func DeleteProviderSettingOfUserHandler(db *sql.DB) func(*gin.Context) {
+func DeleteProviderSettingOfUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		providerSetting, err := model.DeleteProviderSettingOfUser(db, userID)
+		providerSetting, err := s.DeleteProviderSettingOfUser(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), providerSetting)
@@ -2,6 +2,7 @@ package router
 
 import (
 	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/router/user"
 	"wellnus/backend/router/session"
 	"wellnus/backend/router/group"
@@ -13,99 +14,153 @@ import (
 	"wellnus/backend/router/provider"
 	"wellnus/backend/router/event"
 	"wellnus/backend/router/booking"
+	"wellnus/backend/router/admin"
+	"wellnus/backend/router/account"
+	"wellnus/backend/router/health"
 	
 	"wellnus/backend/router/ws"
-	"database/sql"
+	"wellnus/backend/config"
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
 	
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func SetupRouter(db *sql.DB, wsHub *ws.Hub) *gin.Engine {
-	router := gin.Default()
+func SetupRouter(cfg config.Config, s store.Store, wsHub *ws.Hub, m mailer.Mailer) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Logger(), middleware.Recovery())
+	router.Use(middleware.Metrics())
+	router.Use(middleware.Config(cfg))
 
-	// Remove this on production
-	router.LoadHTMLGlob("templates/**/*")
-	router.GET("/testing", testing.GetTestingHomeHandler(db))
-	router.GET("/testing/user", testing.GetTestingAllUsersHandler(db))
-	router.GET("/testing/user/:id", testing.GetTestingUserHandler(db))
-	router.GET("/testing/group", testing.GetTestingAllGroupsHandler(db))
-	router.GET("/testing/group/:id", testing.GetTestingGroupHandler(db))
-	router.GET("/testing/group/:id/chat", testing.GetTestingChatHandler(db))
-	router.GET("/testing/join", testing.GetTestingAllJoinRequestHandler(db))
-	router.GET("/testing/join/:id", testing.GetTestingJoinRequestHandler(db))
-	router.GET("/testing/match", testing.GetTestingMatchHandler(db))
-	router.POST("/testing/match", testing.SetupUsersWithMatchRequests(db))
-	router.GET("/testing/counsel", testing.GetTestingAllCounselRequestsHandler(db))
-	router.GET("/testing/counsel/:id", testing.GetTestingCounselRequestHandler(db))
-	router.GET("/testing/event", testing.GetTestingAllEventsHandler(db))
-	router.GET("/testing/event/:id", testing.GetTestingEventWithUsersHandler(db))
-	router.GET("/testing/provider", testing.GetTestingAllProvidersHandler(db))
-	router.GET("/testing/provider/:id", testing.GetTestingProviderWithEventsHandler(db))
-	router.GET("/testing/booking", testing.GetTestingAllBookingUsersHandler(db))
-	router.GET("/testing/booking/:id", testing.GetTestingBookingProviderHandler(db))
-
-	router.GET("/user", user.GetAllUsersHandler(db))
-	router.POST("/user", user.AddUserHandler(db))
-	router.GET("/user/:id", user.GetUserHandler(db))
-	router.PATCH("/user/:id", user.UpdateUserHandler(db))
-	router.DELETE("/user/:id", user.DeleteUserHandler(db))
-
-	router.POST("/session", session.LoginHandler(db))
-	router.DELETE("/session", session.LogoutHandler(db))
-
-	router.GET("/group", group.GetAllGroupsHandler(db))
-	router.POST("/group", group.AddGroupHandler(db))
-	router.DELETE("/group", group.LeaveAllGroupsHandler(db))
-	router.GET("/group/:id", group.GetGroupHandler(db))
-	router.PATCH("/group/:id", group.UpdateGroupHandler(db))
-	router.DELETE("/group/:id", group.LeaveGroupHandler(db))
+	// Probes and metrics for the orchestrator, registered before authentication so that they never read a session
+	router.GET("/healthz", health.LivenessHandler)
+	router.GET("/readyz", health.ReadinessHandler(s, wsHub))
+	router.GET("/version", health.VersionHandler(s))
+	if cfg.Features.Metrics {
+		router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
+	router.Use(middleware.Authenticate(s))
+
+	if cfg.Features.TestingRoutes {
+		setupTestingRoutes(router, s)
+	}
+
+	// Account endpoints share one limit per client, which slows down password guessing and email spam
+	rateLimit := middleware.RateLimit(middleware.NewRateLimiter(cfg.RateLimit))
+
+	// Public routes. Handlers may still read the user resolved by middleware.Authenticate
+	router.GET("/user", user.GetAllUsersHandler(s))
+	router.POST("/user", rateLimit, user.AddUserHandler(s, m))
+	router.GET("/user/:id", user.GetUserHandler(s))
+	router.POST("/session", rateLimit, session.LoginHandler(s))
+	router.DELETE("/session", session.LogoutHandler(s))
+	router.POST("/email/verify", rateLimit, account.VerifyEmailHandler(s))
+	router.POST("/email/resend", rateLimit, account.ResendVerificationHandler(s, m))
+	router.POST("/password/forgot", rateLimit, account.ForgotPasswordHandler(s, m))
+	router.POST("/password/reset", rateLimit, account.ResetPasswordHandler(s))
+	router.GET("/group/:id", group.GetGroupHandler(s))
+	router.GET("/match", match.GetMatchRequestCount(s))
+	router.GET("/event/:id", event.GetEventHandler(s))
+	router.GET("/provider", provider.GetAllProvidersHandler(s))
+	router.GET("/provider/:id", provider.GetProviderWithEventsHandler(s))
+
+	// Protected routes. Requests without a valid session are rejected with 401
+	protected := router.Group("/", middleware.RequireAuth())
+
+	protected.GET("/session", session.GetAllSessionsHandler(s))
+	protected.DELETE("/session/:id", session.DeleteSessionHandler(s))
+
+	protected.PATCH("/user/:id", user.UpdateUserHandler(s, m))
+	protected.DELETE("/user/:id", user.DeleteUserHandler(s))
+
+	protected.GET("/group", group.GetAllGroupsHandler(s))
+	protected.POST("/group", group.AddGroupHandler(s))
+	protected.DELETE("/group", group.LeaveAllGroupsHandler(s))
+	protected.PATCH("/group/:id", group.UpdateGroupHandler(s))
+	protected.DELETE("/group/:id", group.LeaveGroupHandler(s))
+	protected.GET("/group/:id/compatibility", match.GetCompatibilityBreakdownHandler(s))
 	
-	router.GET("/join", join.GetAllLoadedJoinRequestsHandler(db))
-	router.POST("/join", join.AddJoinRequestHandler(db))
-	router.GET("/join/:id", join.GetLoadedJoinRequestHandler(db))
-	router.PATCH("/join/:id", join.RespondJoinRequestHandler(db))
-	router.DELETE("/join/:id", join.DeleteJoinRequestHandler(db))
-
-	router.GET("/setting", match.GetMatchSettingOfUserHandler(db))
-	router.POST("/setting", match.AddUpdateMatchSettingOfUserHandler(db))
-	router.DELETE("/setting", match.DeleteMatchSettingOfUserHandler(db))
-
-	router.GET("/match", match.GetMatchRequestCount(db))
-	router.POST("/match", match.AddMatchRequestHandler(db))
-	router.DELETE("/match", match.DeleteMatchRequestOfUserHandler(db))
-	router.GET("/match/:id", match.GetLoadedMatchRequestOfUserHandler(db))
-
-	router.GET("/counsel", counsel.GetAllCounselRequestsHandler(db))
-	router.POST("/counsel", counsel.AddUpdateCounselRequestHandler(db))
-	router.DELETE("/counsel", counsel.DeleteCounselRequestHandler(db))
-	router.GET("/counsel/:id", counsel.GetCounselRequestHandler(db))
-	router.POST("/counsel/:id", counsel.AcceptCounselRequestHandler(db))
-
-	router.GET("/event", event.GetAllEventsHandler(db))
-	router.POST("/event", event.AddEventHandler(db))
-	router.DELETE("/event", event.LeaveDeleteAllEventsHandler(db))
-	router.GET("/event/:id", event.GetEventHandler(db))
-	router.POST("/event/:id", event.AddUserToEventHandler(db))
-	router.PATCH("/event/:id", event.UpdateEventHandler(db))
-	router.DELETE("event/:id", event.LeaveDeleteEventHandler(db))
-	router.POST("/event/:id/start", event.CreateGroupDeleteEventHandler(db))
+	protected.GET("/join", join.GetAllLoadedJoinRequestsHandler(s))
+	protected.POST("/join", join.AddJoinRequestHandler(s))
+	protected.GET("/join/:id", join.GetLoadedJoinRequestHandler(s))
+	protected.PATCH("/join/:id", join.RespondJoinRequestHandler(s))
+	protected.DELETE("/join/:id", join.DeleteJoinRequestHandler(s))
+
+	protected.GET("/setting", match.GetMatchSettingOfUserHandler(s))
+	protected.POST("/setting", match.AddUpdateMatchSettingOfUserHandler(s))
+	protected.DELETE("/setting", match.DeleteMatchSettingOfUserHandler(s))
+
+	protected.POST("/match", match.AddMatchRequestHandler(s))
+	protected.DELETE("/match", match.DeleteMatchRequestOfUserHandler(s))
+	protected.GET("/match/:id", match.GetQueuedMatchRequestOfUserHandler(s))
+
+	protected.GET("/counsel", counsel.GetAllCounselRequestsHandler(s))
+	protected.POST("/counsel", counsel.AddUpdateCounselRequestHandler(s))
+	protected.DELETE("/counsel", counsel.DeleteCounselRequestHandler(s))
+	protected.GET("/counsel/:id", counsel.GetCounselRequestHandler(s))
+	protected.POST("/counsel/:id", counsel.AcceptCounselRequestHandler(s))
+
+	protected.GET("/event", event.GetAllEventsHandler(s))
+	protected.POST("/event", event.AddEventHandler(s))
+	protected.DELETE("/event", event.LeaveDeleteAllEventsHandler(s))
+	protected.POST("/event/:id", event.AddUserToEventHandler(s))
+	protected.PATCH("/event/:id", event.UpdateEventHandler(s))
+	protected.DELETE("/event/:id", event.LeaveDeleteEventHandler(s))
+	protected.POST("/event/:id/start", event.CreateGroupDeleteEventHandler(s))
 	
-	router.GET("/provider", provider.GetAllProvidersHandler(db))
-	router.GET("/provider/:id", provider.GetProviderWithEventsHandler(db))
-	router.POST("/provider", provider.AddUpdateProviderSettingOfUserHandler(db))
-	router.DELETE("/provider", provider.DeleteProviderSettingOfUserHandler(db))
-
-	router.GET("/booking", booking.GetAllBookingUsersHandler(db))
-	router.POST("/booking", booking.AddBookingHandler(db))
-	router.GET("/booking/:id", booking.GetBookingProviderHandler(db))
-	router.POST("/booking/:id", booking.RespondBookingHandler(db))
-	router.PATCH("/booking/:id", booking.UpdateBookingHandler(db))
-	router.DELETE("/booking/:id", booking.DeleteBookingHandler(db))
-
-	router.GET("/message/:id", chat.GetMessagesChunkOfGroupHandler(db))
-	router.GET("/ws/:id", ws.ConnectToWSHandler(wsHub, db))
+	protected.POST("/provider", provider.AddUpdateProviderSettingOfUserHandler(s))
+	protected.DELETE("/provider", provider.DeleteProviderSettingOfUserHandler(s))
+
+	protected.GET("/booking", booking.GetAllBookingUsersHandler(s))
+	protected.GET("/booking/:id", booking.GetBookingProviderHandler(s))
+	protected.POST("/booking", booking.AddBookingHandler(s))
+	protected.POST("/booking/:id", booking.RespondBookingHandler(s))
+	protected.PATCH("/booking/:id", booking.UpdateBookingHandler(s))
+	protected.DELETE("/booking/:id", booking.DeleteBookingHandler(s))
+
+	protected.POST("/report", admin.AddReportHandler(s))
+
+	// Admin routes. Handlers return 401 unless the logged in user is an ADMIN
+	protected.POST("/admin/user/:id/verify", admin.VerifyUserHandler(s))
+	protected.POST("/admin/user/:id/role", admin.ChangeUserRoleHandler(s))
+	protected.POST("/admin/user/:id/suspend", admin.SuspendUserHandler(s))
+	protected.DELETE("/admin/group/:id", admin.ForceDeleteGroupHandler(s))
+	protected.DELETE("/admin/event/:id", admin.ForceDeleteEventHandler(s))
+	protected.GET("/admin/report", admin.GetAllReportsHandler(s))
+	protected.DELETE("/admin/report/:id", admin.DeleteReportHandler(s))
+	protected.GET("/admin/matching", admin.GetAllMatchingRunsHandler(s))
+	protected.POST("/admin/matching", admin.RunMatchingHandler(s))
+	protected.GET("/admin/matching/dry_run", admin.DryRunMatchingHandler(s))
+
+	protected.GET("/message/:id", chat.GetMessagesChunkOfGroupHandler(s))
+	protected.GET("/ws/:id", ws.ConnectToWSHandler(wsHub, s))
 	
 	router.NoRoute(http_helper.NoRouteHandler)
 
 	return router
-}
\ No newline at end of file
+}
+
+// Pages for trying out the API in a browser. Turn them off on production with FEATURE_TESTING_ROUTES=false
+func setupTestingRoutes(router *gin.Engine, s store.Store) {
+	router.LoadHTMLGlob("templates/**/*")
+	router.GET("/testing", testing.GetTestingHomeHandler(s))
+	router.GET("/testing/user", testing.GetTestingAllUsersHandler(s))
+	router.GET("/testing/user/:id", testing.GetTestingUserHandler(s))
+	router.GET("/testing/group", testing.GetTestingAllGroupsHandler(s))
+	router.GET("/testing/group/:id", testing.GetTestingGroupHandler(s))
+	router.GET("/testing/group/:id/chat", testing.GetTestingChatHandler(s))
+	router.GET("/testing/join", testing.GetTestingAllJoinRequestHandler(s))
+	router.GET("/testing/join/:id", testing.GetTestingJoinRequestHandler(s))
+	router.GET("/testing/match", testing.GetTestingMatchHandler(s))
+	router.POST("/testing/match", testing.SetupUsersWithMatchRequests(s))
+	router.GET("/testing/counsel", testing.GetTestingAllCounselRequestsHandler(s))
+	router.GET("/testing/counsel/:id", testing.GetTestingCounselRequestHandler(s))
+	router.GET("/testing/event", testing.GetTestingAllEventsHandler(s))
+	router.GET("/testing/event/:id", testing.GetTestingEventWithUsersHandler(s))
+	router.GET("/testing/provider", testing.GetTestingAllProvidersHandler(s))
+	router.GET("/testing/provider/:id", testing.GetTestingProviderWithEventsHandler(s))
+	router.GET("/testing/booking", testing.GetTestingAllBookingUsersHandler(s))
+	router.GET("/testing/booking/:id", testing.GetTestingBookingProviderHandler(s))
+}
@@ -1,13 +1,11 @@
 package session
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db/model"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
 	"wellnus/backend/router/http_helper/http_error"
 
-	"database/sql"
 	"net/http"
 
 	"github.com/alexedwards/argon2id"
@@ -19,71 +17,139 @@ import (
 // JWT uses one secret key across the whole app. When request sent with JWT, secret key is decripted to get userID. No session required in database
 
 // Helper function
-func CreateNewSessionCookie(db *sql.DB, c *gin.Context, userID int64) error {
-	newSessionKey, err := model.CreateNewSession(db, userID)
+func CreateNewSessionCookie(s store.SessionStore, c *gin.Context, userID int64) error {
+	newSessionKey, err := s.CreateNewSession(c.Request.Context(), userID, http_helper.GetDeviceLabel(c))
 	if err != nil {
 		return err
 	}
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("session_key", newSessionKey, 1209600, "/", config.COOKIE_ADDRESS, true, true)
+	http_helper.SetSessionCookie(c, newSessionKey, SessionTTL)
 	return nil
 }
 
-func RemoveSessionCookie(db *sql.DB, c *gin.Context) error {
-	sessionKey, _ := c.Cookie("session_key")
-	if err := model.DeleteSessionWithSessionKey(db, sessionKey); err != nil {
+func RemoveSessionCookie(s store.SessionStore, c *gin.Context) error {
+	sessionKey, _ := c.Cookie(http_helper.SessionCookieName)
+	if err := s.DeleteSessionWithSessionKey(c.Request.Context(), sessionKey); err != nil {
 		return err
 	}
-	c.SetSameSite(http.SameSiteNoneMode)
-	c.SetCookie("session_key", "", -1, "/", config.COOKIE_ADDRESS, true, true)
+	http_helper.SetSessionCookie(c, "", -1)
 	return nil
 }
 
 // Main function
-func LoginHandler(db *sql.DB) func(*gin.Context) {
+func LoginHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		loginUser, err := http_helper.GetUserFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
-		storedUser, err := model.FindUser(db, loginUser.Email)
+		storedUser, err := s.FindUser(c.Request.Context(), loginUser.Email)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
 
 		match, err := argon2id.ComparePasswordAndHash(loginUser.Password, storedUser.PasswordHash)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		if match && storedUser.Suspended {
+			http_helper.WriteError(c, http_error.NewAPIError(http.StatusForbidden, http_error.CodeForbidden, "Account is suspended"))
+			return
+		}
+		if match && !storedUser.EmailVerified {
+			http_helper.WriteError(c, http_error.NewAPIError(http.StatusForbidden, http_error.CodeForbidden, "Email is not verified"))
 			return
 		}
 		if match {
-			err = CreateNewSessionCookie(db, c, storedUser.ID)
+			err = CreateNewSessionCookie(s, c, storedUser.ID)
 			if err != nil {
-				c.JSON(http_error.GetStatusCode(err), err.Error())
+				http_helper.WriteError(c, err)
 				return
 			}
-			c.JSON(http_error.GetStatusCode(err), SessionResponse{LoggedIn: true, User: storedUser})
+			c.JSON(http_error.GetStatusCode(err), SessionResponse{LoggedIn: true, User: storedUser.Private()})
 		} else {
-			RemoveSessionCookie(db, c)
-			c.JSON(http_error.GetStatusCode(err), SessionResponse{LoggedIn: false, User: User{}})
+			RemoveSessionCookie(s, c)
+			c.JSON(http_error.GetStatusCode(err), SessionResponse{LoggedIn: false, User: PrivateUser{}})
+		}
+	}
+}
+
+// Logs out of the current session, or of every session of the user with ?all=true
+func LogoutHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		if c.Query("all") == "true" {
+			userID, err := http_helper.GetAuthUserID(c)
+			if err != nil {
+				http_helper.WriteError(c, err)
+				return
+			}
+			if err := s.DeleteAllSessionsOfUser(c.Request.Context(), userID); err != nil {
+				http_helper.WriteError(c, err)
+				return
+			}
 		}
+		err := RemoveSessionCookie(s, c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		c.JSON(http_error.GetStatusCode(nil), SessionResponse{LoggedIn: false, User: PrivateUser{}})
 	}
 }
 
-func LogoutHandler(db *sql.DB) func(*gin.Context) {
+func GetAllSessionsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		err := RemoveSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		c.JSON(http_error.GetStatusCode(nil), SessionResponse{LoggedIn: false, User: User{}})
+		sessions, err := s.GetAllSessionsOfUser(c.Request.Context(), userID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		sessionKey, _ := c.Cookie(http_helper.SessionCookieName)
+		for i := range sessions {
+			sessions[i].Current = sessions[i].SessionKeyHash == HashSessionKey(sessionKey)
+		}
+		c.JSON(http_error.GetStatusCode(err), sessions)
+	}
+}
+
+// Logs out of the session with given id, which must belong to the logged in user
+func DeleteSessionHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		http_helper.SetHeaders(c)
+
+		sessionIDParam, err := http_helper.GetIDParams(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		userID, err := http_helper.GetAuthUserID(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		deletedSession, err := s.DeleteSessionOfUser(c.Request.Context(), sessionIDParam, userID)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		if sessionKey, _ := c.Cookie(http_helper.SessionCookieName); HashSessionKey(sessionKey) == deletedSession.SessionKeyHash {
+			deletedSession.Current = true
+			http_helper.SetSessionCookie(c, "", -1)
+		}
+		c.JSON(http_error.GetStatusCode(err), deletedSession)
 	}
 }
@@ -1,199 +1,185 @@
 package testing
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/unit_test/test_helper"
 
-	"database/sql"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetTestingHomeHandler(db *sql.DB) func(*gin.Context) {
+// The testing pages show the first page of each list, as large as a page can be
+var testingPage = PageQuery{ Limit: MaxPageLimit }
+
+func GetTestingHomeHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		sID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		c.HTML(http.StatusOK, "home.html", gin.H{ "userID": sID, "backendURL": config.BACKEND_ADDRESS})
+		sID, _ := http_helper.GetAuthUserID(c)
+		c.HTML(http.StatusOK, "home.html", gin.H{ "userID": sID, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingAllUsersHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingAllUsersHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		users, _ := model.GetAllUsers(db)
+		users, _ := s.GetAllUsers(c.Request.Context())
 		c.HTML(http.StatusOK, "users.html", gin.H{ "users": users })
 	}
 }
 
-func GetTestingUserHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		userID, _ := http_helper.GetIDParams(c)
-		userWithGroups, _ := model.GetUserWithGroups(db, userID)
-		c.HTML(http.StatusOK, "user.html", gin.H{ "userWithGroups": userWithGroups, "backendURL": config.BACKEND_ADDRESS })
+		userWithGroups, _ := s.GetUserWithGroups(c.Request.Context(), userID)
+		c.HTML(http.StatusOK, "user.html", gin.H{ "userWithGroups": userWithGroups, "backendURL": http_helper.GetConfig(c).Server.BackendAddress })
 	}
 }
 
-func GetTestingAllGroupsHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingAllGroupsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		groups, _ := model.GetAllGroupsOfUser(db, userID)
-		c.HTML(http.StatusOK, "groups.html", gin.H{ "groups": groups, "backendURL": config.BACKEND_ADDRESS })
+		userID, _ := http_helper.GetAuthUserID(c)
+		groups, _ := s.GetAllGroupsOfUser(c.Request.Context(), userID)
+		c.HTML(http.StatusOK, "groups.html", gin.H{ "groups": groups, "backendURL": http_helper.GetConfig(c).Server.BackendAddress })
 	}
 }
 
-func GetTestingGroupHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingGroupHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		groupID, _ := http_helper.GetIDParams(c)
-		groupWithUsers, _ := model.GetGroupWithUsers(db, groupID)
-		c.HTML(http.StatusOK, "group.html", gin.H{"groupWithUsers": groupWithUsers, "backendURL": config.BACKEND_ADDRESS})
+		groupWithUsers, _ := s.GetGroupWithUsers(c.Request.Context(), groupID)
+		c.HTML(http.StatusOK, "group.html", gin.H{"groupWithUsers": groupWithUsers, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingAllJoinRequestHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingAllJoinRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		if s := c.Query("request"); s == "RECEIVED" {
-			loadedJoinRequests, _ := model.GetAllLoadedJoinRequestsReceivedOfUser(db, userID)
-			c.HTML(http.StatusOK, "joins.html", gin.H{"loadedJoinRequests": loadedJoinRequests, "backendURL": config.BACKEND_ADDRESS})
-		} else if s == "SENT" {
-			loadedJoinRequests, _ := model.GetAllLoadedJoinRequestsSentOfUser(db, userID)
-			c.HTML(http.StatusOK, "joins.html", gin.H{"loadedJoinRequests": loadedJoinRequests, "backendURL": config.BACKEND_ADDRESS})
-		} else {
-			loadedJoinRequests, _ := model.GetAllLoadedJoinRequestsOfUser(db, userID)
-			c.HTML(http.StatusOK, "joins.html", gin.H{"loadedJoinRequests": loadedJoinRequests, "backendURL": config.BACKEND_ADDRESS})
-		}
+		userID, _ := http_helper.GetAuthUserID(c)
+		loadedJoinRequests, _ := s.GetLoadedJoinRequestsPageOfUser(c.Request.Context(), userID, c.Query("request"), testingPage)
+		c.HTML(http.StatusOK, "joins.html", gin.H{"loadedJoinRequests": loadedJoinRequests.Items, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingJoinRequestHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingJoinRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
+		userID, _ := http_helper.GetAuthUserID(c)
 		joinRequestID, _ := http_helper.GetIDParams(c)
-		loadedJoinRequest, _ := model.GetLoadedJoinRequest(db, joinRequestID)
-		c.HTML(http.StatusOK, "join.html", gin.H{"loadedJoinRequest": loadedJoinRequest, "backendURL": config.BACKEND_ADDRESS})
+		loadedJoinRequest, _ := s.GetLoadedJoinRequest(c.Request.Context(), joinRequestID, userID)
+		c.HTML(http.StatusOK, "join.html", gin.H{"loadedJoinRequest": loadedJoinRequest, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingChatHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingChatHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		groupID, _ := http_helper.GetIDParams(c)
-		groupWithUsers, _ := model.GetGroupWithUsers(db, groupID)
-		c.HTML(http.StatusOK, "chat.html", gin.H{"groupWithUsers": groupWithUsers, "backendURL": config.BACKEND_ADDRESS, "wsURL": config.WS_ADDRESS})
+		groupWithUsers, _ := s.GetGroupWithUsers(c.Request.Context(), groupID)
+		c.HTML(http.StatusOK, "chat.html", gin.H{"groupWithUsers": groupWithUsers, "backendURL": http_helper.GetConfig(c).Server.BackendAddress, "wsURL": http_helper.GetConfig(c).Server.WSAddress})
 	}
 }
 
-func GetTestingMatchHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingMatchHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		matchSetting, _ := model.GetMatchSettingOfUser(db, userID)
-		count, _ := model.GetMatchRequestCount(db)
-		c.HTML(http.StatusOK, "match.html", gin.H{"matchSetting": matchSetting, "mrCount": count, "backendURL": config.BACKEND_ADDRESS})
+		userID, _ := http_helper.GetAuthUserID(c)
+		matchSetting, _ := s.GetMatchSettingOfUser(c.Request.Context(), userID)
+		count, _ := s.GetMatchRequestCount(c.Request.Context())
+		c.HTML(http.StatusOK, "match.html", gin.H{"matchSetting": matchSetting, "mrCount": count, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func SetupUsersWithMatchRequests(db *sql.DB) func(*gin.Context) {
+func SetupUsersWithMatchRequests(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		count, err := strconv.Atoi(c.Query("count"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, err.Error())
+			http_helper.WriteError(c, http_error.NewValidationError("count", "must be an integer"))
 			return
 		}
-		users, err := test_helper.SetupUsers(db, count)
+		users, err := test_helper.SetupUsers(s, count)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		_, err = test_helper.SetupMatchSettingForUsers(db, users)
+		_, err = test_helper.SetupMatchSettingForUsers(s, users)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		_, err =test_helper.SetupMatchRequestForUsers(db, users)
+		_, err =test_helper.SetupMatchRequestForUsers(s, users)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		c.JSON(http.StatusOK, users)
+		c.JSON(http.StatusOK, PublicUsers(users))
 	}
 }
 
-func GetTestingAllCounselRequestsHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingAllCounselRequestsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, _ := http_helper.GetAuthUserID(c)
 		topics, _ := c.GetQueryArray("topic")
-		counselRequest, _ := model.GetCounselRequest(db, userID, userID)
-		counselRequests, _ := model.GetAllCounselRequests(db, topics, userID)
-		c.HTML(http.StatusOK, "counsel_requests.html", gin.H{"counselRequests": counselRequests, "counselRequest": counselRequest, "backendURL": config.BACKEND_ADDRESS})
+		counselRequest, _ := s.GetCounselRequest(c.Request.Context(), userID, userID)
+		counselRequests, _ := s.GetCounselRequestsPage(c.Request.Context(), topics, userID, testingPage)
+		c.HTML(http.StatusOK, "counsel_requests.html", gin.H{"counselRequests": counselRequests.Items, "counselRequest": counselRequest, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingCounselRequestHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingCounselRequestHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		userIDParam, _ := http_helper.GetIDParams(c)
-		userIDCookie, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		counselRequest, _ := model.GetCounselRequest(db, userIDParam, userIDCookie)
-		c.HTML(http.StatusOK, "counsel_request.html", gin.H{"counselRequest": counselRequest, "backendURL": config.BACKEND_ADDRESS})
+		userIDCookie, _ := http_helper.GetAuthUserID(c)
+		counselRequest, _ := s.GetCounselRequest(c.Request.Context(), userIDParam, userIDCookie)
+		c.HTML(http.StatusOK, "counsel_request.html", gin.H{"counselRequest": counselRequest, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingAllEventsHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingAllEventsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		events, _ := model.GetAllEventsOfUser(db, userID)
-		c.HTML(http.StatusOK, "events.html", gin.H{"events": events, "backendURL": config.BACKEND_ADDRESS})
+		userID, _ := http_helper.GetAuthUserID(c)
+		events, _ := s.GetAllEventsOfUser(c.Request.Context(), userID)
+		c.HTML(http.StatusOK, "events.html", gin.H{"events": events, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingEventWithUsersHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingEventWithUsersHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		eventID, _ := http_helper.GetIDParams(c)
-		eventWithUsers, _ := model.GetEventWithUsers(db, eventID)
-		c.HTML(http.StatusOK, "event.html", gin.H{"eventWithUsers": eventWithUsers, "backendURL": config.BACKEND_ADDRESS})
+		eventWithUsers, _ := s.GetEventWithUsers(c.Request.Context(), eventID)
+		c.HTML(http.StatusOK, "event.html", gin.H{"eventWithUsers": eventWithUsers, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingAllProvidersHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingAllProvidersHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, _ := http_helper.GetAuthUserID(c)
 		topics, _ := c.GetQueryArray("topic")
-		providerSetting, _ := model.GetProviderSetting(db, userID)
-		providers, _ := model.GetAllProviders(db, topics)
-		c.HTML(http.StatusOK, "providers.html", gin.H{"providers": providers, "providerSetting": providerSetting, "backendURL": config.BACKEND_ADDRESS})
+		providerSetting, _ := s.GetProviderSetting(c.Request.Context(), userID)
+		providers, _ := s.GetProvidersPage(c.Request.Context(), topics, testingPage)
+		c.HTML(http.StatusOK, "providers.html", gin.H{"providers": providers.Items, "providerSetting": providerSetting, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingProviderWithEventsHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingProviderWithEventsHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		userIDParam, _ := http_helper.GetIDParams(c)
-		providerWithEvents, _ := model.GetProviderWithEvents(db, userIDParam)
-		c.HTML(http.StatusOK, "provider.html", gin.H{"providerWithEvents": providerWithEvents, "backendURL": config.BACKEND_ADDRESS})
+		providerWithEvents, _ := s.GetProviderWithEvents(c.Request.Context(), userIDParam)
+		c.HTML(http.StatusOK, "provider.html", gin.H{"providerWithEvents": providerWithEvents, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 } 
 
-func GetTestingAllBookingUsersHandler(db *sql.DB) func(*gin.Context) {
-	return func(c *gin.Context) {
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		if s := c.Query("booking"); s == "RECEIVED" {
-			bookingUsers, _ := model.GetAllBookingUsersReceivedOfUser(db, userID)
-			c.HTML(http.StatusOK, "bookings.html", gin.H{"bookingUsers": bookingUsers, "backendURL": config.BACKEND_ADDRESS})
-		} else if s == "SENT" {
-			bookingUsers, _ := model.GetAllBookingUsersSentOfUser(db, userID)
-			c.HTML(http.StatusOK, "bookings.html", gin.H{"bookingUsers": bookingUsers, "backendURL": config.BACKEND_ADDRESS})
-		} else if s == "REQUIRED" {
-			bookingUsers, _ := model.GetAllBookingUsersRequiredOfUser(db, userID)
-			c.HTML(http.StatusOK, "bookings.html", gin.H{"bookingUsers": bookingUsers, "backendURL": config.BACKEND_ADDRESS})
-		} else {
-			bookingUsers, _ := model.GetAllBookingUsersOfUser(db, userID)
-			c.HTML(http.StatusOK, "bookings.html", gin.H{"bookingUsers": bookingUsers, "backendURL": config.BACKEND_ADDRESS})
-		}
+func GetTestingAllBookingUsersHandler(s store.Store) func(*gin.Context) {
+	return func(c *gin.Context) {
+		userID, _ := http_helper.GetAuthUserID(c)
+		bookingUsers, _ := s.GetBookingUsersPageOfUser(c.Request.Context(), userID, c.Query("booking"), testingPage)
+		c.HTML(http.StatusOK, "bookings.html", gin.H{"bookingUsers": bookingUsers.Items, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
 
-func GetTestingBookingProviderHandler(db *sql.DB) func(*gin.Context) {
+func GetTestingBookingProviderHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
+		userID, _ := http_helper.GetAuthUserID(c)
 		bookingIDParam, _ := http_helper.GetIDParams(c)
-		bookingProvider, _ := model.GetBookingProvider(db, bookingIDParam)
-		c.HTML(http.StatusOK, "booking.html", gin.H{"bookingProvider": bookingProvider, "backendURL": config.BACKEND_ADDRESS})
+		bookingProvider, _ := s.GetBookingProvider(c.Request.Context(), bookingIDParam, userID)
+		c.HTML(http.StatusOK, "booking.html", gin.H{"bookingProvider": bookingProvider, "backendURL": http_helper.GetConfig(c).Server.BackendAddress})
 	}
 }
\ No newline at end of file
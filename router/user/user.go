@@ -1,161 +1,159 @@
 package user
 
 import (
-	"wellnus/backend/db/model"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
+	"wellnus/backend/mailer"
+	"wellnus/backend/router/account"
 	"wellnus/backend/router/http_helper/http_error"
-	"wellnus/backend/router/session"
-
-	"database/sql"
 
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	ROLE_MEMBER     = 0
-	ROLE_VOLUNTEER  = 1
-	ROLE_COUNSELLOR = 2
-	ROLE_PROVIDER   = 3
-	ROLE_ALL        = 4
-)
-
-func getRoleQuery(c *gin.Context) int {
-	if s := c.Query("role"); s == "MEMBER" {
-		return ROLE_MEMBER
-	} else if s == "VOLUNTEER" {
-		return ROLE_VOLUNTEER
-	} else if s == "COUNSELLOR" {
-		return ROLE_COUNSELLOR
-	} else if s == "PROVIDER" {
-		return ROLE_PROVIDER
-	} else {
-		return ROLE_ALL
+func getUserFilterQuery(c *gin.Context) UserFilter {
+	return UserFilter{
+		Roles:      http_helper.GetQueryList(c, "role"),
+		Faculty:    c.Query("faculty"),
+		Gender:     c.Query("gender"),
+		NamePrefix: c.Query("name"),
+		Topics:     http_helper.GetQueryList(c, "topic"),
 	}
 }
 
 // Main functions
-func GetAllUsersHandler(db *sql.DB) func(*gin.Context) {
+func GetAllUsersHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
-		role := getRoleQuery(c)
-		var users []User
-		var err error
-		if role == ROLE_MEMBER {
-			users, err = model.GetAllUsersConditional(db, "user_role = 'MEMBER'")
-		}
-		if role == ROLE_VOLUNTEER {
-			users, err = model.GetAllUsersConditional(db, "user_role = 'VOLUNTEER'")
-		}
-
-		if role == ROLE_COUNSELLOR {
-			users, err = model.GetAllUsersConditional(db, "user_role = 'COUNSELLOR'")
-		}
-
-		if role == ROLE_PROVIDER {
-			users, err = model.GetAllUsersConditional(db, "user_role = 'VOLUNTEER' OR user_role = 'COUNSELLOR'")
-		}
-
-		if role == ROLE_ALL {
-			users, err = model.GetAllUsers(db)
+		page, err := http_helper.GetPageQuery(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
 		}
-
+		users, err := s.SearchUsers(c.Request.Context(), getUserFilterQuery(c), page)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		c.JSON(http_error.GetStatusCode(err), users)
+		c.JSON(http_error.GetStatusCode(err), Page[PublicUser]{Items: PublicUsers(users.Items), NextCursor: users.NextCursor})
 	}
 }
 
-func GetUserHandler(db *sql.DB) func(*gin.Context) {
+// Users viewing their own profile get the private view, everyone else the public one
+func GetUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		userIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userWithGroups, err := model.GetUserWithGroups(db, userIDParam)
+		userWithGroups, err := s.GetUserWithGroups(c.Request.Context(), userIDParam)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		if authUser, err := http_helper.GetAuthUser(c); err == nil && authUser.ID == userIDParam {
+			c.JSON(http_error.GetStatusCode(nil), PrivateUserWithGroups{User: authUser.Private(), Groups: userWithGroups.Groups})
 			return
 		}
 		c.JSON(http_error.GetStatusCode(err), userWithGroups)
 	}
 }
 
-func AddUserHandler(db *sql.DB) func(*gin.Context) {
+// Creates the account of a member and emails a verification link. The user can log in once the
+// email has been verified
+func AddUserHandler(s store.Store, m mailer.Mailer) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		newUser, err := http_helper.GetUserFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		// Every other role is granted by an admin through /admin/user/:id/role
+		if newUser.UserRole != "MEMBER" {
+			http_helper.WriteError(c, http_error.NewValidationError("user_role", "must be MEMBER"))
 			return
 		}
-		newUser, err = model.AddUser(db, newUser)
+		newUser, err = s.AddUser(c.Request.Context(), newUser)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		session.CreateNewSessionCookie(db, c, newUser.ID)
-		c.JSON(http_error.GetStatusCode(err), newUser)
+		account.LogSendError(c.Request.Context(), account.SendVerificationEmail(c.Request.Context(), s, m, http_helper.GetConfig(c).Server.FrontendAddress, newUser), newUser)
+		c.JSON(http_error.GetStatusCode(err), newUser.Private())
 	}
 }
 
-func DeleteUserHandler(db *sql.DB) func(*gin.Context) {
+func DeleteUserHandler(s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		userIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
+			return
+		}
+		authUser, err := http_helper.GetAuthUser(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		if userID != userIDParam {
-			err = http_error.UnauthorizedError
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+		userID := authUser.ID
+		if err := Authorize(authUser, ActionDeleteUser, UserRelations(userID, userIDParam)); err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
-		_, err = model.LeaveAllGroups(db, userID)
-		deletedUser, err := model.DeleteUser(db, userID)
+		deletedUser, err := s.DeleteUser(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		c.JSON(http_error.GetStatusCode(err), deletedUser)
+		c.JSON(http_error.GetStatusCode(err), deletedUser.Private())
 	}
 }
 
-func UpdateUserHandler(db *sql.DB) func(*gin.Context) {
+// A changed email has to be verified again, so a new verification link is sent to it
+func UpdateUserHandler(s store.Store, m mailer.Mailer) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		userIDParam, err := http_helper.GetIDParams(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		userID, _ := http_helper.GetUserIDFromSessionCookie(db, c)
-		if userID != userIDParam {
-			err = http_error.UnauthorizedError
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+		authUser, err := http_helper.GetAuthUser(c)
+		if err != nil {
+			http_helper.WriteError(c, err)
+			return
+		}
+		userID := authUser.ID
+		if err := Authorize(authUser, ActionUpdateUser, UserRelations(userID, userIDParam)); err != nil {
+			http_helper.WriteError(c, err)
 			return
 		}
 		updatedUser, err := http_helper.GetUserFromContext(c)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		updatedUser, err = model.UpdateUser(db, updatedUser, userID)
+		if updatedUser.UserRole != "" && updatedUser.UserRole != authUser.UserRole {
+			http_helper.WriteError(c, http_error.NewValidationError("user_role", "can only be changed by an admin"))
+			return
+		}
+		updatedUser, err = s.UpdateUser(c.Request.Context(), updatedUser, userID)
 		if err != nil {
-			c.JSON(http_error.GetStatusCode(err), err.Error())
+			http_helper.WriteError(c, err)
 			return
 		}
-		c.JSON(http_error.GetStatusCode(err), updatedUser)
+		if updatedUser.Email != authUser.Email {
+			account.LogSendError(c.Request.Context(), account.SendVerificationEmail(c.Request.Context(), s, m, http_helper.GetConfig(c).Server.FrontendAddress, updatedUser), updatedUser)
+		}
+		c.JSON(http_error.GetStatusCode(err), updatedUser.Private())
 	}
 }
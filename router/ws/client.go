@@ -2,13 +2,14 @@ package ws
 
 import (
 	"wellnus/backend/config"
-	"wellnus/backend/db/model"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/logger"
 
 	"bytes"
-	"database/sql"
+	"context"
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -17,6 +18,12 @@ import (
 
 const (
 	loadedMessageBuffer = 256
+
+	// Chat messages the hub may be behind on before readPumps wait for it
+	broadcastBuffer = 256
+
+	// Time allowed to write a message to a client
+	writeWait = 10 * time.Second
 )
 
 var (
@@ -24,13 +31,14 @@ var (
 	space   = []byte{' '}
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		origin := r.Header.Get("Origin")
-		return origin == config.FRONTEND_ADDRESS || origin == config.BACKEND_ADDRESS
-	},
+func newUpgrader(cors config.CORSConfig) websocket.Upgrader {
+	return websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin: func(r *http.Request) bool {
+			return cors.Allows(r.Header.Get("Origin"))
+		},
+	}
 }
 
 // Client is a middleman between the websocket connection and the Hub.
@@ -40,38 +48,67 @@ type Client struct {
 	Hub     *Hub
 	Conn    *websocket.Conn
 	Send    chan interface{}
+
+	// Close frame written once Send is closed. Empty unless the hub is shutting down
+	closeMessage []byte
+
+	// Closed when writePump returns
+	done chan struct{}
+
+	// Carries the request ID of the request the websocket was opened with, so that what is
+	// logged about the client can be traced back to it
+	ctx context.Context
+}
+
+// Logger for what happens to the client, tagged with its user, group and request ID
+func (c *Client) log() *slog.Logger {
+	l := log.With("user_id", c.UserID, "group_id", c.GroupID)
+	if requestID := logger.RequestID(c.ctx); requestID != "" {
+		l = l.With("request_id", requestID)
+	}
+	return l
 }
 
 func (c *Client) readPump() {
 	defer func() {
-		c.Hub.Unregister <- c
+		select {
+		case c.Hub.Unregister <- c:
+		case <-c.Hub.done:
+		}
 		c.Conn.Close()
 	}()
 	for {
 		_, msg, err := c.Conn.ReadMessage() // Read client's input field
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("error: %v", err)
+				c.log().Warn("websocket closed unexpectedly", "error", err)
 			}
 			break
 		}
 		msg = bytes.TrimSpace(bytes.Replace(msg, newline, space, -1))
 		message := Message{UserID: c.UserID, GroupID: c.GroupID, TimeAdded: time.Now(), Msg: string(msg)}
-		c.Hub.Broadcast <- message
+		select {
+		case c.Hub.Broadcast <- message:
+		case <-c.Hub.done:
+			return
+		}
 	}
 }
 
 func (c *Client) writePump() {
 	defer func() {
 		c.Conn.Close()
+		close(c.done)
 	}()
 	for {
 		payload, ok := <-c.Send
 		if !ok {
 			// The Hub closed the channel.
-			c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+			c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
+			c.Conn.WriteMessage(websocket.CloseMessage, c.closeMessage)
 			return
 		}
+		c.Conn.SetWriteDeadline(time.Now().Add(writeWait))
 		w, err := c.Conn.NextWriter(websocket.TextMessage)
 		if err != nil {
 			return
@@ -88,16 +125,16 @@ func (c *Client) writePump() {
 	}
 }
 
-func (c Client) UserName(db *sql.DB) (string, error) {
-	user, err := model.GetUser(db, c.UserID)
+func (c Client) UserName(ctx context.Context, s store.UserStore) (string, error) {
+	user, err := s.GetUser(ctx, c.UserID)
 	if err != nil {
 		return "", err
 	}
 	return user.FirstName, nil
 }
 
-func (c Client) GroupName(db *sql.DB) (string, error) {
-	group, err := model.GetGroup(db, c.GroupID)
+func (c Client) GroupName(ctx context.Context, s store.GroupStore) (string, error) {
+	group, err := s.GetGroup(ctx, c.GroupID)
 	if err != nil {
 		return "", err
 	}
@@ -105,13 +142,20 @@ func (c Client) GroupName(db *sql.DB) (string, error) {
 }
 
 func ServeWs(Hub *Hub, w http.ResponseWriter, r *http.Request, userID int64, groupID int64) {
-	Conn, err := upgrader.Upgrade(w, r, nil)
+	Conn, err := Hub.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println(err)
+		log.WarnContext(r.Context(), "failed to upgrade to websocket", "user_id", userID, "group_id", groupID, "error", err)
+		return
+	}
+	client := &Client{UserID: userID, GroupID: groupID, Hub: Hub, Conn: Conn, Send: make(chan interface{}, loadedMessageBuffer), done: make(chan struct{}), ctx: context.WithoutCancel(r.Context())}
+	select {
+	case client.Hub.Register <- client:
+	case <-client.Hub.done:
+		// The hub has shut down, so nothing would serve the client
+		Conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseServiceRestart, "server restarting"), time.Now().Add(writeWait))
+		Conn.Close()
 		return
 	}
-	client := &Client{UserID: userID, GroupID: groupID, Hub: Hub, Conn: Conn, Send: make(chan interface{}, loadedMessageBuffer)}
-	client.Hub.Register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
 	// new goroutines.
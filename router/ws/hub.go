@@ -1,18 +1,26 @@
 package ws
 
 import (
-	"database/sql"
+	"context"
 	"fmt"
 	"sort"
 	"time"
+	"wellnus/backend/config"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/logger"
+	"wellnus/backend/metrics"
+
+	"github.com/gorilla/websocket"
 )
 
+var log = logger.For("ws")
+
 // Hub maintains the set of active clients and broadcasts messages to the
 // clients.
 type Hub struct {
-	// Connected DB.
-	DB *sql.DB
+	// Store backing users, groups and messages.
+	Store store.Store
 
 	// Registered clients.
 	Clients map[*Client]bool
@@ -25,24 +33,34 @@ type Hub struct {
 
 	// Unregister requests from clients.
 	Unregister chan *Client
+
+	// Upgrades requests from the allowed origins to websockets.
+	upgrader websocket.Upgrader
+
+	// Closed once Run has started, and once it has returned and every client has been closed.
+	started chan struct{}
+	done    chan struct{}
 }
 
-func NewHub(db *sql.DB) *Hub {
+func NewHub(s store.Store, cors config.CORSConfig) *Hub {
 	return &Hub{
-		DB:         db,
+		Store:      s,
+		upgrader:   newUpgrader(cors),
+		started:    make(chan struct{}),
+		done:       make(chan struct{}),
 		Clients:    make(map[*Client]bool),
-		Broadcast:  make(chan Message),
+		Broadcast:  make(chan Message, broadcastBuffer),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 	}
 }
 
-func (h *Hub) ChatStatusPayload(groupID int64) (ChatStatusPayload, error) {
-	group, err := GetGroup(h.DB, groupID)
+func (h *Hub) ChatStatusPayload(ctx context.Context, groupID int64) (ChatStatusPayload, error) {
+	group, err := h.Store.GetGroup(ctx, groupID)
 	if err != nil {
 		return ChatStatusPayload{}, err
 	}
-	usersInGroup, err := GetAllUsersOfGroup(h.DB, groupID)
+	usersInGroup, err := h.Store.GetAllUsersOfGroup(ctx, groupID)
 	if err != nil {
 		return ChatStatusPayload{}, err
 	}
@@ -60,9 +78,9 @@ func (h *Hub) ChatStatusPayload(groupID int64) (ChatStatusPayload, error) {
 	inChatMembers := make([]User, 0)
 	onlineMembers := make([]User, 0)
 	offlineMembers := make([]User, 0)
-	fmt.Printf("Websocket Clients UserID: [")
+	clientUserIDs := make([]int64, 0, len(h.Clients))
 	for client := range h.Clients {
-		fmt.Printf("%d, ", client.UserID)
+		clientUserIDs = append(clientUserIDs, client.UserID)
 		user, ok := usersInGroupMap[client.UserID]
 		if ok {
 			if client.GroupID == groupID {
@@ -73,7 +91,7 @@ func (h *Hub) ChatStatusPayload(groupID int64) (ChatStatusPayload, error) {
 			delete(usersInGroupMap, client.UserID)
 		}
 	}
-	fmt.Println("]")
+	log.Debug("building chat status", "group_id", groupID, "client_user_ids", clientUserIDs)
 	for _, user := range usersInGroupMap {
 		offlineMembers = append(offlineMembers, user)
 	}
@@ -85,9 +103,9 @@ func (h *Hub) ChatStatusPayload(groupID int64) (ChatStatusPayload, error) {
 		Tag:                  ChatStatusTag,
 		GroupID:              groupID,
 		GroupName:            group.GroupName,
-		SortedInChatMembers:  inChatMembers,
-		SortedOnlineMembers:  onlineMembers,
-		SortedOfflineMembers: offlineMembers,
+		SortedInChatMembers:  PublicUsers(inChatMembers),
+		SortedOnlineMembers:  PublicUsers(onlineMembers),
+		SortedOfflineMembers: PublicUsers(offlineMembers),
 	}, nil
 }
 
@@ -98,8 +116,8 @@ func (h *Hub) ChatStatusPayload(groupID int64) (ChatStatusPayload, error) {
 
 // toOnline = true 		means to send to clients in chat or online
 // toOnline = false 	means to send to clients in chat
-func (h *Hub) SendOutToGroup(groupID int64, payload interface{}, toOnline bool) error {
-	recipients, err := GetAllUsersOfGroup(h.DB, groupID)
+func (h *Hub) SendOutToGroup(ctx context.Context, groupID int64, payload interface{}, toOnline bool) error {
+	recipients, err := h.Store.GetAllUsersOfGroup(ctx, groupID)
 	if err != nil {
 		return err
 	}
@@ -118,26 +136,27 @@ func (h *Hub) SendOutToGroup(groupID int64, payload interface{}, toOnline bool)
 			select {
 			case client.Send <- payload:
 			default:
-				close(client.Send)
-				delete(h.Clients, client)
+				h.removeClient(client)
+				metrics.WSDroppedClients.Inc()
+				client.log().Warn("dropped client that fell behind on its messages")
 			}
 		}
 	}
 	return nil
 }
 
-func (h *Hub) SendOutChatStatus(userID int64) error {
+func (h *Hub) SendOutChatStatus(ctx context.Context, userID int64) error {
 	// userID is of user that induce the change in chat status
-	groups, err := GetAllGroupsOfUser(h.DB, userID)
+	groups, err := h.Store.GetAllGroupsOfUser(ctx, userID)
 	if err != nil {
 		return err
 	}
 	for _, group := range groups {
-		chatStatusPayload, err := h.ChatStatusPayload(group.ID)
+		chatStatusPayload, err := h.ChatStatusPayload(ctx, group.ID)
 		if err != nil {
 			return err
 		}
-		err = h.SendOutToGroup(group.ID, chatStatusPayload, false)
+		err = h.SendOutToGroup(ctx, group.ID, chatStatusPayload, false)
 		if err != nil {
 			return err
 		}
@@ -145,81 +164,166 @@ func (h *Hub) SendOutChatStatus(userID int64) error {
 	return nil
 }
 
-func (h *Hub) Run() {
+func (h *Hub) addClient(client *Client) {
+	h.Clients[client] = true
+	client.log().Debug("client connected")
+	metrics.WSClients.WithLabelValues(fmt.Sprint(client.GroupID)).Inc()
+}
+
+// Removes the client and closes its Send channel, which makes its writePump close the connection
+func (h *Hub) removeClient(client *Client) {
+	delete(h.Clients, client)
+	close(client.Send)
+	group := fmt.Sprint(client.GroupID)
+	if h.groupClientCount(client.GroupID) == 0 {
+		metrics.WSClients.DeleteLabelValues(group)
+	} else {
+		metrics.WSClients.WithLabelValues(group).Dec()
+	}
+}
+
+func (h *Hub) groupClientCount(groupID int64) int {
+	count := 0
+	for client := range h.Clients {
+		if client.GroupID == groupID {
+			count++
+		}
+	}
+	return count
+}
+
+// Closes every client with a close frame giving reason, then waits until each has been
+// written out. Writes time out after writeWait, so this does not wait on a stuck connection
+func (h *Hub) closeClients(reason string) {
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseServiceRestart, reason)
+	closed := make([]*Client, 0, len(h.Clients))
+	for client := range h.Clients {
+		client.closeMessage = closeMessage
+		h.removeClient(client)
+		closed = append(closed, client)
+	}
+	for _, client := range closed {
+		<-client.done
+	}
+}
+
+// Waits until Run has returned, or until ctx is done
+func (h *Hub) Wait(ctx context.Context) error {
+	select {
+	case <-h.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stores a chat message and sends it out to the members of its group. Messages are still
+// delivered while the hub stops, so this does not run with the context of Run
+func (h *Hub) broadcast(message Message) {
+	ctx := context.Background()
+	metrics.WSBroadcastQueueLength.Set(float64(len(h.Broadcast)))
+	if !message.IsServerMessage() {
+		if err := h.Store.AddMessage(ctx, message); err != nil {
+			log.Error("failed to store chat message", "user_id", message.UserID, "group_id", message.GroupID, "error", err)
+			return
+		}
+	}
+
+	messagePayload, err := h.Store.GetMessagePayload(ctx, message)
+	if err != nil {
+		log.Error("failed to load chat message", "user_id", message.UserID, "group_id", message.GroupID, "error", err)
+		return
+	}
+
+	err = h.SendOutToGroup(ctx, message.GroupID, messagePayload, true)
+	if err != nil {
+		log.Error("failed to send out chat message", "user_id", message.UserID, "group_id", message.GroupID, "error", err)
+	}
+}
+
+// Reports whether Run is serving clients
+func (h *Hub) Running() bool {
+	select {
+	case <-h.done:
+		return false
+	default:
+	}
+	select {
+	case <-h.started:
+		return true
+	default:
+		return false
+	}
+}
+
+// Serves the clients until ctx is done, when every client is told the server is restarting
+func (h *Hub) Run(ctx context.Context) {
+	close(h.started)
+	defer close(h.done)
 	for {
 		select {
+		case <-ctx.Done():
+			// Messages already sent by clients are still stored and delivered
+			for len(h.Broadcast) > 0 {
+				h.broadcast(<-h.Broadcast)
+			}
+			h.closeClients("server restarting")
+			return
 		case client := <-h.Register:
-			h.Clients[client] = true
+			h.addClient(client)
 
-			err := h.SendOutChatStatus(client.UserID)
+			err := h.SendOutChatStatus(client.ctx, client.UserID)
 			if err != nil {
-				fmt.Printf("An error occured during sending chat status payload. %v \n", err)
+				client.log().Error("failed to send out chat status", "error", err)
 				continue
 			}
 
-			clientName, err := client.UserName(h.DB)
+			clientName, err := client.UserName(client.ctx, h.Store)
 			if err != nil {
-				fmt.Printf("An error occured during retrieving first name of client. %v \n", err)
+				client.log().Error("failed to get the name of the client", "error", err)
 				continue
 			}
-			serverMessagePayload, err := Message{
+			serverMessagePayload, err := h.Store.GetMessagePayload(client.ctx, Message{
 				UserID:    ServerUserID,
 				GroupID:   client.GroupID,
 				TimeAdded: time.Now(),
 				Msg:       fmt.Sprintf("%s has joined the chat.", clientName),
-			}.Payload(h.DB)
+			})
 			if err != nil {
-				fmt.Printf("An error occured during creating server message payload. %v \n", err)
+				client.log().Error("failed to create server message", "error", err)
 				continue
 			}
-			h.SendOutToGroup(client.GroupID, serverMessagePayload, false)
+			h.SendOutToGroup(client.ctx, client.GroupID, serverMessagePayload, false)
 		case client := <-h.Unregister:
 			if _, ok := h.Clients[client]; ok {
-				delete(h.Clients, client)
-				close(client.Send)
+				h.removeClient(client)
+				client.log().Debug("client disconnected")
 
-				err := h.SendOutChatStatus(client.UserID)
+				err := h.SendOutChatStatus(client.ctx, client.UserID)
 				if err != nil {
-					fmt.Printf("An error occured during sending chat status payload. %v \n", err)
+					client.log().Error("failed to send out chat status", "error", err)
 					continue
 				}
 
-				clientName, err := client.UserName(h.DB)
+				clientName, err := client.UserName(client.ctx, h.Store)
 				if err != nil {
-					fmt.Printf("An error occured during retrieving first name of client. %v \n", err)
+					client.log().Error("failed to get the name of the client", "error", err)
 					continue
 				}
-				serverMessagePayload, err := Message{
+				serverMessagePayload, err := h.Store.GetMessagePayload(client.ctx, Message{
 					UserID:    ServerUserID,
 					GroupID:   client.GroupID,
 					TimeAdded: time.Now(),
 					Msg:       fmt.Sprintf("%s has left the chat.", clientName),
-				}.Payload(h.DB)
+				})
 				if err != nil {
-					fmt.Printf("An error occured during creating server message payload. %v \n", err)
+					client.log().Error("failed to create server message", "error", err)
 					continue
 				}
-				h.SendOutToGroup(client.GroupID, serverMessagePayload, false)
+				h.SendOutToGroup(client.ctx, client.GroupID, serverMessagePayload, false)
 			}
 		case message := <-h.Broadcast:
-			if !message.IsServerMessage() {
-				if err := AddMessage(h.DB, message); err != nil {
-					fmt.Printf("An error occured during adding to database. %v \n", err)
-					continue
-				}
-			}
-
-			messagePayload, err := message.Payload(h.DB)
-			if err != nil {
-				fmt.Printf("An error occured during loading. %v \n", err)
-				continue
-			}
-
-			err = h.SendOutToGroup(message.GroupID, messagePayload, true)
-			if err != nil {
-				fmt.Printf("An error occured while getting recipient set. %v \n", err)
-				continue
-			}
+			h.broadcast(message)
 		}
 	}
 }
@@ -1,37 +1,34 @@
 package ws
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper"
-	"wellnus/backend/router/http_helper/http_error"
-	"fmt"
-	"database/sql"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ConnectToWSHandler(wsHub *Hub, db *sql.DB) func(*gin.Context) {
+func ConnectToWSHandler(wsHub *Hub, s store.Store) func(*gin.Context) {
 	return func(c *gin.Context) {
 		http_helper.SetHeaders(c)
 
 		groupID, err := http_helper.GetIDParams(c)
 		if err != nil {
-			fmt.Printf("An error occured when retrieving group ID params. %v \n", err)
+			log.WarnContext(c.Request.Context(), "websocket requested without a group ID", "error", err)
 			return
 		}
-		userID, err := http_helper.GetUserIDFromSessionCookie(db, c)
+		userID, err := http_helper.GetAuthUserID(c)
 		if err != nil {
-			fmt.Printf("An error occured when retrieving user ID cookies. %v \n", err)
+			log.WarnContext(c.Request.Context(), "websocket requested without a session", "error", err)
 			return
 		}
-		isMember, err := model.IsUserInGroup(db, userID, groupID)
+		isMember, err := s.IsUserInGroup(c.Request.Context(), userID, groupID)
 		if err != nil {
-			fmt.Printf("An error occured when checking if user is in group. %v \n", err)
+			log.ErrorContext(c.Request.Context(), "failed to check if user is in group", "user_id", userID, "group_id", groupID, "error", err)
 			return
 		}
-		if !isMember {
-			err = http_error.UnauthorizedError
-			fmt.Printf("User is not part of group. %v \n", err)
+		if err := Authorize(User{ID: userID}, ActionReadGroupMessages, GroupRelations(userID, Group{ID: groupID}, isMember)); err != nil {
+			log.WarnContext(c.Request.Context(), "websocket requested by a user outside the group", "user_id", userID, "group_id", groupID, "error", err)
 			return
 		}
 		ServeWs(wsHub, c.Writer, c.Request, userID, groupID)
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/logger"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"context"
+	"errors"
+	"time"
+)
+
+var log = logger.For("scheduler")
+
+// Wait after a failed run before the triggers are checked again, doubled with every failure in a
+// row up to maxRetryBackoff
+const (
+	minRetryBackoff = time.Minute
+	maxRetryBackoff = time.Hour
+)
+
+// Scheduler starts matching runs in the background, so that joining the queue never waits on
+// matching. Every PollInterval it checks the triggers of its MatchingConfig:
+//   - QUEUE_SIZE once Threshold match requests are queued
+//   - SCHEDULE once Interval has passed since the last run and anyone is queued
+//
+// Admins trigger MANUAL runs through the store. Every instance may run a scheduler, as the
+// store makes sure only one run happens at a time
+type Scheduler struct {
+	Store  store.Store
+	Config config.MatchingConfig
+
+	// Clock the triggers are checked against. Tests may replace it to move time forward
+	Now func() time.Time
+
+	// Time the scheduler was made, which stands in for the last run until there is one
+	since time.Time
+
+	// Failed runs in a row, and the time before which no run is retried after them
+	failures int
+	retryAt  time.Time
+
+	// Closed once Run has returned
+	done chan struct{}
+}
+
+func NewScheduler(s store.Store, cfg config.MatchingConfig) *Scheduler {
+	return &Scheduler{
+		Store:  s,
+		Config: cfg,
+		Now:    time.Now,
+		since:  time.Now(),
+		done:   make(chan struct{}),
+	}
+}
+
+// Returns the trigger of the run that is due, or "" when none is
+func (sch *Scheduler) due(ctx context.Context) (string, error) {
+	count, err := sch.Store.GetMatchRequestCount(ctx)
+	if err != nil {
+		return "", err
+	}
+	if count >= int64(sch.Config.Threshold) {
+		return MatchingTriggerQueueSize, nil
+	}
+	if sch.Config.Interval <= 0 || count == 0 {
+		return "", nil
+	}
+	last := sch.since
+	run, err := sch.Store.GetLatestMatchingRun(ctx)
+	if err == nil && run.TimeStarted.After(last) {
+		last = run.TimeStarted
+	} else if err != nil && err != http_error.NotFoundError {
+		return "", err
+	}
+	if sch.Now().Sub(last) >= sch.Config.Interval {
+		return MatchingTriggerSchedule, nil
+	}
+	return "", nil
+}
+
+// Checks the triggers once and starts a run when one is due. Reports false when no run was due,
+// another instance was running one already, or a failed run is still being backed off from.
+// The error of a failed run is returned with it
+func (sch *Scheduler) Tick(ctx context.Context) (MatchingRun, bool, error) {
+	if sch.Now().Before(sch.retryAt) {
+		return MatchingRun{}, false, nil
+	}
+	trigger, err := sch.due(ctx)
+	if err != nil || trigger == "" {
+		return MatchingRun{}, false, err
+	}
+	run, err := sch.Store.RunMatching(ctx, trigger)
+	if errors.Is(err, MatchingInProgressError) {
+		log.DebugContext(ctx, "matching run skipped as another is in progress", "trigger", trigger)
+		return MatchingRun{}, false, nil
+	}
+	if err != nil {
+		sch.backOff(ctx)
+	} else {
+		sch.failures, sch.retryAt = 0, time.Time{}
+	}
+	return run, true, err
+}
+
+// Holds off the next run after a failed one, so that a run failing every time does not
+// hold the database every PollInterval
+func (sch *Scheduler) backOff(ctx context.Context) {
+	wait := minRetryBackoff
+	if sch.Config.PollInterval > wait {
+		wait = sch.Config.PollInterval
+	}
+	for i := 0; i < sch.failures && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+	sch.failures++
+	sch.retryAt = sch.Now().Add(wait)
+	log.WarnContext(ctx, "matching run failed, backing off", "failures", sch.failures, "retry_at", sch.retryAt)
+}
+
+// Checks the triggers every PollInterval until ctx is done. A run that has started is finished
+// before Run returns, so that its groups are not cut off halfway
+func (sch *Scheduler) Run(ctx context.Context) {
+	defer close(sch.done)
+	if sch.Config.PollInterval <= 0 {
+		log.Info("matching scheduler is off")
+		return
+	}
+	ticker := time.NewTicker(sch.Config.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Failed runs are logged by the store
+			if _, ran, err := sch.Tick(context.WithoutCancel(ctx)); err != nil && !ran {
+				log.Error("failed to check the matching triggers", "error", err)
+			}
+		}
+	}
+}
+
+// Waits for Run to return, or for ctx to be done
+func (sch *Scheduler) Wait(ctx context.Context) error {
+	select {
+	case <-sch.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
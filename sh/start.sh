@@ -1,8 +1,6 @@
 #!/bin/sh
 set -e
 
-echo "run db migration."
-/app/migrate -path /app/migration -database "postgresql://root:password@db:5432/wellnus?sslmode=disable" -verbose up
-
+# The app applies its embedded migrations to DB_ADDRESS on startup
 echo "start the app."
-exec "$@"
\ No newline at end of file
+exec "$@"
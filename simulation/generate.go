@@ -0,0 +1,63 @@
+package simulation
+
+import (
+	. "wellnus/backend/db/model"
+
+	"math/rand"
+)
+
+var faculties = []string{"CHS", "BUSINESS", "COMPUTING", "DENTISTRY", "CDE", "LAW", "MEDICINE", "NURSING", "PHARMACY", "MUSIC"}
+var preferences = []string{"MIX", "SAME", "NONE"}
+var hobbies = []string{"GAMING", "SINGING", "DANCING", "MUSIC", "SPORTS", "OUTDOOR", "BOOK", "ANIME", "MOVIES", "TV", "ART", "STUDY"}
+var genders = []string{"M", "F"}
+var mbtis = []string{"ISTJ", "ISFJ", "INFJ", "INTJ", "ISTP", "ISFP", "INFP", "INTP", "ESTP", "ESFP", "ENFP", "ENTP", "ESTJ", "ESFJ", "ENFJ", "ENTJ"}
+
+// Returns a match setting drawn from rng, so that a seeded rng always gives the same one
+func GenerateMatchSetting(rng *rand.Rand) MatchSetting {
+	facultyPreference := preferences[rng.Intn(len(preferences))]
+	mbti := mbtis[rng.Intn(len(mbtis))]
+	matchHobbies := make([]string, 0)
+	for _, hobby := range hobbies {
+		if rng.Intn(3) == 1 {
+			matchHobbies = append(matchHobbies, hobby)
+		}
+		if len(matchHobbies) >= 4 {
+			break
+		}
+	}
+	availability := make([]string, 0)
+	for _, slot := range Availabilities {
+		if rng.Intn(2) == 1 {
+			availability = append(availability, slot)
+		}
+	}
+	return MatchSetting{
+		FacultyPreference:  facultyPreference,
+		Hobbies:            matchHobbies,
+		MBTI:               mbti,
+		GenderPreference:   preferences[rng.Intn(len(preferences))],
+		YearOfStudy:        rng.Intn(7),
+		Availability:       availability,
+		PreferredGroupSize: []int{0, 3, 4, 5}[rng.Intn(4)],
+	}
+}
+
+// Returns a queue of n match requests of users of any faculty drawn from rng, without touching a store
+func GenerateLoadedMatchRequests(rng *rand.Rand, n int) []LoadedMatchRequest {
+	lmrs := make([]LoadedMatchRequest, n)
+	for i := range lmrs {
+		userID := int64(i + 1)
+		matchSetting := GenerateMatchSetting(rng)
+		matchSetting.UserID = userID
+		lmrs[i] = LoadedMatchRequest{
+			MatchRequest: MatchRequest{UserID: userID},
+			User: PublicUser{
+				ID:      userID,
+				Gender:  genders[rng.Intn(len(genders))],
+				Faculty: faculties[rng.Intn(len(faculties))],
+			},
+			MatchSetting: matchSetting,
+		}
+	}
+	return lmrs
+}
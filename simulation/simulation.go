@@ -0,0 +1,106 @@
+package simulation
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Strategies compared by the simulator
+var Strategies = []string{MatchStrategyGreedy, MatchStrategyAnnealing}
+
+// Queue sizes simulated when none are given
+var DefaultQueueSizes = []int{8, 40, 100, 200}
+
+// Synthetic populations drawn for every queue size, seeded from 1
+const Populations = 3
+
+// Result of matching one synthetic population with one strategy
+type Result struct {
+	Strategy  string
+	QueueSize int
+	Seed      int64 // seed the population was drawn with
+	Stats     MatchingStats
+	Duration  time.Duration
+}
+
+// Draws Populations queues of every size in queueSizes and plans matching on each with every one
+// of strategies, as a run configured by matching would without any open group to fill. The same
+// arguments always give the same stats
+func Simulate(matching config.MatchingConfig, strategies []string, queueSizes []int) []Result {
+	results := make([]Result, 0, len(queueSizes)*Populations*len(strategies))
+	for _, n := range queueSizes {
+		for seed := int64(1); seed <= Populations; seed++ {
+			lmrs := GenerateLoadedMatchRequests(rand.New(rand.NewSource(seed)), n)
+			for _, strategy := range strategies {
+				matching.Strategy = strategy
+				start := time.Now()
+				dryRun := DryRunPlan(lmrs, nil, matching)
+				results = append(results, Result{
+					Strategy:  strategy,
+					QueueSize: n,
+					Seed:      seed,
+					Stats:     dryRun.Stats,
+					Duration:  time.Since(start),
+				})
+			}
+		}
+	}
+	return results
+}
+
+// Runs the simulate subcommand: simulate [queue size...], printing a table that compares every
+// strategy on the same populations. Needs no database
+func Run(matching config.MatchingConfig, args []string, out io.Writer) error {
+	queueSizes := DefaultQueueSizes
+	if len(args) > 0 {
+		queueSizes = make([]int, len(args))
+		for i, arg := range args {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("queue sizes must be positive integers, not %q", arg)
+			}
+			queueSizes[i] = n
+		}
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "queue\tseed\tstrategy\tgroups\tremaining\ttotal\tmean pair\tmin pair\ttime")
+	for _, result := range Simulate(matching, Strategies, queueSizes) {
+		stats := result.Stats
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%d\t%d\t%.2f\t%d\t%v\n",
+			result.QueueSize, result.Seed, result.Strategy, stats.Groups, stats.Remaining,
+			stats.TotalCompatibility, stats.MeanPairScore, stats.MinPairScore, result.Duration.Round(time.Microsecond))
+	}
+	return w.Flush()
+}
+
+// Runs the dry-run subcommand: dry-run [strategy], printing what a matching run would do with the
+// queue of db as JSON, without changing anything
+func DryRun(ctx context.Context, db *sql.DB, matching config.MatchingConfig, args []string, out io.Writer) error {
+	if len(args) > 1 {
+		return errors.New("usage: dry-run [greedy | annealing]")
+	}
+	if len(args) == 1 {
+		if args[0] != MatchStrategyGreedy && args[0] != MatchStrategyAnnealing {
+			return fmt.Errorf("unknown strategy %q", args[0])
+		}
+		matching.Strategy = args[0]
+	}
+	dryRun, err := DryRunMatching(ctx, db, matching)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dryRun)
+}
@@ -0,0 +1,207 @@
+package account
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var newUser User = test_helper.GetTestUser(0)
+var newPassword string = "NewPassword123"
+
+// Full Tests
+
+func TestAccount(t *testing.T) {
+	t.Run("Signup sends verification email", testSignupSendsVerificationEmail)
+	t.Run("Login before verification", testLoginBeforeVerification)
+	t.Run("Verify email with invalid token", testVerifyEmailInvalidToken)
+	t.Run("Resend verification email", testResendVerificationEmail)
+	t.Run("Verify email", testVerifyEmail)
+	t.Run("Verify email token is single use", testVerifyEmailTokenSingleUse)
+	t.Run("Forgot password of unknown email", testForgotPasswordUnknownEmail)
+	t.Run("Reset password", testResetPassword)
+	t.Run("Reset password token is single use", testResetPasswordTokenSingleUse)
+	t.Run("Reset password token expires", testResetPasswordTokenExpires)
+}
+
+// Helpers
+
+func postObject(url string, obj interface{}) *httptest.ResponseRecorder {
+	ioReader, _ := test_helper.GetIOReaderFromObject(obj)
+	req, _ := http.NewRequest("POST", url, ioReader)
+	return test_helper.SimulateRequest(Router, req)
+}
+
+func login(password string) *httptest.ResponseRecorder {
+	return postObject("/session", User{Email: newUser.Email, Password: password})
+}
+
+func latestToken(t *testing.T) string {
+	token, err := test_helper.GetTokenFromMailer(Mailer, newUser.Email)
+	if err != nil {
+		t.Fatalf("Could not read token from email. %v", err)
+	}
+	return token
+}
+
+func sentCount(t *testing.T, email string) int {
+	messages, err := Mailer.Messages(email)
+	if err != nil {
+		t.Fatalf("An error occured while reading sent emails. %v", err)
+	}
+	return len(messages)
+}
+
+func testSignupSendsVerificationEmail(t *testing.T) {
+	w := postObject("/user", newUser)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Request to sign up failed with status code of %d", w.Code)
+	}
+	addedUser, err := test_helper.GetUserFromRecorder(w)
+	if err != nil {
+		t.Fatalf("An error occured while getting added user from body. %v", err)
+	}
+	if addedUser.EmailVerified {
+		t.Errorf("Email of new user was verified on sign up")
+	}
+	if test_helper.GetCookieFromRecorder(w, "session_key") != "" {
+		t.Errorf("Session was created on sign up before the email was verified")
+	}
+	newUser.ID = addedUser.ID
+	if sentCount(t, newUser.Email) != 1 {
+		t.Errorf("Expected exactly 1 verification email after sign up")
+	}
+}
+
+func testLoginBeforeVerification(t *testing.T) {
+	w := login(newUser.Password)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Login with unverified email did not return 403. Status Code: %d", w.Code)
+	}
+	if test_helper.GetCookieFromRecorder(w, "session_key") != "" {
+		t.Errorf("Session was created for a user with an unverified email")
+	}
+}
+
+func testVerifyEmailInvalidToken(t *testing.T) {
+	w := postObject("/email/verify", TokenBody{Token: "not-a-token"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Verifying with an invalid token did not return 422. Status Code: %d", w.Code)
+	}
+}
+
+func testResendVerificationEmail(t *testing.T) {
+	oldToken := latestToken(t)
+	w := postObject("/email/resend", EmailBody{Email: newUser.Email})
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Request to resend verification failed with status code of %d", w.Code)
+	}
+	if sentCount(t, newUser.Email) != 2 {
+		t.Errorf("Verification email was not resent")
+	}
+	w = postObject("/email/verify", TokenBody{Token: oldToken})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Token replaced by a resent email was still accepted. Status Code: %d", w.Code)
+	}
+}
+
+func testVerifyEmail(t *testing.T) {
+	w := postObject("/email/verify", TokenBody{Token: latestToken(t)})
+	sessionResponse, err := test_helper.GetSessionResponseFromRecorder(w)
+	if err != nil {
+		t.Fatalf("An error occured while retrieving response body. %v", err)
+	}
+	if !sessionResponse.LoggedIn || !sessionResponse.User.EmailVerified {
+		t.Errorf("Verifying email did not log in a verified user. %v", sessionResponse)
+	}
+	sessionKey := test_helper.GetCookieFromRecorder(w, "session_key")
+	if userID, err := Store.GetUserIDFromSessionKey(context.Background(), sessionKey); err != nil || userID != newUser.ID {
+		t.Errorf("Session created on verification does not belong to the user. %v", err)
+	}
+	if w := login(newUser.Password); w.Code != http.StatusOK {
+		t.Errorf("Login after verification failed with status code of %d", w.Code)
+	}
+}
+
+func testVerifyEmailTokenSingleUse(t *testing.T) {
+	w := postObject("/email/verify", TokenBody{Token: latestToken(t)})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Verification token was accepted twice. Status Code: %d", w.Code)
+	}
+	postObject("/email/resend", EmailBody{Email: newUser.Email})
+	if sentCount(t, newUser.Email) != 2 {
+		t.Errorf("Verification email was sent to an already verified user")
+	}
+}
+
+func testForgotPasswordUnknownEmail(t *testing.T) {
+	unknownEmail := "unknown@u.nus.edu"
+	wUnknown := postObject("/password/forgot", EmailBody{Email: unknownEmail})
+	wKnown := postObject("/password/forgot", EmailBody{Email: newUser.Email})
+	if wUnknown.Code != http.StatusOK || wKnown.Code != http.StatusOK {
+		t.Errorf("Forgot password responded with %d for an unknown email and %d for a known one", wUnknown.Code, wKnown.Code)
+	}
+	if wUnknown.Body.String() != wKnown.Body.String() {
+		t.Errorf("Forgot password reveals whether an email belongs to an account")
+	}
+	if sentCount(t, unknownEmail) != 0 {
+		t.Errorf("Email was sent to an address without an account")
+	}
+}
+
+func testResetPassword(t *testing.T) {
+	sessionKey, _ := Store.CreateNewSession(context.Background(), newUser.ID, "unit test")
+	w := postObject("/password/reset", TokenBody{Token: latestToken(t), Password: newPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Request to reset password failed with status code of %d", w.Code)
+	}
+	if _, err := Store.GetUserIDFromSessionKey(context.Background(), sessionKey); err == nil {
+		t.Errorf("Session created before the password reset is still valid")
+	}
+	if w := login(newUser.Password); w.Code == http.StatusOK {
+		if sessionResponse, _ := test_helper.GetSessionResponseFromRecorder(w); sessionResponse.LoggedIn {
+			t.Errorf("Old password still works after reset")
+		}
+	}
+	w = login(newPassword)
+	if sessionResponse, _ := test_helper.GetSessionResponseFromRecorder(w); !sessionResponse.LoggedIn {
+		t.Errorf("New password does not work after reset. Status Code: %d", w.Code)
+	}
+}
+
+func testResetPasswordTokenSingleUse(t *testing.T) {
+	w := postObject("/password/reset", TokenBody{Token: latestToken(t), Password: "AnotherPassword"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Password reset token was accepted twice. Status Code: %d", w.Code)
+	}
+}
+
+func testResetPasswordTokenExpires(t *testing.T) {
+	memoryStore, ok := Store.(*store.MemoryStore)
+	if !ok {
+		t.Skip("Token expiry is only simulated against the in memory store")
+	}
+	now := time.Now()
+	memoryStore.Now = func() time.Time { return now }
+	defer func() { memoryStore.Now = time.Now }()
+
+	postObject("/password/forgot", EmailBody{Email: newUser.Email})
+	start := now
+	now = now.Add(TokenTTL[TokenResetPassword] + time.Minute)
+	w := postObject("/password/reset", TokenBody{Token: latestToken(t), Password: "AnotherPassword"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expired password reset token was accepted. Status Code: %d", w.Code)
+	}
+	// The expired token was deleted, so it stays rejected even within its lifetime
+	now = start
+	w = postObject("/password/reset", TokenBody{Token: latestToken(t), Password: "AnotherPassword"})
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expired password reset token was kept after it was rejected. Status Code: %d", w.Code)
+	}
+}
@@ -0,0 +1,49 @@
+package account
+
+import (
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
+	"wellnus/backend/router/account"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/router/session"
+	"wellnus/backend/router/user"
+	"wellnus/backend/unit_test/test_helper"
+
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store  store.Store
+	Router *gin.Engine
+	Mailer *mailer.FileMailer
+)
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+
+	router.POST("/user", user.AddUserHandler(Store, Mailer))
+	router.POST("/session", session.LoginHandler(Store))
+	router.POST("/email/verify", account.VerifyEmailHandler(Store))
+	router.POST("/email/resend", account.ResendVerificationHandler(Store, Mailer))
+	router.POST("/password/forgot", account.ForgotPasswordHandler(Store, Mailer))
+	router.POST("/password/reset", account.ResetPasswordHandler(Store))
+	protected.GET("/session", session.GetAllSessionsHandler(Store))
+
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	Mailer = test_helper.SetupMailer()
+	Router = setupRouter()
+
+	code := m.Run()
+	os.RemoveAll(Mailer.Dir)
+	os.Exit(code)
+}
@@ -0,0 +1,308 @@
+package admin
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// Full test
+func TestAdminHandler(t *testing.T) {
+	t.Run("AddUserHandler as admin rejected", testAddUserHandlerAsAdminRejected)
+	t.Run("AddUserHandler as volunteer rejected", testAddUserHandlerAsVolunteerRejected)
+	t.Run("UpdateUserHandler role change rejected", testUpdateUserHandlerRoleChangeRejected)
+	t.Run("AddReportHandler", testAddReportHandler)
+	t.Run("AddReportHandler duplicate", testAddReportHandlerDuplicate)
+	t.Run("AddReportHandler missing target", testAddReportHandlerMissingTarget)
+	t.Run("GetAllReportsHandler as member", testGetAllReportsHandlerAsMember)
+	t.Run("GetAllReportsHandler", testGetAllReportsHandler)
+	t.Run("GetAllReportsHandler paged", testGetAllReportsHandlerPaged)
+	t.Run("DeleteReportHandler", testDeleteReportHandler)
+	t.Run("VerifyUserHandler unverified counsellor", testVerifyUserHandlerUnverifiedCounsellor)
+	t.Run("ChangeUserRoleHandler as member", testChangeUserRoleHandlerAsMember)
+	t.Run("ChangeUserRoleHandler", testChangeUserRoleHandler)
+	t.Run("SuspendUserHandler", testSuspendUserHandler)
+	t.Run("ForceDeleteGroupHandler as member", testForceDeleteGroupHandlerAsMember)
+	t.Run("ForceDeleteGroupHandler", testForceDeleteGroupHandler)
+	t.Run("ForceDeleteEventHandler", testForceDeleteEventHandler)
+}
+
+// Helper
+func newRequestWithSession(method string, url string, body interface{}, sessionKey string) *http.Request {
+	ioReader, _ := test_helper.GetIOReaderFromObject(body)
+	req, _ := http.NewRequest(method, url, ioReader)
+	req.AddCookie(&http.Cookie{
+		Name:  "session_key",
+		Value: sessionKey,
+	})
+	return req
+}
+
+func testAddUserHandlerAsAdminRejected(t *testing.T) {
+	newUser := test_helper.GetTestUser(3)
+	newUser.UserRole = "ADMIN"
+	ioReaderUser, _ := test_helper.GetIOReaderFromObject(newUser)
+	req, _ := http.NewRequest("POST", "/user", ioReaderUser)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("HTTP Request to register an admin did not return 422. Status Code: %d", w.Code)
+	}
+}
+
+func testAddUserHandlerAsVolunteerRejected(t *testing.T) {
+	newUser := test_helper.GetTestUser(4)
+	ioReaderUser, _ := test_helper.GetIOReaderFromObject(newUser)
+	req, _ := http.NewRequest("POST", "/user", ioReaderUser)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("HTTP Request to register a volunteer did not return 422. Status Code: %d", w.Code)
+	}
+
+	newUser.UserRole = "MEMBER"
+	ioReaderUser, _ = test_helper.GetIOReaderFromObject(newUser)
+	req, _ = http.NewRequest("POST", "/user", ioReaderUser)
+	w = test_helper.SimulateRequest(Router, req)
+	addedUser, err := test_helper.GetUserFromRecorder(w)
+	if err != nil {
+		t.Fatalf("An error occured while registering as a member after the volunteer was rejected. %v", err)
+	}
+	if _, err := test_helper.VerifyEmailOfUser(Store, addedUser); err != nil {
+		t.Fatalf("An error occured while verifying the email of the member. %v", err)
+	}
+	sessionKey, _ := Store.CreateNewSession(context.Background(), addedUser.ID, "unit test")
+	w = test_helper.SimulateRequest(Router, newRequestWithSession("GET", "/counsel", nil, sessionKey))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for a member who asked to be a volunteer to list counsel requests was not Unauthorized. Status Code: %d", w.Code)
+	}
+}
+
+func testUpdateUserHandlerRoleChangeRejected(t *testing.T) {
+	req := newRequestWithSession("PATCH", fmt.Sprintf("/user/%d", testUsers[0].ID), User{UserRole: "COUNSELLOR"}, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("HTTP Request for member to promote themselves did not return 422. Status Code: %d", w.Code)
+	}
+	user, _ := Store.GetUser(context.Background(), testUsers[0].ID)
+	if user.UserRole != "MEMBER" {
+		t.Errorf("User role was changed to %s through UpdateUserHandler", user.UserRole)
+	}
+}
+
+func testAddReportHandler(t *testing.T) {
+	report := Report{TargetType: "GROUP", TargetID: testGroups[1].ID, Reason: "Spam"}
+	req := newRequestWithSession("POST", "/report", report, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	addedReport, err := test_helper.GetReportFromRecorder(w)
+	if err != nil {
+		t.Errorf("An error occured while getting report from recorder. %v", err)
+	}
+	if addedReport.ID == 0 || addedReport.ReporterID != testUsers[0].ID {
+		t.Errorf("Added report %v did not have an ID or the reporter's ID", addedReport)
+	}
+}
+
+func testAddReportHandlerDuplicate(t *testing.T) {
+	report := Report{TargetType: "GROUP", TargetID: testGroups[1].ID, Reason: "Spam again"}
+	req := newRequestWithSession("POST", "/report", report, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("HTTP Request to report the same group twice did not return 409. Status Code: %d", w.Code)
+	}
+}
+
+func testAddReportHandlerMissingTarget(t *testing.T) {
+	report := Report{TargetType: "EVENT", TargetID: testEvents[2].ID + 100, Reason: "Spam"}
+	req := newRequestWithSession("POST", "/report", report, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP Request to report a missing event did not return 404. Status Code: %d", w.Code)
+	}
+}
+
+func testGetAllReportsHandlerAsMember(t *testing.T) {
+	req := newRequestWithSession("GET", "/admin/report", nil, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for member to list reports was not Unauthorized. Status Code: %d", w.Code)
+	}
+}
+
+func testGetAllReportsHandler(t *testing.T) {
+	req := newRequestWithSession("GET", "/admin/report", nil, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	reports, err := test_helper.GetPageFromRecorder[Report](w)
+	if err != nil {
+		t.Errorf("An error occured while getting reports from recorder. %v", err)
+	}
+	if len(reports.Items) != 1 || reports.NextCursor != "" {
+		t.Errorf("%d reports found on more than one page despite 1 report being added", len(reports.Items))
+	}
+}
+
+func testGetAllReportsHandlerPaged(t *testing.T) {
+	report := Report{TargetType: "GROUP", TargetID: testGroups[1].ID, Reason: "Spam"}
+	addedReport, err := Store.AddReport(context.Background(), report, testUsers[1].ID)
+	if err != nil {
+		t.Fatalf("An error occured while adding a second report. %v", err)
+	}
+	defer Store.DeleteReport(context.Background(), addedReport.ID, testAdmin.ID)
+
+	req := newRequestWithSession("GET", "/admin/report?limit=1&order=desc", nil, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	firstPage, err := test_helper.GetPageFromRecorder[Report](w)
+	if err != nil {
+		t.Fatalf("An error occured while getting reports from recorder. %v", err)
+	}
+	if len(firstPage.Items) != 1 || firstPage.Items[0].ID != addedReport.ID || firstPage.NextCursor == "" {
+		t.Fatalf("First page %v did not hold only the newest report with a cursor to the next", firstPage)
+	}
+	req = newRequestWithSession("GET", "/admin/report?limit=1&order=desc&cursor="+firstPage.NextCursor, nil, adminSessionKey)
+	w = test_helper.SimulateRequest(Router, req)
+	secondPage, err := test_helper.GetPageFromRecorder[Report](w)
+	if err != nil {
+		t.Fatalf("An error occured while getting reports from recorder. %v", err)
+	}
+	if len(secondPage.Items) != 1 || secondPage.Items[0].ID == addedReport.ID || secondPage.NextCursor != "" {
+		t.Errorf("Second page %v did not hold only the older report as the last page", secondPage)
+	}
+}
+
+func testDeleteReportHandler(t *testing.T) {
+	reports, _ := Store.GetReportsPage(context.Background(), testAdmin.ID, PageQuery{})
+	req := newRequestWithSession("DELETE", fmt.Sprintf("/admin/report/%d", reports.Items[0].ID), nil, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP Request for admin to delete report failed. Status Code: %d", w.Code)
+	}
+	reports, _ = Store.GetReportsPage(context.Background(), testAdmin.ID, PageQuery{})
+	if len(reports.Items) != 0 {
+		t.Errorf("%d reports found after the only report was deleted", len(reports.Items))
+	}
+}
+
+func testVerifyUserHandlerUnverifiedCounsellor(t *testing.T) {
+	// Counsellors cannot sign up, so this one is added as if promoted in the database
+	addedCounsellor, err := Store.AddUser(context.Background(), test_helper.GetTestUser(5))
+	if err != nil {
+		t.Fatalf("An error occured while adding a counsellor. %v", err)
+	}
+	if addedCounsellor.Verified {
+		t.Errorf("Newly registered counsellor was already verified")
+	}
+	if _, err := test_helper.VerifyEmailOfUser(Store, addedCounsellor); err != nil {
+		t.Fatalf("An error occured while verifying the email of the counsellor. %v", err)
+	}
+	counsellorSessionKey, _ := Store.CreateNewSession(context.Background(), addedCounsellor.ID, "unit test")
+
+	req := newRequestWithSession("POST", "/provider", test_helper.GetTestProviderSetting(0), counsellorSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for unverified counsellor to add provider setting was not Unauthorized. Status Code: %d", w.Code)
+	}
+
+	req = newRequestWithSession("POST", fmt.Sprintf("/admin/user/%d/verify", addedCounsellor.ID), VerifiedBody{Verified: true}, adminSessionKey)
+	w = test_helper.SimulateRequest(Router, req)
+	verifiedCounsellor, err := test_helper.GetUserFromRecorder(w)
+	if err != nil {
+		t.Errorf("An error occured while verifying counsellor. %v", err)
+	}
+	if !verifiedCounsellor.Verified {
+		t.Errorf("Counsellor was not verified by admin")
+	}
+
+	req = newRequestWithSession("POST", "/provider", test_helper.GetTestProviderSetting(0), counsellorSessionKey)
+	w = test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP Request for verified counsellor to add provider setting failed. Status Code: %d", w.Code)
+	}
+}
+
+func testChangeUserRoleHandlerAsMember(t *testing.T) {
+	req := newRequestWithSession("POST", fmt.Sprintf("/admin/user/%d/role", testUsers[0].ID), UserRoleBody{UserRole: "COUNSELLOR"}, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for member to change their own role was not Unauthorized. Status Code: %d", w.Code)
+	}
+}
+
+func testChangeUserRoleHandler(t *testing.T) {
+	req := newRequestWithSession("POST", fmt.Sprintf("/admin/user/%d/role", testUsers[0].ID), UserRoleBody{UserRole: "VOLUNTEER"}, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	updatedUser, err := test_helper.GetUserFromRecorder(w)
+	if err != nil {
+		t.Errorf("An error occured while changing user role. %v", err)
+	}
+	if updatedUser.UserRole != "VOLUNTEER" || !updatedUser.Verified {
+		t.Errorf("User role was not changed to a verified VOLUNTEER. %v", updatedUser)
+	}
+
+	req = newRequestWithSession("POST", fmt.Sprintf("/admin/user/%d/role", testUsers[0].ID), UserRoleBody{UserRole: "SUPERUSER"}, adminSessionKey)
+	w = test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("HTTP Request to change to an invalid role did not return 422. Status Code: %d", w.Code)
+	}
+}
+
+func testSuspendUserHandler(t *testing.T) {
+	req := newRequestWithSession("POST", fmt.Sprintf("/admin/user/%d/suspend", testUsers[1].ID), SuspendedBody{Suspended: true}, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP Request for admin to suspend user failed. Status Code: %d", w.Code)
+	}
+
+	report := Report{TargetType: "USER", TargetID: testUsers[0].ID, Reason: "Spam"}
+	req = newRequestWithSession("POST", "/report", report, sessionKeys[1])
+	w = test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request with the session of a suspended user was not Unauthorized. Status Code: %d", w.Code)
+	}
+
+	ioReaderLogin, _ := test_helper.GetIOReaderFromObject(User{Email: testUsers[1].Email, Password: "123"})
+	req, _ = http.NewRequest("POST", "/session", ioReaderLogin)
+	w = test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("HTTP Request for suspended user to log in did not return 403. Status Code: %d", w.Code)
+	}
+}
+
+func testForceDeleteGroupHandlerAsMember(t *testing.T) {
+	req := newRequestWithSession("DELETE", fmt.Sprintf("/admin/group/%d", testGroups[2].ID), nil, sessionKeys[0])
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for member to force delete group was not Unauthorized. Status Code: %d", w.Code)
+	}
+}
+
+func testForceDeleteGroupHandler(t *testing.T) {
+	req := newRequestWithSession("DELETE", fmt.Sprintf("/admin/group/%d", testGroups[2].ID), nil, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	deletedGroup, err := test_helper.GetGroupFromRecorder(w)
+	if err != nil {
+		t.Errorf("An error occured while force deleting group. %v", err)
+	}
+	if deletedGroup.ID != testGroups[2].ID {
+		t.Errorf("Deleted group %d was not the requested group %d", deletedGroup.ID, testGroups[2].ID)
+	}
+	if _, err := Store.GetGroup(context.Background(), testGroups[2].ID); err == nil {
+		t.Errorf("Group was still found after being force deleted")
+	}
+}
+
+func testForceDeleteEventHandler(t *testing.T) {
+	req := newRequestWithSession("DELETE", fmt.Sprintf("/admin/event/%d", testEvents[2].ID), nil, adminSessionKey)
+	w := test_helper.SimulateRequest(Router, req)
+	deletedEvent, err := test_helper.GetEventFromRecorder(w)
+	if err != nil {
+		t.Errorf("An error occured while force deleting event. %v", err)
+	}
+	if deletedEvent.ID != testEvents[2].ID {
+		t.Errorf("Deleted event %d was not the requested event %d", deletedEvent.ID, testEvents[2].ID)
+	}
+	if _, err := Store.GetEvent(context.Background(), testEvents[2].ID); err == nil {
+		t.Errorf("Event was still found after being force deleted")
+	}
+}
@@ -0,0 +1,102 @@
+package admin
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
+	"wellnus/backend/router/admin"
+	"wellnus/backend/router/counsel"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/router/provider"
+	"wellnus/backend/router/session"
+	"wellnus/backend/router/user"
+	"wellnus/backend/unit_test/test_helper"
+
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store                    store.Store
+	Router                   *gin.Engine
+	Mailer                   *mailer.FileMailer
+	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
+	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
+)
+
+// [member, volunteer, counsellor]
+var testUsers []User
+var testAdmin User
+var testGroups []Group
+var testEvents []Event
+var sessionKeys []string
+var adminSessionKey string
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+
+	router.POST("/user", user.AddUserHandler(Store, Mailer))
+	router.POST("/session", session.LoginHandler(Store))
+	protected.PATCH("/user/:id", user.UpdateUserHandler(Store, Mailer))
+	protected.POST("/provider", provider.AddUpdateProviderSettingOfUserHandler(Store))
+	protected.GET("/counsel", counsel.GetAllCounselRequestsHandler(Store))
+
+	protected.POST("/report", admin.AddReportHandler(Store))
+	protected.POST("/admin/user/:id/verify", admin.VerifyUserHandler(Store))
+	protected.POST("/admin/user/:id/role", admin.ChangeUserRoleHandler(Store))
+	protected.POST("/admin/user/:id/suspend", admin.SuspendUserHandler(Store))
+	protected.DELETE("/admin/group/:id", admin.ForceDeleteGroupHandler(Store))
+	protected.DELETE("/admin/event/:id", admin.ForceDeleteEventHandler(Store))
+	protected.GET("/admin/report", admin.GetAllReportsHandler(Store))
+	protected.DELETE("/admin/report/:id", admin.DeleteReportHandler(Store))
+
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	Mailer = test_helper.SetupMailer()
+	Router = setupRouter()
+	var err error
+
+	testUsers, err = test_helper.SetupUsers(Store, 3)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
+	}
+
+	testAdmin, err = test_helper.SetupAdmin(Store)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test admin. %v", err))
+	}
+
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+
+	adminSessionKeys, err := test_helper.SetupSessionForUsers(Store, []User{testAdmin})
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test admin session. %v", err))
+	}
+	adminSessionKey = adminSessionKeys[0]
+
+	testGroups, err = test_helper.SetupGroupsForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test groups. %v", err))
+	}
+
+	testEvents, err = test_helper.SetupEventForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test events. %v", err))
+	}
+
+	os.Exit(m.Run())
+}
@@ -1,7 +1,10 @@
 package booking
 
 import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/unit_test/test_helper"
+	"context"
 	"testing"
 	"net/http"
 	"fmt"
@@ -10,7 +13,8 @@ import (
 // Full test
 func TestBookingHandler(t *testing.T) {
 	t.Run("AddBookingHandler to no provider setting", testAddBookingHandlerToNoProviderSettingUser1ByUser0)
-	t.Run("GetBookingProviderHandler not logged in of no provider setting", testGetBookingProviderHandlerAsNotLoggedInNoProviderSetting)
+	t.Run("GetBookingProviderHandler not logged in", testGetBookingProviderHandlerAsNotLoggedIn)
+	t.Run("GetBookingProviderHandler as user0 of no provider setting", testGetBookingProviderHandlerAsUser0NoProviderSetting)
 	t.Run("GetAllBookingUsersHandler not logged in", testGetAllBookingUsersHandlerAsNotLoggedIn)
 	t.Run("GetAllBookingUsersHander as user1", testGetAllBookingUserHandlerAsUser1)
 	t.Run("GetAllBookingUsersHandler sent as user1", testGetAllBookingUsersHandlerSentAsUser1)
@@ -19,12 +23,15 @@ func TestBookingHandler(t *testing.T) {
 	t.Run("GetAllBookingusershandler required as user2", testGetAllBookingUsersHandlerRequiredAsUser2)
 	t.Run("RespondBookingHandler reject not logged in", testRespondBookingHandlerRejectNotLoggedIn)
 	t.Run("RespondBookingHandler reject as user1", testRespondBookingHandlerRejectAsUser1)
+	t.Run("RespondBookingHandler of no booking", testRespondBookingHandlerOfNoBooking)
 	t.Run("GetAllBookingUsersHandler required as user0", testGetAllBookingUserHandlerRequiredAsUser0)
 	t.Run("RespondBookingHandler approve as user0", testRespondBookingHandlerApproveAsUser0)
 	t.Run("UpdateBookinghandler not logged in", testUpdateBookingHandlerOfBooking1To2NotLoggedIn)
 	t.Run("UpdateBookingHandler unauthorised", testUpdateBookingHandlerOfBooking1To2AsUser2Unauthorized)
 	t.Run("UpdateBookingHandler authorised", testUpdateBookingHandlerOfBooking1To2AsUser1Authorized)
 	t.Run("GetBookingProvider after update", testGetBookingProviderOfBooking1To2AfterUpdate)
+	t.Run("GetBookingProvider as provider", testGetBookingProviderOfBooking1To2AsUser2)
+	t.Run("GetBookingProvider unauthorised", testGetBookingProviderOfBooking1To2AsUser0Unauthorized)
 	t.Run("DeleteBookingHandler unauthorised", testDeleteBookingHandlerOfBooking1To2AsUser2)
 	t.Run("DeleteBookingHandler authorised", testDeleteBookingHandlerOfBooking1To2AsUser1)
 	t.Run("GetBookingProvider after delete", testGetBookingProviderHandlerOfBooking1To2AfterDeletion)
@@ -55,9 +62,21 @@ func testAddBookingHandlerToNoProviderSettingUser1ByUser0(t *testing.T) {
 	}
 }
 
-func testGetBookingProviderHandlerAsNotLoggedInNoProviderSetting(t *testing.T) {
+func testGetBookingProviderHandlerAsNotLoggedIn(t *testing.T) {
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/booking/%d", testBooking0to1.ID), nil)
 	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetBookingProvider as not logged in did not give status unauthorized but status code of %d", w.Code)
+	}
+}
+
+func testGetBookingProviderHandlerAsUser0NoProviderSetting(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/booking/%d", testBooking0to1.ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[0],
+	})
+	w := test_helper.SimulateRequest(Router, req)
 	if w.Code != http.StatusNotFound { 
 		t.Errorf("HTTP Request to GetBookingProvider did not give status notfound but status code of %d", w.Code)
 	}
@@ -66,15 +85,8 @@ func testGetBookingProviderHandlerAsNotLoggedInNoProviderSetting(t *testing.T) {
 func testGetAllBookingUsersHandlerAsNotLoggedIn(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/booking", nil)
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code != http.StatusOK { 
-		t.Errorf("HTTP Request to GetAllBookingUser failed with status code of %d", w.Code)
-	}
-	bookingUsers, err := test_helper.GetBookingUsersFromRecorder(w)
-	if err != nil {
-		t.Errorf("An error occured while retrieving all bookingUsers from response. %v", err)
-	}
-	if len(bookingUsers) != 0 {
-		t.Errorf("A user who is not logged in saw some bookings involving him")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetAllBookingUsers as not logged in did not give status unauthorized but status code of %d", w.Code)
 	}
 }
 
@@ -217,6 +229,20 @@ func testRespondBookingHandlerRejectAsUser1(t *testing.T) {
 	testBooking0to1 = respond.Booking
 }
 
+func testRespondBookingHandlerOfNoBooking(t *testing.T) {
+	respond := BookingRespond{ Approve: true }
+	ioReaderRespond, _ := test_helper.GetIOReaderFromObject(respond)
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/booking/%d", testBooking0to1.ID + 1000), ioReaderRespond)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[0],
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP Request to respond to a booking that does not exist did not return 404. Status Code: %d", w.Code)
+	}
+}
+
 func testGetAllBookingUserHandlerRequiredAsUser0(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/booking?booking=REQUIRED", nil)
 	req.AddCookie(&http.Cookie{
@@ -257,19 +283,9 @@ func testRespondBookingHandlerApproveAsUser0(t *testing.T) {
 	}
 
 	//Assert booking deleted
-	rows, err := DB.Query(
-		`SELECT COUNT(*) FROM wn_booking
-		WHERE id = $1`,
-		testBooking0to1.ID)
-	if err != nil {
-		t.Errorf("An error occured while getting count from DB. %v", err)
-	}
-	c, err := test_helper.ReadInt(rows)
-	if err != nil {
-		t.Errorf("An error occured while reading int. %v", err)
-	}
-	if c != 0 {
-		t.Errorf("The booking still exist and has not been deleted")
+	_, err = Store.GetBooking(context.Background(), testBooking0to1.ID)
+	if err != http_error.NotFoundError {
+		t.Errorf("The booking still exist and has not been deleted. %v", err)
 	}
 
 	//Assert event is created
@@ -330,6 +346,10 @@ func testUpdateBookingHandlerOfBooking1To2AsUser1Authorized(t *testing.T) {
 
 func testGetBookingProviderOfBooking1To2AfterUpdate(t *testing.T) {
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/booking/%d", testBookingsTo2[1].ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[1],
+	})
 	w := test_helper.SimulateRequest(Router, req)
 	if w.Code != http.StatusOK {
 		t.Errorf("HTTP Request to get booking failed with Status code: %d", w.Code)
@@ -343,6 +363,30 @@ func testGetBookingProviderOfBooking1To2AfterUpdate(t *testing.T) {
 	}
 }
 
+func testGetBookingProviderOfBooking1To2AsUser2(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/booking/%d", testBookingsTo2[1].ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[2],
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("HTTP Request to get booking as its provider failed with Status code: %d", w.Code)
+	}
+}
+
+func testGetBookingProviderOfBooking1To2AsUser0Unauthorized(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/booking/%d", testBookingsTo2[1].ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[0],
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to get booking of other users did not respond with unauthorized code but gave Status code: %d", w.Code)
+	}
+}
+
 func testDeleteBookingHandlerOfBooking1To2AsUser2(t *testing.T) {
 	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/booking/%d", testBookingsTo2[1].ID), nil)
 	req.AddCookie(&http.Cookie{
@@ -369,6 +413,10 @@ func testDeleteBookingHandlerOfBooking1To2AsUser1(t *testing.T) {
 
 func testGetBookingProviderHandlerOfBooking1To2AfterDeletion(t *testing.T) {
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/booking/%d", testBookingsTo2[1].ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[1],
+	})
 	w := test_helper.SimulateRequest(Router, req)
 	if w.Code != http.StatusNotFound { 
 		t.Errorf("HTTP Request to GetBookingProvider did not respond with NotFound Code but with status code of %d", w.Code)
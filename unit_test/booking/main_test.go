@@ -1,11 +1,11 @@
 package booking
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/booking"
 	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -37,43 +35,42 @@ var testBooking0to1 Booking
 
 func setupRouter() *gin.Engine {
 	Router := gin.Default()
+	Router.Use(middleware.Authenticate(Store))
+	protected := Router.Group("/", middleware.RequireAuth())
 
-	Router.GET("/booking", booking.GetAllBookingUsersHandler(DB))
-	Router.POST("/booking", booking.AddBookingHandler(DB))
-	Router.GET("/booking/:id", booking.GetBookingProviderHandler(DB))
-	Router.POST("/booking/:id", booking.RespondBookingHandler(DB))
-	Router.PATCH("/booking/:id", booking.UpdateBookingHandler(DB))
-	Router.DELETE("/booking/:id", booking.DeleteBookingHandler(DB))
+	protected.GET("/booking", booking.GetAllBookingUsersHandler(Store))
+	protected.POST("/booking", booking.AddBookingHandler(Store))
+	protected.GET("/booking/:id", booking.GetBookingProviderHandler(Store))
+	protected.POST("/booking/:id", booking.RespondBookingHandler(Store))
+	protected.PATCH("/booking/:id", booking.UpdateBookingHandler(Store))
+	protected.DELETE("/booking/:id", booking.DeleteBookingHandler(Store))
 
 	return Router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 3)
+	testUsers, err = test_helper.SetupUsers(Store, 3)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
 
-	testProviderSettings, err = test_helper.SetupProviderSettingForUsers(DB, testUsers[2:])
+	testProviderSettings, err = test_helper.SetupProviderSettingForUsers(Store, testUsers[2:])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test provider setting. %v", err))
 	}
 	// Filling 0, 1 index with empty first
 	testProviderSettings = append([]ProviderSetting{ProviderSetting{}, ProviderSetting{}}, testProviderSettings...)
 
-	testBookingsTo2, err = test_helper.SetupBookingToUserForUsers(DB, testUsers[:2], testUsers[2])
+	testBookingsTo2, err = test_helper.SetupBookingToUserForUsers(Store, testUsers[:2], testUsers[2])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test Booking. %v", err))
 	}
@@ -0,0 +1,147 @@
+package compatibility
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/unit_test/test_helper"
+
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+)
+
+func TestCompatibility(t *testing.T) {
+	t.Run("Signals are scored and weighed", testScoreCompatibility)
+	t.Run("Unset signals score half", testUnsetSignals)
+	t.Run("Weights leave signals out", testWeights)
+	t.Run("GetCompatibilityBreakdownHandler as member", testGetCompatibilityBreakdownHandler)
+	t.Run("GetCompatibilityBreakdownHandler as member without setting", testGetCompatibilityBreakdownHandlerNoSetting)
+	t.Run("GetCompatibilityBreakdownHandler as non member", testGetCompatibilityBreakdownHandlerNonMember)
+	t.Run("GetCompatibilityBreakdownHandler of missing group", testGetCompatibilityBreakdownHandlerNotFound)
+}
+
+// Helper
+func newRequestWithSession(method string, url string, sessionKey string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "session_key",
+		Value: sessionKey,
+	})
+	return req
+}
+
+func loadedMatchRequest(gender string, faculty string, matchSetting MatchSetting) LoadedMatchRequest {
+	return LoadedMatchRequest{
+		User:         PublicUser{Gender: gender, Faculty: faculty},
+		MatchSetting: matchSetting,
+	}
+}
+
+var allWeights = config.CompatibilityWeights{Faculty: 1, MBTI: 2, Hobbies: 3, Gender: 4, YearOfStudy: 5, Availability: 6, GroupSize: 7}
+
+func testScoreCompatibility(t *testing.T) {
+	lmr1 := loadedMatchRequest("M", "COMPUTING", MatchSetting{
+		FacultyPreference:  "SAME",
+		Hobbies:            []string{"GAMING", "MUSIC", "ART"},
+		MBTI:               "INTJ",
+		GenderPreference:   "MIX",
+		YearOfStudy:        2,
+		Availability:       []string{"WEEKDAY_EVENING", "WEEKEND_MORNING"},
+		PreferredGroupSize: 4,
+	})
+	lmr2 := loadedMatchRequest("F", "COMPUTING", MatchSetting{
+		FacultyPreference:  "SAME",
+		Hobbies:            []string{"MUSIC", "ART", "TV"},
+		MBTI:               "ENTJ",
+		GenderPreference:   "MIX",
+		YearOfStudy:        3,
+		Availability:       []string{"WEEKDAY_EVENING", "WEEKEND_MORNING", "WEEKEND_EVENING", "WEEKDAY_MORNING"},
+		PreferredGroupSize: 5,
+	})
+	expected := CompatibilityScore{
+		Faculty:      1 * 4,
+		MBTI:         2 * 3,
+		Hobbies:      3 * 2,
+		Gender:       4 * 4,
+		YearOfStudy:  5 * 3,
+		Availability: 6 * 4,
+		GroupSize:    7 * 2,
+	}
+	expected.Total = 4 + 6 + 6 + 16 + 15 + 24 + 14
+	if score := ScoreCompatibility(lmr1, lmr2, allWeights); score != expected {
+		t.Errorf("The pair scored %+v instead of %+v", score, expected)
+	}
+	if score := ScoreCompatibility(lmr2, lmr1, allWeights); score != expected {
+		t.Errorf("The pair scored %+v the other way around instead of %+v", score, expected)
+	}
+	if max := MaxCompatibility(allWeights); max != 4*28 {
+		t.Errorf("The most a pair can score was %d instead of %d", max, 4*28)
+	}
+}
+
+func testUnsetSignals(t *testing.T) {
+	matchSetting := MatchSetting{FacultyPreference: "NONE", MBTI: "INTJ", GenderPreference: "NONE"}
+	score := ScoreCompatibility(loadedMatchRequest("M", "LAW", matchSetting), loadedMatchRequest("F", "CHS", matchSetting), allWeights)
+	if score.Gender != 4*2 || score.YearOfStudy != 5*2 || score.Availability != 6*2 || score.GroupSize != 7*2 {
+		t.Errorf("Unset signals scored %+v instead of half their points", score)
+	}
+}
+
+func testWeights(t *testing.T) {
+	weights := config.CompatibilityWeights{Hobbies: 2}
+	lmrs := test_helper.GenerateLoadedMatchRequests(rand.New(rand.NewSource(1)), 10)
+	for i := range lmrs {
+		for j := range lmrs {
+			score := ScoreCompatibility(lmrs[i], lmrs[j], weights)
+			if score.Total != score.Hobbies || Compatibility(lmrs[i], lmrs[j], weights) != score.Hobbies {
+				t.Fatalf("Signals without weight were counted in %+v", score)
+			}
+		}
+	}
+}
+
+func testGetCompatibilityBreakdownHandler(t *testing.T) {
+	url := fmt.Sprintf("/group/%d/compatibility", testGroup.ID)
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", url, sessionKeys[0]))
+	breakdown, err := test_helper.GetCompatibilityBreakdownFromRecorder(w)
+	if err != nil {
+		t.Fatalf("HTTP Request for the compatibility of a member failed. %v", err)
+	}
+	if breakdown.GroupID != testGroup.ID || breakdown.UserID != testUsers[0].ID {
+		t.Errorf("The breakdown was of user %d in group %d", breakdown.UserID, breakdown.GroupID)
+	}
+	if len(breakdown.Members) != 1 || breakdown.Members[0].User.ID != testUsers[1].ID {
+		t.Fatalf("The breakdown was against %d members instead of only the one with a match setting", len(breakdown.Members))
+	}
+	if breakdown.Total != breakdown.Members[0].Score {
+		t.Errorf("The total %+v was not the sum of the scores with the members", breakdown.Total)
+	}
+	if breakdown.MaxScore != MaxCompatibility(Matching.Weights) || breakdown.Total.Total > breakdown.MaxScore {
+		t.Errorf("The breakdown scored %d out of %d", breakdown.Total.Total, breakdown.MaxScore)
+	}
+}
+
+func testGetCompatibilityBreakdownHandlerNoSetting(t *testing.T) {
+	url := fmt.Sprintf("/group/%d/compatibility", testGroup.ID)
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", url, sessionKeys[2]))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP Request for the compatibility of a member without a match setting did not return 404. Status Code: %d", w.Code)
+	}
+}
+
+func testGetCompatibilityBreakdownHandlerNonMember(t *testing.T) {
+	url := fmt.Sprintf("/group/%d/compatibility", testGroup.ID)
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", url, sessionKeys[3]))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for the compatibility of a non member did not return 401. Status Code: %d", w.Code)
+	}
+}
+
+func testGetCompatibilityBreakdownHandlerNotFound(t *testing.T) {
+	url := fmt.Sprintf("/group/%d/compatibility", testGroup.ID+1000)
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", url, sessionKeys[0]))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("HTTP Request for the compatibility in a missing group did not return 404. Status Code: %d", w.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package compatibility
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/match"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store  store.Store
+	Router *gin.Engine
+)
+
+// Matching parameters the store was set up with
+var Matching config.MatchingConfig = test_helper.LoadConfig().Matching
+
+// testUsers[:3] are in testGroup, and testUsers[2] has no match setting
+var testUsers []User
+var testGroup Group
+var sessionKeys []string
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+	protected.GET("/group/:id/compatibility", match.GetCompatibilityBreakdownHandler(Store))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	Router = setupRouter()
+
+	var err error
+	testUsers, err = test_helper.SetupUsers(Store, 4)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	_, err = test_helper.SetupMatchSettingForUsers(Store, []User{testUsers[0], testUsers[1], testUsers[3]})
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating match settings. %v", err))
+	}
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+	groupWithUsers, err := Store.AddGroupWithUserIDs(context.Background(), test_helper.GetTestGroup(0), []int64{testUsers[0].ID, testUsers[1].ID, testUsers[2].ID})
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test group. %v", err))
+	}
+	testGroup = groupWithUsers.Group
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,89 @@
+package concurrency
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Full test
+func TestConcurrentInserts(t *testing.T) {
+	t.Run("AddUser in parallel", testAddUserInParallel)
+	t.Run("AddGroupWithUserIDs in parallel", testAddGroupWithUserIDsInParallel)
+}
+
+func testAddUserInParallel(t *testing.T) {
+	s := postgresStore(t)
+	addedUsers := make([]User, parallelCalls)
+	errs := make([]error, parallelCalls)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelCalls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user := test_helper.GetTestUser(i)
+			user.Email = fmt.Sprintf("concurrent%d@u.nus.edu", i)
+			addedUsers[i], errs[i] = s.AddUser(context.Background(), user)
+		}(i)
+	}
+	wg.Wait()
+
+	seenIDs := make(map[int64]bool)
+	for i, addedUser := range addedUsers {
+		if errs[i] != nil {
+			t.Errorf("An error occured while adding user %d in parallel. %v", i, errs[i])
+			continue
+		}
+		if seenIDs[addedUser.ID] {
+			t.Errorf("User %d was given an ID already returned to another caller. ID: %d", i, addedUser.ID)
+		}
+		seenIDs[addedUser.ID] = true
+		storedUser, err := s.GetUser(context.Background(), addedUser.ID)
+		if err != nil {
+			t.Errorf("An error occured while retrieving user %d. %v", addedUser.ID, err)
+			continue
+		}
+		if storedUser.Email != fmt.Sprintf("concurrent%d@u.nus.edu", i) {
+			t.Errorf("User %d was returned the ID of another row. Expected email concurrent%d@u.nus.edu but got %s", i, i, storedUser.Email)
+		}
+	}
+}
+
+func testAddGroupWithUserIDsInParallel(t *testing.T) {
+	s := postgresStore(t)
+	addedGroups := make([]GroupWithUsers, parallelCalls)
+	errs := make([]error, parallelCalls)
+	var wg sync.WaitGroup
+	for i := 0; i < parallelCalls; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			addedGroups[i], errs[i] = s.AddGroupWithUserIDs(context.Background(), test_helper.GetTestGroup(i), []int64{testUsers[0].ID})
+		}(i)
+	}
+	wg.Wait()
+
+	seenIDs := make(map[int64]bool)
+	for i, addedGroup := range addedGroups {
+		if errs[i] != nil {
+			t.Errorf("An error occured while adding group %d in parallel. %v", i, errs[i])
+			continue
+		}
+		if seenIDs[addedGroup.Group.ID] {
+			t.Errorf("Group %d was given an ID already returned to another caller. ID: %d", i, addedGroup.Group.ID)
+		}
+		seenIDs[addedGroup.Group.ID] = true
+		storedGroup, err := s.GetGroup(context.Background(), addedGroup.Group.ID)
+		if err != nil {
+			t.Errorf("An error occured while retrieving group %d. %v", addedGroup.Group.ID, err)
+			continue
+		}
+		if storedGroup.GroupName != test_helper.GetTestGroup(i).GroupName {
+			t.Errorf("Group %d was returned the ID of another row. Expected %s but got %s", i, test_helper.GetTestGroup(i).GroupName, storedGroup.GroupName)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package concurrency
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/unit_test/test_helper"
+
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store store.Store
+)
+
+var testUsers []User
+
+const parallelCalls = 20
+
+// Returns the postgres store, skipping the test on any other, as only postgres hands out IDs
+// from sequences shared between connections
+func postgresStore(t *testing.T) *store.PostgresStore {
+	s, ok := Store.(*store.PostgresStore)
+	if !ok {
+		t.Skip("Parallel inserts need postgres. Run with UNIT_TEST_STORE=postgres")
+	}
+	return s
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	var err error
+
+	testUsers, err = test_helper.SetupUsers(Store, 1)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
+	}
+
+	os.Exit(m.Run())
+}
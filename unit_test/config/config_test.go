@@ -0,0 +1,174 @@
+package config
+
+import (
+	"wellnus/backend/config"
+
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Full test
+func TestConfig(t *testing.T) {
+	t.Run("Defaults without .env", testDefaults)
+	t.Run("Values from .env", testDotEnv)
+	t.Run("Environment over .env", testEnvOverDotEnv)
+	t.Run("Flags over environment", testFlagsOverEnv)
+	t.Run("Heroku PORT", testHerokuPort)
+	t.Run("Renamed key", testRenamedKey)
+	t.Run("Malformed value", testMalformedValue)
+	t.Run("Validate", testValidate)
+}
+
+func load(t *testing.T, path string, args ...string) (config.Config, []string) {
+	cfg, rest, err := config.Load(path, args)
+	if err != nil {
+		t.Fatalf("An error occured while loading the config. %v", err)
+	}
+	return cfg, rest
+}
+
+func testDefaults(t *testing.T) {
+	cfg, rest := load(t, "/nonexistent/.env")
+	if !reflect.DeepEqual(cfg, config.Default()) {
+		t.Errorf("Config without any settings was %+v instead of the defaults %+v", cfg, config.Default())
+	}
+	if len(rest) != 0 {
+		t.Errorf("Arguments %v were left without any arguments given", rest)
+	}
+}
+
+func testDotEnv(t *testing.T) {
+	cfg, _ := load(t, envPath)
+	if cfg.DB.MaxOpenConns != 10 || cfg.DB.MaxIdleConns != 5 {
+		t.Errorf("DB pool sizes were %d and %d instead of 10 and 5", cfg.DB.MaxOpenConns, cfg.DB.MaxIdleConns)
+	}
+	if cfg.Matching.Threshold != 12 || cfg.Matching.GroupSize != 4 {
+		t.Errorf("Matching was %+v instead of threshold 12 with the default group size 4", cfg.Matching)
+	}
+	if cfg.Cookie.SameSite != "lax" {
+		t.Errorf("Cookie SameSite was %s instead of lax", cfg.Cookie.SameSite)
+	}
+	origins := []string{"http://localhost:3000", "http://localhost:8080"}
+	if !reflect.DeepEqual(cfg.CORS.AllowedOrigins, origins) {
+		t.Errorf("CORS origins were %v instead of the frontend and backend addresses %v", cfg.CORS.AllowedOrigins, origins)
+	}
+}
+
+func testEnvOverDotEnv(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "20")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "90s")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://wellnus.example, https://admin.wellnus.example")
+	t.Setenv("FEATURE_TESTING_ROUTES", "false")
+	cfg, _ := load(t, envPath)
+	if cfg.DB.MaxOpenConns != 20 || cfg.DB.MaxIdleConns != 5 {
+		t.Errorf("DB pool sizes were %d and %d instead of 20 from the environment and 5 from .env", cfg.DB.MaxOpenConns, cfg.DB.MaxIdleConns)
+	}
+	if cfg.DB.ConnMaxLifetime != 90*time.Second {
+		t.Errorf("Connection lifetime was %v instead of 90s", cfg.DB.ConnMaxLifetime)
+	}
+	origins := []string{"https://wellnus.example", "https://admin.wellnus.example"}
+	if !reflect.DeepEqual(cfg.CORS.AllowedOrigins, origins) {
+		t.Errorf("CORS origins were %v instead of %v", cfg.CORS.AllowedOrigins, origins)
+	}
+	if cfg.Features.TestingRoutes {
+		t.Errorf("Testing routes were on after FEATURE_TESTING_ROUTES=false")
+	}
+}
+
+func testFlagsOverEnv(t *testing.T) {
+	t.Setenv("MATCH_GROUP_SIZE", "3")
+	cfg, rest := load(t, envPath, "-match-group-size=5", "-rate-limit-per-minute", "0", "migrate", "down", "1")
+	if cfg.Matching.GroupSize != 5 {
+		t.Errorf("Group size was %d instead of 5 from the flag", cfg.Matching.GroupSize)
+	}
+	if cfg.RateLimit.RequestsPerMinute != 0 {
+		t.Errorf("Rate limit was %d instead of turned off by the flag", cfg.RateLimit.RequestsPerMinute)
+	}
+	if !reflect.DeepEqual(rest, []string{"migrate", "down", "1"}) {
+		t.Errorf("Arguments left after the flags were %v instead of the migrate subcommand", rest)
+	}
+}
+
+func testHerokuPort(t *testing.T) {
+	t.Setenv("SERVER_ADDRESS", ":9000")
+	t.Setenv("PORT", "5000")
+	cfg, _ := load(t, envPath)
+	if cfg.Server.Address != ":5000" {
+		t.Errorf("Server address was %s instead of :5000 from PORT", cfg.Server.Address)
+	}
+}
+
+func testRenamedKey(t *testing.T) {
+	t.Setenv("MATCH_GROUPSIZE", "3")
+	cfg, _ := load(t, envPath)
+	if cfg.Matching.GroupSize != 3 {
+		t.Errorf("Group size was %d instead of 3 from the old MATCH_GROUPSIZE", cfg.Matching.GroupSize)
+	}
+	t.Setenv("MATCH_GROUP_SIZE", "5")
+	cfg, _ = load(t, envPath)
+	if cfg.Matching.GroupSize != 5 {
+		t.Errorf("Group size was %d instead of 5 from MATCH_GROUP_SIZE, which takes precedence over its old name", cfg.Matching.GroupSize)
+	}
+	t.Setenv("MATCH_GROUP_SIZE", "")
+	t.Setenv("MATCH_GROUPSIZE", "many")
+	_, _, err := config.Load(envPath, nil)
+	if err == nil || !strings.Contains(err.Error(), "MATCH_GROUPSIZE") {
+		t.Errorf("Loading a malformed MATCH_GROUPSIZE gave %v instead of an error naming it", err)
+	}
+}
+
+func testMalformedValue(t *testing.T) {
+	t.Setenv("DB_MAX_OPEN_CONNS", "many")
+	_, _, err := config.Load(envPath, nil)
+	if err == nil || !strings.Contains(err.Error(), "DB_MAX_OPEN_CONNS") {
+		t.Errorf("Loading a malformed DB_MAX_OPEN_CONNS gave %v instead of an error naming it", err)
+	}
+}
+
+func testValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*config.Config)
+		key    string // part of the error, empty when the config is valid
+	}{
+		{"defaults", func(cfg *config.Config) {}, ""},
+		{"negative pool", func(cfg *config.Config) { cfg.DB.MaxOpenConns = -1 }, "DB_MAX_OPEN_CONNS"},
+		{"negative statement timeout", func(cfg *config.Config) { cfg.DB.StatementTimeout = -time.Second }, "DB_STATEMENT_TIMEOUT"},
+		{"idle over open", func(cfg *config.Config) { cfg.DB.MaxIdleConns = 30 }, "DB_MAX_IDLE_CONNS"},
+		{"unknown same site", func(cfg *config.Config) { cfg.Cookie.SameSite = "loose" }, "COOKIE_SAME_SITE"},
+		{"insecure none cookie", func(cfg *config.Config) { cfg.Cookie.Secure = false }, "COOKIE_SECURE"},
+		{"insecure lax cookie", func(cfg *config.Config) { cfg.Cookie.Secure = false; cfg.Cookie.SameSite = "lax" }, ""},
+		{"origin with path", func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"http://localhost:3000/app"} }, "CORS_ALLOWED_ORIGINS"},
+		{"origin without scheme", func(cfg *config.Config) { cfg.CORS.AllowedOrigins = []string{"localhost:3000"} }, "CORS_ALLOWED_ORIGINS"},
+		{"group of one", func(cfg *config.Config) { cfg.Matching.GroupSize = 1 }, "MATCH_GROUP_SIZE"},
+		{"threshold under group size", func(cfg *config.Config) { cfg.Matching.Threshold = 3 }, "MATCH_THRESHOLD"},
+		{"negative match interval", func(cfg *config.Config) { cfg.Matching.Interval = -time.Hour }, "MATCH_INTERVAL"},
+		{"scheduler off", func(cfg *config.Config) { cfg.Matching.PollInterval = 0 }, ""},
+		{"unknown match strategy", func(cfg *config.Config) { cfg.Matching.Strategy = "random" }, "MATCH_STRATEGY"},
+		{"greedy match strategy", func(cfg *config.Config) { cfg.Matching.Strategy = "greedy" }, ""},
+		{"negative weight", func(cfg *config.Config) { cfg.Matching.Weights.MBTI = -1 }, "MATCH_WEIGHT_"},
+		{"no weights", func(cfg *config.Config) { cfg.Matching.Weights = config.CompatibilityWeights{} }, "MATCH_WEIGHT_"},
+		{"one weight", func(cfg *config.Config) { cfg.Matching.Weights = config.CompatibilityWeights{Hobbies: 3} }, ""},
+		{"no burst", func(cfg *config.Config) { cfg.RateLimit.Burst = 0 }, "RATE_LIMIT_BURST"},
+		{"no burst without limit", func(cfg *config.Config) { cfg.RateLimit = config.RateLimitConfig{} }, ""},
+		{"unknown mailer", func(cfg *config.Config) { cfg.Mail.Mailer = "pigeon" }, "MAILER"},
+		{"smtp without host", func(cfg *config.Config) { cfg.Mail.Mailer = "smtp" }, "SMTP_HOST"},
+		{"file without dir", func(cfg *config.Config) { cfg.Mail.Mailer = "file" }, "MAIL_DIR"},
+		{"unknown log level", func(cfg *config.Config) { cfg.Log.Level = "loud" }, "LOG_LEVEL"},
+		{"package level", func(cfg *config.Config) { cfg.Log.Levels = []string{"ws=debug", "model=WARN"} }, ""},
+		{"package level without package", func(cfg *config.Config) { cfg.Log.Levels = []string{"debug"} }, "LOG_LEVELS"},
+	}
+	for _, c := range cases {
+		cfg := config.Default()
+		c.modify(&cfg)
+		err := cfg.Validate()
+		if c.key == "" && err != nil {
+			t.Errorf("Config with %s was refused. %v", c.name, err)
+		}
+		if c.key != "" && (err == nil || !strings.Contains(err.Error(), c.key)) {
+			t.Errorf("Config with %s gave %v instead of an error naming %s", c.name, err, c.key)
+		}
+	}
+}
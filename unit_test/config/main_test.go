@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// .env written for the tests, in a directory that is removed after them
+var envPath string
+
+const envFile = `FRONTEND_ADDRESS=http://localhost:3000
+BACKEND_ADDRESS=http://localhost:8080
+DB_MAX_OPEN_CONNS=10
+DB_MAX_IDLE_CONNS=5
+MATCH_THRESHOLD=12
+COOKIE_SAME_SITE=lax
+`
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "wellnus-config")
+	if err != nil {
+		panic(err)
+	}
+	envPath = filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte(envFile), 0o600); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -1,6 +1,7 @@
 package counsel
 
 import (
+	. "wellnus/backend/db/model"
 	"wellnus/backend/unit_test/test_helper"
 
 	"testing"
@@ -1,11 +1,11 @@
 package counsel
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/counsel"
 	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -37,35 +35,34 @@ var testCounselRequests []CounselRequest
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
 
-	router.GET("/counsel", counsel.GetAllCounselRequestsHandler(DB))
-	router.POST("/counsel", counsel.AddUpdateCounselRequestHandler(DB))
-	router.DELETE("/counsel", counsel.DeleteCounselRequestHandler(DB))
-	router.GET("/counsel/:id", counsel.GetCounselRequestHandler(DB))
-	router.POST("/counsel/:id", counsel.AcceptCounselRequestHandler(DB))
+	protected.GET("/counsel", counsel.GetAllCounselRequestsHandler(Store))
+	protected.POST("/counsel", counsel.AddUpdateCounselRequestHandler(Store))
+	protected.DELETE("/counsel", counsel.DeleteCounselRequestHandler(Store))
+	protected.GET("/counsel/:id", counsel.GetCounselRequestHandler(Store))
+	protected.POST("/counsel/:id", counsel.AcceptCounselRequestHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 3)
+	testUsers, err = test_helper.SetupUsers(Store, 3)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
 
-	testCounselRequests, err = test_helper.SetupCounselRequestForUsers(DB, testUsers[:2])
+	testCounselRequests, err = test_helper.SetupCounselRequestForUsers(Store, testUsers[:2])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test Counsel Request. %v", err))
 	}
@@ -1,9 +1,11 @@
 package event
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/unit_test/test_helper"
 
+	"context"
 	"testing"
 	"net/http"
 	"fmt"
@@ -26,6 +28,7 @@ func TestEventHandler(t *testing.T) {
 	t.Run("AddUser0ToEvent1 not logged in", testAddUser0ToPrivateEvent1HandlerNotLoggedIn)
 	t.Run("Adduser0ToEvent1 as user1", testAddUser0ToPrivateEvent1HandlerAsUser1)
 	t.Run("GetAllEventHandler as user0 after addition", testGetAllEventHandlerAsUser0AfterAddition)
+	t.Run("GetAllEventsHandler pages as user0", testGetAllEventsHandlerPagesAsUser0)
 	t.Run("UpdateEvent0Handler as not user0", testUpdateEvent0HandlerAsNotUser0)
 	t.Run("UpdateEvent0Handler as user0", testUpdateEvent0HandlerAsUser0)
 	t.Run("GetAllEventshandler as user1 after update", testGetAllEventsHandlerAsUser1AfterUpdate)
@@ -152,15 +155,8 @@ func testGetAllEventsHandlerAsUser0(t *testing.T) {
 func testGetAllEventsHandlerAsNotLoggedIn(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/event", nil)
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("HTTP Request to GetAllEvents failed with status code of %d", w.Code)
-	}
-	events, err := test_helper.GetEventsFromRecorder(w)
-	if err != nil {
-		t.Errorf("An error occured while getting all events of not logged in. %v", err)
-	}
-	if l := len(events); l != 0 {
-		t.Errorf("GetAllEventsHandler does not show 0 events but instead shows %d events", len(events))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetAllEvents as not logged in did not give status unauthorized but status code of %d", w.Code)
 	}
 }
 
@@ -304,6 +300,34 @@ func testGetAllEventHandlerAsUser0AfterAddition(t *testing.T) {
 	}
 }
 
+func testGetAllEventsHandlerPagesAsUser0(t *testing.T) {
+	cursor := ""
+	eventIDs := make([]int64, 0)
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", "/event?limit=1&sort_by=start_time&order=desc&cursor=" + cursor, nil)
+		req.AddCookie(&http.Cookie{
+			Name: "session_key",
+			Value: sessionKeys[0],
+		})
+		w := test_helper.SimulateRequest(Router, req)
+		page, err := test_helper.GetPageFromRecorder[Event](w)
+		if err != nil {
+			t.Fatalf("An error occured while getting a page of events of user0. %v", err)
+		}
+		for _, event := range page.Items {
+			eventIDs = append(eventIDs, event.ID)
+		}
+		if cursor = page.NextCursor; cursor == "" {
+			break
+		}
+	}
+	// Both events start at the same time, so they are ordered by ID
+	expected := []int64{testEvents[1].ID, testEvents[0].ID}
+	if fmt.Sprint(eventIDs) != fmt.Sprint(expected) {
+		t.Errorf("Pages of events of user0 held %v instead of %v", eventIDs, expected)
+	}
+}
+
 func testUpdateEvent0HandlerAsNotUser0(t *testing.T) {
 	ioReaderEvent, _ := test_helper.GetIOReaderFromObject(Event{ EventName: "UpdatedEventName" })
 	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/event/%d", testEvents[0].ID), ioReaderEvent)
@@ -409,16 +433,9 @@ func testLeaveEvent1HandlerAsUser1(t *testing.T) {
 		t.Errorf("HTTP Request to LeaveEvent failed with status code of %d", w.Code)
 	}
 
-	rows, err := DB.Query("SELECT COUNT(*) FROM wn_event WHERE id = $1", testEvents[1].ID)
-	if err != nil {
-		t.Errorf("An error occured while querying count. %v", err)
-	}
-	count, err := test_helper.ReadInt(rows)
-	if err != nil {
-		t.Errorf("An error occured while retrieving count. %v", err)
-	}
-	if count != 0 {
-		t.Errorf("Event1 was not deleted as count is %d", count)
+	_, err := Store.GetEvent(context.Background(), testEvents[1].ID)
+	if err != http_error.NotFoundError {
+		t.Errorf("Event1 was not deleted. %v", err)
 	}
 }
 
@@ -432,22 +449,20 @@ func testLeaveAllEventsHandlerAsUser0(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("HTTP Request to LeaveAllEvents failed with status code of %d", w.Code)
 	}
-	rows, err := DB.Query("SELECT COUNT(*) FROM wn_event")
-	if err != nil {
-		t.Errorf("An error occured while querying count. %v", err)
-	}
-	count, err := test_helper.ReadInt(rows)
-	if err != nil {
-		t.Errorf("An error occured while retrieving count. %v", err)
-	}
-	if count != 0 {
-		t.Errorf("Not all events were deleted was not deleted as count is %d", count)
+	for _, testUser := range testUsers {
+		events, err := Store.GetAllEventsOfUser(context.Background(), testUser.ID)
+		if err != nil {
+			t.Errorf("An error occured while retrieving events. %v", err)
+		}
+		if count := len(events); count != 0 {
+			t.Errorf("Not all events were deleted as count is %d", count)
+		}
 	}
 }
 
 func testCreateGroupDeleteEventUnauthorised(t *testing.T) {
-	testEvents, _ = test_helper.SetupEventForUsers(DB, testUsers[:1])
-	eventWithUsers, _ := model.AddUserToEventAuthorized(DB, testUsers[1].ID, testEvents[0].ID, testUsers[0].ID)
+	testEvents, _ = test_helper.SetupEventForUsers(Store, testUsers[:1])
+	eventWithUsers, _ := Store.AddUserToEventAuthorized(context.Background(), testUsers[1].ID, testEvents[0].ID, testUsers[0].ID)
 	if l := len(eventWithUsers.Users); l != 2 {
 		t.Errorf("Something went wrong wile setting up event for creategroupdeleteevent. group has only %d users", l)
 	}
@@ -468,8 +483,8 @@ func testCreateGroupDeleteEventUnauthorised(t *testing.T) {
 }
 
 func testCreateGroupDeleteEventAuthorised(t *testing.T) {
-	testEvents, _ = test_helper.SetupEventForUsers(DB, testUsers[:1])
-	eventWithUsers, _ := model.AddUserToEventAuthorized(DB, testUsers[1].ID, testEvents[0].ID, testUsers[0].ID)
+	testEvents, _ = test_helper.SetupEventForUsers(Store, testUsers[:1])
+	eventWithUsers, _ := Store.AddUserToEventAuthorized(context.Background(), testUsers[1].ID, testEvents[0].ID, testUsers[0].ID)
 	if l := len(eventWithUsers.Users); l != 2 {
 		t.Errorf("Something went wrong wile setting up event for creategroupdeleteevent. group has only %d users", l)
 	}
@@ -503,7 +518,7 @@ func testCreateGroupDeleteEventAuthorised(t *testing.T) {
 		}
 	}
 
-	_, err = model.GetEvent(DB, testEvents[0].ID)
+	_, err = Store.GetEvent(context.Background(), testEvents[0].ID)
 	if err.Error() != NotFoundErrorMessage {
 		t.Errorf("error thrown is same as NotfoundError message, suggesting event was not deleted. %v", err)
 	}
@@ -1,11 +1,11 @@
 package event
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/event"
 	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -34,38 +32,37 @@ var testEvents []Event
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
-
-	router.GET("/event", event.GetAllEventsHandler(DB))
-	router.POST("/event", event.AddEventHandler(DB))
-	router.DELETE("/event", event.LeaveDeleteAllEventsHandler(DB))
-	router.GET("/event/:id", event.GetEventHandler(DB))
-	router.POST("/event/:id", event.AddUserToEventHandler(DB))
-	router.PATCH("/event/:id", event.UpdateEventHandler(DB))
-	router.DELETE("event/:id", event.LeaveDeleteEventHandler(DB))
-	router.POST("/event/:id/start", event.CreateGroupDeleteEventHandler(DB))
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+
+	protected.GET("/event", event.GetAllEventsHandler(Store))
+	protected.POST("/event", event.AddEventHandler(Store))
+	protected.DELETE("/event", event.LeaveDeleteAllEventsHandler(Store))
+	router.GET("/event/:id", event.GetEventHandler(Store))
+	protected.POST("/event/:id", event.AddUserToEventHandler(Store))
+	protected.PATCH("/event/:id", event.UpdateEventHandler(Store))
+	protected.DELETE("/event/:id", event.LeaveDeleteEventHandler(Store))
+	protected.POST("/event/:id/start", event.CreateGroupDeleteEventHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 2)
+	testUsers, err = test_helper.SetupUsers(Store, 2)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
 
-	testEvents, err = test_helper.SetupEventForUsers(DB, testUsers[:1])
+	testEvents, err = test_helper.SetupEventForUsers(Store, testUsers[:1])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test Events. %v", err))
 	}
@@ -1,9 +1,10 @@
 package group
 
 import (
-	// "wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
 	"wellnus/backend/unit_test/test_helper"
 
+	"context"
 	"testing"
 	"net/http"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 
 // Full test
 func TestGroupHandler(t *testing.T) {
+	t.Run("AddGroupWithUserIDs with missing member rolls back", testAddGroupWithUserIDsMissingMemberRollsBack)
 	t.Run("AddGroupHandler with no group name", testAddGroupHandlerNoGroupName)
 	t.Run("AddGroupHandler not logged in", testAddGroupHandlerNotLoggedIn)
 	t.Run("AddGroupHandler successful as User1", testAddGroupHandlerAsUser1)
@@ -29,6 +31,22 @@ func TestGroupHandler(t *testing.T) {
 	t.Run("GetGrouphandler after delete", testGetGroupHandlerAfterDelete)
 }
 
+func testAddGroupWithUserIDsMissingMemberRollsBack(t *testing.T) {
+	userIDs := []int64{testUsers[0].ID, testUsers[1].ID, -1}
+	if _, err := Store.AddGroupWithUserIDs(context.Background(), validAddedGroup1, userIDs); err == nil {
+		t.Errorf("Group with a non existent member was successfully added")
+	}
+	for _, userID := range userIDs[:2] {
+		groups, err := Store.GetAllGroupsOfUser(context.Background(), userID)
+		if err != nil {
+			t.Errorf("An error occured while retrieving groups of user %d. %v", userID, err)
+		}
+		if len(groups) != 0 {
+			t.Errorf("User %d was left in %d groups after a failed group creation", userID, len(groups))
+		}
+	}
+}
+
 func testAddGroupHandlerNoGroupName(t *testing.T) {
 	testGroup := Group{
 		GroupDescription: validAddedGroup1.GroupDescription,
@@ -139,15 +157,8 @@ func testGetAllGroupsHandlerAsUser1(t *testing.T) {
 func testGetAllGroupsHandlerAsNotLoggedIn(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/group", nil)
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code != http.StatusOK {
-		t.Errorf("HTTP Request to GetAllGroups failed with status code of %d", w.Code)
-	}
-	groups, err := test_helper.GetGroupsFromRecorder(w)
-	if err != nil {
-		t.Errorf("An error occured while getting all groups of not logged in. %v", err)
-	}
-	if l := len(groups); l != 0 {
-		t.Errorf("GetAllGroupsHandler does not show 0 groups but instead shows %d groups", len(groups))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetAllGroups as not logged in did not give status unauthorized but status code of %d", w.Code)
 	}
 }
 
@@ -189,13 +200,7 @@ func testGetGroupHandlerAsNotLoggedIn(t *testing.T) {
 }
 
 func testGetAllGroupHandlerAsUser2AfterJoining(t *testing.T) {
-	_, err := DB.Query(
-		`INSERT INTO wn_user_group (
-			user_id, 
-			group_id) 
-		VALUES ($1, $2)`, 
-		testUsers[1].ID, 
-		validAddedGroup1.ID)
+	err := Store.AddUserToGroup(context.Background(), validAddedGroup1.ID, testUsers[1].ID)
 	if err != nil {
 		t.Errorf("An error occured while adding user2 into group. %v", err)
 	}
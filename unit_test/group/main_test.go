@@ -1,11 +1,11 @@
 package group
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/group"
 	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -36,31 +34,30 @@ var validAddedGroup2 Group = Group{
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
 
-	router.GET("/group", group.GetAllGroupsHandler(DB))
-	router.POST("/group", group.AddGroupHandler(DB))
-	router.DELETE("/group", group.LeaveAllGroupsHandler(DB))
-	router.GET("/group/:id", group.GetGroupHandler(DB))
-	router.PATCH("/group/:id", group.UpdateGroupHandler(DB))
-	router.DELETE("/group/:id", group.LeaveGroupHandler(DB))
+	protected.GET("/group", group.GetAllGroupsHandler(Store))
+	protected.POST("/group", group.AddGroupHandler(Store))
+	protected.DELETE("/group", group.LeaveAllGroupsHandler(Store))
+	router.GET("/group/:id", group.GetGroupHandler(Store))
+	protected.PATCH("/group/:id", group.UpdateGroupHandler(Store))
+	protected.DELETE("/group/:id", group.LeaveGroupHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 3)
+	testUsers, err = test_helper.SetupUsers(Store, 3)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
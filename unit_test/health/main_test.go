@@ -0,0 +1,69 @@
+package health
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/db/migration"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/health"
+	"wellnus/backend/router/ws"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	Store   store.Store
+	WSHub   *ws.Hub
+	stopHub context.CancelFunc = func() {}
+)
+
+// Store whose database does not answer and whose schema is behind
+type brokenStore struct {
+	store.Store
+}
+
+var pingError = errors.New("connection refused")
+
+func (s brokenStore) Ping(ctx context.Context) error {
+	return pingError
+}
+
+func (s brokenStore) GetSchemaStatus(ctx context.Context) (migration.Status, error) {
+	status, err := s.Store.GetSchemaStatus(ctx)
+	status.Version--
+	return status, err
+}
+
+// Store whose every query fails with an error that must not be shown to clients
+type failingStore struct {
+	store.Store
+}
+
+var queryError = errors.New(`pq: relation "wn_secret" does not exist`)
+
+func (s failingStore) Ping(ctx context.Context) error {
+	return queryError
+}
+
+func (s failingStore) GetSchemaStatus(ctx context.Context) (migration.Status, error) {
+	return migration.Status{}, queryError
+}
+
+func setupRouter(s store.Store) *gin.Engine {
+	router := gin.Default()
+	router.GET("/healthz", health.LivenessHandler)
+	router.GET("/readyz", health.ReadinessHandler(s, WSHub))
+	router.GET("/version", health.VersionHandler(s))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	WSHub = ws.NewHub(Store, config.CORSConfig{})
+	os.Exit(m.Run())
+}
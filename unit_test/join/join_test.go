@@ -1,7 +1,10 @@
 package join
 
 import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/unit_test/test_helper"
+	"context"
 	"testing"
 	"net/http"
 	"fmt"
@@ -11,6 +14,9 @@ import (
 func TestJoinHandler(t *testing.T) {
 	t.Run("AddJoinRequestHandler", testAddJoinRequestHandler)
 	t.Run("GetJoinRequestHandler as not logged in", testGetLoadedJoinRequestHandlerAsNotLoggedIn)
+	t.Run("GetJoinRequestHandler as user1", testGetLoadedJoinRequestHandlerAsUser1)
+	t.Run("GetJoinRequestHandler as user2", testGetLoadedJoinRequestHandlerAsUser2)
+	t.Run("GetJoinRequestHandler as user3", testGetLoadedJoinRequestHandlerAsUser3)
 	t.Run("GetAllLoadedJoinRequestHandler as not logged in", testGetAllLoadedJoinRequestHandlerAsNotLoggedIn)
 	t.Run("GetAllLoadedJoinRequestHandler as user1", testGetAllLoadedJoinRequestHandlerAsUser1)
 	t.Run("GetAllLoadedJoinRequestSentHandler as user1", testGetAllLoadedJoinRequestHandlerSentAsUser1)
@@ -54,6 +60,40 @@ func testAddJoinRequestHandler(t *testing.T) {
 func testGetLoadedJoinRequestHandlerAsNotLoggedIn(t *testing.T) {
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/join/%d", addedJoinRequest.ID), nil)
 	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetJoinRequest as not logged in did not give status unauthorized but status code of %d", w.Code)
+	}
+}
+
+// Owner of the group the request is for
+func testGetLoadedJoinRequestHandlerAsUser1(t *testing.T) {
+	assertGetLoadedJoinRequestAs(t, sessionKeys[0])
+}
+
+// Sender of the request
+func testGetLoadedJoinRequestHandlerAsUser2(t *testing.T) {
+	assertGetLoadedJoinRequestAs(t, sessionKeys[1])
+}
+
+func testGetLoadedJoinRequestHandlerAsUser3(t *testing.T) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/join/%d", addedJoinRequest.ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[2],
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetJoinRequest of another user did not give status unauthorized but status code of %d", w.Code)
+	}
+}
+
+func assertGetLoadedJoinRequestAs(t *testing.T, sessionKey string) {
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/join/%d", addedJoinRequest.ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKey,
+	})
+	w := test_helper.SimulateRequest(Router, req)
 	if w.Code != http.StatusOK { 
 		t.Errorf("HTTP Request to GetJoinRequest failed with status code of %d", w.Code)
 	}
@@ -64,7 +104,7 @@ func testGetLoadedJoinRequestHandlerAsNotLoggedIn(t *testing.T) {
 	if !retrievedLoadedJoinRequest.JoinRequest.Equal(addedJoinRequest) {
 		t.Errorf("The retrieved JoinRequest component did not match the added join request")
 	}
-	if !retrievedLoadedJoinRequest.User.Equal(testUsers[1]) {
+	if retrievedLoadedJoinRequest.User != testUsers[1].Public() {
 		t.Errorf("The retrieved User component did not match the added join user 2")
 	}
 	if !retrievedLoadedJoinRequest.Group.Equal(testGroups[0]) {
@@ -75,15 +115,8 @@ func testGetLoadedJoinRequestHandlerAsNotLoggedIn(t *testing.T) {
 func testGetAllLoadedJoinRequestHandlerAsNotLoggedIn(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/join", nil)
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code != http.StatusOK { 
-		t.Errorf("HTTP Request to GetAllJoinRequest failed with status code of %d", w.Code)
-	}
-	loadedjoinRequests, err := test_helper.GetLoadedJoinRequestsFromRecorder(w)
-	if err != nil {
-		t.Errorf("An error occured while retrieving all join request from response. %v", err)
-	}
-	if len(loadedjoinRequests) != 0 {
-		t.Errorf("A user who is not logged in saw some join requests directed to him")
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request to GetAllJoinRequest as not logged in did not give status unauthorized but status code of %d", w.Code)
 	}
 }
 
@@ -265,51 +298,27 @@ func testRespondJoinRequestHandlerRejectAsUser1(t *testing.T) {
 	}
 
 	//Assert joinRequest deleted
-	rows, err := DB.Query(
-		`SELECT COUNT(*) FROM wn_join_request 
-		WHERE id = $1`,
-		addedJoinRequest.ID)
-	if err != nil {
-		t.Errorf("An error occured while getting count from DB. %v", err)
-	}
-	c, err := test_helper.ReadInt(rows)
-	if err != nil {
-		t.Errorf("An error occured while reading int. %v", err)
-	}
-	if c != 0 {
-		t.Errorf("The join request still exist and has not been deleted")
+	_, err = Store.GetLoadedJoinRequest(context.Background(), addedJoinRequest.ID, testUsers[0].ID)
+	if err != http_error.NotFoundError {
+		t.Errorf("The join request still exist and has not been deleted. %v", err)
 	}
 
 
 	//Assert user2 was not added to group
-	rows, err = DB.Query(
-		`SELECT COUNT(*) FROM wn_user_group
-		WHERE user_id = $1`,
-		testUsers[1].ID)
-	if err != nil {
-		t.Errorf("An errpr pccired while getting count from DB. %v", err)
-	}
-	c, err = test_helper.ReadInt(rows)
+	groups, err := Store.GetAllGroupsOfUser(context.Background(), testUsers[1].ID)
 	if err != nil {
-		t.Errorf("An error occured while reading int. %v", err)
+		t.Errorf("An error occured while getting groups of user2. %v", err)
 	}
-	if c != 0 {
+	if c := len(groups); c != 0 {
 		t.Errorf("User2 is in some group despite being rejected")
 	}
 }
 
 func testRespondJoinRequestHandlerApproveAsUser1(t *testing.T) {
-	_, err := DB.Exec(
-		`INSERT INTO wn_join_request (
-			id,
-			user_id,
-			group_id
-		) values ($1, $2, $3)`,
-		addedJoinRequest.ID,
-		addedJoinRequest.UserID,
-		addedJoinRequest.GroupID)
+	var err error
+	addedJoinRequest, err = Store.AddJoinRequest(context.Background(), addedJoinRequest.GroupID, addedJoinRequest.UserID)
 	if err != nil {
-		t.Errorf("An error occured while brute adding the join request back. %v", err)
+		t.Errorf("An error occured while adding the join request back. %v", err)
 	}
 
 	joinRequestRespond := JoinRequestRespond{ Approve: true }
@@ -329,51 +338,26 @@ func testRespondJoinRequestHandlerApproveAsUser1(t *testing.T) {
 	}
 
 	//Assert joinRequest deleted
-	rows, err := DB.Query(
-		`SELECT COUNT(*) FROM wn_join_request 
-		WHERE id = $1`,
-		addedJoinRequest.ID)
-	if err != nil {
-		t.Errorf("An error occured while getting count from DB. %v", err)
-	}
-	c, err := test_helper.ReadInt(rows)
-	if err != nil {
-		t.Errorf("An error occured while reading int. %v", err)
-	}
-	if c != 0 {
-		t.Errorf("The join request still exist and has not been deleted")
+	_, err = Store.GetLoadedJoinRequest(context.Background(), addedJoinRequest.ID, testUsers[0].ID)
+	if err != http_error.NotFoundError {
+		t.Errorf("The join request still exist and has not been deleted. %v", err)
 	}
 
 	//Assert user2 was not added to group
-	rows, err = DB.Query(
-		`SELECT COUNT(*) FROM wn_user_group
-		WHERE user_id = $1 AND group_id = $2`,
-		testUsers[1].ID,
-		testGroups[0].ID)
+	inGroup, err := Store.IsUserInGroup(context.Background(), testUsers[1].ID, testGroups[0].ID)
 	if err != nil {
-		t.Errorf("An error occured while getting count from DB. %v", err)
+		t.Errorf("An error occured while checking membership of user2. %v", err)
 	}
-	c, err = test_helper.ReadInt(rows)
-	if err != nil {
-		t.Errorf("An error occured while reading int. %v", err)
-	}
-	if c != 1 {
+	if !inGroup {
 		t.Errorf("User2 is not in the group despite being approved")
 	}
 }
 
 func testDeleteJoinRequestHandlerAsUser1(t *testing.T) {
-	_, err := DB.Exec(
-		`INSERT INTO wn_join_request (
-			id,
-			user_id,
-			group_id
-		) values ($1, $2, $3)`,
-		addedJoinRequest.ID,
-		addedJoinRequest.UserID,
-		addedJoinRequest.GroupID)
+	var err error
+	addedJoinRequest, err = Store.AddJoinRequest(context.Background(), addedJoinRequest.GroupID, addedJoinRequest.UserID)
 	if err != nil {
-		t.Errorf("An error occured while brute adding the join request back. %v", err)
+		t.Errorf("An error occured while adding the join request back. %v", err)
 	}
 
 	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/join/%d", addedJoinRequest.ID), nil)
@@ -408,6 +392,10 @@ func testDeleteJoinRequestHandlerAsUser2(t *testing.T) {
 
 func testGetLoadedJoinRequestHandlerAfterDeletion(t *testing.T) {
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/join/%d", addedJoinRequest.ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[0],
+	})
 	w := test_helper.SimulateRequest(Router, req)
 	if w.Code != http.StatusNotFound { 
 		t.Errorf("HTTP Request to GetJoinRequest did not respond with NotFound Code but with status code of %d", w.Code)
@@ -1,11 +1,11 @@
 package join
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/router/join"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -33,35 +31,34 @@ var testGroups []Group
 
 func setupRouter() *gin.Engine {
 	Router := gin.Default()
+	Router.Use(middleware.Authenticate(Store))
+	protected := Router.Group("/", middleware.RequireAuth())
 
-	Router.GET("/join", join.GetAllLoadedJoinRequestsHandler(DB))
-	Router.POST("/join", join.AddJoinRequestHandler(DB))
-	Router.GET("/join/:id", join.GetLoadedJoinRequestHandler(DB))
-	Router.PATCH("/join/:id", join.RespondJoinRequestHandler(DB))
-	Router.DELETE("/join/:id", join.DeleteJoinRequestHandler(DB))
+	protected.GET("/join", join.GetAllLoadedJoinRequestsHandler(Store))
+	protected.POST("/join", join.AddJoinRequestHandler(Store))
+	protected.GET("/join/:id", join.GetLoadedJoinRequestHandler(Store))
+	protected.PATCH("/join/:id", join.RespondJoinRequestHandler(Store))
+	protected.DELETE("/join/:id", join.DeleteJoinRequestHandler(Store))
 
 	return Router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 2)
+	testUsers, err = test_helper.SetupUsers(Store, 3)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
 	}
 
-	testGroups, err = test_helper.SetupGroupsForUsers(DB, testUsers[:1])
+	testGroups, err = test_helper.SetupGroupsForUsers(Store, testUsers[:1])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test group. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
@@ -0,0 +1,257 @@
+package leftover
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLeftover(t *testing.T) {
+	t.Run("Oldest match requests are grouped first", testOldestFirst)
+	t.Run("Uneven groups take in leftovers", testUnevenGroups)
+	t.Run("Leftovers fill open groups", testFillOpenGroups)
+	t.Run("Estimated wait", testEstimateWait)
+	t.Run("GetQueuedMatchRequestOfUserHandler", testGetQueuedMatchRequestOfUserHandler)
+	t.Run("Run fills the open group", testRunFillsOpenGroup)
+	t.Run("Run leaves the rest queued once the open group is full", testRunLeavesRestQueued)
+	t.Run("Run forms a group one member short", testRunFormsShortGroup)
+}
+
+// Helper
+func matchingWith(uneven bool, fill bool) config.MatchingConfig {
+	matching := Matching
+	matching.UnevenGroups = uneven
+	matching.FillGroups = fill
+	return matching
+}
+
+func plan(n int, openGroups []OpenGroup, matching config.MatchingConfig) ([]LoadedMatchRequest, MatchingPlan) {
+	lmrs := test_helper.GenerateLoadedMatchRequests(rand.New(rand.NewSource(int64(n))), n)
+	return lmrs, PlanMatching(NewMatcher(matching), lmrs, openGroups, matching)
+}
+
+func groupSizes(plan MatchingPlan) []int {
+	sizes := make([]int, len(plan.Groups))
+	for i, group := range plan.Groups {
+		sizes[i] = len(group)
+	}
+	return sizes
+}
+
+func newRequestWithSession(method string, url string, sessionKey string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "session_key",
+		Value: sessionKey,
+	})
+	return req
+}
+
+func assertQueueSize(t *testing.T, size int) {
+	if count, _ := Store.GetMatchRequestCount(context.Background()); int(count) != size {
+		t.Errorf("%d match requests were queued instead of %d", count, size)
+	}
+}
+
+func assertGroupSize(t *testing.T, groupID int64, size int) {
+	groupWithUsers, err := Store.GetGroupWithUsers(context.Background(), groupID)
+	if err != nil {
+		t.Fatalf("An error occured while getting group %d. %v", groupID, err)
+	}
+	if len(groupWithUsers.Users) != size {
+		t.Errorf("Group %d has %d members instead of %d", groupID, len(groupWithUsers.Users), size)
+	}
+}
+
+func testOldestFirst(t *testing.T) {
+	n := 2*Matching.GroupSize + 1
+	_, p := plan(n, nil, matchingWith(false, false))
+	if !reflect.DeepEqual(p.Remaining, []int{n - 1}) {
+		t.Errorf("%v were left over instead of only the newest", p.Remaining)
+	}
+}
+
+func testUnevenGroups(t *testing.T) {
+	size := Matching.GroupSize
+	cases := []struct {
+		n         int
+		sizes     []int
+		remaining int
+	}{
+		{size - 1, []int{size - 1}, 0},
+		{size + 1, []int{size + 1}, 0},
+		{2*size + 2, []int{size + 1, size + 1}, 0},
+		{size + 2, []int{size}, 2},
+		{2, []int{}, 2},
+	}
+	for _, c := range cases {
+		_, p := plan(c.n, nil, matchingWith(true, false))
+		if sizes := groupSizes(p); !reflect.DeepEqual(sizes, c.sizes) || len(p.Remaining) != c.remaining {
+			t.Errorf("A queue of %d formed groups of %v leaving %d instead of %v leaving %d", c.n, sizes, len(p.Remaining), c.sizes, c.remaining)
+		}
+		if left := LeftoverCount(matchingWith(true, false), c.n); left != c.remaining {
+			t.Errorf("%d of a queue of %d were counted as left over instead of %d", left, c.n, c.remaining)
+		}
+	}
+	// Enlarged groups must not share the array of the group after them
+	lmrs, p := plan(2*size+2, nil, matchingWith(true, false))
+	seen := make(map[int]bool)
+	for _, group := range p.Groups {
+		for _, index := range group {
+			if seen[index] {
+				t.Errorf("Match request %d was placed twice", index)
+			}
+			seen[index] = true
+		}
+	}
+	if len(seen) != len(lmrs) {
+		t.Errorf("%d of %d match requests were placed", len(seen), len(lmrs))
+	}
+}
+
+func testFillOpenGroups(t *testing.T) {
+	size := Matching.GroupSize
+	lmrs := test_helper.GenerateLoadedMatchRequests(rand.New(rand.NewSource(1)), size+3)
+	// The first open group has room for one, and the second has the newest match request as a member.
+	// With this seed the older leftovers prefer the second, leaving the first for the newest
+	openGroups := []OpenGroup{
+		{Group: Group{ID: 1}, Members: []LoadedMatchRequest{}},
+		{Group: Group{ID: 2}, Members: append([]LoadedMatchRequest(nil), lmrs[size+2])},
+	}
+	for i := 0; i < size-1; i++ {
+		openGroups[0].Members = append(openGroups[0].Members, LoadedMatchRequest{User: PublicUser{ID: int64(100 + i)}})
+	}
+	p := PlanMatching(NewMatcher(Matching), lmrs, openGroups, matchingWith(false, true))
+	placed := make(map[int]int64)
+	for _, fill := range p.Fills {
+		for _, index := range fill.Indices {
+			placed[index] = fill.GroupID
+		}
+	}
+	if len(placed) != 3 || placed[size+2] != 1 {
+		t.Errorf("Leftovers were placed as %v instead of the newest joining the group it is not in", placed)
+	}
+	if len(p.Remaining) != 0 {
+		t.Errorf("%d leftovers were left queued instead of none", len(p.Remaining))
+	}
+	filled := 0
+	for _, groupID := range placed {
+		if groupID == 1 {
+			filled++
+		}
+	}
+	if filled > 1 {
+		t.Errorf("%d leftovers joined an open group with room for 1", filled)
+	}
+}
+
+func testEstimateWait(t *testing.T) {
+	now := time.Now()
+	matching := matchingWith(false, false)
+	matching.Threshold, matching.GroupSize, matching.Interval, matching.PollInterval = 8, 4, 24*time.Hour, time.Minute
+	cases := []struct {
+		name      string
+		position  int
+		queueSize int
+		lastRun   time.Time
+		interval  time.Duration
+		wait      time.Duration
+		ok        bool
+	}{
+		{"threshold reached", 8, 8, now, 24 * time.Hour, time.Minute, true},
+		{"below threshold", 1, 5, now.Add(-time.Hour), 24 * time.Hour, 23 * time.Hour, true},
+		{"interval passed", 1, 5, now.Add(-48 * time.Hour), 24 * time.Hour, time.Minute, true},
+		{"left over", 5, 5, now.Add(-time.Hour), 24 * time.Hour, 47 * time.Hour, true},
+		{"no schedule", 1, 5, now, 0, 0, false},
+	}
+	for _, c := range cases {
+		matching.Interval = c.interval
+		wait, ok := EstimateWait(matching, c.position, c.queueSize, c.lastRun, now)
+		if wait != c.wait || ok != c.ok {
+			t.Errorf("%s: estimated a wait of %v (%v) instead of %v (%v)", c.name, wait, ok, c.wait, c.ok)
+		}
+	}
+}
+
+func testGetQueuedMatchRequestOfUserHandler(t *testing.T) {
+	queued := testUsers[1 : Matching.GroupSize+3]
+	if _, err := test_helper.SetupMatchRequestForUsers(Store, queued); err != nil {
+		t.Fatalf("An error occured while creating match requests. %v", err)
+	}
+	for i, user := range queued {
+		url := fmt.Sprintf("/match/%d", user.ID)
+		w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", url, sessionKeys[i+1]))
+		qmr, err := test_helper.GetQueuedMatchRequestFromRecorder(w)
+		if err != nil {
+			t.Fatalf("HTTP Request for a queued match request failed. %v", err)
+		}
+		if qmr.QueuePosition != i+1 || qmr.QueueSize != len(queued) || qmr.MatchRequest.UserID != user.ID {
+			t.Errorf("User %d was at %d of %d instead of %d of %d", user.ID, qmr.QueuePosition, qmr.QueueSize, i+1, len(queued))
+		}
+		if qmr.EstimatedWaitSeconds == nil || *qmr.EstimatedWaitSeconds <= 0 {
+			t.Errorf("User %d had no estimated wait", user.ID)
+		}
+	}
+}
+
+func testRunFillsOpenGroup(t *testing.T) {
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerSchedule)
+	if err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	if run.Outcome != MatchingOutcomeMatched || len(run.GroupIDs) != 1 || !reflect.DeepEqual(run.FilledGroupIDs, []int64{openGroup.ID}) {
+		t.Fatalf("The run was %s forming %v and filling %v instead of 1 group and the open group", run.Outcome, run.GroupIDs, run.FilledGroupIDs)
+	}
+	assertQueueSize(t, 0)
+	assertGroupSize(t, openGroup.ID, 3)
+	assertGroupSize(t, privateGroup.ID, 1)
+
+	groupWithUsers, _ := Store.GetGroupWithUsers(context.Background(), run.GroupIDs[0])
+	for _, user := range groupWithUsers.Users {
+		if user.ID > testUsers[Matching.GroupSize].ID {
+			t.Errorf("User %d was grouped before those queued earlier", user.ID)
+		}
+	}
+}
+
+func testRunLeavesRestQueued(t *testing.T) {
+	queued := testUsers[Matching.GroupSize+3 : 2*Matching.GroupSize+2]
+	if _, err := test_helper.SetupMatchRequestForUsers(Store, queued); err != nil {
+		t.Fatalf("An error occured while creating match requests. %v", err)
+	}
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerSchedule)
+	if err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	if run.Outcome != MatchingOutcomeMatched || len(run.GroupIDs) != 0 || len(run.FilledGroupIDs) != 1 {
+		t.Errorf("The run was %s forming %v and filling %v instead of only filling the open group", run.Outcome, run.GroupIDs, run.FilledGroupIDs)
+	}
+	assertGroupSize(t, openGroup.ID, Matching.GroupSize)
+	assertQueueSize(t, len(queued)-(Matching.GroupSize-3))
+	if _, err := Store.GetQueuedMatchRequestOfUser(context.Background(), queued[0].ID); err == nil {
+		t.Errorf("The oldest match request was left queued instead of filling the open group")
+	}
+}
+
+func testRunFormsShortGroup(t *testing.T) {
+	if _, err := test_helper.SetupMatchRequestForUsers(Store, testUsers[2*Matching.GroupSize+2:]); err != nil {
+		t.Fatalf("An error occured while creating match requests. %v", err)
+	}
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerSchedule)
+	if err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	if run.Outcome != MatchingOutcomeMatched || len(run.GroupIDs) != 1 || len(run.FilledGroupIDs) != 0 {
+		t.Fatalf("The run was %s forming %v and filling %v instead of 1 group", run.Outcome, run.GroupIDs, run.FilledGroupIDs)
+	}
+	assertGroupSize(t, run.GroupIDs[0], Matching.GroupSize-1)
+	assertQueueSize(t, 0)
+}
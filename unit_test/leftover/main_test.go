@@ -0,0 +1,104 @@
+package leftover
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/match"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store  store.Store
+	Router *gin.Engine
+)
+
+// Matching parameters the store was set up with
+var Matching config.MatchingConfig = test_helper.LoadConfig().Matching
+
+// testUsers[0] is alone in openGroup, formed by a matching run that the leavers have since left,
+// and the others are queued by each test as it needs them. The first leaver is alone in
+// privateGroup, which was made by the user and so is never filled
+var testUsers []User
+var leavers []User
+var openGroup Group
+var privateGroup Group
+var sessionKeys []string
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+	protected.GET("/match/:id", match.GetQueuedMatchRequestOfUserHandler(Store))
+	return router
+}
+
+// Matches testUsers[0] with the leavers, who then leave the group formed
+func setupOpenGroup() (Group, error) {
+	var err error
+	if leavers, err = test_helper.SetupUsers(Store, Matching.GroupSize-1); err != nil {
+		return Group{}, err
+	}
+	if _, err = test_helper.SetupMatchSettingForUsers(Store, leavers); err != nil {
+		return Group{}, err
+	}
+	if _, err = test_helper.SetupMatchRequestForUsers(Store, append([]User{testUsers[0]}, leavers...)); err != nil {
+		return Group{}, err
+	}
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerManual)
+	if err != nil {
+		return Group{}, err
+	}
+	if len(run.GroupIDs) != 1 {
+		return Group{}, fmt.Errorf("run formed groups %v instead of 1", run.GroupIDs)
+	}
+	for _, leaver := range leavers {
+		if _, err = Store.LeaveGroup(context.Background(), run.GroupIDs[0], leaver.ID); err != nil {
+			return Group{}, err
+		}
+	}
+	return Store.GetGroup(context.Background(), run.GroupIDs[0])
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	Router = setupRouter()
+
+	var err error
+	testUsers, err = test_helper.SetupUsers(Store, 2*Matching.GroupSize+3)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	_, err = test_helper.SetupMatchSettingForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating match settings. %v", err))
+	}
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+	if openGroup, err = setupOpenGroup(); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating the open group. %v", err))
+	}
+	groupWithUsers, err := Store.AddGroupWithUserIDs(context.Background(), Group{
+		GroupName:        "Support Group",
+		GroupDescription: "A support group of my own",
+		Category:         "SUPPORT",
+	}, []int64{leavers[0].ID})
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test group. %v", err))
+	}
+	privateGroup = groupWithUsers.Group
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,204 @@
+package logging
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/logger"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/unit_test/test_helper"
+
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Full test
+func TestLogging(t *testing.T) {
+	t.Run("Request ID generated and logged", testRequestIDGenerated)
+	t.Run("Request ID taken from header", testRequestIDFromHeader)
+	t.Run("Invalid request ID replaced", testRequestIDInvalid)
+	t.Run("Database error logged with request ID", testErrorLoggedWithRequestID)
+	t.Run("Panic logged and answered with 500", testPanic)
+	t.Run("Levels by package", testPackageLevels)
+	t.Run("Secrets redacted", testRedaction)
+}
+
+// Helpers
+
+// Sends logs to a buffer at the levels of cfg and returns it
+func captureLogs(cfg config.LogConfig) *bytes.Buffer {
+	var buffer bytes.Buffer
+	logger.Setup(cfg, &buffer)
+	return &buffer
+}
+
+func readLines(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	lines := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Log line %q is not JSON. %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func findLine(t *testing.T, buffer *bytes.Buffer, msg string) map[string]interface{} {
+	for _, entry := range readLines(t, buffer) {
+		if entry["msg"] == msg {
+			return entry
+		}
+	}
+	t.Fatalf("No %q line was logged in %s", msg, buffer.String())
+	return nil
+}
+
+func request(path string, requestID string) *http.Response {
+	req, _ := http.NewRequest("GET", path, nil)
+	if requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
+	return test_helper.SimulateRequest(setupRouter(), req).Result()
+}
+
+func testRequestIDGenerated(t *testing.T) {
+	buffer := captureLogs(config.LogConfig{Level: "info"})
+	res := request("/ok", "")
+	requestID := res.Header.Get(middleware.RequestIDHeader)
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(requestID) {
+		t.Fatalf("Response had request ID %q instead of a generated one", requestID)
+	}
+	entry := findLine(t, buffer, "request")
+	if entry["request_id"] != requestID || entry["package"] != "http" || entry["level"] != "INFO" {
+		t.Errorf("Request line %v did not have request ID %s, package http and level INFO", entry, requestID)
+	}
+	if entry["route"] != "/ok" || entry["status"] != float64(http.StatusOK) {
+		t.Errorf("Request line %v did not have route /ok and status %d", entry, http.StatusOK)
+	}
+}
+
+func testRequestIDFromHeader(t *testing.T) {
+	buffer := captureLogs(config.LogConfig{Level: "info"})
+	res := request("/ok", "proxy-1234.abc")
+	if requestID := res.Header.Get(middleware.RequestIDHeader); requestID != "proxy-1234.abc" {
+		t.Errorf("Response had request ID %q instead of the one sent", requestID)
+	}
+	if entry := findLine(t, buffer, "request"); entry["request_id"] != "proxy-1234.abc" {
+		t.Errorf("Request line had request ID %v instead of the one sent", entry["request_id"])
+	}
+}
+
+func testRequestIDInvalid(t *testing.T) {
+	captureLogs(config.LogConfig{Level: "info"})
+	for _, sent := range []string{"with spaces", strings.Repeat("a", 65), "{\"json\":1}"} {
+		res := request("/ok", sent)
+		if requestID := res.Header.Get(middleware.RequestIDHeader); requestID == sent || requestID == "" {
+			t.Errorf("Request ID %q was used instead of replaced", sent)
+		}
+	}
+}
+
+func testErrorLoggedWithRequestID(t *testing.T) {
+	buffer := captureLogs(config.LogConfig{Level: "info"})
+	res := request("/fail", "failing-request")
+	failure := findLine(t, buffer, "request failed")
+	if failure["request_id"] != "failing-request" || failure["error"] != databaseError.Error() {
+		t.Errorf("Failure line %v did not have the request ID and the database error", failure)
+	}
+	if entry := findLine(t, buffer, "request"); entry["level"] != "ERROR" || entry["status"] != float64(http.StatusInternalServerError) {
+		t.Errorf("Request line %v of a failed request was not an error with status 500", entry)
+	}
+	body, _ := io.ReadAll(res.Body)
+	if strings.Contains(string(body), "wn_user") {
+		t.Errorf("The database error was sent to the client. %s", body)
+	}
+}
+
+func testPanic(t *testing.T) {
+	buffer := captureLogs(config.LogConfig{Level: "info"})
+	res := request("/panic", "panicking-request")
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Panicking handler responded %d instead of %d", res.StatusCode, http.StatusInternalServerError)
+	}
+	var body http_error.ErrorResponse
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil || body.Error == nil || body.Error.Code != http_error.CodeInternal {
+		t.Errorf("Panicking handler did not respond with the internal error envelope. %v", err)
+	} else if strings.Contains(body.Error.Message, "handler bug") {
+		t.Errorf("Panicking handler responded with the panic. %s", body.Error.Message)
+	}
+	entry := findLine(t, buffer, "handler panicked")
+	if entry["request_id"] != "panicking-request" || entry["panic"] != "handler bug" || entry["level"] != "ERROR" {
+		t.Errorf("Panic line %v did not have the request ID, the panic and level ERROR", entry)
+	}
+	if entry := findLine(t, buffer, "request"); entry["level"] != "ERROR" {
+		t.Errorf("Request line of a panicking handler was at %v instead of ERROR", entry["level"])
+	}
+}
+
+func testPackageLevels(t *testing.T) {
+	buffer := captureLogs(config.LogConfig{Level: "warn", Levels: []string{"ws=debug"}})
+	// Loggers made before Setup follow the levels set after
+	ws, model := logger.For("ws"), logger.For("model")
+	ws.Debug("ws debug")
+	model.Info("model info")
+	model.Warn("model warn")
+	ctx := logger.WithRequestID(context.Background(), "ws-request")
+	ws.With("user_id", 1).DebugContext(ctx, "ws with context")
+
+	messages := make([]interface{}, 0)
+	for _, entry := range readLines(t, buffer) {
+		messages = append(messages, entry["msg"])
+	}
+	want := []interface{}{"ws debug", "model warn", "ws with context"}
+	if len(messages) != len(want) {
+		t.Fatalf("Logged %v instead of %v", messages, want)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Logged %v instead of %v", messages, want)
+		}
+	}
+	entry := findLine(t, buffer, "ws with context")
+	if entry["request_id"] != "ws-request" || entry["user_id"] != float64(1) || entry["package"] != "ws" {
+		t.Errorf("Line %v did not have the request ID of its context, its attributes and its package", entry)
+	}
+}
+
+func testRedaction(t *testing.T) {
+	buffer := captureLogs(config.LogConfig{Level: "info"})
+	log := logger.For("test")
+	log.Info("login",
+		"password", "hunter2",
+		"new_password", "hunter3",
+		"session_key", "sk-secret-1",
+		"Cookie", "theme=dark; session_key=sk-secret-2",
+		"body", `{"email":"a@u.nus.edu","password":"hunter4"}`,
+		"address", "postgres://wellnus:dbsecret@db:5432/wellnus",
+		"error", databaseError)
+	log.Info("cookie session_key=sk-secret-3 seen")
+	log.Info("mail", "body", "Verify your email at http://localhost:3000/verify?token=tk-secret-1")
+	log.Info("verify", "body", `{"token":"tk-secret-2"}`)
+
+	output := buffer.String()
+	for _, secret := range []string{"hunter2", "hunter3", "hunter4", "sk-secret-1", "sk-secret-2", "sk-secret-3", "tk-secret-1", "tk-secret-2", "dbsecret"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("Secret %q was logged in %s", secret, output)
+		}
+	}
+	entry := findLine(t, buffer, "login")
+	if entry["Cookie"] != logger.Redacted || entry["address"] != "postgres://wellnus:"+logger.Redacted+"@db:5432/wellnus" {
+		t.Errorf("Line %v did not redact the cookie and the connection string", entry)
+	}
+	if !strings.Contains(entry["body"].(string), "a@u.nus.edu") || entry["error"] != databaseError.Error() {
+		t.Errorf("Line %v redacted more than the secrets", entry)
+	}
+}
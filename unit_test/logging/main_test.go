@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/router/middleware"
+
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var databaseError = errors.New("pq: relation \"wn_user\" does not exist")
+
+func setupRouter() *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID(), middleware.Logger(), middleware.Recovery())
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(200, gin.H{"ok": true})
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		http_helper.WriteError(c, databaseError)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("handler bug")
+	})
+	return router
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
@@ -1,11 +1,11 @@
 package match
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/router/match"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -32,33 +30,32 @@ var validMatchSetting MatchSetting = test_helper.GetRandomTestMatchSetting()
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
 
-	router.GET("/setting", match.GetMatchSettingOfUserHandler(DB))
-	router.POST("/setting", match.AddUpdateMatchSettingOfUserHandler(DB))
-	router.DELETE("/setting", match.DeleteMatchSettingOfUserHandler(DB))
+	protected.GET("/setting", match.GetMatchSettingOfUserHandler(Store))
+	protected.POST("/setting", match.AddUpdateMatchSettingOfUserHandler(Store))
+	protected.DELETE("/setting", match.DeleteMatchSettingOfUserHandler(Store))
 
-	router.GET("/match", match.GetMatchRequestCount(DB))
-	router.POST("/match", match.AddMatchRequestHandler(DB))
-	router.DELETE("/match", match.DeleteMatchRequestOfUserHandler(DB))
-	router.GET("/match/:id", match.GetLoadedMatchRequestOfUserHandler(DB))
+	router.GET("/match", match.GetMatchRequestCount(Store))
+	protected.POST("/match", match.AddMatchRequestHandler(Store))
+	protected.DELETE("/match", match.DeleteMatchRequestOfUserHandler(Store))
+	protected.GET("/match/:id", match.GetQueuedMatchRequestOfUserHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 1)
+	testUsers, err = test_helper.SetupUsers(Store, 1)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
@@ -16,6 +16,8 @@ func TestMatchHandler(t *testing.T) {
 	t.Run("GetMatchSettingHandler Unauthorized", testGetMatchSettingHandlerUnauthorized)
 	t.Run("GetMatchSettingHandler NotFound", testGetMatchSettingHandlerNotFound)
 	t.Run("AddMatchSettingHandler Unauthorized", testAddMatchSettingHandlerUnauthorized)
+	t.Run("AddMatchSettingHandler Invalid availability", testAddMatchSettingHandlerInvalidAvailability)
+	t.Run("AddMatchSettingHandler Without gender preference", testAddMatchSettingHandlerWithoutGenderPreference)
 	t.Run("AddMatchSettingHandler Success", testAddMatchSettingHandler)
 	t.Run("UpdateMatchSettingHandler Success", testUpdateMatchSettingHandler)
 	t.Run("AddMatchRequestHandler Success", testAddMatchRequestHandlerSuccessful)
@@ -58,10 +60,10 @@ func testAddMatchRequestHandlerNoSetting(t *testing.T) {
 		Value: sessionKeys[0],
 	})
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code != http.StatusBadRequest {
+	if w.Code != http.StatusUnprocessableEntity {
 		t.Errorf("Adding match request without setting passed with status code = %d", w.Code)
 	}
-	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, "foreign key constraint")
+	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, `"field":"user_id"`)
 	if !matched {
 		t.Errorf("The error that occured was not due to match setting. %s", errString)
 	}
@@ -96,6 +98,47 @@ func testAddMatchSettingHandlerUnauthorized(t *testing.T) {
 	}
 }
 
+func testAddMatchSettingHandlerInvalidAvailability(t *testing.T) {
+	matchSetting := test_helper.GetRandomTestMatchSetting()
+	matchSetting.Availability = []string{"WEEKDAY_MORNING", "MIDNIGHT"}
+	ioReaderMatchSetting, _ := test_helper.GetIOReaderFromObject(matchSetting)
+	req, _ := http.NewRequest("POST", "/setting", ioReaderMatchSetting)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[0],
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code == http.StatusOK {
+		t.Errorf("Match setting with an unknown availability was added")
+	}
+	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, `"field":"availability"`)
+	if !matched {
+		t.Errorf("The error that occured was not due to availability. %s", errString)
+	}
+}
+
+// Clients from before gender preference existed leave it out
+func testAddMatchSettingHandlerWithoutGenderPreference(t *testing.T) {
+	ioReaderMatchSetting, _ := test_helper.GetIOReaderFromObject(map[string]interface{}{
+		"faculty_preference": "MIX",
+		"hobbies": []string{"GAMING"},
+		"mbti": "INTJ",
+	})
+	req, _ := http.NewRequest("POST", "/setting", ioReaderMatchSetting)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKeys[0],
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	matchSetting, err := test_helper.GetMatchSettingFromRecorder(w)
+	if err != nil {
+		t.Fatalf("Match setting without gender preference was not added. %v", err)
+	}
+	if matchSetting.GenderPreference != "NONE" || matchSetting.YearOfStudy != 0 || matchSetting.PreferredGroupSize != 0 {
+		t.Errorf("Match setting without the new signals was added as %+v instead of leaving them unset", matchSetting)
+	}
+}
+
 func testAddMatchSettingHandler(t *testing.T) {
 	ioReaderMatchSetting, _ := test_helper.GetIOReaderFromObject(validMatchSetting)
 	req, _ := http.NewRequest("POST", "/setting", ioReaderMatchSetting)
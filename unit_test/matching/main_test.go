@@ -2,10 +2,11 @@ package matching
 
 import (
 	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/router/match"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/unit_test/test_helper"
 
 	"fmt"
@@ -13,56 +14,56 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
 )
 
+// Matching parameters the store was set up with
+var Matching config.MatchingConfig = test_helper.LoadConfig().Matching
+
 var testUsers []User
 var sessionKeys []string
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
-	router.POST("/match", match.AddMatchRequestHandler(DB))
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+	protected.POST("/match", match.AddMatchRequestHandler(Store))
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
 
-	test_helper.ResetDB(DB)
 	var err error
 	// Setup test users
-	testUsers, err = test_helper.SetupUsers(DB, config.MATCH_THRESHOLD)
+	testUsers, err = test_helper.SetupUsers(Store, Matching.Threshold)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
 	}
 
 	// Create match settings for all users
-	_, err = test_helper.SetupMatchSettingForUsers(DB, testUsers)
+	_, err = test_helper.SetupMatchSettingForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating match settings. %v", err))
 	}
 
 	// Create sessions for the first 2 users
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers[:2])
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers[:2])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
 
 	// Create match request for the rest of users
-	_, err = test_helper.SetupMatchRequestForUsers(DB, testUsers[2:])
+	_, err = test_helper.SetupMatchRequestForUsers(Store, testUsers[2:])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Match Request. %v", err))
 	}
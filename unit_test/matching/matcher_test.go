@@ -0,0 +1,131 @@
+package matching
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/unit_test/test_helper"
+
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Sizes of the queues the matchers are compared on
+var queueSizes = []int{8, 40, 100, 200}
+
+func TestMatcher(t *testing.T) {
+	matchers := map[string]Matcher{
+		MatchStrategyGreedy:    GreedyMatcher{Seed: 1, Weights: Matching.Weights},
+		MatchStrategyAnnealing: AnnealingMatcher{Seed: 1, Weights: Matching.Weights},
+	}
+	for name, matcher := range matchers {
+		t.Run(fmt.Sprintf("%s forms valid groups", name), func(t *testing.T) { testValidGroups(t, matcher) })
+		t.Run(fmt.Sprintf("%s is deterministic", name), func(t *testing.T) { testDeterministic(t, matcher) })
+	}
+	t.Run("NewMatcher picks the strategy", testNewMatcher)
+	t.Run("annealing is at least as compatible as greedy", testAnnealingQuality)
+	t.Run("annealing swaps are capped", testAnnealingIterationsCapped)
+}
+
+// Helper
+func generateQueue(seed int64, n int) []LoadedMatchRequest {
+	return test_helper.GenerateLoadedMatchRequests(rand.New(rand.NewSource(seed)), n)
+}
+
+func testValidGroups(t *testing.T, matcher Matcher) {
+	for _, n := range append([]int{0, Matching.GroupSize - 1, Matching.GroupSize + 1}, queueSizes...) {
+		groups, remaining := matcher.Match(generateQueue(int64(n), n), Matching.GroupSize)
+		if len(groups) != n/Matching.GroupSize || len(remaining) != n%Matching.GroupSize {
+			t.Errorf("A queue of %d formed %d groups leaving %d instead of %d leaving %d", n, len(groups), len(remaining), n/Matching.GroupSize, n%Matching.GroupSize)
+		}
+		seen := make(map[int]bool)
+		for _, group := range append(groups, remaining) {
+			for _, index := range group {
+				if index < 0 || index >= n || seen[index] {
+					t.Errorf("Match request %d of a queue of %d was not placed exactly once", index, n)
+				}
+				seen[index] = true
+			}
+		}
+		for _, group := range groups {
+			if len(group) != Matching.GroupSize {
+				t.Errorf("A group of %d was formed instead of %d", len(group), Matching.GroupSize)
+			}
+		}
+		if len(seen) != n {
+			t.Errorf("%d of a queue of %d match requests were placed", len(seen), n)
+		}
+	}
+}
+
+func testDeterministic(t *testing.T, matcher Matcher) {
+	lmrs := generateQueue(1, 40)
+	groups, remaining := matcher.Match(lmrs, Matching.GroupSize)
+	againGroups, againRemaining := matcher.Match(lmrs, Matching.GroupSize)
+	if !reflect.DeepEqual(groups, againGroups) || !reflect.DeepEqual(remaining, againRemaining) {
+		t.Errorf("Matching the same queue with the same seed formed different groups")
+	}
+}
+
+func testNewMatcher(t *testing.T) {
+	matching := Matching
+	matching.Strategy, matching.Seed = MatchStrategyGreedy, 7
+	if matcher := NewMatcher(matching); matcher != (GreedyMatcher{Seed: 7, Weights: Matching.Weights}) {
+		t.Errorf("The greedy strategy made %#v", matcher)
+	}
+	matching.Strategy = MatchStrategyAnnealing
+	if matcher := NewMatcher(matching); matcher != (AnnealingMatcher{Seed: 7, Weights: Matching.Weights}) {
+		t.Errorf("The annealing strategy made %#v", matcher)
+	}
+}
+
+func testAnnealingQuality(t *testing.T) {
+	for _, n := range queueSizes {
+		for seed := int64(1); seed <= 3; seed++ {
+			lmrs := generateQueue(seed, n)
+			greedyGroups, _ := GreedyMatcher{Seed: seed, Weights: Matching.Weights}.Match(lmrs, Matching.GroupSize)
+			annealingGroups, _ := AnnealingMatcher{Seed: seed, Weights: Matching.Weights}.Match(lmrs, Matching.GroupSize)
+			greedy, annealing := TotalCompatibility(lmrs, greedyGroups, Matching.Weights), TotalCompatibility(lmrs, annealingGroups, Matching.Weights)
+			if annealing < greedy {
+				t.Errorf("Annealing scored %d on a queue of %d with seed %d, below the %d of greedy", annealing, n, seed, greedy)
+			}
+			t.Logf("queue of %d with seed %d: greedy %d, annealing %d (%+.1f%%)", n, seed, greedy, annealing, 100*float64(annealing-greedy)/float64(greedy))
+		}
+	}
+}
+
+func testAnnealingIterationsCapped(t *testing.T) {
+	matcher := AnnealingMatcher{Seed: 1, Weights: Matching.Weights}
+	if iterations := matcher.IterationsFor(40); iterations != 40*AnnealingIterationsPerRequest {
+		t.Errorf("A queue of 40 was given %d swaps instead of %d", iterations, 40*AnnealingIterationsPerRequest)
+	}
+	if iterations := matcher.IterationsFor(10000); iterations != AnnealingMaxIterations {
+		t.Errorf("A queue of 10000 was given %d swaps instead of the cap of %d", iterations, AnnealingMaxIterations)
+	}
+	matcher.Iterations = 10
+	if iterations := matcher.IterationsFor(10000); iterations != 10 {
+		t.Errorf("A matcher set to 10 swaps was given %d", iterations)
+	}
+}
+
+func benchmarkMatcher(b *testing.B, matcher Matcher) {
+	for _, n := range queueSizes {
+		lmrs := generateQueue(1, n)
+		b.Run(fmt.Sprintf("queue of %d", n), func(b *testing.B) {
+			var total int
+			for i := 0; i < b.N; i++ {
+				groups, _ := matcher.Match(lmrs, Matching.GroupSize)
+				total = TotalCompatibility(lmrs, groups, Matching.Weights)
+			}
+			b.ReportMetric(float64(total), "compatibility")
+		})
+	}
+}
+
+func BenchmarkGreedyMatcher(b *testing.B) {
+	benchmarkMatcher(b, GreedyMatcher{Seed: 1, Weights: Matching.Weights})
+}
+
+func BenchmarkAnnealingMatcher(b *testing.B) {
+	benchmarkMatcher(b, AnnealingMatcher{Seed: 1, Weights: Matching.Weights})
+}
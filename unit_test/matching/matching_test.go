@@ -1,9 +1,11 @@
 package matching
 
 import (
-	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/scheduler"
 	"wellnus/backend/unit_test/test_helper"
 
+	"context"
 	"testing"
 	"net/http"
 )
@@ -14,30 +16,40 @@ func TestMatching(t *testing.T) {
 	t.Run("TestAssertState of DB after User0", testAssertDatabaseStateAfterUser0)
 	t.Run("TestAddMatchRequestHandler as User1", testAddMatchRequestHandlerAsUser1)
 	t.Run("TestAssertState of DB after User1", testAssertDatabaseStateAfterUser1)
+	t.Run("TestScheduler matches the full queue", testSchedulerMatchesFullQueue)
+	t.Run("TestAssertState of DB after matching", testAssertDatabaseStateAfterMatching)
 }
 
 // Helper
 func assertDatabaseState(t *testing.T, nUsers, nGroups, nMS, nMR int) {
-	rows, _ := DB.Query(`SELECT COUNT(*) FROM wn_user`)
-	if count, _ := test_helper.ReadInt(rows); count != nUsers {
+	users, _ := Store.GetAllUsers(context.Background())
+	if count := len(users); count != nUsers {
 		t.Errorf("The number of users is not equal to %d. No. of users = %d", nUsers, count)
 	}
-	rows, _ = DB.Query(`SELECT COUNT(*) FROM wn_group`)
-	if count, _ := test_helper.ReadInt(rows); count != nGroups {
+	groupIDs := make(map[int64]bool)
+	matchSettingCount := 0
+	for _, user := range users {
+		groups, _ := Store.GetAllGroupsOfUser(context.Background(), user.ID)
+		for _, group := range groups {
+			groupIDs[group.ID] = true
+		}
+		if _, err := Store.GetMatchSettingOfUser(context.Background(), user.ID); err == nil {
+			matchSettingCount++
+		}
+	}
+	if count := len(groupIDs); count != nGroups {
 		t.Errorf("The number of groups initially present is not %d. No. of groups = %d", nGroups, count)
 	}
-	rows, _ = DB.Query(`SELECT COUNT(*) FROM wn_match_setting`)
-	if count, _ := test_helper.ReadInt(rows); count != nMS {
+	if count := matchSettingCount; count != nMS {
 		t.Errorf("The number of match settings is not %d. No. of match request = %d", nMS, count)
 	}
-	rows, _ = DB.Query(`SELECT COUNT(*) FROM wn_match_request`)
-	if count, _ := test_helper.ReadInt(rows); count != nMR {
+	if count, _ := Store.GetMatchRequestCount(context.Background()); int(count) != nMR {
 		t.Errorf("The number of match requests made is not %d. No. of match request = %d", nMR, count)
 	}
 }
 
 func testAssertInitialDatabaseState(t *testing.T) {
-	assertDatabaseState(t, config.MATCH_THRESHOLD, 0, config.MATCH_THRESHOLD, config.MATCH_THRESHOLD - 2)
+	assertDatabaseState(t, Matching.Threshold, 0, Matching.Threshold, Matching.Threshold - 2)
 }
 
 func testAddMatchRequestHandlerAsUser0(t *testing.T) {
@@ -60,7 +72,7 @@ func testAddMatchRequestHandlerAsUser0(t *testing.T) {
 }
 
 func testAssertDatabaseStateAfterUser0(t *testing.T) {
-	assertDatabaseState(t, config.MATCH_THRESHOLD, 0, config.MATCH_THRESHOLD, config.MATCH_THRESHOLD - 1)
+	assertDatabaseState(t, Matching.Threshold, 0, Matching.Threshold, Matching.Threshold - 1)
 }
 
 func testAddMatchRequestHandlerAsUser1(t *testing.T) {
@@ -82,8 +94,29 @@ func testAddMatchRequestHandlerAsUser1(t *testing.T) {
 	}
 }
 
+// Joining the queue leaves matching to the scheduler
 func testAssertDatabaseStateAfterUser1(t *testing.T) {
-	nGroups := config.MATCH_THRESHOLD / config.MATCH_GROUPSIZE
-	nMR := config.MATCH_THRESHOLD % config.MATCH_GROUPSIZE
-	assertDatabaseState(t, config.MATCH_THRESHOLD, nGroups, config.MATCH_THRESHOLD, nMR)
+	assertDatabaseState(t, Matching.Threshold, 0, Matching.Threshold, Matching.Threshold)
+}
+
+func testSchedulerMatchesFullQueue(t *testing.T) {
+	run, ran, err := scheduler.NewScheduler(Store, Matching).Tick(context.Background())
+	if err != nil || !ran {
+		t.Fatalf("The scheduler did not run matching on a full queue. %v", err)
+	}
+	if run.Trigger != MatchingTriggerQueueSize || run.Outcome != MatchingOutcomeMatched {
+		t.Errorf("The run was %s with outcome %s instead of QUEUE_SIZE with MATCHED", run.Trigger, run.Outcome)
+	}
+	if run.QueueSize != Matching.Threshold || len(run.UserIDs) != Matching.Threshold {
+		t.Errorf("The run recorded a queue of %d instead of %d", run.QueueSize, Matching.Threshold)
+	}
+	if len(run.GroupIDs) != Matching.Threshold / Matching.GroupSize {
+		t.Errorf("The run recorded %d groups instead of %d", len(run.GroupIDs), Matching.Threshold / Matching.GroupSize)
+	}
+}
+
+func testAssertDatabaseStateAfterMatching(t *testing.T) {
+	nGroups := Matching.Threshold / Matching.GroupSize
+	nMR := Matching.Threshold % Matching.GroupSize
+	assertDatabaseState(t, Matching.Threshold, nGroups, Matching.Threshold, nMR)
 }
\ No newline at end of file
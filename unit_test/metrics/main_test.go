@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/router/user"
+	"wellnus/backend/router/ws"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const origin = "http://localhost:3000"
+
+var (
+	Store   store.Store
+	Server  *httptest.Server
+	WSHub   *ws.Hub
+	stopHub context.CancelFunc
+)
+
+var Matching config.MatchingConfig = test_helper.LoadConfig().Matching
+
+var testUsers []User
+var testGroups []Group
+var sessionKeys []string
+
+// Last run of testMatchingRuns, whose groups testMatchingRunFillingGroup fills
+var matchedRun MatchingRun
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Metrics())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.Use(middleware.Authenticate(Store))
+	router.GET("/user/:id", user.GetUserHandler(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+	protected.GET("/ws/:id", ws.ConnectToWSHandler(WSHub, Store))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	WSHub = ws.NewHub(Store, config.CORSConfig{AllowedOrigins: []string{origin}})
+	Server = httptest.NewServer(setupRouter())
+	var err error
+
+	if testUsers, err = test_helper.SetupUsers(Store, 1); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	if testGroups, err = test_helper.SetupGroupsForUsers(Store, testUsers); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test groups. %v", err))
+	}
+	if sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+
+	var ctx context.Context
+	ctx, stopHub = context.WithCancel(context.Background())
+	go WSHub.Run(ctx)
+
+	code := m.Run()
+	stopHub()
+	Server.Close()
+	os.Exit(code)
+}
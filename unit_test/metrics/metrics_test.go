@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/metrics"
+	"wellnus/backend/router/http_helper"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Full test
+func TestMetrics(t *testing.T) {
+	t.Run("Exposition format", testExpositionFormat)
+	t.Run("HTTP requests by route", testHTTPRequests)
+	t.Run("Matching run outcomes", testMatchingRuns)
+	t.Run("Matching run filling a group", testMatchingRunFillingGroup)
+	t.Run("Websocket clients by group", testWSClients)
+}
+
+// Helpers
+
+func scrape(t *testing.T) string {
+	res, err := http.Get(Server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("An error occured while scraping the metrics. %v", err)
+	}
+	defer res.Body.Close()
+	if contentType := res.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Metrics were served as %q instead of the text format", contentType)
+	}
+	b, _ := io.ReadAll(res.Body)
+	return string(b)
+}
+
+func assertLines(t *testing.T, exposition string, lines ...string) {
+	for _, line := range lines {
+		if !strings.Contains("\n"+exposition, "\n"+line+"\n") {
+			t.Errorf("Metrics did not include the line %q", line)
+		}
+	}
+}
+
+func testExpositionFormat(t *testing.T) {
+	assertLines(t, scrape(t),
+		"# HELP wellnus_matching_groups_created_total Support groups formed by matching.",
+		"# TYPE wellnus_matching_groups_created_total counter",
+		"# TYPE wellnus_matching_groups_filled_total counter",
+		"# TYPE wellnus_ws_broadcast_queue_length gauge",
+		"# TYPE wellnus_matching_run_duration_seconds histogram",
+		"# TYPE go_goroutines gauge")
+}
+
+func testHTTPRequests(t *testing.T) {
+	for _, path := range []string{fmt.Sprintf("/user/%d", testUsers[0].ID), "/user/0", "/nothing/here"} {
+		res, err := http.Get(Server.URL + path)
+		if err != nil {
+			t.Fatalf("An error occured while requesting %s. %v", path, err)
+		}
+		res.Body.Close()
+	}
+	assertLines(t, scrape(t),
+		`wellnus_http_requests_total{method="GET",route="/user/:id",status="200"} 1`,
+		`wellnus_http_requests_total{method="GET",route="/user/:id",status="404"} 1`,
+		`wellnus_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`wellnus_http_request_duration_seconds_count{method="GET",route="/user/:id"} 2`)
+}
+
+func testMatchingRuns(t *testing.T) {
+	belowThreshold := testutil.ToFloat64(metrics.MatchingRuns.WithLabelValues(metrics.MatchingBelowThreshold))
+	matched := testutil.ToFloat64(metrics.MatchingRuns.WithLabelValues(metrics.MatchingMatched))
+	groups := testutil.ToFloat64(metrics.MatchingGroupsCreated)
+
+	users, err := test_helper.SetupUsers(Store, Matching.Threshold)
+	if err != nil {
+		t.Fatalf("An error occured while creating users. %v", err)
+	}
+	if _, err := test_helper.SetupMatchSettingForUsers(Store, users); err != nil {
+		t.Fatalf("An error occured while creating match settings. %v", err)
+	}
+	// One short of the threshold, then full
+	if _, err := test_helper.SetupMatchRequestForUsers(Store, users[1:]); err != nil {
+		t.Fatalf("An error occured while creating match requests. %v", err)
+	}
+	if _, err := Store.RunMatching(context.Background(), MatchingTriggerQueueSize); err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	if _, err := test_helper.SetupMatchRequestForUsers(Store, users[:1]); err != nil {
+		t.Fatalf("An error occured while creating match requests. %v", err)
+	}
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerQueueSize)
+	if err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	matchedRun = run
+
+	if delta := testutil.ToFloat64(metrics.MatchingRuns.WithLabelValues(metrics.MatchingBelowThreshold)) - belowThreshold; delta != 1 {
+		t.Errorf("%v runs were counted below the threshold instead of 1", delta)
+	}
+	if delta := testutil.ToFloat64(metrics.MatchingRuns.WithLabelValues(metrics.MatchingMatched)) - matched; delta != 1 {
+		t.Errorf("%v runs were counted as matched instead of 1", delta)
+	}
+	if delta := testutil.ToFloat64(metrics.MatchingGroupsCreated) - groups; delta < 1 {
+		t.Errorf("No groups were counted after a matching run")
+	}
+}
+
+// A member leaves a group of the last run and queues again, so that the next run only fills
+func testMatchingRunFillingGroup(t *testing.T) {
+	if len(matchedRun.GroupIDs) == 0 {
+		t.Skip("No run formed groups to fill")
+	}
+	matched := testutil.ToFloat64(metrics.MatchingRuns.WithLabelValues(metrics.MatchingMatched))
+	groups := testutil.ToFloat64(metrics.MatchingGroupsCreated)
+	filled := testutil.ToFloat64(metrics.MatchingGroupsFilled)
+
+	group, err := Store.GetGroupWithUsers(context.Background(), matchedRun.GroupIDs[0])
+	if err != nil {
+		t.Fatalf("An error occured while retrieving the group. %v", err)
+	}
+	leaver := group.Users[0]
+	if _, err := Store.LeaveGroup(context.Background(), group.Group.ID, leaver.ID); err != nil {
+		t.Fatalf("An error occured while leaving the group. %v", err)
+	}
+	if _, err := Store.AddMatchRequest(context.Background(), leaver.ID); err != nil {
+		t.Fatalf("An error occured while creating the match request. %v", err)
+	}
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerManual)
+	if err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	if len(run.GroupIDs) != 0 || len(run.FilledGroupIDs) != 1 {
+		t.Fatalf("The run formed %d groups and filled %d instead of 0 and 1", len(run.GroupIDs), len(run.FilledGroupIDs))
+	}
+
+	if delta := testutil.ToFloat64(metrics.MatchingRuns.WithLabelValues(metrics.MatchingMatched)) - matched; delta != 1 {
+		t.Errorf("%v runs were counted as matched instead of 1", delta)
+	}
+	if delta := testutil.ToFloat64(metrics.MatchingGroupsCreated) - groups; delta != 0 {
+		t.Errorf("%v groups were counted as formed instead of 0", delta)
+	}
+	if delta := testutil.ToFloat64(metrics.MatchingGroupsFilled) - filled; delta != 1 {
+		t.Errorf("%v groups were counted as filled instead of 1", delta)
+	}
+}
+
+func testWSClients(t *testing.T) {
+	url := fmt.Sprintf("ws%s/ws/%d", strings.TrimPrefix(Server.URL, "http"), testGroups[0].ID)
+	header := http.Header{}
+	header.Set("Origin", origin)
+	header.Set("Cookie", fmt.Sprintf("%s=%s", http_helper.SessionCookieName, sessionKeys[0]))
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("An error occured while connecting to the websocket. %v", err)
+	}
+	// Chat status is sent once the hub has registered the client
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("An error occured while waiting for the chat status. %v", err)
+	}
+	line := fmt.Sprintf(`wellnus_ws_clients{group="%d"}`, testGroups[0].ID)
+	assertLines(t, scrape(t), line+" 1")
+
+	conn.Close()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if !strings.Contains(scrape(t), line) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Group %d still had a websocket client series after its last client left", testGroups[0].ID)
+}
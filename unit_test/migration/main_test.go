@@ -0,0 +1,25 @@
+package migration
+
+import (
+	"wellnus/backend/db"
+	"wellnus/backend/unit_test/test_helper"
+
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// Only set when UNIT_TEST_STORE=postgres; the tests that need a database skip otherwise
+var DB *sql.DB
+
+func TestMain(m *testing.M) {
+	if os.Getenv("UNIT_TEST_STORE") == "postgres" {
+		var err error
+		if DB, err = db.ConnectDB(test_helper.LoadConfig().DB); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
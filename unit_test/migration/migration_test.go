@@ -0,0 +1,78 @@
+package migration
+
+import (
+	"wellnus/backend/db/migration"
+
+	"context"
+	"strings"
+	"testing"
+)
+
+// Full test
+func TestMigration(t *testing.T) {
+	t.Run("Embedded migrations", testEmbeddedMigrations)
+	t.Run("Check dirty and newer schema", testCheckStatus)
+	t.Run("Up down and status", testUpDownStatus)
+}
+
+func testEmbeddedMigrations(t *testing.T) {
+	migrations, err := migration.Migrations()
+	if err != nil {
+		t.Fatalf("An error occured while reading the embedded migrations. %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatalf("No migrations were embedded")
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("Migration %s has version %d instead of %d", m.Name, m.Version, i+1)
+		}
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("Migration %d_%s has an empty up or down file", m.Version, m.Name)
+		}
+	}
+	latest, err := migration.Latest()
+	if err != nil || latest != int64(len(migrations)) {
+		t.Errorf("Latest migration was %d instead of %d. %v", latest, len(migrations), err)
+	}
+}
+
+func testCheckStatus(t *testing.T) {
+	if err := (migration.Status{Version: 3, Latest: 5}).Check(); err != nil {
+		t.Errorf("An older clean schema was refused. %v", err)
+	}
+	if err := (migration.Status{Version: 5, Latest: 5}).Check(); err != nil {
+		t.Errorf("An up to date schema was refused. %v", err)
+	}
+	if err := (migration.Status{Version: 5, Dirty: true, Latest: 5}).Check(); err == nil {
+		t.Errorf("A dirty schema was accepted")
+	}
+	if err := (migration.Status{Version: 6, Latest: 5}).Check(); err == nil {
+		t.Errorf("A schema newer than the latest migration was accepted")
+	}
+}
+
+func testUpDownStatus(t *testing.T) {
+	if DB == nil {
+		t.Skip("Needs UNIT_TEST_STORE=postgres")
+	}
+	if _, err := migration.Up(DB); err != nil {
+		t.Fatalf("An error occured while migrating up. %v", err)
+	}
+	status, err := migration.GetStatus(context.Background(), DB)
+	if err != nil || status.Version != status.Latest || status.Dirty {
+		t.Fatalf("Status after migrating up was %v. %v", status, err)
+	}
+	if n, err := migration.Down(DB, 1); err != nil || n != 1 {
+		t.Fatalf("Rolled back %d migrations instead of 1. %v", n, err)
+	}
+	if status, _ := migration.GetStatus(context.Background(), DB); status.Version != status.Latest-1 {
+		t.Errorf("Status after rolling back one migration was %v", status)
+	}
+	if n, err := migration.Up(DB); err != nil || n != 1 {
+		t.Errorf("Applied %d migrations instead of 1. %v", n, err)
+	}
+	if n, err := migration.Up(DB); err != nil || n != 0 {
+		t.Errorf("Applied %d migrations to an up to date schema. %v", n, err)
+	}
+}
@@ -0,0 +1,28 @@
+package policy
+
+import (
+	. "wellnus/backend/db/model"
+
+	"os"
+	"testing"
+)
+
+// [member, volunteer, counsellor, admin]
+var testUsers []User
+var unverifiedCounsellor = User{ID: 5, UserRole: "COUNSELLOR"}
+
+var testRelations = []Relation{
+	RelationSelf,
+	RelationOwner,
+	RelationMember,
+	RelationProvider,
+	RelationRecipient,
+	RelationApprover,
+}
+
+func TestMain(m *testing.M) {
+	for i, role := range AllRoles {
+		testUsers = append(testUsers, User{ID: int64(i + 1), UserRole: role, Verified: true})
+	}
+	os.Exit(m.Run())
+}
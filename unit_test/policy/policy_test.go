@@ -0,0 +1,177 @@
+package policy
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+
+	"testing"
+)
+
+// Roles and relations that are granted each action, restated independently of model.Policy
+var expectedGrants = map[Action][]string{
+	ActionUpdateUser:            {"self"},
+	ActionDeleteUser:            {"self"},
+	ActionViewMatchRequest:      {"self"},
+	ActionUpdateGroup:           {"owner"},
+	ActionReadGroupMessages:     {"member"},
+	ActionViewCompatibility:     {"member"},
+	ActionViewJoinRequest:       {"self", "owner"},
+	ActionRespondJoinRequest:    {"owner"},
+	ActionDeleteJoinRequest:     {"self"},
+	ActionUpdateEvent:           {"owner"},
+	ActionStartEvent:            {"owner"},
+	ActionJoinPublicEvent:       {"MEMBER", "VOLUNTEER", "COUNSELLOR", "ADMIN"},
+	ActionAddUserToPrivateEvent: {"owner"},
+	ActionViewBooking:           {"recipient", "provider"},
+	ActionProvideBooking:        {"VOLUNTEER", "COUNSELLOR"},
+	ActionUpdateBooking:         {"recipient"},
+	ActionRespondBooking:        {"approver"},
+	ActionDeleteBooking:         {"recipient"},
+	ActionListCounselRequests:   {"VOLUNTEER", "COUNSELLOR"},
+	ActionViewCounselRequest:    {"VOLUNTEER", "COUNSELLOR", "recipient"},
+	ActionAcceptCounselRequest:  {"VOLUNTEER", "COUNSELLOR"},
+	ActionUpdateProviderSetting: {"VOLUNTEER", "COUNSELLOR"},
+	ActionVerifyUser:            {"ADMIN"},
+	ActionChangeUserRole:        {"ADMIN"},
+	ActionSuspendUser:           {"ADMIN"},
+	ActionForceDeleteGroup:      {"ADMIN"},
+	ActionForceDeleteEvent:      {"ADMIN"},
+	ActionListReports:           {"ADMIN"},
+	ActionDeleteReport:          {"ADMIN"},
+	ActionRunMatching:           {"ADMIN"},
+	ActionListMatchingRuns:      {"ADMIN"},
+	ActionDryRunMatching:        {"ADMIN"},
+}
+
+// Full test
+func TestPolicy(t *testing.T) {
+	t.Run("Policy covers every action", testPolicyCoversEveryAction)
+	t.Run("Policy matrix", testPolicyMatrix)
+	t.Run("Unknown action denied", testUnknownActionDenied)
+	t.Run("Unverified counsellor acts as member", testUnverifiedCounsellorActsAsMember)
+	t.Run("Authorize returns Unauthorized", testAuthorizeReturnsUnauthorized)
+	t.Run("GroupRelations", testGroupRelations)
+	t.Run("JoinRequestRelations", testJoinRequestRelations)
+	t.Run("BookingRelations", testBookingRelations)
+}
+
+// Helper
+func isGranted(grants []string, role string, relations []Relation) bool {
+	for _, grant := range grants {
+		if grant == role { return true }
+		for _, relation := range relations {
+			if grant == string(relation) { return true }
+		}
+	}
+	return false
+}
+
+func testPolicyCoversEveryAction(t *testing.T) {
+	if len(Policy) != len(expectedGrants) {
+		t.Errorf("Policy has %d actions but %d were expected", len(Policy), len(expectedGrants))
+	}
+	for action := range expectedGrants {
+		if _, ok := Policy[action]; !ok {
+			t.Errorf("Policy is missing action %s", action)
+		}
+	}
+}
+
+func testPolicyMatrix(t *testing.T) {
+	relationSets := [][]Relation{nil}
+	for _, relation := range testRelations {
+		relationSets = append(relationSets, []Relation{relation})
+	}
+	for action, grants := range expectedGrants {
+		for _, user := range testUsers {
+			for _, relations := range relationSets {
+				expected := isGranted(grants, user.UserRole, relations)
+				if got := Can(user, action, relations); got != expected {
+					t.Errorf("Can(%s, %s, %v) = %t, expected %t", user.UserRole, action, relations, got, expected)
+				}
+			}
+		}
+	}
+}
+
+func testUnknownActionDenied(t *testing.T) {
+	for _, user := range testUsers {
+		if Can(user, Action("unknown"), testRelations) {
+			t.Errorf("Unknown action was allowed for %s", user.UserRole)
+		}
+	}
+}
+
+func testUnverifiedCounsellorActsAsMember(t *testing.T) {
+	for action := range expectedGrants {
+		for _, relations := range [][]Relation{nil, testRelations} {
+			expected := Can(testUsers[0], action, relations)
+			if got := Can(unverifiedCounsellor, action, relations); got != expected {
+				t.Errorf("Can(unverified COUNSELLOR, %s, %v) = %t, expected %t as for MEMBER", action, relations, got, expected)
+			}
+		}
+	}
+}
+
+func testAuthorizeReturnsUnauthorized(t *testing.T) {
+	if err := Authorize(testUsers[0], ActionUpdateProviderSetting, nil); err != http_error.UnauthorizedError {
+		t.Errorf("Member updating provider setting did not return UnauthorizedError. %v", err)
+	}
+	if err := Authorize(testUsers[2], ActionUpdateProviderSetting, nil); err != nil {
+		t.Errorf("Counsellor updating provider setting was not authorized. %v", err)
+	}
+}
+
+func testGroupRelations(t *testing.T) {
+	group := Group{ID: 1, OwnerID: testUsers[0].ID}
+	relations := GroupRelations(testUsers[0].ID, group, true)
+	if !Can(testUsers[0], ActionUpdateGroup, relations) || !Can(testUsers[0], ActionReadGroupMessages, relations) {
+		t.Errorf("Owner and member of group was not allowed to update group or read messages")
+	}
+	relations = GroupRelations(testUsers[1].ID, group, true)
+	if Can(testUsers[1], ActionUpdateGroup, relations) {
+		t.Errorf("Member of group who is not owner was allowed to update group")
+	}
+	relations = GroupRelations(testUsers[2].ID, group, false)
+	if Can(testUsers[2], ActionReadGroupMessages, relations) {
+		t.Errorf("Non member of group was allowed to read messages")
+	}
+}
+
+func testJoinRequestRelations(t *testing.T) {
+	loadedJoinRequest := LoadedJoinRequest{
+		JoinRequest: JoinRequest{UserID: testUsers[1].ID},
+		Group:       Group{OwnerID: testUsers[0].ID},
+	}
+	if !Can(testUsers[0], ActionViewJoinRequest, JoinRequestRelations(testUsers[0].ID, loadedJoinRequest)) {
+		t.Errorf("Owner of group was not allowed to view a join request for it")
+	}
+	if !Can(testUsers[1], ActionViewJoinRequest, JoinRequestRelations(testUsers[1].ID, loadedJoinRequest)) {
+		t.Errorf("Sender of join request was not allowed to view it")
+	}
+	if Can(testUsers[2], ActionViewJoinRequest, JoinRequestRelations(testUsers[2].ID, loadedJoinRequest)) {
+		t.Errorf("User who neither sent the join request nor owns the group was allowed to view it")
+	}
+}
+
+func testBookingRelations(t *testing.T) {
+	booking := Booking{RecipientID: testUsers[0].ID, ProviderID: testUsers[2].ID, ApproveBy: testUsers[2].ID}
+	if !Can(testUsers[0], ActionUpdateBooking, BookingRelations(testUsers[0].ID, booking)) {
+		t.Errorf("Recipient of booking was not allowed to update booking")
+	}
+	if Can(testUsers[0], ActionRespondBooking, BookingRelations(testUsers[0].ID, booking)) {
+		t.Errorf("Recipient of booking was allowed to respond while approval is with the provider")
+	}
+	if !Can(testUsers[2], ActionRespondBooking, BookingRelations(testUsers[2].ID, booking)) {
+		t.Errorf("Provider of booking was not allowed to respond while approval is with them")
+	}
+	if Can(testUsers[2], ActionDeleteBooking, BookingRelations(testUsers[2].ID, booking)) {
+		t.Errorf("Provider of booking was allowed to delete booking")
+	}
+	if !Can(testUsers[2], ActionViewBooking, BookingRelations(testUsers[2].ID, booking)) {
+		t.Errorf("Provider of booking was not allowed to view booking")
+	}
+	if Can(testUsers[1], ActionViewBooking, BookingRelations(testUsers[1].ID, booking)) {
+		t.Errorf("User who is not party to booking was allowed to view booking")
+	}
+}
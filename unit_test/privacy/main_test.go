@@ -0,0 +1,110 @@
+package privacy
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
+	"wellnus/backend/router/booking"
+	"wellnus/backend/router/event"
+	"wellnus/backend/router/group"
+	"wellnus/backend/router/join"
+	"wellnus/backend/router/match"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/router/provider"
+	"wellnus/backend/router/session"
+	"wellnus/backend/router/user"
+	"wellnus/backend/router/ws"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store  store.Store
+	Router *gin.Engine
+	Mailer *mailer.FileMailer
+	WSHub  *ws.Hub
+)
+
+// [member, volunteer, counsellor]
+var testUsers []User
+var testGroups []Group
+var testEvents []Event
+var testBookings []Booking
+var testJoinRequest JoinRequest
+var sessionKeys []string
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+
+	router.GET("/user", user.GetAllUsersHandler(Store))
+	router.GET("/user/:id", user.GetUserHandler(Store))
+	router.POST("/session", session.LoginHandler(Store))
+	router.GET("/group/:id", group.GetGroupHandler(Store))
+	router.GET("/event/:id", event.GetEventHandler(Store))
+	router.GET("/provider", provider.GetAllProvidersHandler(Store))
+	router.GET("/provider/:id", provider.GetProviderWithEventsHandler(Store))
+
+	protected.PATCH("/user/:id", user.UpdateUserHandler(Store, Mailer))
+	protected.GET("/group", group.GetAllGroupsHandler(Store))
+	protected.GET("/join", join.GetAllLoadedJoinRequestsHandler(Store))
+	protected.GET("/join/:id", join.GetLoadedJoinRequestHandler(Store))
+	protected.GET("/match/:id", match.GetQueuedMatchRequestOfUserHandler(Store))
+	protected.GET("/event", event.GetAllEventsHandler(Store))
+	protected.GET("/booking", booking.GetAllBookingUsersHandler(Store))
+	protected.GET("/booking/:id", booking.GetBookingProviderHandler(Store))
+
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	Mailer = test_helper.SetupMailer()
+	WSHub = ws.NewHub(Store, test_helper.LoadConfig().CORS)
+	Router = setupRouter()
+	var err error
+
+	if testUsers, err = test_helper.SetupUsers(Store, 3); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	if testGroups, err = test_helper.SetupGroupsForUsers(Store, testUsers); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test groups. %v", err))
+	}
+	if err = Store.AddUserToGroup(context.Background(), testGroups[0].ID, testUsers[1].ID); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when adding Test user to group. %v", err))
+	}
+	if testJoinRequest, err = Store.AddJoinRequest(context.Background(), testGroups[0].ID, testUsers[2].ID); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test join request. %v", err))
+	}
+	if testEvents, err = test_helper.SetupEventForUsers(Store, testUsers); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test events. %v", err))
+	}
+	if _, err = test_helper.SetupMatchSettingForUsers(Store, testUsers[:1]); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test match settings. %v", err))
+	}
+	if _, err = test_helper.SetupMatchRequestForUsers(Store, testUsers[:1]); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test match requests. %v", err))
+	}
+	if _, err = test_helper.SetupProviderSettingForUsers(Store, testUsers[1:]); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test provider settings. %v", err))
+	}
+	if testBookings, err = test_helper.SetupBookingToUserForUsers(Store, testUsers[:1], testUsers[2]); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test bookings. %v", err))
+	}
+	if sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+
+	code := m.Run()
+	os.RemoveAll(Mailer.Dir)
+	os.Exit(code)
+}
@@ -0,0 +1,142 @@
+package privacy
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Full Tests
+
+func TestPrivacy(t *testing.T) {
+	t.Run("No password hash in HTTP responses", testNoPasswordHashInResponses)
+	t.Run("No password hash in chat status payload", testNoPasswordHashInChatStatusPayload)
+	t.Run("No password hash in an encoded user", testNoPasswordHashInEncodedUser)
+	t.Run("Private profile only for self", testPrivateProfileOnlyForSelf)
+}
+
+// Helpers
+
+type probe struct {
+	method     string
+	path       string
+	body       interface{}
+	sessionKey string
+}
+
+func (p probe) request() *http.Request {
+	var req *http.Request
+	if p.body == nil {
+		req, _ = http.NewRequest(p.method, p.path, nil)
+	} else {
+		ioReader, _ := test_helper.GetIOReaderFromObject(p.body)
+		req, _ = http.NewRequest(p.method, p.path, ioReader)
+	}
+	if p.sessionKey != "" {
+		req.AddCookie(&http.Cookie{Name: "session_key", Value: p.sessionKey})
+	}
+	return req
+}
+
+// Reports what in body gives away a password or its hash, or "" if nothing does
+func leak(body string) string {
+	if strings.Contains(body, "password") {
+		return "password field"
+	}
+	if strings.Contains(body, "$argon2id$") {
+		return "argon2id hash"
+	}
+	for _, user := range testUsers {
+		if strings.Contains(body, user.PasswordHash) {
+			return fmt.Sprintf("password hash of user %d", user.ID)
+		}
+	}
+	return ""
+}
+
+func testNoPasswordHashInResponses(t *testing.T) {
+	member, provider := testUsers[0], testUsers[2]
+	probes := []probe{
+		{"GET", "/user", nil, ""},
+		{"GET", "/user?role=PROVIDER", nil, ""},
+		{"GET", fmt.Sprintf("/user/%d", member.ID), nil, ""},
+		{"GET", fmt.Sprintf("/user/%d", member.ID), nil, sessionKeys[0]},
+		{"POST", "/session", User{Email: member.Email, Password: test_helper.GetTestUser(0).Password}, ""},
+		{"PATCH", fmt.Sprintf("/user/%d", member.ID), User{FirstName: "Renamed"}, sessionKeys[0]},
+		{"GET", "/group", nil, sessionKeys[0]},
+		{"GET", fmt.Sprintf("/group/%d", testGroups[0].ID), nil, ""},
+		{"GET", "/join", nil, sessionKeys[0]},
+		{"GET", fmt.Sprintf("/join/%d", testJoinRequest.ID), nil, sessionKeys[0]},
+		{"GET", fmt.Sprintf("/match/%d", member.ID), nil, sessionKeys[0]},
+		{"GET", "/event", nil, sessionKeys[0]},
+		{"GET", fmt.Sprintf("/event/%d", testEvents[0].ID), nil, ""},
+		{"GET", "/provider", nil, ""},
+		{"GET", fmt.Sprintf("/provider/%d", provider.ID), nil, ""},
+		{"GET", "/booking", nil, sessionKeys[0]},
+		{"GET", fmt.Sprintf("/booking/%d", testBookings[0].ID), nil, sessionKeys[0]},
+	}
+	for _, p := range probes {
+		w := test_helper.SimulateRequest(Router, p.request())
+		if w.Code != http.StatusOK {
+			t.Errorf("%s %s failed with status code of %d", p.method, p.path, w.Code)
+			continue
+		}
+		if found := leak(w.Body.String()); found != "" {
+			t.Errorf("%s %s responded with the %s", p.method, p.path, found)
+		}
+	}
+}
+
+func testNoPasswordHashInChatStatusPayload(t *testing.T) {
+	payload, err := WSHub.ChatStatusPayload(context.Background(), testGroups[0].ID)
+	if err != nil {
+		t.Fatalf("An error occured while building the chat status payload. %v", err)
+	}
+	if len(payload.SortedOfflineMembers) != 2 {
+		t.Errorf("Expected 2 offline members in the chat status payload. Got %d", len(payload.SortedOfflineMembers))
+	}
+	b, _ := json.Marshal(payload)
+	if found := leak(string(b)); found != "" {
+		t.Errorf("Chat status payload contains the %s", found)
+	}
+}
+
+// Guards any handler that responds with a User by mistake
+func testNoPasswordHashInEncodedUser(t *testing.T) {
+	b, _ := json.Marshal(testUsers[0])
+	if strings.Contains(string(b), "password_hash") || strings.Contains(string(b), "$argon2id$") {
+		t.Errorf("Encoded user contains the password hash. %s", b)
+	}
+	var user User
+	if err := json.Unmarshal([]byte(`{"password":"secret","password_hash":"forged"}`), &user); err != nil {
+		t.Fatalf("An error occured while decoding a user. %v", err)
+	}
+	if user.Password != "secret" || user.PasswordHash != "" {
+		t.Errorf("Decoded user had password %q and hash %q instead of the password only", user.Password, user.PasswordHash)
+	}
+}
+
+func testPrivateProfileOnlyForSelf(t *testing.T) {
+	route := fmt.Sprintf("/user/%d", testUsers[1].ID)
+	req, _ := http.NewRequest("GET", route, nil)
+	w := test_helper.SimulateRequest(Router, req)
+	if strings.Contains(w.Body.String(), testUsers[1].Email) {
+		t.Errorf("Email of user was shown to an anonymous user")
+	}
+	req = probe{"GET", route, nil, sessionKeys[0]}.request()
+	w = test_helper.SimulateRequest(Router, req)
+	if strings.Contains(w.Body.String(), testUsers[1].Email) {
+		t.Errorf("Email of user was shown to another user")
+	}
+	req = probe{"GET", route, nil, sessionKeys[1]}.request()
+	w = test_helper.SimulateRequest(Router, req)
+	if !strings.Contains(w.Body.String(), testUsers[1].Email) {
+		t.Errorf("Email of user was not shown to the user themselves")
+	}
+}
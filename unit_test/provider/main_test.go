@@ -1,10 +1,10 @@
 package provider
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/router/provider"
 	"wellnus/backend/unit_test/test_helper"
 
@@ -13,14 +13,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -36,41 +34,40 @@ var sessionKeys []string
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
 
-	router.GET("/provider", provider.GetAllProvidersHandler(DB))
-	router.GET("/provider/:id", provider.GetProviderWithEventsHandler(DB))
-	router.POST("/provider", provider.AddUpdateProviderSettingOfUserHandler(DB))
-	router.DELETE("/provider", provider.DeleteProviderSettingOfUserHandler(DB))
+	router.GET("/provider", provider.GetAllProvidersHandler(Store))
+	router.GET("/provider/:id", provider.GetProviderWithEventsHandler(Store))
+	protected.POST("/provider", provider.AddUpdateProviderSettingOfUserHandler(Store))
+	protected.DELETE("/provider", provider.DeleteProviderSettingOfUserHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 3)
+	testUsers, err = test_helper.SetupUsers(Store, 3)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
 
-	sessionKeys, err = test_helper.SetupSessionForUsers(DB, testUsers)
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
 	}
 
-	testProviderSettings, err = test_helper.SetupProviderSettingForUsers(DB, testUsers[2:])
+	testProviderSettings, err = test_helper.SetupProviderSettingForUsers(Store, testUsers[2:])
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test provider setting. %v", err))
 	}
 	// Filling 0, 1 index with empty first
 	testProviderSettings = append([]ProviderSetting{ProviderSetting{}, ProviderSetting{}}, testProviderSettings...)
 
-	testEvents, err = test_helper.SetupEventForUsers(DB, testUsers)
+	testEvents, err = test_helper.SetupEventForUsers(Store, testUsers)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test events. %v", err))
 	}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/router/session"
+	"wellnus/backend/unit_test/test_helper"
+
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	Store   store.Store
+	Router  *gin.Engine
+	Limiter *middleware.RateLimiter
+	Config  = test_helper.LoadConfig()
+)
+
+var testUsers []User
+
+// Clock of Limiter, moved forward by the tests
+var now = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Config(Config))
+	router.Use(middleware.Authenticate(Store))
+	router.POST("/session", middleware.RateLimit(Limiter), session.LoginHandler(Store))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Config.RateLimit.RequestsPerMinute = 6
+	Config.RateLimit.Burst = 3
+	Config.CORS.AllowedOrigins = []string{"http://localhost:3000", "http://localhost:3001"}
+
+	Store = test_helper.SetupStore()
+	Limiter = middleware.NewRateLimiter(Config.RateLimit)
+	Limiter.Now = func() time.Time { return now }
+	Router = setupRouter()
+	var err error
+
+	testUsers, err = test_helper.SetupUsers(Store, 1)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/unit_test/test_helper"
+
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Full test
+func TestRateLimit(t *testing.T) {
+	t.Run("Burst of logins then limited", testBurstThenLimited)
+	t.Run("Tokens refill over time", testRefill)
+	t.Run("Clients limited separately", testSeparateClients)
+	t.Run("Rate limit turned off", testTurnedOff)
+}
+
+// Helpers
+
+func login(client string, origin string) *httptest.ResponseRecorder {
+	attempt := User{Email: testUsers[0].Email, Password: test_helper.GetTestUser(0).Password}
+	body, _ := test_helper.GetIOReaderFromObject(attempt)
+	req, _ := http.NewRequest("POST", "/session", body)
+	req.RemoteAddr = client + ":12345"
+	req.Header.Set("Origin", origin)
+	return test_helper.SimulateRequest(Router, req)
+}
+
+func testBurstThenLimited(t *testing.T) {
+	for i := 0; i < Config.RateLimit.Burst; i++ {
+		if w := login("10.0.0.1", "http://localhost:3001"); w.Code != http.StatusOK {
+			t.Fatalf("Login %d of the burst had status %d instead of %d", i, w.Code, http.StatusOK)
+		}
+	}
+	w := login("10.0.0.1", "http://localhost:3001")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Login after the burst had status %d instead of %d", w.Code, http.StatusTooManyRequests)
+	}
+	if _, ok := test_helper.CheckErrorMessageFromRecorder(w, middleware.RateLimitedError.Error()); !ok {
+		t.Errorf("Limited login did not respond with the rate limited error")
+	}
+	// 6 per minute is a token every 10 seconds
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "10" {
+		t.Errorf("Retry-After was %q instead of 10", retryAfter)
+	}
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "http://localhost:3001" {
+		t.Errorf("Allowed origin was %q instead of the allowed origin of the request", origin)
+	}
+	if code := http_error.FromError(middleware.RateLimitedError).Code; code != http_error.CodeRateLimited {
+		t.Errorf("Rate limited error had code %s instead of %s", code, http_error.CodeRateLimited)
+	}
+}
+
+func testRefill(t *testing.T) {
+	now = now.Add(9 * time.Second)
+	if w := login("10.0.0.1", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Login before a token was added had status %d instead of %d", w.Code, http.StatusTooManyRequests)
+	}
+	now = now.Add(time.Second)
+	if w := login("10.0.0.1", ""); w.Code != http.StatusOK {
+		t.Errorf("Login after a token was added had status %d instead of %d", w.Code, http.StatusOK)
+	}
+	if w := login("10.0.0.1", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Second login after a single token was added had status %d instead of %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func testSeparateClients(t *testing.T) {
+	w := login("10.0.0.2", "http://evil.example")
+	if w.Code != http.StatusOK {
+		t.Errorf("Login of another client had status %d instead of %d", w.Code, http.StatusOK)
+	}
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != Config.CORS.AllowedOrigins[0] {
+		t.Errorf("Allowed origin for an unknown origin was %q instead of the first allowed origin", origin)
+	}
+}
+
+func testTurnedOff(t *testing.T) {
+	limiter := middleware.NewRateLimiter(Config.RateLimit)
+	limiter.Now = func() time.Time { return now }
+	for i := 0; i < 2*Config.RateLimit.Burst; i++ {
+		limiter.Allow("10.0.0.3")
+	}
+	if ok, _ := limiter.Allow("10.0.0.3"); ok {
+		t.Errorf("Client over the limit was allowed")
+	}
+	off := middleware.NewRateLimiter(config.RateLimitConfig{})
+	for i := 0; i < 2*Config.RateLimit.Burst; i++ {
+		if ok, _ := off.Allow("10.0.0.3"); !ok {
+			t.Fatalf("Request %d was limited with the rate limit turned off", i)
+		}
+	}
+}
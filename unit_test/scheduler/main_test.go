@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/admin"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/unit_test/test_helper"
+
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+)
+
+var (
+	Store  store.Store
+	Router *gin.Engine
+)
+
+// Matching parameters the store was set up with
+var Matching config.MatchingConfig = test_helper.LoadConfig().Matching
+
+var testUsers []User
+var testAdmin User
+var sessionKeys []string
+var adminSessionKey string
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+	protected.GET("/admin/matching", admin.GetAllMatchingRunsHandler(Store))
+	protected.POST("/admin/matching", admin.RunMatchingHandler(Store))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	Router = setupRouter()
+
+	var err error
+	// Enough users for one group, queued by each test as it needs them
+	testUsers, err = test_helper.SetupUsers(Store, Matching.GroupSize)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	_, err = test_helper.SetupMatchSettingForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating match settings. %v", err))
+	}
+	sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+
+	testAdmin, err = test_helper.SetupAdmin(Store)
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test admin. %v", err))
+	}
+	adminSessionKeys, err := test_helper.SetupSessionForUsers(Store, []User{testAdmin})
+	if err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test admin session. %v", err))
+	}
+	adminSessionKey = adminSessionKeys[0]
+
+	os.Exit(m.Run())
+}
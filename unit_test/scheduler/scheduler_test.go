@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/scheduler"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Full test
+func TestScheduler(t *testing.T) {
+	t.Run("Nothing due below the threshold", testNothingDueBelowThreshold)
+	t.Run("Schedule trigger after the interval", testScheduleTrigger)
+	t.Run("Schedule trigger waits for the interval after the last run", testScheduleWaitsForLastRun)
+	t.Run("RunMatchingHandler as member", testRunMatchingHandlerAsMember)
+	t.Run("RunMatchingHandler", testRunMatchingHandler)
+	t.Run("GetAllMatchingRunsHandler as member", testGetAllMatchingRunsHandlerAsMember)
+	t.Run("GetAllMatchingRunsHandler", testGetAllMatchingRunsHandler)
+	t.Run("Run matches in the background until stopped", testRunInBackground)
+	t.Run("One run at a time across instances", testOneRunAcrossInstances)
+	t.Run("Back off after a failed run", testBackOffAfterFailedRun)
+}
+
+// Helper
+func queueTestUsers(t *testing.T) {
+	if _, err := test_helper.SetupMatchRequestForUsers(Store, testUsers); err != nil {
+		t.Fatalf("An error occured while creating match requests. %v", err)
+	}
+}
+
+func assertQueueSize(t *testing.T, size int) {
+	if count, _ := Store.GetMatchRequestCount(context.Background()); int(count) != size {
+		t.Errorf("%d match requests were queued instead of %d", count, size)
+	}
+}
+
+// Returns a scheduler whose clock is ahead of the real one by ahead
+func newScheduler(ahead time.Duration) *scheduler.Scheduler {
+	sch := scheduler.NewScheduler(Store, Matching)
+	sch.Now = func() time.Time { return time.Now().Add(ahead) }
+	return sch
+}
+
+func newRequestWithSession(method string, url string, sessionKey string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "session_key",
+		Value: sessionKey,
+	})
+	return req
+}
+
+func testNothingDueBelowThreshold(t *testing.T) {
+	queueTestUsers(t)
+	_, ran, err := newScheduler(0).Tick(context.Background())
+	if err != nil || ran {
+		t.Errorf("Matching ran on %d match requests before the interval. %v", Matching.GroupSize, err)
+	}
+	assertQueueSize(t, Matching.GroupSize)
+	if _, err := Store.GetLatestMatchingRun(context.Background()); err != http_error.NotFoundError {
+		t.Errorf("A matching run was recorded without running. %v", err)
+	}
+}
+
+func testScheduleTrigger(t *testing.T) {
+	run, ran, err := newScheduler(Matching.Interval).Tick(context.Background())
+	if err != nil || !ran {
+		t.Fatalf("Matching did not run once the interval passed. %v", err)
+	}
+	if run.Trigger != MatchingTriggerSchedule || run.Outcome != MatchingOutcomeMatched {
+		t.Errorf("The run was %s with outcome %s instead of SCHEDULE with MATCHED", run.Trigger, run.Outcome)
+	}
+	if run.QueueSize != Matching.GroupSize || len(run.UserIDs) != Matching.GroupSize || len(run.GroupIDs) != 1 {
+		t.Errorf("The run recorded a queue of %d and %d groups instead of %d and 1", run.QueueSize, len(run.GroupIDs), Matching.GroupSize)
+	}
+	if run.GroupSize != Matching.GroupSize || run.Threshold != Matching.Threshold {
+		t.Errorf("The run recorded a group size of %d and threshold of %d instead of %d and %d", run.GroupSize, run.Threshold, Matching.GroupSize, Matching.Threshold)
+	}
+	assertQueueSize(t, 0)
+	latest, err := Store.GetLatestMatchingRun(context.Background())
+	if err != nil || latest.ID != run.ID {
+		t.Errorf("The run was not recorded as the latest. %v", err)
+	}
+}
+
+func testScheduleWaitsForLastRun(t *testing.T) {
+	queueTestUsers(t)
+	_, ran, err := newScheduler(Matching.Interval / 2).Tick(context.Background())
+	if err != nil || ran {
+		t.Errorf("Matching ran again before the interval since the last run passed. %v", err)
+	}
+	assertQueueSize(t, Matching.GroupSize)
+}
+
+func testRunMatchingHandlerAsMember(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("POST", "/admin/matching", sessionKeys[0]))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for a member to run matching did not return 401. Status Code: %d", w.Code)
+	}
+	assertQueueSize(t, Matching.GroupSize)
+}
+
+func testRunMatchingHandler(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("POST", "/admin/matching", adminSessionKey))
+	run, err := test_helper.GetMatchingRunFromRecorder(w)
+	if err != nil {
+		t.Fatalf("HTTP Request for an admin to run matching failed. %v", err)
+	}
+	if run.Trigger != MatchingTriggerManual || run.TriggeredBy != testAdmin.ID || run.Outcome != MatchingOutcomeMatched {
+		t.Errorf("The run was %s by %d with outcome %s instead of MANUAL by the admin with MATCHED", run.Trigger, run.TriggeredBy, run.Outcome)
+	}
+	assertQueueSize(t, 0)
+}
+
+func testGetAllMatchingRunsHandlerAsMember(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", "/admin/matching", sessionKeys[0]))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for a member to list matching runs did not return 401. Status Code: %d", w.Code)
+	}
+}
+
+func testGetAllMatchingRunsHandler(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", "/admin/matching?order=desc", adminSessionKey))
+	page, err := test_helper.GetPageFromRecorder[MatchingRun](w)
+	if err != nil {
+		t.Fatalf("HTTP Request for an admin to list matching runs failed. %v", err)
+	}
+	if len(page.Items) != 2 {
+		t.Fatalf("%d matching runs were listed instead of 2", len(page.Items))
+	}
+	if page.Items[0].Trigger != MatchingTriggerManual || page.Items[1].Trigger != MatchingTriggerSchedule {
+		t.Errorf("Matching runs were listed as %s, %s instead of newest first", page.Items[0].Trigger, page.Items[1].Trigger)
+	}
+}
+
+func testRunInBackground(t *testing.T) {
+	queueTestUsers(t)
+	sch := newScheduler(Matching.Interval)
+	sch.Config.PollInterval = 10 * time.Millisecond
+	ctx, stop := context.WithCancel(context.Background())
+	go sch.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for count, _ := Store.GetMatchRequestCount(context.Background()); count > 0; count, _ = Store.GetMatchRequestCount(context.Background()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("The queue was not matched in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stop()
+	waitCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sch.Wait(waitCtx); err != nil {
+		t.Errorf("The scheduler did not stop once its context was done. %v", err)
+	}
+}
+
+// A transaction holding the advisory lock stands in for a run on another instance
+func testOneRunAcrossInstances(t *testing.T) {
+	s, ok := Store.(*store.PostgresStore)
+	if !ok {
+		t.Skip("Advisory locks need postgres. Run with UNIT_TEST_STORE=postgres")
+	}
+	latest, _ := Store.GetLatestMatchingRun(context.Background())
+	queueTestUsers(t)
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		t.Fatalf("An error occured while starting a transaction. %v", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", MatchingLockID); err != nil {
+		t.Fatalf("An error occured while taking the matching lock. %v", err)
+	}
+
+	if _, err := Store.RunMatching(context.Background(), MatchingTriggerSchedule); !errors.Is(err, MatchingInProgressError) {
+		t.Errorf("Matching ran while another run held the lock. %v", err)
+	}
+	if _, ran, err := newScheduler(Matching.Interval).Tick(context.Background()); err != nil || ran {
+		t.Errorf("The scheduler ran matching while another run held the lock. %v", err)
+	}
+	assertQueueSize(t, Matching.GroupSize)
+	if run, _ := Store.GetLatestMatchingRun(context.Background()); run.ID != latest.ID {
+		t.Errorf("A matching run was recorded while another run held the lock")
+	}
+
+	tx.Rollback()
+	if _, ran, err := newScheduler(Matching.Interval).Tick(context.Background()); err != nil || !ran {
+		t.Errorf("Matching did not run once the lock was released. %v", err)
+	}
+	assertQueueSize(t, 0)
+}
+
+// Store whose matching runs always fail
+type failingStore struct {
+	store.Store
+}
+
+func (s failingStore) RunMatching(ctx context.Context, trigger string) (MatchingRun, error) {
+	return MatchingRun{}, errors.New("matching run failed")
+}
+
+func testBackOffAfterFailedRun(t *testing.T) {
+	queueTestUsers(t)
+	sch := scheduler.NewScheduler(failingStore{Store}, Matching)
+	now := time.Now().Add(Matching.Interval)
+	sch.Now = func() time.Time { return now }
+
+	if _, ran, err := sch.Tick(context.Background()); err == nil || !ran {
+		t.Fatalf("The failing run was not reported. %v", err)
+	}
+	if _, ran, err := sch.Tick(context.Background()); err != nil || ran {
+		t.Errorf("Matching was retried right after a failed run. %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, ran, err := sch.Tick(context.Background()); err == nil || !ran {
+		t.Errorf("Matching was not retried once the backoff passed. %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, ran, err := sch.Tick(context.Background()); err != nil || ran {
+		t.Errorf("The backoff did not grow after a second failed run. %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	sch.Store = Store
+	if _, ran, err := sch.Tick(context.Background()); err != nil || !ran {
+		t.Errorf("Matching did not run once the store recovered. %v", err)
+	}
+	assertQueueSize(t, 0)
+}
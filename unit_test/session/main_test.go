@@ -1,9 +1,9 @@
 package session
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/router/session"
 	"wellnus/backend/unit_test/test_helper"
 
@@ -12,14 +12,12 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 )
 
 var (
-	DB          *sql.DB
+	Store       store.Store
 	Router      *gin.Engine
 	sessionKey1 string
 	sessionKey2 string
@@ -30,22 +28,24 @@ var sessionKey string
 
 func setupRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+
+	protected := router.Group("/", middleware.RequireAuth())
 
-	router.POST("/session", session.LoginHandler(DB))
-	router.DELETE("/session", session.LogoutHandler(DB))
+	router.POST("/session", session.LoginHandler(Store))
+	router.DELETE("/session", session.LogoutHandler(Store))
+	protected.GET("/session", session.GetAllSessionsHandler(Store))
+	protected.DELETE("/session/:id", session.DeleteSessionHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
 	Router = setupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 1)
+	testUsers, err = test_helper.SetupUsers(Store, 1)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
@@ -1,20 +1,34 @@
 package session
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
 	"wellnus/backend/router/http_helper/http_error"
 	"wellnus/backend/unit_test/test_helper"
 
+	"context"
+	"fmt"
 	"testing"
 	"net/http"
+	"strings"
+	"time"
 )
 
+var deviceSessionKeys []string
+
 // Full Tests
 
 func TestSession(t *testing.T) {
 	t.Run("Successful Login Handler", testSuccessfulLoginHandler)
 	t.Run("Failed Login Handler", testFailedLoginHandler)
 	t.Run("Logout Handler", testLogoutHandler)
+	t.Run("Login Handler on multiple devices", testLoginHandlerMultipleDevices)
+	t.Run("GetAllSessionsHandler", testGetAllSessionsHandler)
+	t.Run("DeleteSessionHandler of other user", testDeleteSessionHandlerOfOtherUser)
+	t.Run("DeleteSessionHandler", testDeleteSessionHandler)
+	t.Run("Logout Handler of all sessions", testLogoutHandlerAll)
+	t.Run("Session sliding expiry", testSessionSlidingExpiry)
+	t.Run("Session key stored as hash", testSessionKeyStoredAsHash)
 }
 
 // Helpers
@@ -31,7 +45,7 @@ func testSuccessfulLoginHandler(t *testing.T) {
 	if err != nil { t.Errorf("An error occured while retrieving response body. %v", err)}
 	if !sessionResponse.LoggedIn { t.Errorf("Not logged in despite logging in") }
 	sessionKey = test_helper.GetCookieFromRecorder(w, "session_key")
-	userID, err := model.GetUserIDFromSessionKey(DB, sessionKey)
+	userID, err := Store.GetUserIDFromSessionKey(context.Background(), sessionKey)
 	if err != nil { t.Errorf("An error occured while retrieving userID from session key. %v", err)}
 	if userID != sessionResponse.User.ID { 
 		t.Errorf("Logged in as a user of id = %d instead of correct user of id = %d", userID, sessionResponse.User.ID)
@@ -64,8 +78,138 @@ func testLogoutHandler(t *testing.T) {
 	if newSessionKey != "" { t.Errorf("Session Key cookie is still present after logout. SessionKey = '%s'", newSessionKey) }
 
 	//Check if session is still stored in DB
-	_, err = model.GetUserIDFromSessionKey(DB, sessionKey)
+	_, err = Store.GetUserIDFromSessionKey(context.Background(), sessionKey)
 	if err != http_error.UnauthorizedError {
 		t.Errorf("Session still exist in DB as no unauthorized error was thrown. %v", err)
 	}
-}
\ No newline at end of file
+}
+func loginWithDeviceLabel(t *testing.T, deviceLabel string) string {
+	loginAttempt := User{
+		Email: testUsers[0].Email,
+		Password: test_helper.GetTestUser(0).Password}
+	IOReaderAttempt, _ := test_helper.GetIOReaderFromObject(loginAttempt)
+	req, _ := http.NewRequest("POST", "/session", IOReaderAttempt)
+	req.Header.Set("X-Device-Label", deviceLabel)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusOK { t.Errorf("HTTP Request to login failed with status code of %d", w.Code) }
+	return test_helper.GetCookieFromRecorder(w, "session_key")
+}
+
+func requestWithSession(method string, url string, sessionKey string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKey,
+	})
+	return req
+}
+
+func testLoginHandlerMultipleDevices(t *testing.T) {
+	deviceSessionKeys = []string{loginWithDeviceLabel(t, "Laptop"), loginWithDeviceLabel(t, "Phone")}
+	for i, key := range deviceSessionKeys {
+		userID, err := Store.GetUserIDFromSessionKey(context.Background(), key)
+		if err != nil { t.Errorf("Session %d was logged out by a later login. %v", i, err) }
+		if userID != testUsers[0].ID { t.Errorf("Session %d belongs to user %d instead of %d", i, userID, testUsers[0].ID) }
+	}
+}
+
+func testGetAllSessionsHandler(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, requestWithSession("GET", "/session", deviceSessionKeys[0]))
+	sessions, err := test_helper.GetSessionsFromRecorder(w)
+	if err != nil { t.Errorf("An error occured while retrieving sessions. %v", err) }
+	if len(sessions) != 2 { t.Fatalf("%d sessions listed despite logging in on 2 devices", len(sessions)) }
+	labels := map[string]bool{}
+	currentCount := 0
+	for _, session := range sessions {
+		labels[session.DeviceLabel] = true
+		if session.Current { currentCount++ }
+		if !session.ExpiresAt.After(session.CreatedAt) { t.Errorf("Session %d expires before it was created", session.ID) }
+	}
+	if !labels["Laptop"] || !labels["Phone"] { t.Errorf("Device labels were not kept. %v", labels) }
+	if currentCount != 1 { t.Errorf("%d sessions were marked current instead of 1", currentCount) }
+	body := test_helper.GetBufferFromRecorder(w).String()
+	for _, key := range deviceSessionKeys {
+		if strings.Contains(body, key) { t.Errorf("Session listing exposed a session key") }
+	}
+}
+
+func testDeleteSessionHandlerOfOtherUser(t *testing.T) {
+	otherUser, _ := Store.AddUser(context.Background(), test_helper.GetTestUser(1))
+	otherSessionKey, _ := Store.CreateNewSession(context.Background(), otherUser.ID, "Other")
+	sessions, _ := Store.GetAllSessionsOfUser(context.Background(), testUsers[0].ID)
+	w := test_helper.SimulateRequest(Router, requestWithSession("DELETE", fmt.Sprintf("/session/%d", sessions[0].ID), otherSessionKey))
+	if w.Code != http.StatusNotFound { t.Errorf("Deleting another user's session did not return 404. Status Code: %d", w.Code) }
+}
+
+func testDeleteSessionHandler(t *testing.T) {
+	sessions, _ := Store.GetAllSessionsOfUser(context.Background(), testUsers[0].ID)
+	var phoneSession Session
+	for _, session := range sessions {
+		if session.DeviceLabel == "Phone" { phoneSession = session }
+	}
+	w := test_helper.SimulateRequest(Router, requestWithSession("DELETE", fmt.Sprintf("/session/%d", phoneSession.ID), deviceSessionKeys[0]))
+	deletedSession, err := test_helper.GetSessionFromRecorder(w)
+	if err != nil { t.Errorf("An error occured while deleting session. %v", err) }
+	if deletedSession.ID != phoneSession.ID || deletedSession.Current { t.Errorf("Deleted session %v was not the phone session", deletedSession) }
+	if _, err := Store.GetUserIDFromSessionKey(context.Background(), deviceSessionKeys[1]); err != http_error.UnauthorizedError {
+		t.Errorf("Deleted phone session is still valid. %v", err)
+	}
+	if _, err := Store.GetUserIDFromSessionKey(context.Background(), deviceSessionKeys[0]); err != nil {
+		t.Errorf("Laptop session was logged out by deleting the phone session. %v", err)
+	}
+}
+
+func testLogoutHandlerAll(t *testing.T) {
+	phoneSessionKey := loginWithDeviceLabel(t, "Phone")
+	w := test_helper.SimulateRequest(Router, requestWithSession("DELETE", "/session?all=true", deviceSessionKeys[0]))
+	if w.Code != http.StatusOK { t.Errorf("HTTP Request to logout of all sessions failed with status code of %d", w.Code) }
+	for _, key := range []string{deviceSessionKeys[0], phoneSessionKey} {
+		if _, err := Store.GetUserIDFromSessionKey(context.Background(), key); err != http_error.UnauthorizedError {
+			t.Errorf("Session is still valid after logging out of all sessions. %v", err)
+		}
+	}
+}
+
+func testSessionSlidingExpiry(t *testing.T) {
+	memoryStore, ok := Store.(*store.MemoryStore)
+	if !ok { t.Skip("Session expiry is only simulated against the in memory store") }
+	now := time.Now()
+	memoryStore.Now = func() time.Time { return now }
+	defer func() { memoryStore.Now = time.Now }()
+
+	key, _ := Store.CreateNewSession(context.Background(), testUsers[0].ID, "Laptop")
+	now = now.Add(SessionTTL - time.Hour)
+	if _, err := Store.GetUserIDFromSessionKey(context.Background(), key); err != nil {
+		t.Errorf("Session expired before its TTL. %v", err)
+	}
+	now = now.Add(SessionTTL - time.Hour)
+	if _, err := Store.GetUserIDFromSessionKey(context.Background(), key); err != nil {
+		t.Errorf("Session in use did not have its expiry extended. %v", err)
+	}
+	now = now.Add(SessionTTL)
+	if _, err := Store.GetUserIDFromSessionKey(context.Background(), key); err != http_error.UnauthorizedError {
+		t.Errorf("Session unused for longer than its TTL was still valid. %v", err)
+	}
+}
+
+func testSessionKeyStoredAsHash(t *testing.T) {
+	keys := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		key, err := Store.CreateNewSession(context.Background(), testUsers[0].ID, "Hash")
+		if err != nil { t.Fatalf("An error occured while creating session. %v", err) }
+		if keys[key] { t.Errorf("Session key %s was generated twice", key) }
+		keys[key] = true
+	}
+	sessions, _ := Store.GetAllSessionsOfUser(context.Background(), testUsers[0].ID)
+	for _, session := range sessions {
+		if keys[session.SessionKeyHash] { t.Errorf("Session key was stored in plaintext") }
+		if len(session.SessionKeyHash) != 64 { t.Errorf("Stored session key hash %s is not a sha256 hex digest", session.SessionKeyHash) }
+	}
+	for key := range keys {
+		found := false
+		for _, session := range sessions {
+			if session.SessionKeyHash == HashSessionKey(key) { found = true }
+		}
+		if !found { t.Errorf("No session was stored under the hash of key %s", key) }
+	}
+}
@@ -0,0 +1,68 @@
+package shutdown
+
+import (
+	"wellnus/backend/config"
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/middleware"
+	"wellnus/backend/router/ws"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const origin = "http://localhost:3000"
+
+var (
+	Store   store.Store
+	Server  *httptest.Server
+	WSHub   *ws.Hub
+	stopHub context.CancelFunc
+)
+
+var testUsers []User
+var testGroups []Group
+var sessionKeys []string
+
+func setupRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
+	protected.GET("/ws/:id", ws.ConnectToWSHandler(WSHub, Store))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	WSHub = ws.NewHub(Store, config.CORSConfig{AllowedOrigins: []string{origin}})
+	Server = httptest.NewServer(setupRouter())
+	var err error
+
+	if testUsers, err = test_helper.SetupUsers(Store, 2); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	if testGroups, err = test_helper.SetupGroupsForUsers(Store, testUsers[:1]); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test groups. %v", err))
+	}
+	if err = Store.AddUserToGroup(context.Background(), testGroups[0].ID, testUsers[1].ID); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when adding Test user to group. %v", err))
+	}
+	if sessionKeys, err = test_helper.SetupSessionForUsers(Store, testUsers); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test sessions. %v", err))
+	}
+
+	var ctx context.Context
+	ctx, stopHub = context.WithCancel(context.Background())
+	go WSHub.Run(ctx)
+
+	code := m.Run()
+	Server.Close()
+	os.Exit(code)
+}
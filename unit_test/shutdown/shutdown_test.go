@@ -0,0 +1,87 @@
+package shutdown
+
+import (
+	"wellnus/backend/router/http_helper"
+
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Full test
+func TestShutdown(t *testing.T) {
+	t.Run("Clients closed with server restarting", testClientsClosedOnShutdown)
+	t.Run("Connecting after shutdown", testConnectAfterShutdown)
+}
+
+// Helpers
+
+func dial(t *testing.T, sessionKey string) *websocket.Conn {
+	url := fmt.Sprintf("ws%s/ws/%d", strings.TrimPrefix(Server.URL, "http"), testGroups[0].ID)
+	header := http.Header{}
+	header.Set("Origin", origin)
+	header.Set("Cookie", fmt.Sprintf("%s=%s", http_helper.SessionCookieName, sessionKey))
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	if err != nil {
+		t.Fatalf("An error occured while connecting to the websocket. %v", err)
+	}
+	return conn
+}
+
+// Reads until the connection is closed and returns the close frame the server sent
+func readUntilClosed(t *testing.T, conn *websocket.Conn) *websocket.CloseError {
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("Connection ended without a close frame. %v", err)
+			}
+			return closeErr
+		}
+	}
+}
+
+func assertRestartClose(t *testing.T, closeErr *websocket.CloseError) {
+	if closeErr.Code != websocket.CloseServiceRestart || closeErr.Text != "server restarting" {
+		t.Errorf("Close frame was %d %q instead of %d %q", closeErr.Code, closeErr.Text, websocket.CloseServiceRestart, "server restarting")
+	}
+}
+
+func testClientsClosedOnShutdown(t *testing.T) {
+	conns := []*websocket.Conn{dial(t, sessionKeys[0]), dial(t, sessionKeys[1])}
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	// Chat status is sent once the hub has registered a client
+	for _, conn := range conns {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, _, err := conn.ReadMessage(); err != nil {
+			t.Fatalf("An error occured while waiting for the chat status. %v", err)
+		}
+	}
+
+	stopHub()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WSHub.Wait(ctx); err != nil {
+		t.Fatalf("Hub did not stop before the deadline. %v", err)
+	}
+	for _, conn := range conns {
+		assertRestartClose(t, readUntilClosed(t, conn))
+	}
+}
+
+func testConnectAfterShutdown(t *testing.T) {
+	conn := dial(t, sessionKeys[0])
+	defer conn.Close()
+	assertRestartClose(t, readUntilClosed(t, conn))
+}
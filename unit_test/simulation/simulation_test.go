@@ -0,0 +1,218 @@
+package simulation
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/simulation"
+	"wellnus/backend/unit_test/test_helper"
+
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Full test
+func TestSimulation(t *testing.T) {
+	t.Run("DryRunMatchingHandler as member", testDryRunMatchingHandlerAsMember)
+	t.Run("DryRunMatchingHandler", testDryRunMatchingHandler)
+	t.Run("DryRunMatchingHandler with strategy", testDryRunMatchingHandlerWithStrategy)
+	t.Run("DryRunMatchingHandler with unknown strategy", testDryRunMatchingHandlerWithUnknownStrategy)
+	t.Run("Dry run proposes open groups to fill", testDryRunFills)
+	t.Run("Simulate compares strategies", testSimulate)
+	t.Run("Simulate command", testSimulateCommand)
+	t.Run("Run forms the groups of the dry run", testRunFormsDryRunGroups)
+}
+
+// Helper
+func newRequestWithSession(method string, url string, sessionKey string) *http.Request {
+	req, _ := http.NewRequest(method, url, nil)
+	req.AddCookie(&http.Cookie{
+		Name:  "session_key",
+		Value: sessionKey,
+	})
+	return req
+}
+
+func assertQueueSize(t *testing.T, size int) {
+	if count, _ := Store.GetMatchRequestCount(context.Background()); int(count) != size {
+		t.Errorf("%d match requests were queued instead of %d", count, size)
+	}
+}
+
+func getDryRun(t *testing.T, query string) MatchingDryRun {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", "/admin/matching/dry_run"+query, adminSessionKey))
+	dryRun, err := test_helper.GetMatchingDryRunFromRecorder(w)
+	if err != nil {
+		t.Fatalf("HTTP Request for an admin to dry run matching failed. %v", err)
+	}
+	return dryRun
+}
+
+func sortedIDs(ids []int64) []int64 {
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+func testDryRunMatchingHandlerAsMember(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", "/admin/matching/dry_run", sessionKeys[0]))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("HTTP Request for a member to dry run matching did not return 401. Status Code: %d", w.Code)
+	}
+}
+
+func testDryRunMatchingHandler(t *testing.T) {
+	dryRun := getDryRun(t, "")
+	if dryRun.Strategy != Matching.Strategy {
+		t.Errorf("The dry run used %s instead of %s", dryRun.Strategy, Matching.Strategy)
+	}
+
+	placed := make(map[int64]int)
+	total, pairs := 0, 0
+	for _, group := range dryRun.Groups {
+		n := len(group.UserIDs)
+		if group.GroupID != 0 || len(group.MemberIDs) != 0 {
+			t.Errorf("A new group was proposed as group %d with members %v", group.GroupID, group.MemberIDs)
+		}
+		if len(group.Pairs) != n*(n-1)/2 {
+			t.Errorf("A group of %d was proposed with %d pairs", n, len(group.Pairs))
+		}
+		sum := 0
+		for _, pair := range group.Pairs {
+			sum += pair.Score.Total
+		}
+		if sum != group.Total {
+			t.Errorf("A group was proposed with a total of %d instead of the %d of its pairs", group.Total, sum)
+		}
+		for _, userID := range group.UserIDs {
+			placed[userID]++
+		}
+		total += group.Total
+		pairs += len(group.Pairs)
+	}
+	for _, userID := range dryRun.RemainingUserIDs {
+		placed[userID]++
+	}
+	for _, user := range testUsers {
+		if placed[user.ID] != 1 {
+			t.Errorf("User %d was proposed %d times instead of once", user.ID, placed[user.ID])
+		}
+	}
+
+	stats := dryRun.Stats
+	if stats.QueueSize != len(testUsers) || stats.Groups != len(dryRun.Groups) || stats.Matched+stats.Remaining != stats.QueueSize {
+		t.Errorf("The stats counted a queue of %d with %d groups, %d matched and %d remaining", stats.QueueSize, stats.Groups, stats.Matched, stats.Remaining)
+	}
+	if stats.TotalCompatibility != total || stats.Pairs != pairs || stats.MinPairScore > stats.MaxPairScore || stats.MaxPairScore > stats.MaxScore {
+		t.Errorf("The stats were %+v for a total of %d over %d pairs", stats, total, pairs)
+	}
+
+	assertQueueSize(t, len(testUsers))
+	if _, err := Store.GetLatestMatchingRun(context.Background()); err != http_error.NotFoundError {
+		t.Errorf("A matching run was recorded by a dry run. %v", err)
+	}
+}
+
+func testDryRunMatchingHandlerWithStrategy(t *testing.T) {
+	for _, strategy := range simulation.Strategies {
+		if dryRun := getDryRun(t, "?strategy="+strategy); dryRun.Strategy != strategy {
+			t.Errorf("The dry run used %s instead of %s", dryRun.Strategy, strategy)
+		}
+	}
+}
+
+func testDryRunMatchingHandlerWithUnknownStrategy(t *testing.T) {
+	w := test_helper.SimulateRequest(Router, newRequestWithSession("GET", "/admin/matching/dry_run?strategy=random", adminSessionKey))
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("HTTP Request to dry run matching with an unknown strategy did not return 422. Status Code: %d", w.Code)
+	}
+}
+
+func testDryRunFills(t *testing.T) {
+	matching := Matching
+	matching.UnevenGroups, matching.FillGroups = false, true
+	lmrs := test_helper.GenerateLoadedMatchRequests(rand.New(rand.NewSource(1)), Matching.GroupSize+1)
+	member := LoadedMatchRequest{User: PublicUser{ID: 100}}
+	openGroups := []OpenGroup{{Group: Group{ID: 1}, Members: []LoadedMatchRequest{member}}}
+
+	dryRun := DryRunPlan(lmrs, openGroups, matching)
+	if len(dryRun.Groups) != 1 || len(dryRun.Fills) != 1 || len(dryRun.RemainingUserIDs) != 0 {
+		t.Fatalf("The dry run proposed %d groups and %d fills leaving %d instead of 1, 1 and none", len(dryRun.Groups), len(dryRun.Fills), len(dryRun.RemainingUserIDs))
+	}
+	fill := dryRun.Fills[0]
+	newest := lmrs[len(lmrs)-1].User.ID
+	if fill.GroupID != 1 || !reflect.DeepEqual(fill.UserIDs, []int64{newest}) || !reflect.DeepEqual(fill.MemberIDs, []int64{member.User.ID}) {
+		t.Errorf("The fill was proposed as %+v", fill)
+	}
+	if len(fill.Pairs) != 1 || fill.Pairs[0].UserIDs != [2]int64{newest, member.User.ID} {
+		t.Errorf("The fill was proposed with pairs %+v instead of its member with the newest", fill.Pairs)
+	}
+	if dryRun.Stats.FilledGroups != 1 || dryRun.Stats.Matched != len(lmrs) {
+		t.Errorf("The stats counted %d filled groups and %d matched", dryRun.Stats.FilledGroups, dryRun.Stats.Matched)
+	}
+}
+
+func testSimulate(t *testing.T) {
+	queueSizes := []int{Matching.GroupSize + 1, 40}
+	results := simulation.Simulate(Matching, simulation.Strategies, queueSizes)
+	if len(results) != len(queueSizes)*simulation.Populations*len(simulation.Strategies) {
+		t.Fatalf("%d results were simulated", len(results))
+	}
+	again := simulation.Simulate(Matching, simulation.Strategies, queueSizes)
+	for i := 0; i < len(results); i += 2 {
+		greedy, annealing := results[i], results[i+1]
+		if greedy.Strategy != MatchStrategyGreedy || annealing.Strategy != MatchStrategyAnnealing || greedy.Seed != annealing.Seed {
+			t.Fatalf("Strategies were not compared on the same population")
+		}
+		if annealing.Stats.TotalCompatibility < greedy.Stats.TotalCompatibility {
+			t.Errorf("Annealing scored %d on a queue of %d with seed %d, below the %d of greedy", annealing.Stats.TotalCompatibility, annealing.QueueSize, annealing.Seed, greedy.Stats.TotalCompatibility)
+		}
+		if results[i].Stats != again[i].Stats || results[i+1].Stats != again[i+1].Stats {
+			t.Errorf("Simulating the same populations gave different stats")
+		}
+	}
+}
+
+func testSimulateCommand(t *testing.T) {
+	var out bytes.Buffer
+	if err := simulation.Run(Matching, []string{"8"}, &out); err != nil {
+		t.Fatalf("The simulate command failed. %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1+simulation.Populations*len(simulation.Strategies) || !strings.HasPrefix(lines[0], "queue") {
+		t.Errorf("The simulate command printed\n%s", out.String())
+	}
+	if err := simulation.Run(Matching, []string{"many"}, &out); err == nil {
+		t.Errorf("The simulate command took a queue size that is not a number")
+	}
+}
+
+func testRunFormsDryRunGroups(t *testing.T) {
+	dryRun := getDryRun(t, "")
+	run, err := Store.RunMatching(context.Background(), MatchingTriggerManual)
+	if err != nil {
+		t.Fatalf("An error occured while running matching. %v", err)
+	}
+	if len(run.GroupIDs) != len(dryRun.Groups) {
+		t.Fatalf("The run formed %d groups instead of the %d of the dry run", len(run.GroupIDs), len(dryRun.Groups))
+	}
+	for i, groupID := range run.GroupIDs {
+		groupWithUsers, err := Store.GetGroupWithUsers(context.Background(), groupID)
+		if err != nil {
+			t.Fatalf("An error occured while getting group %d. %v", groupID, err)
+		}
+		userIDs := make([]int64, len(groupWithUsers.Users))
+		for j, user := range groupWithUsers.Users {
+			userIDs[j] = user.ID
+		}
+		if !reflect.DeepEqual(sortedIDs(userIDs), sortedIDs(dryRun.Groups[i].UserIDs)) {
+			t.Errorf("The run formed a group of %v instead of %v", sortedIDs(userIDs), sortedIDs(dryRun.Groups[i].UserIDs))
+		}
+	}
+	assertQueueSize(t, len(dryRun.RemainingUserIDs))
+}
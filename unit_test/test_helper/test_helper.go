@@ -1,9 +1,16 @@
 package test_helper
 
 import (
+	"wellnus/backend/config"
+	"wellnus/backend/db"
+	"wellnus/backend/db/migration"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
+	"wellnus/backend/simulation"
 
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -12,6 +19,7 @@ import (
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"regexp"
 	"time"
 
@@ -20,9 +28,6 @@ import (
 
 var ref_user_role []string = []string{"MEMBER", "VOLUNTEER", "COUNSELLOR"}
 var ref_category []string = []string{"COUNSEL", "SUPPORT", "CUSTOM"}
-var ref_faculty []string = []string{"MIX", "SAME", "NONE"}
-var ref_hobbies []string = []string{"GAMING", "SINGING", "DANCING", "MUSIC", "SPORTS", "OUTDOOR", "BOOK", "ANIME", "MOVIES", "TV", "ART", "STUDY"}
-var ref_mbti []string = []string{"ISTJ", "ISFJ", "INFJ", "INTJ", "ISTP", "ISFP", "INFP", "INTP", "ESTP", "ESFP", "ENFP", "ENTP", "ESTJ", "ESFJ", "ENFJ", "ENTJ"}
 var ref_topics []string = []string{"Anxiety", "OffMyChest", "SelfHarm"}
 var ref_access []string = []string{"PUBLIC", "PRIVATE"}
 
@@ -30,6 +35,61 @@ func ResetDB(db *sql.DB) {
 	db.Exec("DELETE FROM wn_group")
 	db.Exec("DELETE FROM wn_event")
 	db.Exec("DELETE FROM wn_user")
+	db.Exec("DELETE FROM wn_matching_run")
+}
+
+// Returns the config of the server as it would be loaded from the .env at the root of the repo
+func LoadConfig() config.Config {
+	cfg, _, err := config.Load("../../.env", nil)
+	if err != nil {
+		panic(err)
+	}
+	return cfg
+}
+
+// Returns the store that unit tests run against. Tests use a fresh in memory store unless
+// UNIT_TEST_STORE=postgres, in which case the database at DB_ADDRESS is migrated, reset and used.
+func SetupStore() store.Store {
+	cfg := LoadConfig()
+	if os.Getenv("UNIT_TEST_STORE") != "postgres" {
+		return store.NewMemoryStore(cfg.Matching)
+	}
+	DB, err := db.ConnectDB(cfg.DB)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := migration.Up(DB); err != nil {
+		panic(err)
+	}
+	ResetDB(DB)
+	return store.NewPostgresStore(DB, cfg.DB.StatementTimeout, cfg.Matching)
+}
+
+// Returns a mailer that writes into a new temporary directory so tests can read the emails sent
+func SetupMailer() *mailer.FileMailer {
+	dir, err := os.MkdirTemp("", "wellnus-mail")
+	if err != nil {
+		panic(err)
+	}
+	return mailer.NewFileMailer(dir)
+}
+
+var tokenLinkPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// Returns the token in the latest email sent to the address
+func GetTokenFromMailer(m *mailer.FileMailer, to string) (string, error) {
+	messages, err := m.Messages(to)
+	if err != nil {
+		return "", err
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("no email was sent to %s", to)
+	}
+	match := tokenLinkPattern.FindStringSubmatch(messages[len(messages)-1])
+	if match == nil {
+		return "", fmt.Errorf("latest email to %s has no token", to)
+	}
+	return match[1], nil
 }
 
 func GetBufferFromRecorder(w *httptest.ResponseRecorder) *bytes.Buffer {
@@ -54,15 +114,6 @@ func SimulateRequest(router *gin.Engine, req *http.Request) *httptest.ResponseRe
 	return w
 }
 
-func ReadInt(row *sql.Rows) (int, error) {
-	row.Next()
-	var c int
-	if err := row.Scan(&c); err != nil {
-		return 0, err
-	}
-	return c, nil
-}
-
 func GetInt64FromRecorder(w *httptest.ResponseRecorder) (int64, error) {
 	buf := GetBufferFromRecorder(w)
 	if w.Code != http.StatusOK {
@@ -107,18 +158,26 @@ func GetUserWithGroupsFromRecorder(w *httptest.ResponseRecorder) (UserWithGroups
 	return userWithGroups, nil
 }
 
-func GetUsersFromRecorder(w *httptest.ResponseRecorder) ([]User, error) {
+// Reads a page of a list endpoint
+func GetPageFromRecorder[T any](w *httptest.ResponseRecorder) (Page[T], error) {
 	buf := GetBufferFromRecorder(w)
 	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
+		return Page[T]{}, errors.New(buf.String())
 	}
-	// fmt.Printf("Response Body: %v \n", buf)
-	users := make([]User, 0)
-	err := json.NewDecoder(buf).Decode(&users)
+	var page Page[T]
+	err := json.NewDecoder(buf).Decode(&page)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	return page, nil
+}
+
+func GetUsersFromRecorder(w *httptest.ResponseRecorder) ([]User, error) {
+	page, err := GetPageFromRecorder[User](w)
 	if err != nil {
 		return nil, err
 	}
-	return users, nil
+	return page.Items, nil
 }
 
 func GetSessionResponseFromRecorder(w *httptest.ResponseRecorder) (SessionResponse, error) {
@@ -137,6 +196,32 @@ func GetSessionResponseFromRecorder(w *httptest.ResponseRecorder) (SessionRespon
 	return sessionResponse, nil
 }
 
+func GetSessionFromRecorder(w *httptest.ResponseRecorder) (Session, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return Session{}, errors.New(buf.String())
+	}
+	var session Session
+	err := json.NewDecoder(buf).Decode(&session)
+	if err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+func GetSessionsFromRecorder(w *httptest.ResponseRecorder) ([]Session, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return nil, errors.New(buf.String())
+	}
+	var sessions []Session
+	err := json.NewDecoder(buf).Decode(&sessions)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 func GetGroupFromRecorder(w *httptest.ResponseRecorder) (Group, error) {
 	buf := GetBufferFromRecorder(w)
 	if w.Code != http.StatusOK {
@@ -153,18 +238,11 @@ func GetGroupFromRecorder(w *httptest.ResponseRecorder) (Group, error) {
 }
 
 func GetGroupsFromRecorder(w *httptest.ResponseRecorder) ([]Group, error) {
-	buf := GetBufferFromRecorder(w)
-	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
-	}
-
-	// fmt.Printf("Response Body: %v \n", buf)
-	var groups []Group
-	err := json.NewDecoder(buf).Decode(&groups)
+	page, err := GetPageFromRecorder[Group](w)
 	if err != nil {
 		return nil, err
 	}
-	return groups, nil
+	return page.Items, nil
 }
 
 func GetGroupWithUsersFromRecorder(w *httptest.ResponseRecorder) (GroupWithUsers, error) {
@@ -210,17 +288,11 @@ func GetLoadedJoinRequestFromRecorder(w *httptest.ResponseRecorder) (LoadedJoinR
 }
 
 func GetLoadedJoinRequestsFromRecorder(w *httptest.ResponseRecorder) ([]LoadedJoinRequest, error) {
-	buf := GetBufferFromRecorder(w)
-	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
-	}
-
-	var loadedJoinRequests []LoadedJoinRequest
-	err := json.NewDecoder(buf).Decode(&loadedJoinRequests)
+	page, err := GetPageFromRecorder[LoadedJoinRequest](w)
 	if err != nil {
 		return nil, err
 	}
-	return loadedJoinRequests, nil
+	return page.Items, nil
 }
 
 func GetJoinRequestFromRecorder(w *httptest.ResponseRecorder) (JoinRequest, error) {
@@ -307,6 +379,19 @@ func GetLoadedMatchRequestFromRecorder(w *httptest.ResponseRecorder) (LoadedMatc
 	return loadedMatchRequest, nil
 }
 
+func GetQueuedMatchRequestFromRecorder(w *httptest.ResponseRecorder) (QueuedMatchRequest, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return QueuedMatchRequest{}, errors.New(buf.String())
+	}
+	var queuedMatchRequest QueuedMatchRequest
+	err := json.NewDecoder(buf).Decode(&queuedMatchRequest)
+	if err != nil {
+		return QueuedMatchRequest{}, err
+	}
+	return queuedMatchRequest, nil
+}
+
 func GetCounselRequestFromRecorder(w *httptest.ResponseRecorder) (CounselRequest, error) {
 	buf := GetBufferFromRecorder(w)
 	if w.Code != http.StatusOK {
@@ -321,16 +406,11 @@ func GetCounselRequestFromRecorder(w *httptest.ResponseRecorder) (CounselRequest
 }
 
 func GetCounselRequestsFromRecorder(w *httptest.ResponseRecorder) ([]CounselRequest, error) {
-	buf := GetBufferFromRecorder(w)
-	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
-	}
-	var counselRequests []CounselRequest
-	err := json.NewDecoder(buf).Decode(&counselRequests)
+	page, err := GetPageFromRecorder[CounselRequest](w)
 	if err != nil {
 		return nil, err
 	}
-	return counselRequests, nil
+	return page.Items, nil
 }
 
 func GetEventFromRecorder(w *httptest.ResponseRecorder) (Event, error) {
@@ -347,16 +427,11 @@ func GetEventFromRecorder(w *httptest.ResponseRecorder) (Event, error) {
 }
 
 func GetEventsFromRecorder(w *httptest.ResponseRecorder) ([]Event, error) {
-	buf := GetBufferFromRecorder(w)
-	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
-	}
-	var events []Event
-	err := json.NewDecoder(buf).Decode(&events)
+	page, err := GetPageFromRecorder[Event](w)
 	if err != nil {
 		return nil, err
 	}
-	return events, nil
+	return page.Items, nil
 }
 
 func GetEventWithUsersFromRecorder(w *httptest.ResponseRecorder) (EventWithUsers, error) {
@@ -425,16 +500,11 @@ func GetProviderFromRecorder(w *httptest.ResponseRecorder) (Provider, error) {
 }
 
 func GetProvidersFromRecorder(w *httptest.ResponseRecorder) ([]Provider, error) {
-	buf := GetBufferFromRecorder(w)
-	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
-	}
-	var providers []Provider
-	err := json.NewDecoder(buf).Decode(&providers)
+	page, err := GetPageFromRecorder[Provider](w)
 	if err != nil {
 		return nil, err
 	}
-	return providers, nil
+	return page.Items, nil
 }
 
 func GetProviderWithEventsFromRecorder(w *httptest.ResponseRecorder) (ProviderWithEvents, error) {
@@ -477,16 +547,11 @@ func GetBookingUserFromRecorder(w *httptest.ResponseRecorder) (BookingUser, erro
 }
 
 func GetBookingUsersFromRecorder(w *httptest.ResponseRecorder) ([]BookingUser, error) {
-	buf := GetBufferFromRecorder(w)
-	if w.Code != http.StatusOK {
-		return nil, errors.New(buf.String())
-	}
-	var bookingUsers []BookingUser
-	err := json.NewDecoder(buf).Decode(&bookingUsers)
+	page, err := GetPageFromRecorder[BookingUser](w)
 	if err != nil {
 		return nil, err
 	}
-	return bookingUsers, nil
+	return page.Items, nil
 }
 
 func GetBookingProviderFromRecorder(w *httptest.ResponseRecorder) (BookingProvider, error) {
@@ -515,6 +580,58 @@ func GetBookingRespondFromRecorder(w *httptest.ResponseRecorder) (BookingRespond
 	return bookingRespond, nil
 }
 
+func GetReportFromRecorder(w *httptest.ResponseRecorder) (Report, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return Report{}, errors.New(buf.String())
+	}
+	var report Report
+	err := json.NewDecoder(buf).Decode(&report)
+	if err != nil {
+		return Report{}, err
+	}
+	return report, nil
+}
+
+func GetMatchingRunFromRecorder(w *httptest.ResponseRecorder) (MatchingRun, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return MatchingRun{}, errors.New(buf.String())
+	}
+	var run MatchingRun
+	err := json.NewDecoder(buf).Decode(&run)
+	if err != nil {
+		return MatchingRun{}, err
+	}
+	return run, nil
+}
+
+func GetMatchingDryRunFromRecorder(w *httptest.ResponseRecorder) (MatchingDryRun, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return MatchingDryRun{}, errors.New(buf.String())
+	}
+	var dryRun MatchingDryRun
+	err := json.NewDecoder(buf).Decode(&dryRun)
+	if err != nil {
+		return MatchingDryRun{}, err
+	}
+	return dryRun, nil
+}
+
+func GetCompatibilityBreakdownFromRecorder(w *httptest.ResponseRecorder) (CompatibilityBreakdown, error) {
+	buf := GetBufferFromRecorder(w)
+	if w.Code != http.StatusOK {
+		return CompatibilityBreakdown{}, errors.New(buf.String())
+	}
+	var breakdown CompatibilityBreakdown
+	err := json.NewDecoder(buf).Decode(&breakdown)
+	if err != nil {
+		return CompatibilityBreakdown{}, err
+	}
+	return breakdown, nil
+}
+
 func CheckErrorMessageFromRecorder(w *httptest.ResponseRecorder, pattern string) (string, bool) {
 	errString := GetBufferFromRecorder(w).String()
 	matched, _ := regexp.MatchString(pattern, errString)
@@ -582,24 +699,17 @@ func GetTestGroup(i int) Group {
 }
 
 func GetRandomTestMatchSetting() MatchSetting {
-	Rand := rand.New(rand.NewSource(time.Now().UnixNano()))
-	facultyPreference := ref_faculty[Rand.Intn(len(ref_faculty))]
-	mbti := ref_mbti[Rand.Intn(len(ref_mbti))]
-	hobbies := make([]string, 0)
-	for _, hobby := range ref_hobbies {
-		if Rand.Intn(3) == 1 {
-			hobbies = append(hobbies, hobby)
-		}
-		if len(hobbies) >= 4 {
-			break
-		}
-	}
-	matchSetting := MatchSetting{
-		FacultyPreference: facultyPreference,
-		Hobbies:           hobbies,
-		MBTI:              mbti,
-	}
-	return matchSetting
+	return GenerateMatchSetting(rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// Returns a match setting drawn from rng, so that a seeded rng always gives the same one
+func GenerateMatchSetting(rng *rand.Rand) MatchSetting {
+	return simulation.GenerateMatchSetting(rng)
+}
+
+// Returns a queue of n match requests of users of any faculty drawn from rng, without touching the store
+func GenerateLoadedMatchRequests(rng *rand.Rand, n int) []LoadedMatchRequest {
+	return simulation.GenerateLoadedMatchRequests(rng, n)
 }
 
 func GetTestCounselRequest(i int) CounselRequest {
@@ -654,22 +764,71 @@ func GetTestBooking(i int, providerID int64) Booking {
 	}
 }
 
-func SetupUsers(db *sql.DB, num int) ([]User, error) {
+// Counsellors among the users are verified so that they can act as providers
+func SetupUsers(s store.Store, num int) ([]User, error) {
 	users := make([]User, num)
 	for i := 0; i < num; i++ {
-		user, err := AddUser(db, GetTestUser(i))
+		user, err := s.AddUser(context.Background(), GetTestUser(i))
 		if err != nil {
 			return nil, err
 		}
+		if user, err = VerifyEmailOfUser(s, user); err != nil {
+			return nil, err
+		}
 		users[i] = user
 	}
+	if err := VerifyCounsellors(s, users); err != nil {
+		return nil, err
+	}
 	return users, nil
 }
 
-func SetupGroupsForUsers(db *sql.DB, users []User) ([]Group, error) {
+func SetupAdmin(s store.Store) (User, error) {
+	admin := GetTestUser(0)
+	admin.FirstName = "TestAdmin"
+	admin.UserRole = "ADMIN"
+	admin, err := s.AddUser(context.Background(), admin)
+	if err != nil {
+		return User{}, err
+	}
+	return VerifyEmailOfUser(s, admin)
+}
+
+func VerifyEmailOfUser(s store.Store, user User) (User, error) {
+	token, err := s.CreateUserToken(context.Background(), user.ID, TokenVerifyEmail)
+	if err != nil {
+		return User{}, err
+	}
+	return s.VerifyEmail(context.Background(), token)
+}
+
+// Verifies counsellors in place through a temporary admin that is removed afterwards
+func VerifyCounsellors(s store.Store, users []User) error {
+	var admin User
+	for i, user := range users {
+		if user.UserRole != "COUNSELLOR" || user.Verified {
+			continue
+		}
+		if admin.ID == 0 {
+			var err error
+			if admin, err = SetupAdmin(s); err != nil {
+				return err
+			}
+			defer s.DeleteUser(context.Background(), admin.ID)
+		}
+		verifiedUser, err := s.VerifyUser(context.Background(), user.ID, true, admin.ID)
+		if err != nil {
+			return err
+		}
+		users[i] = verifiedUser
+	}
+	return nil
+}
+
+func SetupGroupsForUsers(s store.Store, users []User) ([]Group, error) {
 	groups := make([]Group, len(users))
 	for i, user := range users {
-		groupWithUsers, err := AddGroupWithUserIDs(db, GetTestGroup(i), []int64{user.ID})
+		groupWithUsers, err := s.AddGroupWithUserIDs(context.Background(), GetTestGroup(i), []int64{user.ID})
 		if err != nil {
 			return nil, err
 		}
@@ -678,10 +837,10 @@ func SetupGroupsForUsers(db *sql.DB, users []User) ([]Group, error) {
 	return groups, nil
 }
 
-func SetupMatchSettingForUsers(db *sql.DB, users []User) ([]MatchSetting, error) {
+func SetupMatchSettingForUsers(s store.Store, users []User) ([]MatchSetting, error) {
 	matchSettings := make([]MatchSetting, len(users))
 	for i, user := range users {
-		matchSetting, err := AddUpdateMatchSettingOfUser(db, GetRandomTestMatchSetting(), user.ID)
+		matchSetting, err := s.AddUpdateMatchSettingOfUser(context.Background(), GetRandomTestMatchSetting(), user.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -690,10 +849,10 @@ func SetupMatchSettingForUsers(db *sql.DB, users []User) ([]MatchSetting, error)
 	return matchSettings, nil
 }
 
-func SetupSessionForUsers(db *sql.DB, users []User) ([]string, error) {
+func SetupSessionForUsers(s store.Store, users []User) ([]string, error) {
 	sessionKeys := make([]string, len(users))
 	for i, user := range users {
-		sessionKey, err := CreateNewSession(db, user.ID)
+		sessionKey, err := s.CreateNewSession(context.Background(), user.ID, "unit test")
 		if err != nil {
 			return nil, err
 		}
@@ -702,10 +861,10 @@ func SetupSessionForUsers(db *sql.DB, users []User) ([]string, error) {
 	return sessionKeys, nil
 }
 
-func SetupMatchRequestForUsers(db *sql.DB, users []User) ([]MatchRequest, error) {
+func SetupMatchRequestForUsers(s store.Store, users []User) ([]MatchRequest, error) {
 	matchRequests := make([]MatchRequest, len(users))
 	for i, user := range users {
-		matchRequest, err := AddMatchRequest(db, user.ID)
+		matchRequest, err := s.AddMatchRequest(context.Background(), user.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -714,10 +873,10 @@ func SetupMatchRequestForUsers(db *sql.DB, users []User) ([]MatchRequest, error)
 	return matchRequests, nil
 }
 
-func SetupCounselRequestForUsers(db *sql.DB, users []User) ([]CounselRequest, error) {
+func SetupCounselRequestForUsers(s store.Store, users []User) ([]CounselRequest, error) {
 	counselRequests := make([]CounselRequest, len(users))
 	for i, user := range users {
-		counselRequest, err := AddUpdateCounselRequest(db, GetTestCounselRequest(i), user.ID)
+		counselRequest, err := s.AddUpdateCounselRequest(context.Background(), GetTestCounselRequest(i), user.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -726,10 +885,10 @@ func SetupCounselRequestForUsers(db *sql.DB, users []User) ([]CounselRequest, er
 	return counselRequests, nil
 }
 
-func SetupEventForUsers(db *sql.DB, users []User) ([]Event, error) {
+func SetupEventForUsers(s store.Store, users []User) ([]Event, error) {
 	events := make([]Event, len(users))
 	for i, user := range users {
-		eventWithUsers, err := AddEventWithUserIDs(db, GetTestEvent(i), []int64{user.ID})
+		eventWithUsers, err := s.AddEventWithUserIDs(context.Background(), GetTestEvent(i), []int64{user.ID})
 		if err != nil {
 			return nil, err
 		}
@@ -738,10 +897,10 @@ func SetupEventForUsers(db *sql.DB, users []User) ([]Event, error) {
 	return events, nil
 }
 
-func SetupProviderSettingForUsers(db *sql.DB, users []User) ([]ProviderSetting, error) {
+func SetupProviderSettingForUsers(s store.Store, users []User) ([]ProviderSetting, error) {
 	providerSettings := make([]ProviderSetting, len(users))
 	for i, user := range users {
-		providerSetting, err := AddUpdateProviderSettingOfUser(db, GetTestProviderSetting(i), user.ID)
+		providerSetting, err := s.AddUpdateProviderSettingOfUser(context.Background(), GetTestProviderSetting(i), user.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -750,10 +909,10 @@ func SetupProviderSettingForUsers(db *sql.DB, users []User) ([]ProviderSetting,
 	return providerSettings, nil
 }
 
-func SetupBookingToUserForUsers(db *sql.DB, users []User, pUser User) ([]Booking, error) {
+func SetupBookingToUserForUsers(s store.Store, users []User, pUser User) ([]Booking, error) {
 	bookings := make([]Booking, len(users))
 	for i, user := range users {
-		booking, err := AddBooking(db, GetTestBooking(i, pUser.ID), pUser.ID, user.ID)
+		booking, err := s.AddBooking(context.Background(), GetTestBooking(i, pUser.ID), pUser.ID, user.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,55 @@
+package timeout
+
+import (
+	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/user"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var Store store.Store
+
+var testUsers []User
+
+// Store whose user queries take slowQuery, or until their context is done, and which records
+// the error of the context they were cancelled with
+type slowStore struct {
+	store.Store
+	cancelled chan error
+}
+
+const slowQuery = 5 * time.Second
+
+func (s slowStore) GetUserWithGroups(ctx context.Context, userID int64) (UserWithGroups, error) {
+	select {
+	case <-time.After(slowQuery):
+		return s.Store.GetUserWithGroups(ctx, userID)
+	case <-ctx.Done():
+		s.cancelled <- ctx.Err()
+		return UserWithGroups{}, ctx.Err()
+	}
+}
+
+func setupRouter(s store.Store) *gin.Engine {
+	router := gin.Default()
+	router.GET("/user/:id", user.GetUserHandler(s))
+	return router
+}
+
+func TestMain(m *testing.M) {
+	Store = test_helper.SetupStore()
+	var err error
+	if testUsers, err = test_helper.SetupUsers(Store, 1); err != nil {
+		log.Fatal(fmt.Sprintf("Something went wrong when creating Test users. %v", err))
+	}
+	os.Exit(m.Run())
+}
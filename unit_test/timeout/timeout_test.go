@@ -0,0 +1,139 @@
+package timeout
+
+import (
+	"wellnus/backend/config"
+	"wellnus/backend/db/store"
+	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/unit_test/test_helper"
+
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Full test
+func TestTimeout(t *testing.T) {
+	t.Run("Context errors respond with 503", testContextErrors)
+	t.Run("Request deadline cancels the query of its handler", testRequestDeadline)
+	t.Run("Abandoned request cancels the query of its handler", testRequestAbandoned)
+	t.Run("Cancelled context aborts a long query", testLongQueryCancelled)
+	t.Run("Statement timeout aborts a long query", testStatementTimeout)
+}
+
+// Helpers
+
+func requestUser(t *testing.T, ctx context.Context, s slowStore) (int, http_error.ErrorResponse) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("/user/%d", testUsers[0].ID), nil)
+	w := test_helper.SimulateRequest(setupRouter(s), req)
+	var response http_error.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("An error occured while reading the response. %v", err)
+	}
+	return w.Code, response
+}
+
+func assertCancelled(t *testing.T, s slowStore, want error) {
+	select {
+	case err := <-s.cancelled:
+		if !errors.Is(err, want) {
+			t.Errorf("Query was cancelled with %v instead of %v", err, want)
+		}
+	default:
+		t.Errorf("Query was not cancelled")
+	}
+}
+
+// Opens a transaction holding a lock on every user, so that queries on users wait until it ends
+func lockUsers(t *testing.T, db *sql.DB) *sql.Tx {
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("An error occured while beginning a transaction. %v", err)
+	}
+	if _, err := tx.Exec("LOCK TABLE wn_user IN ACCESS EXCLUSIVE MODE"); err != nil {
+		tx.Rollback()
+		t.Fatalf("An error occured while locking the users. %v", err)
+	}
+	return tx
+}
+
+func postgresStore(t *testing.T) *store.PostgresStore {
+	s, ok := Store.(*store.PostgresStore)
+	if !ok {
+		t.Skip("Long queries need postgres. Run with UNIT_TEST_STORE=postgres")
+	}
+	return s
+}
+
+// Runs GetUser on s with ctx while the users are locked, and checks it gave up after about abortAfter
+func assertAborted(t *testing.T, s *store.PostgresStore, ctx context.Context, abortAfter time.Duration) {
+	tx := lockUsers(t, s.DB)
+	defer tx.Rollback()
+	start := time.Now()
+	_, err := s.GetUser(ctx, testUsers[0].ID)
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatalf("Query on locked users succeeded instead of being aborted")
+	}
+	if elapsed > abortAfter+2*time.Second {
+		t.Errorf("Query was aborted after %v instead of about %v", elapsed, abortAfter)
+	}
+	if apiErr := http_error.FromError(err); apiErr.Status != http.StatusServiceUnavailable || apiErr.Code != http_error.CodeTimeout {
+		t.Errorf("Aborted query error %v was turned into %d %s instead of %d %s", err, apiErr.Status, apiErr.Code, http.StatusServiceUnavailable, http_error.CodeTimeout)
+	}
+}
+
+func testContextErrors(t *testing.T) {
+	for _, err := range []error{context.DeadlineExceeded, context.Canceled, fmt.Errorf("loading user: %w", context.Canceled), &pq.Error{Code: "57014"}} {
+		if apiErr := http_error.FromError(err); apiErr.Status != http.StatusServiceUnavailable || apiErr.Code != http_error.CodeTimeout {
+			t.Errorf("%v was turned into %d %s instead of %d %s", err, apiErr.Status, apiErr.Code, http.StatusServiceUnavailable, http_error.CodeTimeout)
+		}
+	}
+}
+
+func testRequestDeadline(t *testing.T) {
+	s := slowStore{Store: Store, cancelled: make(chan error, 1)}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	code, response := requestUser(t, ctx, s)
+	if elapsed := time.Since(start); elapsed >= slowQuery {
+		t.Errorf("Handler took %v, the whole of the slow query", elapsed)
+	}
+	if code != http.StatusServiceUnavailable || response.Error == nil || response.Error.Code != http_error.CodeTimeout {
+		t.Errorf("Timed out request responded %d %+v instead of %d %s", code, response.Error, http.StatusServiceUnavailable, http_error.CodeTimeout)
+	}
+	assertCancelled(t, s, context.DeadlineExceeded)
+}
+
+func testRequestAbandoned(t *testing.T) {
+	s := slowStore{Store: Store, cancelled: make(chan error, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	// The server cancels the context of a request once its client disconnects
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	requestUser(t, ctx, s)
+	if elapsed := time.Since(start); elapsed >= slowQuery {
+		t.Errorf("Handler took %v, the whole of the slow query", elapsed)
+	}
+	assertCancelled(t, s, context.Canceled)
+}
+
+func testLongQueryCancelled(t *testing.T) {
+	s := postgresStore(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	assertAborted(t, s, ctx, 300*time.Millisecond)
+}
+
+func testStatementTimeout(t *testing.T) {
+	s := postgresStore(t)
+	timedStore := store.NewPostgresStore(s.DB, 300*time.Millisecond, config.Default().Matching)
+	assertAborted(t, timedStore, context.Background(), 300*time.Millisecond)
+}
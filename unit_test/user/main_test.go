@@ -1,10 +1,11 @@
 package user
 
 import (
-	"wellnus/backend/config"
-	"wellnus/backend/db"
 	. "wellnus/backend/db/model"
+	"wellnus/backend/db/store"
+	"wellnus/backend/mailer"
 	"wellnus/backend/router/http_helper/http_error"
+	"wellnus/backend/router/middleware"
 	"wellnus/backend/router/user"
 	"wellnus/backend/unit_test/test_helper"
 
@@ -13,14 +14,13 @@ import (
 	"os"
 	"testing"
 
-	"database/sql"
-
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	DB                       *sql.DB
+	Store                    store.Store
 	Router                   *gin.Engine
+	Mailer                   *mailer.FileMailer
 	addedUser                User
 	NotFoundErrorMessage     string = http_error.NotFoundError.Error()
 	UnauthorizedErrorMessage string = http_error.UnauthorizedError.Error()
@@ -33,25 +33,25 @@ var sessionKey string
 
 func SetupRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(middleware.Authenticate(Store))
+	protected := router.Group("/", middleware.RequireAuth())
 
-	router.GET("/user", user.GetAllUsersHandler(DB))
-	router.POST("/user", user.AddUserHandler(DB))
-	router.GET("/user/:id", user.GetUserHandler(DB))
-	router.PATCH("/user/:id", user.UpdateUserHandler(DB))
-	router.DELETE("/user/:id", user.DeleteUserHandler(DB))
+	router.GET("/user", user.GetAllUsersHandler(Store))
+	router.POST("/user", user.AddUserHandler(Store, Mailer))
+	router.GET("/user/:id", user.GetUserHandler(Store))
+	protected.PATCH("/user/:id", user.UpdateUserHandler(Store, Mailer))
+	protected.DELETE("/user/:id", user.DeleteUserHandler(Store))
 
 	return router
 }
 
 func TestMain(m *testing.M) {
-	config.LoadENV("../../.env")
-
-	DB = db.ConnectDB()
+	Store = test_helper.SetupStore()
+	Mailer = test_helper.SetupMailer()
 	Router = SetupRouter()
-	test_helper.ResetDB(DB)
 	var err error
 
-	testUsers, err = test_helper.SetupUsers(DB, 3)
+	testUsers, err = test_helper.SetupUsers(Store, 3)
 	if err != nil {
 		log.Fatal(fmt.Sprintf("Something went wrong when creating Test user. %v", err))
 	}
@@ -1,10 +1,12 @@
 package user
 
 import (
-	"wellnus/backend/db/model"
+	. "wellnus/backend/db/model"
 	"wellnus/backend/unit_test/test_helper"
 
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"net/http"
 )
@@ -17,6 +19,11 @@ func TestUserHandlers(t *testing.T) {
 	t.Run("GetAllUsersHandlerVolunteer", testGetAllUsersHandlerVolunteer)
 	t.Run("GetAllUsersHandlerCounsellor", testGetAllUsersHandlerCounsellor)	
 	t.Run("GetAllUsersHandlerProvider", testGetAllUsersHandlerProvider)
+	t.Run("GetAllUsersHandler filters", testGetAllUsersHandlerFilters)
+	t.Run("GetAllUsersHandler topics", testGetAllUsersHandlerTopics)
+	t.Run("GetAllUsersHandler sorting", testGetAllUsersHandlerSorting)
+	t.Run("GetAllUsersHandler pagination", testGetAllUsersHandlerPagination)
+	t.Run("GetAllUsersHandler invalid query", testGetAllUsersHandlerInvalidQuery)
 	t.Run("GetUserHandler miss", testGetUserHandlerMiss)
 	t.Run("AddUserHandler", testAddUserHandler)
 	t.Run("GetUserHandler", testGetUserHandler)
@@ -27,9 +34,12 @@ func TestUserHandlers(t *testing.T) {
 	t.Run("AddUserHandler no email", testAddUserHandlerNoEmail)
 	t.Run("AddUserHandler no user role", testAddUserHandlerNoUserRole)
 	t.Run("AddUserHandler same user", testAddSameUserHandler)
+	t.Run("AddUserHandler malformed body", testAddUserHandlerMalformedBody)
+	t.Run("AddUserHandler wrong type", testAddUserHandlerWrongType)
 	t.Run("UpdateUserHandler unauthorized", testUpdateUserHandlerUnauthorized)
 	t.Run("UpdateUserHandler authorized", testUpdateUserHandlerAuthorized)
 	t.Run("DeleteUserHandler unauthorized", testDeleteUserHandlerUnauthorized)
+	t.Run("DeleteUserHandler event owner", testDeleteUserHandlerEventOwner)
 	t.Run("DeleteUserHandler authorized", testDeleteUserHandlerAuthorised)
 }
 
@@ -108,6 +118,139 @@ func testGetAllUsersHandlerProvider(t *testing.T) {
 	}
 }
 
+func searchUsers(t *testing.T, query string) []User {
+	req, _ := http.NewRequest("GET", "/user?" + query, nil)
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("HTTP Request to GetAllUser?%s failed with status code of %d", query, w.Code)
+	}
+	users, err := test_helper.GetUsersFromRecorder(w)
+	if err != nil {
+		t.Fatalf("An error occured while getting user slice from recorder. %v", err)
+	}
+	return users
+}
+
+func userIDs(users []User) []int64 {
+	ids := make([]int64, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
+
+func testGetAllUsersHandlerFilters(t *testing.T) {
+	expectedCounts := map[string]int{
+		"faculty=COMPUTING":                 3,
+		"faculty=LAW":                       0,
+		"gender=M":                          3,
+		"gender=F":                          0,
+		"name=testuser1":                    1,
+		"name=TestLastName":                 3,
+		"name=LastName":                     0,
+		"name=%25":                          0,
+		"name=_":                            0,
+		"role=MEMBER,COUNSELLOR":            2,
+		"role=MEMBER&role=VOLUNTEER":        2,
+		"role=PROVIDER&faculty=COMPUTING":   2,
+		"role=MEMBER&name=TestUser1":        0,
+	}
+	for query, count := range expectedCounts {
+		if users := searchUsers(t, query); len(users) != count {
+			t.Errorf("%d users found for %s instead of %d", len(users), query, count)
+		}
+	}
+}
+
+func testGetAllUsersHandlerTopics(t *testing.T) {
+	for i, user := range testUsers[1:] {
+		if _, err := Store.AddUpdateProviderSettingOfUser(context.Background(), test_helper.GetTestProviderSetting(i), user.ID); err != nil {
+			t.Fatalf("An error occured while setting up provider settings. %v", err)
+		}
+	}
+	if users := searchUsers(t, "topic=OffMyChest"); len(users) != 2 {
+		t.Errorf("%d users found for a topic of both providers instead of 2", len(users))
+	}
+	users := searchUsers(t, "topic=Anxiety,OffMyChest")
+	if len(users) != 1 || users[0].ID != testUsers[1].ID {
+		t.Errorf("Users found for topics of only the volunteer were %v", userIDs(users))
+	}
+	if users := searchUsers(t, "topic=Anxiety&topic=SelfHarm"); len(users) != 0 {
+		t.Errorf("%d users found for topics no provider covers", len(users))
+	}
+}
+
+func testGetAllUsersHandlerSorting(t *testing.T) {
+	ids := userIDs(testUsers)
+	reversed := []int64{ids[2], ids[1], ids[0]}
+	expectedOrders := map[string][]int64{
+		"":                               ids,
+		"order=desc":                     reversed,
+		"sort_by=first_name":             ids,
+		"sort_by=last_name&order=desc":   reversed,
+	}
+	for query, expected := range expectedOrders {
+		if got := userIDs(searchUsers(t, query)); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Users sorted by %q were %v instead of %v", query, got, expected)
+		}
+	}
+}
+
+func searchUsersPage(t *testing.T, query string) Page[User] {
+	req, _ := http.NewRequest("GET", "/user?" + query, nil)
+	w := test_helper.SimulateRequest(Router, req)
+	page, err := test_helper.GetPageFromRecorder[User](w)
+	if err != nil {
+		t.Fatalf("An error occured while getting a page of users from GetAllUser?%s. %v", query, err)
+	}
+	return page
+}
+
+func testGetAllUsersHandlerPagination(t *testing.T) {
+	ids := userIDs(testUsers)
+	expectedOrders := map[string][]int64{
+		"limit=2":                                 ids,
+		"limit=2&sort_by=first_name&order=desc":   {ids[2], ids[1], ids[0]},
+	}
+	for query, expected := range expectedOrders {
+		firstPage := searchUsersPage(t, query)
+		if len(firstPage.Items) != 2 || firstPage.NextCursor == "" {
+			t.Fatalf("First page of %s had %d users and next cursor %q", query, len(firstPage.Items), firstPage.NextCursor)
+		}
+		secondPage := searchUsersPage(t, query + "&cursor=" + firstPage.NextCursor)
+		if len(secondPage.Items) != 1 || secondPage.NextCursor != "" {
+			t.Fatalf("Last page of %s had %d users and next cursor %q", query, len(secondPage.Items), secondPage.NextCursor)
+		}
+		if got := userIDs(append(firstPage.Items, secondPage.Items...)); fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Pages of %s together held users %v instead of %v", query, got, expected)
+		}
+	}
+	if page := searchUsersPage(t, ""); len(page.Items) != 3 || page.NextCursor != "" {
+		t.Errorf("Default page held %d users and next cursor %q", len(page.Items), page.NextCursor)
+	}
+}
+
+func testGetAllUsersHandlerInvalidQuery(t *testing.T) {
+	invalidQueries := []string{
+		"role=KING",
+		"sort_by=password_hash",
+		"order=sideways",
+		"limit=abc",
+		"limit=1000",
+		"limit=-1",
+		"cursor=abc",
+		"cursor=" + searchUsersPage(t, "limit=1").NextCursor + "&order=desc",
+		"cursor=" + searchUsersPage(t, "limit=1").NextCursor + "&sort_by=last_name",
+	}
+	for _, query := range invalidQueries {
+		req, _ := http.NewRequest("GET", "/user?" + query, nil)
+		w := test_helper.SimulateRequest(Router, req)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("HTTP Request to GetAllUser?%s did not return 422. Status Code: %d", query, w.Code)
+		}
+	}
+}
+
 func testGetUserHandlerMiss(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/user/999", nil)
 	w := test_helper.SimulateRequest(Router, req)
@@ -135,10 +278,22 @@ func testAddUserHandler(t *testing.T) {
 	if addedUser.ID == 0 {
 		t.Errorf("addedUser ID was not written by addUser call")
 	}
-	sessionKey = test_helper.GetCookieFromRecorder(w, "session_key")
-	userID, err := model.GetUserIDFromSessionKey(DB, sessionKey)
-	if err != nil || userID != addedUser.ID {
-		t.Errorf("Error when retrieving userID from sessionKey or the userID does not matched added User. %v", err)
+	if addedUser.EmailVerified {
+		t.Errorf("addedUser email was verified before the verification link was used")
+	}
+	if test_helper.GetCookieFromRecorder(w, "session_key") != "" {
+		t.Errorf("A session was created for addedUser before their email was verified")
+	}
+	token, err := test_helper.GetTokenFromMailer(Mailer, addedUser.Email)
+	if err != nil {
+		t.Fatalf("No verification email was sent to addedUser. %v", err)
+	}
+	if addedUser, err = Store.VerifyEmail(context.Background(), token); err != nil || !addedUser.EmailVerified {
+		t.Fatalf("An error occured while verifying the email of addedUser. %v", err)
+	}
+	sessionKey, err = Store.CreateNewSession(context.Background(), addedUser.ID, "unit test")
+	if err != nil {
+		t.Errorf("An error occured while creating a session for addedUser. %v", err)
 	}
 }
 
@@ -153,7 +308,7 @@ func testGetUserHandler(t *testing.T) {
 	if err != nil {
 		t.Errorf("An error occured while getting user of id = %d from body. %v", addedUser.ID, err)
 	}
-	if !retrivedUserWithGroups.User.Equal(addedUser) {
+	if retrivedUserWithGroups.User != addedUser.Public() {
 		t.Errorf("retrieved user is not the same as the added user")
 	}
 }
@@ -171,8 +326,8 @@ func testAddUserHandlerNoFirstName(t *testing.T) {
 	ioReaderUser, _ := test_helper.GetIOReaderFromObject(newUser)
 	req, _ := http.NewRequest("POST", "/user", ioReaderUser)
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code == http.StatusOK {
-		t.Errorf("User with no first_name successfully added. Status Code: %d", w.Code)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("User with no first_name was not rejected as invalid. Status Code: %d", w.Code)
 	}
 	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, "first_name")
 	if !matched {
@@ -295,8 +450,36 @@ func testAddSameUserHandler(t *testing.T) {
 	ioReaderUser, _ := test_helper.GetIOReaderFromObject(validUser)
 	req, _ := http.NewRequest("POST", "/user", ioReaderUser)
 	w := test_helper.SimulateRequest(Router, req)
-	if w.Code == http.StatusOK {
-		t.Errorf("User with same details as addedUser successfully added. Status Code: %d", w.Code)
+	if w.Code != http.StatusConflict {
+		t.Errorf("User with same details as addedUser was not rejected as a conflict. Status Code: %d", w.Code)
+	}
+	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, `"field":"email"`)
+	if !matched {
+		t.Errorf("response body did not report email as the conflicting field. %s", errString)
+	}
+}
+
+func testAddUserHandlerMalformedBody(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/user", strings.NewReader(`{"first_name": "Malformed"`))
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("User with a malformed body was not rejected as a bad request. Status Code: %d", w.Code)
+	}
+	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, "BAD_REQUEST")
+	if !matched {
+		t.Errorf("response body was not a bad request error. %s", errString)
+	}
+}
+
+func testAddUserHandlerWrongType(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/user", strings.NewReader(`{"first_name": 1}`))
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("User with a first_name of the wrong type was not rejected as a bad request. Status Code: %d", w.Code)
+	}
+	errString, matched := test_helper.CheckErrorMessageFromRecorder(w, `"field":"first_name"`)
+	if !matched {
+		t.Errorf("response body did not report first_name as the field of the wrong type. %s", errString)
 	}
 }
 
@@ -356,4 +539,32 @@ func testDeleteUserHandlerAuthorised(t *testing.T) {
 	if err != nil {
 		t.Errorf("Unable to delete user despite being authorized. %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func testDeleteUserHandlerEventOwner(t *testing.T) {
+	groupWithUsers, err := Store.AddGroupWithUserIDs(context.Background(), test_helper.GetTestGroup(0), []int64{addedUser.ID, testUsers[0].ID})
+	if err != nil {
+		t.Fatalf("An error occured while adding a group. %v", err)
+	}
+	eventWithUsers, err := Store.AddEventWithUserIDs(context.Background(), test_helper.GetTestEvent(0), []int64{addedUser.ID})
+	if err != nil {
+		t.Fatalf("An error occured while adding an event. %v", err)
+	}
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/user/%d", addedUser.ID), nil)
+	req.AddCookie(&http.Cookie{
+		Name: "session_key",
+		Value: sessionKey,
+	})
+	w := test_helper.SimulateRequest(Router, req)
+	if w.Code == http.StatusOK {
+		t.Errorf("Owner of an event was deleted")
+	}
+	retrievedGroupWithUsers, _ := Store.GetGroupWithUsers(context.Background(), groupWithUsers.Group.ID)
+	if len(retrievedGroupWithUsers.Users) != 2 || retrievedGroupWithUsers.Group.OwnerID != addedUser.ID {
+		t.Errorf("User that was not deleted left their group")
+	}
+	if _, err := Store.LeaveDeleteEvent(context.Background(), eventWithUsers.Event.ID, addedUser.ID); err != nil {
+		t.Fatalf("An error occured while deleting the event. %v", err)
+	}
+}
+